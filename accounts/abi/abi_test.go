@@ -203,6 +203,169 @@ func TestMultiPack(t *testing.T) {
 	}
 }
 
+func TestTuplePack(t *testing.T) {
+	const definition = `[
+	{ "type" : "function", "name" : "static", "inputs" : [ { "name" : "p", "type" : "tuple", "components" : [ { "name" : "a", "type" : "uint256" }, { "name" : "b", "type" : "address" } ] } ] },
+	{ "type" : "function", "name" : "dynamic", "inputs" : [ { "name" : "p", "type" : "tuple", "components" : [ { "name" : "a", "type" : "uint256" }, { "name" : "s", "type" : "string" }, { "name" : "c", "type" : "uint8[]" } ] }, { "name" : "x", "type" : "uint256" } ] }
+	]`
+	abi, err := JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig := abi.Methods["dynamic"].Sig(); sig != "dynamic((uint256,string,uint8[]),uint256)" {
+		t.Errorf("signature mismatch: have %s, want %s", sig, "dynamic((uint256,string,uint8[]),uint256)")
+	}
+	// Static tuples are encoded in place
+	packed, err := abi.Pack("static", struct {
+		A *big.Int
+		B common.Address
+	}{big.NewInt(1), common.Address{1}})
+	if err != nil {
+		t.Fatalf("failed to pack static tuple: %v", err)
+	}
+	want := abi.Methods["static"].Id()
+	want = append(want, common.LeftPadBytes([]byte{1}, 32)...)
+	want = append(want, common.LeftPadBytes(common.Address{1}.Bytes(), 32)...)
+	if !bytes.Equal(packed, want) {
+		t.Errorf("static tuple mismatch: have %x, want %x", packed, want)
+	}
+	// Dynamic tuples are referenced by offset, their fields relative to the tuple
+	packed, err = abi.Pack("dynamic", struct {
+		A *big.Int
+		S string
+		C []uint8
+	}{big.NewInt(1), "hi", []uint8{1, 2}}, big.NewInt(7))
+	if err != nil {
+		t.Fatalf("failed to pack dynamic tuple: %v", err)
+	}
+	want = abi.Methods["dynamic"].Id()
+	for _, word := range []string{
+		"0000000000000000000000000000000000000000000000000000000000000040", // offset of p
+		"0000000000000000000000000000000000000000000000000000000000000007", // x
+		"0000000000000000000000000000000000000000000000000000000000000001", // p.a
+		"0000000000000000000000000000000000000000000000000000000000000060", // offset of p.s
+		"00000000000000000000000000000000000000000000000000000000000000a0", // offset of p.c
+		"0000000000000000000000000000000000000000000000000000000000000002", // len(p.s)
+		"6869000000000000000000000000000000000000000000000000000000000000", // p.s
+		"0000000000000000000000000000000000000000000000000000000000000002", // len(p.c)
+		"0000000000000000000000000000000000000000000000000000000000000001", // p.c[0]
+		"0000000000000000000000000000000000000000000000000000000000000002", // p.c[1]
+	} {
+		want = append(want, common.Hex2Bytes(word)...)
+	}
+	if !bytes.Equal(packed, want) {
+		t.Errorf("dynamic tuple mismatch: have %x, want %x", packed, want)
+	}
+}
+
+func TestTupleUnpack(t *testing.T) {
+	const definition = `[
+	{ "type" : "function", "name" : "tuples", "outputs" : [
+		{ "name" : "static", "type" : "tuple", "components" : [ { "name" : "a", "type" : "uint256" }, { "name" : "b", "type" : "uint256[2]" } ] },
+		{ "name" : "list", "type" : "tuple[]", "components" : [ { "name" : "id", "type" : "uint8" }, { "name" : "name", "type" : "string" } ] },
+		{ "name" : "nested", "type" : "tuple", "components" : [ { "name" : "inner", "type" : "tuple", "components" : [ { "name" : "owner", "type" : "address" } ] }, { "name" : "flag", "type" : "bool" } ] }
+	] }
+	]`
+	abi, err := JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	type entry struct {
+		Id   uint8
+		Name string
+	}
+	type inner struct {
+		Owner common.Address
+	}
+	var out struct {
+		Static struct {
+			A *big.Int
+			B [2]*big.Int
+		}
+		List   []entry
+		Nested struct {
+			Inner inner
+			Flag  bool
+		}
+	}
+	var data []byte
+	for _, word := range []string{
+		"0000000000000000000000000000000000000000000000000000000000000001", // static.a
+		"0000000000000000000000000000000000000000000000000000000000000002", // static.b[0]
+		"0000000000000000000000000000000000000000000000000000000000000003", // static.b[1]
+		"00000000000000000000000000000000000000000000000000000000000000c0", // offset of list
+		"0000000000000000000000000000000000000000000000000000000000000000", // nested.inner.owner
+		"0000000000000000000000000000000000000000000000000000000000000001", // nested.flag
+		"0000000000000000000000000000000000000000000000000000000000000002", // len(list)
+		"0000000000000000000000000000000000000000000000000000000000000040", // offset of list[0]
+		"00000000000000000000000000000000000000000000000000000000000000c0", // offset of list[1]
+		"0000000000000000000000000000000000000000000000000000000000000005", // list[0].id
+		"0000000000000000000000000000000000000000000000000000000000000040", // offset of list[0].name
+		"0000000000000000000000000000000000000000000000000000000000000003", // len(list[0].name)
+		"666f6f0000000000000000000000000000000000000000000000000000000000", // list[0].name
+		"0000000000000000000000000000000000000000000000000000000000000006", // list[1].id
+		"0000000000000000000000000000000000000000000000000000000000000040", // offset of list[1].name
+		"0000000000000000000000000000000000000000000000000000000000000003", // len(list[1].name)
+		"6261720000000000000000000000000000000000000000000000000000000000", // list[1].name
+	} {
+		data = append(data, common.Hex2Bytes(word)...)
+	}
+	if err := abi.Unpack(&out, "tuples", data); err != nil {
+		t.Fatalf("failed to unpack tuples: %v", err)
+	}
+	if out.Static.A.Cmp(big.NewInt(1)) != 0 || out.Static.B[0].Cmp(big.NewInt(2)) != 0 || out.Static.B[1].Cmp(big.NewInt(3)) != 0 {
+		t.Errorf("static tuple mismatch: have %v", out.Static)
+	}
+	if want := []entry{{5, "foo"}, {6, "bar"}}; !reflect.DeepEqual(out.List, want) {
+		t.Errorf("tuple list mismatch: have %v, want %v", out.List, want)
+	}
+	if out.Nested.Inner.Owner != (common.Address{}) || !out.Nested.Flag {
+		t.Errorf("nested tuple mismatch: have %v", out.Nested)
+	}
+	// Packing the decoded values back must reproduce the original encoding
+	method := abi.Methods["tuples"]
+	method.Inputs = method.Outputs
+	abi.Methods["tuples"] = method
+
+	packed, err := abi.Pack("tuples", out.Static, out.List, out.Nested)
+	if err != nil {
+		t.Fatalf("failed to repack tuples: %v", err)
+	}
+	if !bytes.Equal(packed[4:], data) {
+		t.Errorf("repacked tuples mismatch: have %x, want %x", packed[4:], data)
+	}
+}
+
+// Tests that dynamic tuples pointing out of the output are rejected instead of
+// wrapping to negative or out of range offsets.
+func TestTupleUnpackInvalidOffset(t *testing.T) {
+	const definition = `[
+	{ "type" : "function", "name" : "tuple", "outputs" : [
+		{ "name" : "p", "type" : "tuple", "components" : [ { "name" : "a", "type" : "uint256" }, { "name" : "s", "type" : "string" } ] }
+	] }
+	]`
+	abi, err := JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, offset := range []string{
+		"000000000000000000000000000000000000000000000000ffffffffffffffe0", // wraps to -32
+		"0000000000000000000000000000000000000000000000000000000000000040", // len(output)
+	} {
+		data := common.Hex2Bytes(offset + "0000000000000000000000000000000000000000000000000000000000000001")
+
+		var out struct {
+			P struct {
+				A *big.Int
+				S string
+			}
+		}
+		if err := abi.Unpack(&out, "tuple", data); err == nil {
+			t.Errorf("offset %s: expected error", offset)
+		}
+	}
+}
+
 func ExampleJSON() {
 	const definition = `[{"constant":true,"inputs":[{"name":"","type":"address"}],"name":"isBar","outputs":[{"name":"","type":"bool"}],"type":"function"}]`
 
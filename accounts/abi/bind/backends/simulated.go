@@ -28,17 +28,38 @@ import (
 	"github.com/meitu/go-ethereum/accounts/abi/bind"
 	"github.com/meitu/go-ethereum/common"
 	"github.com/meitu/go-ethereum/common/math"
+	"github.com/meitu/go-ethereum/consensus"
+	"github.com/meitu/go-ethereum/consensus/dpos"
 	"github.com/meitu/go-ethereum/consensus/ethash"
 	"github.com/meitu/go-ethereum/core"
+	"github.com/meitu/go-ethereum/core/bloombits"
 	"github.com/meitu/go-ethereum/core/state"
 	"github.com/meitu/go-ethereum/core/types"
 	"github.com/meitu/go-ethereum/core/vm"
+	"github.com/meitu/go-ethereum/eth/filters"
 	"github.com/meitu/go-ethereum/ethdb"
+	"github.com/meitu/go-ethereum/event"
 	"github.com/meitu/go-ethereum/params"
+	"github.com/meitu/go-ethereum/rpc"
 )
 
-// This nil assignment ensures compile time that SimulatedBackend implements bind.ContractBackend.
-var _ bind.ContractBackend = (*SimulatedBackend)(nil)
+// These nil assignments ensure compile time that SimulatedBackend implements
+// bind.ContractBackend and the interfaces of the ethereum package.
+var (
+	_ bind.ContractBackend           = (*SimulatedBackend)(nil)
+	_ ethereum.ChainReader           = (*SimulatedBackend)(nil)
+	_ ethereum.TransactionReader     = (*SimulatedBackend)(nil)
+	_ ethereum.ChainStateReader      = (*SimulatedBackend)(nil)
+	_ ethereum.ChainSyncReader       = (*SimulatedBackend)(nil)
+	_ ethereum.ContractCaller        = (*SimulatedBackend)(nil)
+	_ ethereum.LogFilterer           = (*SimulatedBackend)(nil)
+	_ ethereum.TransactionSender     = (*SimulatedBackend)(nil)
+	_ ethereum.GasPricer             = (*SimulatedBackend)(nil)
+	_ ethereum.PendingStateReader    = (*SimulatedBackend)(nil)
+	_ ethereum.PendingContractCaller = (*SimulatedBackend)(nil)
+	_ ethereum.GasEstimator          = (*SimulatedBackend)(nil)
+	_ ethereum.PendingStateEventer   = (*SimulatedBackend)(nil)
+)
 
 var errBlockNumberUnsupported = errors.New("SimulatedBackend cannot access blocks other than the latest block")
 var errGasEstimationFailed = errors.New("gas required exceeds allowance or always failing transaction")
@@ -52,18 +73,31 @@ type SimulatedBackend struct {
 	mu           sync.Mutex
 	pendingBlock *types.Block   // Currently pending block that will be imported on request
 	pendingState *state.StateDB // Currently pending state that will be the active on on request
+	timeOffset   int64          // Seconds the pending block is moved into the future by
+
+	events      *filters.EventSystem // Event system for filtering log events live
+	pendingFeed event.Feed           // Feed announcing the transactions added to the pending block
 
 	config *params.ChainConfig
 }
 
 // NewSimulatedBackend creates a new binding backend using a simulated blockchain
 // for testing purposes.
+//
+// Blocks aren't sealed by validators, but the dpos operations and the validator
+// elections at the epoch boundaries are processed as on a live chain. Elections
+// short of candidates keep the previous validators instead of failing the block.
 func NewSimulatedBackend(alloc core.GenesisAlloc) *SimulatedBackend {
 	database, _ := ethdb.NewMemDatabase()
-	genesis := core.Genesis{Config: params.DposChainConfig, Alloc: alloc}
+	genesis := core.Genesis{Config: params.DposTestChainConfig, Alloc: alloc}
 	genesis.MustCommit(database)
-	blockchain, _ := core.NewBlockChain(database, genesis.Config, ethash.NewFaker(), vm.Config{})
-	backend := &SimulatedBackend{database: database, blockchain: blockchain, config: genesis.Config}
+	blockchain, _ := core.NewBlockChain(database, nil, genesis.Config, &simulatedEngine{ethash.NewFaker()}, vm.Config{})
+	backend := &SimulatedBackend{
+		database:   database,
+		blockchain: blockchain,
+		config:     genesis.Config,
+		events:     filters.NewEventSystem(new(event.TypeMux), &filterBackend{database, blockchain}, false),
+	}
 	backend.rollback()
 	return backend
 }
@@ -89,9 +123,33 @@ func (b *SimulatedBackend) Rollback() {
 }
 
 func (b *SimulatedBackend) rollback() {
-	blocks, _ := core.GenerateChain(b.config, b.blockchain.CurrentBlock(), b.database, 1, func(int, *core.BlockGen) {})
+	b.timeOffset = 0
+	if err := b.generate(nil); err != nil {
+		panic(err) // Elections of an empty block fail only if the simulator is wrong
+	}
+}
+
+// generate rebuilds the pending block on top of the current head out of the
+// given transactions, running the validator elections of the epochs it begins.
+func (b *SimulatedBackend) generate(txs []*types.Transaction) error {
+	var err error
+
+	genesis := b.blockchain.Genesis().Header()
+	blocks, _ := core.GenerateChain(b.config, b.blockchain.CurrentBlock(), b.database, 1, func(number int, block *core.BlockGen) {
+		if b.timeOffset > 0 {
+			block.OffsetTime(b.timeOffset)
+		}
+		for _, tx := range txs {
+			block.AddTx(tx)
+		}
+		err = keepValidators(block.Elect(genesis))
+	})
+	if err != nil {
+		return err
+	}
 	b.pendingBlock = blocks[0]
 	b.pendingState, _ = state.New(b.pendingBlock.Root(), state.NewDatabase(b.database))
+	return nil
 }
 
 // CodeAt returns the code associated with a certain account in the blockchain.
@@ -149,6 +207,172 @@ func (b *SimulatedBackend) TransactionReceipt(ctx context.Context, txHash common
 	return receipt, nil
 }
 
+// TransactionByHash checks the pool of pending transactions in addition to the
+// blockchain. The isPending return value indicates whether the transaction has
+// been mined yet.
+func (b *SimulatedBackend) TransactionByHash(ctx context.Context, txHash common.Hash) (*types.Transaction, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if tx := b.pendingBlock.Transaction(txHash); tx != nil {
+		return tx, true, nil
+	}
+	if tx, _, _, _ := core.GetTransaction(b.database, txHash); tx != nil {
+		return tx, false, nil
+	}
+	return nil, false, ethereum.NotFound
+}
+
+// BlockByHash retrieves a block based on the block hash.
+func (b *SimulatedBackend) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if hash == b.pendingBlock.Hash() {
+		return b.pendingBlock, nil
+	}
+	if block := b.blockchain.GetBlockByHash(hash); block != nil {
+		return block, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+// BlockByNumber retrieves a block from the canonical chain. If number is nil,
+// the latest known block is returned.
+func (b *SimulatedBackend) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if number == nil {
+		return b.blockchain.CurrentBlock(), nil
+	}
+	if block := b.blockchain.GetBlockByNumber(number.Uint64()); block != nil {
+		return block, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+// HeaderByHash returns a block header based on the block hash.
+func (b *SimulatedBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if hash == b.pendingBlock.Hash() {
+		return b.pendingBlock.Header(), nil
+	}
+	if header := b.blockchain.GetHeaderByHash(hash); header != nil {
+		return header, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+// HeaderByNumber returns a block header from the canonical chain. If number is
+// nil, the latest known header is returned.
+func (b *SimulatedBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if number == nil {
+		return b.blockchain.CurrentHeader(), nil
+	}
+	if header := b.blockchain.GetHeaderByNumber(number.Uint64()); header != nil {
+		return header, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+// TransactionCount returns the number of transactions in the given block.
+func (b *SimulatedBackend) TransactionCount(ctx context.Context, blockHash common.Hash) (uint, error) {
+	block, err := b.BlockByHash(ctx, blockHash)
+	if err != nil {
+		return 0, err
+	}
+	return uint(block.Transactions().Len()), nil
+}
+
+// TransactionInBlock returns the transaction at the given index in the given block.
+func (b *SimulatedBackend) TransactionInBlock(ctx context.Context, blockHash common.Hash, index uint) (*types.Transaction, error) {
+	block, err := b.BlockByHash(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+	txs := block.Transactions()
+	if index >= uint(len(txs)) {
+		return nil, ethereum.NotFound
+	}
+	return txs[index], nil
+}
+
+// SubscribeNewHead subscribes to notifications about the chain head, which
+// changes whenever the pending block is committed.
+func (b *SimulatedBackend) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	sink := make(chan core.ChainHeadEvent)
+	sub := b.blockchain.SubscribeChainHeadEvent(sink)
+
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case head := <-sink:
+				select {
+				case ch <- head.Block.Header():
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// SyncProgress implements ethereum.ChainSyncReader. The simulated chain is
+// never synchronising, so no progress is ever reported.
+func (b *SimulatedBackend) SyncProgress(ctx context.Context) (*ethereum.SyncProgress, error) {
+	return nil, nil
+}
+
+// DposContextAt returns the dpos context, holding the candidates, votes and
+// validators, of a block of the canonical chain. If blockNumber is nil, the
+// context of the latest block is returned.
+func (b *SimulatedBackend) DposContextAt(ctx context.Context, blockNumber *big.Int) (*types.DposContext, error) {
+	header, err := b.HeaderByNumber(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return b.blockchain.DposContextAt(header.DposContext)
+}
+
+// PendingDposContext returns the dpos context of the pending block.
+func (b *SimulatedBackend) PendingDposContext(ctx context.Context) (*types.DposContext, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.blockchain.DposContextAt(b.pendingBlock.Header().DposContext)
+}
+
+// PendingBalanceAt returns the wei balance of an account in the pending state.
+func (b *SimulatedBackend) PendingBalanceAt(ctx context.Context, account common.Address) (*big.Int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.pendingState.GetBalance(account), nil
+}
+
+// PendingStorageAt returns the value of key in the storage of an account in the
+// pending state.
+func (b *SimulatedBackend) PendingStorageAt(ctx context.Context, account common.Address, key common.Hash) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	val := b.pendingState.GetState(account, key)
+	return val[:], nil
+}
+
 // PendingCodeAt returns the code associated with an account in the pending state.
 func (b *SimulatedBackend) PendingCodeAt(ctx context.Context, contract common.Address) ([]byte, error) {
 	b.mu.Lock()
@@ -157,6 +381,14 @@ func (b *SimulatedBackend) PendingCodeAt(ctx context.Context, contract common.Ad
 	return b.pendingState.GetCode(contract), nil
 }
 
+// PendingTransactionCount returns the number of transactions in the pending block.
+func (b *SimulatedBackend) PendingTransactionCount(ctx context.Context) (uint, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return uint(b.pendingBlock.Transactions().Len()), nil
+}
+
 // CallContract executes a contract call.
 func (b *SimulatedBackend) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
 	b.mu.Lock()
@@ -267,6 +499,9 @@ func (b *SimulatedBackend) callContract(ctx context.Context, call ethereum.CallM
 	// Execute the call.
 	msg := callmsg{call}
 
+	if dposContext, err := types.NewDposContextFromProto(b.database, block.Header().DposContext); err == nil {
+		statedb.SetDposContext(dposContext)
+	}
 	evmContext := core.NewEVMContext(msg, block.Header(), b.blockchain, nil)
 	// Create a new environment which holds all relevant information
 	// about the transaction and calling mechanisms.
@@ -290,31 +525,137 @@ func (b *SimulatedBackend) SendTransaction(ctx context.Context, tx *types.Transa
 	if tx.Nonce() != nonce {
 		panic(fmt.Errorf("invalid transaction nonce: got %d, want %d", tx.Nonce(), nonce))
 	}
+	txs := append(b.pendingBlock.Transactions(), tx)
+	if err := b.generate(txs); err != nil {
+		return err
+	}
+	b.pendingFeed.Send(tx)
+	return nil
+}
 
-	blocks, _ := core.GenerateChain(b.config, b.blockchain.CurrentBlock(), b.database, 1, func(number int, block *core.BlockGen) {
-		for _, tx := range b.pendingBlock.Transactions() {
-			block.AddTx(tx)
+// SubscribePendingTransactions subscribes to notifications about the
+// transactions added to the pending block.
+func (b *SimulatedBackend) SubscribePendingTransactions(ctx context.Context, ch chan<- *types.Transaction) (ethereum.Subscription, error) {
+	return b.pendingFeed.Subscribe(ch), nil
+}
+
+// LoginCandidate sends a transaction registering the transactor as a dpos
+// candidate.
+func (b *SimulatedBackend) LoginCandidate(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return bind.TransactDpos(opts, b, types.LoginCandidate, common.Address{})
+}
+
+// LogoutCandidate sends a transaction withdrawing the candidacy of the transactor.
+func (b *SimulatedBackend) LogoutCandidate(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return bind.TransactDpos(opts, b, types.LogoutCandidate, common.Address{})
+}
+
+// Delegate sends a transaction voting for candidate with the balance of the
+// transactor.
+func (b *SimulatedBackend) Delegate(opts *bind.TransactOpts, candidate common.Address) (*types.Transaction, error) {
+	return bind.TransactDpos(opts, b, types.Delegate, candidate)
+}
+
+// UnDelegate sends a transaction withdrawing the vote of the transactor from
+// candidate.
+func (b *SimulatedBackend) UnDelegate(opts *bind.TransactOpts, candidate common.Address) (*types.Transaction, error) {
+	return bind.TransactDpos(opts, b, types.UnDelegate, candidate)
+}
+
+// FilterLogs executes a log filter operation, blocking during execution and
+// returning all the results in one batch.
+func (b *SimulatedBackend) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	// Initialize unset filter boundaries to run from genesis to chain head
+	from := int64(0)
+	if query.FromBlock != nil {
+		from = query.FromBlock.Int64()
+	}
+	to := int64(-1)
+	if query.ToBlock != nil {
+		to = query.ToBlock.Int64()
+	}
+	// Construct and execute the filter
+	filter := filters.New(&filterBackend{b.database, b.blockchain}, from, to, query.Addresses, query.Topics)
+
+	logs, err := filter.Logs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]types.Log, len(logs))
+	for i, log := range logs {
+		res[i] = *log
+	}
+	return res, nil
+}
+
+// SubscribeFilterLogs creates a background log filtering operation, returning a
+// subscription immediately, which can be used to stream the found events.
+func (b *SimulatedBackend) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	// Subscribe to contract events
+	sink := make(chan []*types.Log)
+
+	sub, err := b.events.SubscribeLogs(filters.FilterCriteria(query), sink)
+	if err != nil {
+		return nil, err
+	}
+	// Since we're getting logs in batches, we need to flatten them into a plain stream
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case logs := <-sink:
+				for _, log := range logs {
+					select {
+					case ch <- *log:
+					case err := <-sub.Err():
+						return err
+					case <-quit:
+						return nil
+					}
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
 		}
-		block.AddTx(tx)
-	})
-	b.pendingBlock = blocks[0]
-	b.pendingState, _ = state.New(b.pendingBlock.Root(), state.NewDatabase(b.database))
-	return nil
+	}), nil
 }
 
-// JumpTimeInSeconds adds skip seconds to the clock
+// AdjustTime moves the timestamp of the pending block forward by adjustment,
+// rerunning its transactions at the new time.
 func (b *SimulatedBackend) AdjustTime(adjustment time.Duration) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	blocks, _ := core.GenerateChain(b.config, b.blockchain.CurrentBlock(), b.database, 1, func(number int, block *core.BlockGen) {
-		for _, tx := range b.pendingBlock.Transactions() {
-			block.AddTx(tx)
-		}
-		block.OffsetTime(int64(adjustment.Seconds()))
-	})
-	b.pendingBlock = blocks[0]
-	b.pendingState, _ = state.New(b.pendingBlock.Root(), state.NewDatabase(b.database))
 
+	return b.shiftTime(int64(adjustment.Seconds()))
+}
+
+// SkipEpochs moves the timestamp of the pending block forward to the start of
+// the epoch the given number of epochs later, so that committing it runs the
+// validator elections of the epochs skipped.
+func (b *SimulatedBackend) SkipEpochs(epochs int) error {
+	if epochs <= 0 {
+		return errors.New("must skip at least one epoch")
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.pendingBlock.Time().Int64()
+	return b.shiftTime(dpos.EpochStart(dpos.EpochID(now)+int64(epochs)) - now)
+}
+
+// shiftTime moves the pending block forward by the given number of seconds,
+// restoring it if the elections of the epochs reached fail.
+func (b *SimulatedBackend) shiftTime(seconds int64) error {
+	if seconds <= 0 {
+		return errors.New("time can only be moved forward")
+	}
+	b.timeOffset += seconds
+	if err := b.generate(b.pendingBlock.Transactions()); err != nil {
+		b.timeOffset -= seconds
+		return err
+	}
 	return nil
 }
 
@@ -331,3 +672,76 @@ func (m callmsg) GasPrice() *big.Int   { return m.CallMsg.GasPrice }
 func (m callmsg) Gas() *big.Int        { return m.CallMsg.Gas }
 func (m callmsg) Value() *big.Int      { return m.CallMsg.Value }
 func (m callmsg) Data() []byte         { return m.CallMsg.Data }
+
+// filterBackend implements filters.Backend to support filtering for logs without
+// taking bloom-bits acceleration structures into account.
+type filterBackend struct {
+	db ethdb.Database
+	bc *core.BlockChain
+}
+
+func (fb *filterBackend) ChainDb() ethdb.Database  { return fb.db }
+func (fb *filterBackend) EventMux() *event.TypeMux { panic("not supported") }
+
+func (fb *filterBackend) HeaderByNumber(ctx context.Context, block rpc.BlockNumber) (*types.Header, error) {
+	if block == rpc.LatestBlockNumber {
+		return fb.bc.CurrentHeader(), nil
+	}
+	return fb.bc.GetHeaderByNumber(uint64(block.Int64())), nil
+}
+
+func (fb *filterBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return core.GetBlockReceipts(fb.db, hash, core.GetBlockNumber(fb.db, hash)), nil
+}
+
+func (fb *filterBackend) SubscribeTxPreEvent(ch chan<- core.TxPreEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+func (fb *filterBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
+	return fb.bc.SubscribeChainEvent(ch)
+}
+func (fb *filterBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
+	return fb.bc.SubscribeRemovedLogsEvent(ch)
+}
+func (fb *filterBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription {
+	return fb.bc.SubscribeLogsEvent(ch)
+}
+
+func (fb *filterBackend) BloomStatus() (uint64, uint64) { return 4096, 0 }
+func (fb *filterBackend) ServiceFilter(ctx context.Context, ms *bloombits.MatcherSession) {
+	panic("not supported")
+}
+
+// simulatedEngine is the consensus engine of the simulated chain. It accepts
+// any block, like the faker engine it wraps, but runs the dpos validator
+// elections when finalizing the blocks that begin new epochs.
+type simulatedEngine struct {
+	consensus.Engine
+}
+
+// Finalize implements consensus.Engine, running the elections of the epochs the
+// block begins before accumulating the block rewards.
+func (e *simulatedEngine) Finalize(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt, dposContext *types.DposContext) (*types.Block, error) {
+	genesis := chain.GetHeaderByNumber(0)
+	parent := chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	if parent == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	if err := keepValidators(dpos.Elect(genesis, parent, header.Time.Int64(), state, dposContext)); err != nil {
+		return nil, err
+	}
+	return e.Engine.Finalize(chain, header, state, txs, uncles, receipts, dposContext)
+}
+
+// keepValidators filters out the election errors caused by too few candidates,
+// in which case the validators of the previous epoch stay in office. Contracts
+// can this way be tested without registering a full validator set.
+func keepValidators(err error) error {
+	if err == dpos.ErrNoCandidates || err == dpos.ErrTooFewCandidates {
+		return nil
+	}
+	return err
+}
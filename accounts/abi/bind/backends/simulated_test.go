@@ -0,0 +1,176 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package backends
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/meitu/go-ethereum"
+	"github.com/meitu/go-ethereum/accounts/abi/bind"
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/consensus/dpos"
+	"github.com/meitu/go-ethereum/core"
+	"github.com/meitu/go-ethereum/core/types"
+	"github.com/meitu/go-ethereum/crypto"
+)
+
+// newFundedKeys generates n keys along with a genesis allocation funding them.
+func newFundedKeys(n int) ([]*ecdsa.PrivateKey, core.GenesisAlloc) {
+	keys := make([]*ecdsa.PrivateKey, n)
+	alloc := make(core.GenesisAlloc)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		alloc[crypto.PubkeyToAddress(keys[i].PublicKey)] = core.GenesisAccount{Balance: big.NewInt(1000000000000000000)}
+	}
+	return keys, alloc
+}
+
+// Tests that transactions and headers can be looked up both while pending and
+// after they are mined.
+func TestSimulatedChainReader(t *testing.T) {
+	keys, alloc := newFundedKeys(1)
+	sim := NewSimulatedBackend(alloc)
+	ctx := context.Background()
+
+	auth := bind.NewKeyedTransactor(keys[0])
+	tx, err := sim.LoginCandidate(auth)
+	if err != nil {
+		t.Fatalf("failed to login candidate: %v", err)
+	}
+	if _, pending, err := sim.TransactionByHash(ctx, tx.Hash()); err != nil || !pending {
+		t.Fatalf("pending transaction lookup mismatch: pending %v, err %v", pending, err)
+	}
+	if count, _ := sim.PendingTransactionCount(ctx); count != 1 {
+		t.Fatalf("pending transaction count mismatch: have %d, want 1", count)
+	}
+	sim.Commit()
+
+	mined, pending, err := sim.TransactionByHash(ctx, tx.Hash())
+	if err != nil || pending {
+		t.Fatalf("mined transaction lookup mismatch: pending %v, err %v", pending, err)
+	}
+	if mined.Type() != types.LoginCandidate {
+		t.Fatalf("mined transaction type mismatch: have %v, want %v", mined.Type(), types.LoginCandidate)
+	}
+	head, err := sim.HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve head: %v", err)
+	}
+	if head.Number.Uint64() != 1 {
+		t.Fatalf("head number mismatch: have %d, want 1", head.Number)
+	}
+	header, err := sim.HeaderByNumber(ctx, big.NewInt(1))
+	if err != nil || header.Hash() != head.Hash() {
+		t.Fatalf("header lookup mismatch: have %v, want %x (err %v)", header, head.Hash(), err)
+	}
+	if _, err := sim.HeaderByNumber(ctx, big.NewInt(2)); err != ethereum.NotFound {
+		t.Fatalf("missing header error mismatch: have %v, want %v", err, ethereum.NotFound)
+	}
+	if _, _, err := sim.TransactionByHash(ctx, common.Hash{1}); err != ethereum.NotFound {
+		t.Fatalf("missing transaction error mismatch: have %v, want %v", err, ethereum.NotFound)
+	}
+}
+
+// Tests that dpos operations are recorded in the dpos context and that skipping
+// an epoch elects the validators out of the candidates.
+func TestSimulatedDposElection(t *testing.T) {
+	keys, alloc := newFundedKeys(15)
+	sim := NewSimulatedBackend(alloc)
+	ctx := context.Background()
+
+	for _, key := range keys {
+		auth := bind.NewKeyedTransactor(key)
+		if _, err := sim.LoginCandidate(auth); err != nil {
+			t.Fatalf("failed to login candidate: %v", err)
+		}
+		if _, err := sim.Delegate(auth, auth.From); err != nil {
+			t.Fatalf("failed to delegate: %v", err)
+		}
+	}
+	// The operations are visible in the pending context before being committed
+	dposContext, err := sim.PendingDposContext(ctx)
+	if err != nil {
+		t.Fatalf("failed to retrieve pending dpos context: %v", err)
+	}
+	for _, key := range keys {
+		addr := crypto.PubkeyToAddress(key.PublicKey)
+		if dposContext.CandidateTrie().Get(addr.Bytes()) == nil {
+			t.Fatalf("candidate %x missing from pending context", addr)
+		}
+		if vote := dposContext.VoteTrie().Get(addr.Bytes()); common.BytesToAddress(vote) != addr {
+			t.Fatalf("vote of %x mismatch: have %x", addr, vote)
+		}
+	}
+	sim.Commit()
+
+	if _, err := sim.DposContextAt(ctx, big.NewInt(0)); err != nil {
+		t.Fatalf("failed to retrieve genesis dpos context: %v", err)
+	}
+	dposContext, err = sim.DposContextAt(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve dpos context: %v", err)
+	}
+	if _, err := dposContext.GetValidators(); err == nil {
+		t.Fatalf("validators elected before the epoch ended")
+	}
+	// Skip into the next epoch and check the election outcome
+	if err := sim.SkipEpochs(1); err != nil {
+		t.Fatalf("failed to skip epoch: %v", err)
+	}
+	sim.Commit()
+
+	head, _ := sim.HeaderByNumber(ctx, nil)
+	if epoch := dpos.EpochID(head.Time.Int64()); epoch != 1 {
+		t.Fatalf("head epoch mismatch: have %d, want 1", epoch)
+	}
+	dposContext, err = sim.DposContextAt(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve dpos context: %v", err)
+	}
+	validators, err := dposContext.GetValidators()
+	if err != nil {
+		t.Fatalf("failed to retrieve validators: %v", err)
+	}
+	if len(validators) != len(keys) {
+		t.Fatalf("validator count mismatch: have %d, want %d", len(validators), len(keys))
+	}
+}
+
+// Tests that epochs can be skipped without enough candidates for an election.
+func TestSimulatedSkipEpochsWithoutCandidates(t *testing.T) {
+	keys, alloc := newFundedKeys(1)
+	sim := NewSimulatedBackend(alloc)
+
+	if _, err := sim.LoginCandidate(bind.NewKeyedTransactor(keys[0])); err != nil {
+		t.Fatalf("failed to login candidate: %v", err)
+	}
+	if err := sim.SkipEpochs(0); err == nil {
+		t.Fatalf("skipping no epochs succeeded")
+	}
+	if err := sim.SkipEpochs(2); err != nil {
+		t.Fatalf("failed to skip epochs: %v", err)
+	}
+	sim.Commit()
+
+	head, _ := sim.HeaderByNumber(context.Background(), nil)
+	if epoch := dpos.EpochID(head.Time.Int64()); epoch != 2 {
+		t.Fatalf("head epoch mismatch: have %d, want 2", epoch)
+	}
+}
@@ -22,8 +22,10 @@ package bind
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 	"text/template"
 	"unicode"
@@ -47,7 +49,10 @@ const (
 // manually maintain hard coded strings that break on runtime.
 func Bind(types []string, abis []string, bytecodes []string, pkg string, lang Lang) (string, error) {
 	// Process each individual contract requested binding
-	contracts := make(map[string]*tmplContract)
+	var (
+		contracts = make(map[string]*tmplContract)
+		structs   = make(map[string]*tmplStruct)
+	)
 
 	for i := 0; i < len(types); i++ {
 		// Parse the actual ABI to generate the binding for
@@ -94,6 +99,25 @@ func Bind(types []string, abis []string, bytecodes []string, pkg string, lang La
 				transacts[original.Name] = &tmplMethod{Original: original, Normalized: normalized, Structured: structured(original)}
 			}
 		}
+		// Normalize the events for capital cases and non-anonymous inputs
+		events := make(map[string]*tmplEvent)
+		for _, original := range evmABI.Events {
+			normalized := original
+			normalized.Name = methodNormalizer[lang](original.Name)
+
+			normalized.Inputs = make([]abi.Argument, len(original.Inputs))
+			copy(normalized.Inputs, original.Inputs)
+			for j, input := range normalized.Inputs {
+				if input.Name == "" {
+					normalized.Inputs[j].Name = fmt.Sprintf("arg%d", j)
+				}
+			}
+			events[original.Name] = &tmplEvent{Original: original, Normalized: normalized}
+		}
+		// Gather the tuple types used by the contract into shared struct definitions
+		if err := bindStructs(evmABI, lang, structs); err != nil {
+			return "", err
+		}
 		contracts[types[i]] = &tmplContract{
 			Type:        capitalise(types[i]),
 			InputABI:    strings.Replace(strippedABI, "\"", "\\\"", -1),
@@ -101,20 +125,28 @@ func Bind(types []string, abis []string, bytecodes []string, pkg string, lang La
 			Constructor: evmABI.Constructor,
 			Calls:       calls,
 			Transacts:   transacts,
+			Events:      events,
 		}
 	}
 	// Generate the contract template data content and render it
 	data := &tmplData{
 		Package:   pkg,
 		Contracts: contracts,
+		Structs:   structs,
 	}
 	buffer := new(bytes.Buffer)
 
 	funcs := map[string]interface{}{
-		"bindtype":     bindType[lang],
+		"bindtype": func(kind abi.Type) string {
+			return bindType[lang](kind, structs)
+		},
+		"bindtopictype": func(kind abi.Type) string {
+			return bindTopicType[lang](kind, structs)
+		},
 		"namedtype":    namedType[lang],
 		"capitalise":   capitalise,
 		"decapitalise": decapitalise,
+		"indexed":      indexed,
 	}
 	tmpl := template.Must(template.New("").Funcs(funcs).Parse(tmplSource[lang]))
 	if err := tmpl.Execute(buffer, data); err != nil {
@@ -134,7 +166,7 @@ func Bind(types []string, abis []string, bytecodes []string, pkg string, lang La
 
 // bindType is a set of type binders that convert Solidity types to some supported
 // programming language.
-var bindType = map[Lang]func(kind abi.Type) string{
+var bindType = map[Lang]func(kind abi.Type, structs map[string]*tmplStruct) string{
 	LangGo:   bindTypeGo,
 	LangJava: bindTypeJava,
 }
@@ -142,7 +174,10 @@ var bindType = map[Lang]func(kind abi.Type) string{
 // bindTypeGo converts a Solidity type to a Go one. Since there is no clear mapping
 // from all Solidity types to Go ones (e.g. uint17), those that cannot be exactly
 // mapped will use an upscaled type (e.g. *big.Int).
-func bindTypeGo(kind abi.Type) string {
+func bindTypeGo(kind abi.Type, structs map[string]*tmplStruct) string {
+	if hasTuple(kind) {
+		return bindStructTypeGo(kind, structs)
+	}
 	stringKind := kind.String()
 
 	switch {
@@ -183,10 +218,153 @@ func bindTypeGo(kind abi.Type) string {
 	}
 }
 
+// bindTopicType is a set of type binders that convert Solidity types to some
+// supported programming language topic types.
+var bindTopicType = map[Lang]func(kind abi.Type, structs map[string]*tmplStruct) string{
+	LangGo:   bindTopicTypeGo,
+	LangJava: bindTopicTypeJava,
+}
+
+// bindTopicTypeGo converts a Solidity topic type to a Go one. It is almost the same
+// funcionality as for simple types, but dynamic types get converted to hashes.
+func bindTopicTypeGo(kind abi.Type, structs map[string]*tmplStruct) string {
+	bound := bindTypeGo(kind, structs)
+	if isDynamicTopic(kind) {
+		bound = "common.Hash"
+	}
+	return bound
+}
+
+// bindTopicTypeJava converts a Solidity topic type to a Java one. It is almost the same
+// funcionality as for simple types, but dynamic types get converted to hashes.
+func bindTopicTypeJava(kind abi.Type, structs map[string]*tmplStruct) string {
+	bound := bindTypeJava(kind, structs)
+	if isDynamicTopic(kind) {
+		bound = "Hash"
+	}
+	return bound
+}
+
+// isDynamicTopic returns whether an indexed event input of the given type is
+// stored in the topics as the hash of its value.
+func isDynamicTopic(kind abi.Type) bool {
+	switch kind.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+		return true
+	}
+	return false
+}
+
+// bindStructs collects the tuple types of all the arguments in a contract ABI
+// into the shared set of struct definitions, in a deterministic order so that
+// struct names are stable across generations.
+func bindStructs(evmABI abi.ABI, lang Lang, structs map[string]*tmplStruct) error {
+	args := evmABI.Constructor.Inputs
+
+	methods := make([]string, 0, len(evmABI.Methods))
+	for name := range evmABI.Methods {
+		methods = append(methods, name)
+	}
+	sort.Strings(methods)
+	for _, name := range methods {
+		args = append(args, evmABI.Methods[name].Inputs...)
+		args = append(args, evmABI.Methods[name].Outputs...)
+	}
+	events := make([]string, 0, len(evmABI.Events))
+	for name := range evmABI.Events {
+		events = append(events, name)
+	}
+	sort.Strings(events)
+	for _, name := range events {
+		args = append(args, evmABI.Events[name].Inputs...)
+	}
+	for _, arg := range args {
+		if !hasTuple(arg.Type) {
+			continue
+		}
+		if lang != LangGo {
+			return errors.New("tuple arguments are only supported in Go bindings")
+		}
+		bindStructTypeGo(arg.Type, structs)
+	}
+	return nil
+}
+
+// bindStructTypeGo converts a Solidity tuple type (or slices and arrays of them)
+// to a Go one, registering a named struct for every distinct tuple encountered.
+func bindStructTypeGo(kind abi.Type, structs map[string]*tmplStruct) string {
+	switch kind.T {
+	case abi.TupleTy:
+		key := structKey(kind)
+		if s, exist := structs[key]; exist {
+			return s.Name
+		}
+		fields := make([]*tmplField, len(kind.TupleElems))
+		for i, elem := range kind.TupleElems {
+			fields[i] = &tmplField{
+				Type:    bindTypeGo(*elem, structs),
+				Name:    abi.ToCamelCase(kind.TupleRawNames[i]),
+				SolKind: *elem,
+			}
+		}
+		name := fmt.Sprintf("Struct%d", len(structs))
+		structs[key] = &tmplStruct{Name: name, Fields: fields}
+		return name
+
+	case abi.ArrayTy:
+		return fmt.Sprintf("[%d]", kind.Size) + bindStructTypeGo(*kind.Elem, structs)
+
+	case abi.SliceTy:
+		return "[]" + bindStructTypeGo(*kind.Elem, structs)
+
+	default:
+		return bindTypeGo(kind, structs)
+	}
+}
+
+// structKey returns a unique identifier of a tuple type, covering both the types
+// and the names of its (possibly nested) fields.
+func structKey(kind abi.Type) string {
+	fields := make([]string, len(kind.TupleElems))
+	for i, elem := range kind.TupleElems {
+		fields[i] = kind.TupleRawNames[i] + " "
+		if hasTuple(*elem) {
+			fields[i] += structKey(innerTuple(*elem)) + strings.TrimPrefix(elem.String(), innerTuple(*elem).String())
+		} else {
+			fields[i] += elem.String()
+		}
+	}
+	return "(" + strings.Join(fields, ",") + ")"
+}
+
+// hasTuple returns whether the type is a tuple or a slice/array of tuples.
+func hasTuple(kind abi.Type) bool {
+	return innerTuple(kind).T == abi.TupleTy
+}
+
+// innerTuple strips any slice and array wrappers from the type.
+func innerTuple(kind abi.Type) abi.Type {
+	for kind.T == abi.SliceTy || kind.T == abi.ArrayTy {
+		kind = *kind.Elem
+	}
+	return kind
+}
+
+// indexed filters an event argument list down to the indexed (topic) ones.
+func indexed(args []abi.Argument) []abi.Argument {
+	var topics []abi.Argument
+	for _, arg := range args {
+		if arg.Indexed {
+			topics = append(topics, arg)
+		}
+	}
+	return topics
+}
+
 // bindTypeJava converts a Solidity type to a Java one. Since there is no clear mapping
 // from all Solidity types to Java ones (e.g. uint17), those that cannot be exactly
 // mapped will use an upscaled type (e.g. BigDecimal).
-func bindTypeJava(kind abi.Type) string {
+func bindTypeJava(kind abi.Type, structs map[string]*tmplStruct) string {
 	stringKind := kind.String()
 
 	switch {
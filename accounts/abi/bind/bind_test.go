@@ -447,6 +447,162 @@ var bindTests = []struct {
 			}
 		`,
 	},
+	// Tests that events can be filtered for and watched through the typed bindings
+	{
+		`Eventer`,
+		`
+			contract Eventer {
+				event SimpleEvent(address indexed addr, bytes32 indexed id, bool indexed flag, uint256 value);
+
+				function raiseSimpleEvent(address addr, bytes32 id, bool flag, uint256 value) {
+					SimpleEvent(addr, id, flag, value);
+				}
+			}
+		`,
+		`603680600b6000396000f3606435600052604435602435600435` + "7f1f097de4289df643bd9c11011cc61367aa12983405c021056e706eb5ba1250c8" + `60206000a400`,
+		`[{"constant":false,"inputs":[{"name":"addr","type":"address"},{"name":"id","type":"bytes32"},{"name":"flag","type":"bool"},{"name":"value","type":"uint256"}],"name":"raiseSimpleEvent","outputs":[],"payable":false,"type":"function"},{"anonymous":false,"inputs":[{"indexed":true,"name":"addr","type":"address"},{"indexed":true,"name":"id","type":"bytes32"},{"indexed":true,"name":"flag","type":"bool"},{"indexed":false,"name":"value","type":"uint256"}],"name":"SimpleEvent","type":"event"}]`,
+		`
+			// Generate a new random account and a funded simulator
+			key, _ := crypto.GenerateKey()
+			auth := bind.NewKeyedTransactor(key)
+			sim := backends.NewSimulatedBackend(core.GenesisAlloc{auth.From: {Balance: big.NewInt(10000000000)}})
+
+			// Deploy an eventer contract
+			_, _, eventer, err := DeployEventer(auth, sim)
+			if err != nil {
+				t.Fatalf("Failed to deploy eventer contract: %v", err)
+			}
+			sim.Commit()
+
+			// Inject a few events into the contract, gradually more in each block
+			for i := 1; i <= 3; i++ {
+				for j := 1; j <= i; j++ {
+					if _, err := eventer.RaiseSimpleEvent(auth, common.Address{byte(j)}, [32]byte{byte(j)}, j%2 == 1, big.NewInt(int64(10*i+j))); err != nil {
+						t.Fatalf("block %d, event %d: raise failed: %v", i, j, err)
+					}
+				}
+				sim.Commit()
+			}
+			// Test filtering for certain events and ensure they can be found
+			sit, err := eventer.FilterSimpleEvent(nil, []common.Address{common.Address{1}, common.Address{3}}, [][32]byte{{byte(3)}}, []bool{true})
+			if err != nil {
+				t.Fatalf("failed to filter for simple events: %v", err)
+			}
+			defer sit.Close()
+
+			sit.Next()
+			if sit.Event.Value.Uint64() != 33 || sit.Event.Addr != (common.Address{3}) || sit.Event.Id != ([32]byte{3}) || !sit.Event.Flag {
+				t.Errorf("simple log content mismatch: have %v, want {33, 0x03..., 0x03..., true}", sit.Event)
+			}
+			if sit.Next() {
+				t.Errorf("unexpected simple event found: %+v", sit.Event)
+			}
+			if err = sit.Error(); err != nil {
+				t.Fatalf("simple event iteration failed: %v", err)
+			}
+			// Test filtering for all events of a certain kind
+			all, err := eventer.FilterSimpleEvent(&bind.FilterOpts{Start: 0}, nil, nil, []bool{false})
+			if err != nil {
+				t.Fatalf("failed to filter for simple events: %v", err)
+			}
+			defer all.Close()
+
+			found := 0
+			for all.Next() {
+				if all.Event.Flag {
+					t.Errorf("filtered event flag mismatch: have true, want false")
+				}
+				found++
+			}
+			if err = all.Error(); err != nil {
+				t.Fatalf("simple event iteration failed: %v", err)
+			}
+			if found != 2 {
+				t.Errorf("filtered event count mismatch: have %d, want %d", found, 2)
+			}
+			// Test watching for new events and ensure they are delivered
+			ch := make(chan *EventerSimpleEvent, 16)
+			sub, err := eventer.WatchSimpleEvent(nil, ch, nil, nil, nil)
+			if err != nil {
+				t.Fatalf("failed to subscribe to simple events: %v", err)
+			}
+			defer sub.Unsubscribe()
+
+			if _, err := eventer.RaiseSimpleEvent(auth, common.Address{255}, [32]byte{255}, true, big.NewInt(255)); err != nil {
+				t.Fatalf("failed to raise watched event: %v", err)
+			}
+			sim.Commit()
+
+			select {
+			case event := <-ch:
+				if event.Value.Uint64() != 255 || event.Addr != (common.Address{255}) || event.Id != ([32]byte{255}) || !event.Flag {
+					t.Errorf("watched log content mismatch: have %v, want {255, 0xff..., 0xff..., true}", event)
+				}
+			case err := <-sub.Err():
+				t.Fatalf("simple event subscription failed: %v", err)
+			case <-time.After(250 * time.Millisecond):
+				t.Fatalf("watched event not delivered")
+			}
+		`,
+	},
+	// Tests that tuple arguments and returns are bound to Go structs
+	{
+		`Tupler`,
+		`
+			pragma experimental ABIEncoderV2;
+
+			contract Tupler {
+				struct Record { uint256 id; string name; address[] owners; }
+				struct Flag { uint256 id; bool flag; }
+
+				function echo(Record p) constant returns (Record) {
+					return p;
+				}
+				function echoList(Flag[] list) constant returns (Flag[]) {
+					return list;
+				}
+			}
+		`,
+		`600e80600b6000396000f336600490038060046000376000f3`,
+		`[{"constant":true,"inputs":[{"name":"p","type":"tuple","components":[{"name":"id","type":"uint256"},{"name":"name","type":"string"},{"name":"owners","type":"address[]"}]}],"name":"echo","outputs":[{"name":"","type":"tuple","components":[{"name":"id","type":"uint256"},{"name":"name","type":"string"},{"name":"owners","type":"address[]"}]}],"payable":false,"type":"function"},{"constant":true,"inputs":[{"name":"list","type":"tuple[]","components":[{"name":"id","type":"uint256"},{"name":"flag","type":"bool"}]}],"name":"echoList","outputs":[{"name":"","type":"tuple[]","components":[{"name":"id","type":"uint256"},{"name":"flag","type":"bool"}]}],"payable":false,"type":"function"}]`,
+		`
+			// Generate a new random account and a funded simulator
+			key, _ := crypto.GenerateKey()
+			auth := bind.NewKeyedTransactor(key)
+			sim := backends.NewSimulatedBackend(core.GenesisAlloc{auth.From: {Balance: big.NewInt(10000000000)}})
+
+			// Deploy a tuple echoing contract
+			_, _, tupler, err := DeployTupler(auth, sim)
+			if err != nil {
+				t.Fatalf("Failed to deploy tupler contract: %v", err)
+			}
+			sim.Commit()
+
+			// Round trip a dynamic tuple through the contract
+			record := Struct0{Id: big.NewInt(7), Name: "tuple", Owners: []common.Address{{1}, {2}}}
+			res, err := tupler.Echo(nil, record)
+			if err != nil {
+				t.Fatalf("Failed to echo tuple: %v", err)
+			}
+			if res.Id.Cmp(record.Id) != 0 || res.Name != record.Name || !reflect.DeepEqual(res.Owners, record.Owners) {
+				t.Fatalf("Tuple mismatch: have %+v, want %+v", res, record)
+			}
+			// Round trip a list of static tuples through the contract
+			flags := []Struct1{{Id: big.NewInt(1), Flag: true}, {Id: big.NewInt(2), Flag: false}}
+			list, err := tupler.EchoList(nil, flags)
+			if err != nil {
+				t.Fatalf("Failed to echo tuple list: %v", err)
+			}
+			if len(list) != len(flags) {
+				t.Fatalf("Tuple list length mismatch: have %d, want %d", len(list), len(flags))
+			}
+			for i := range flags {
+				if list[i].Id.Cmp(flags[i].Id) != 0 || list[i].Flag != flags[i].Flag {
+					t.Errorf("Tuple %d mismatch: have %+v, want %+v", i, list[i], flags[i])
+				}
+			}
+		`,
+	},
 }
 
 // Tests that packages generated by the binder can be successfully compiled and
@@ -0,0 +1,89 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/core/types"
+	"github.com/meitu/go-ethereum/params"
+)
+
+// TransactDpos builds a dpos staking operation of the given type, signs it with
+// the transactor and sends it through the backend. Logging in and out doesn't
+// take a candidate, delegating and undelegating need the one voted for. The
+// operation is a typed transaction, accepted from the typed transaction fork on.
+//
+// Unset nonce, gas price and gas limit fields of the options are filled in from
+// the backend and the intrinsic gas cost of the operation.
+func TransactDpos(opts *TransactOpts, transactor ContractTransactor, txType types.TxType, candidate common.Address) (*types.Transaction, error) {
+	var (
+		to  common.Address
+		gas uint64
+	)
+	switch txType {
+	case types.LoginCandidate, types.LogoutCandidate:
+		// Operations without a recipient are charged as contract creations
+		gas = params.TxGasContractCreation
+	case types.Delegate, types.UnDelegate:
+		if (candidate == common.Address{}) {
+			return nil, errors.New("no candidate to vote for")
+		}
+		to, gas = candidate, params.TxGas
+	default:
+		return nil, types.ErrInvalidType
+	}
+	var (
+		nonce uint64
+		err   error
+	)
+	if opts.Nonce == nil {
+		nonce, err = transactor.PendingNonceAt(ensureContext(opts.Context), opts.From)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve account nonce: %v", err)
+		}
+	} else {
+		nonce = opts.Nonce.Uint64()
+	}
+	gasPrice := opts.GasPrice
+	if gasPrice == nil {
+		gasPrice, err = transactor.SuggestGasPrice(ensureContext(opts.Context))
+		if err != nil {
+			return nil, fmt.Errorf("failed to suggest gas price: %v", err)
+		}
+	}
+	gasLimit := opts.GasLimit
+	if gasLimit == nil {
+		gasLimit = new(big.Int).SetUint64(gas)
+	}
+	// Create the transaction, sign it and schedule it for execution
+	rawTx := types.NewTransaction(txType, nonce, to, nil, gasLimit, gasPrice, nil)
+	if opts.Signer == nil {
+		return nil, errors.New("no signer to authorize the transaction with")
+	}
+	signedTx, err := opts.Signer(types.HomesteadSigner{}, opts.From, rawTx)
+	if err != nil {
+		return nil, err
+	}
+	if err := transactor.SendTransaction(ensureContext(opts.Context), signedTx); err != nil {
+		return nil, err
+	}
+	return signedTx, nil
+}
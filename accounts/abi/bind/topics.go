@@ -0,0 +1,160 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/meitu/go-ethereum/accounts/abi"
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/common/math"
+	"github.com/meitu/go-ethereum/crypto"
+)
+
+// makeTopics converts a filter query argument list into a filter topic set.
+// Dynamic types (strings, byte slices) are matched by the hash of their value.
+func makeTopics(query ...[]interface{}) ([][]common.Hash, error) {
+	topics := make([][]common.Hash, len(query))
+	for i, filter := range query {
+		for _, rule := range filter {
+			var topic common.Hash
+
+			// Try to generate the topic based on simple types
+			switch rule := rule.(type) {
+			case common.Hash:
+				copy(topic[:], rule[:])
+			case common.Address:
+				copy(topic[common.HashLength-common.AddressLength:], rule[:])
+			case *big.Int:
+				copy(topic[:], math.PaddedBigBytes(math.U256(new(big.Int).Set(rule)), common.HashLength))
+			case bool:
+				if rule {
+					topic[common.HashLength-1] = 1
+				}
+			case int8:
+				topic = intTopic(int64(rule))
+			case int16:
+				topic = intTopic(int64(rule))
+			case int32:
+				topic = intTopic(int64(rule))
+			case int64:
+				topic = intTopic(rule)
+			case uint8:
+				topic = uintTopic(uint64(rule))
+			case uint16:
+				topic = uintTopic(uint64(rule))
+			case uint32:
+				topic = uintTopic(uint64(rule))
+			case uint64:
+				topic = uintTopic(rule)
+			case string:
+				topic = crypto.Keccak256Hash([]byte(rule))
+			case []byte:
+				topic = crypto.Keccak256Hash(rule)
+
+			default:
+				// Attempt to generate the topic from fixed size byte arrays, which
+				// are left aligned
+				val := reflect.ValueOf(rule)
+				if val.Kind() != reflect.Array || val.Type().Elem().Kind() != reflect.Uint8 || val.Len() > common.HashLength {
+					return nil, fmt.Errorf("unsupported indexed type: %T", rule)
+				}
+				reflect.Copy(reflect.ValueOf(topic[:val.Len()]), val)
+			}
+			topics[i] = append(topics[i], topic)
+		}
+	}
+	return topics, nil
+}
+
+// intTopic returns the two's complement topic of a signed integer.
+func intTopic(n int64) common.Hash {
+	return common.BytesToHash(math.PaddedBigBytes(math.U256(big.NewInt(n)), common.HashLength))
+}
+
+// uintTopic returns the topic of an unsigned integer.
+func uintTopic(n uint64) common.Hash {
+	return common.BytesToHash(new(big.Int).SetUint64(n).Bytes())
+}
+
+// parseTopics converts the indexed topic fields into actual log field values.
+//
+// Note, dynamic types cannot be reconstructed since they get mapped to Keccak256
+// hashes as the topic value, so the output fields must be hashes for them.
+func parseTopics(out interface{}, fields []abi.Argument, topics []common.Hash) error {
+	// Sanity check that the fields and topics match up
+	if len(fields) != len(topics) {
+		return errors.New("topic/field count mismatch")
+	}
+	value := reflect.ValueOf(out)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		if len(fields) == 0 {
+			return nil
+		}
+		return fmt.Errorf("abi: cannot unmarshal topics in to %T", out)
+	}
+	for i, arg := range fields {
+		if !arg.Indexed {
+			return errors.New("non-indexed field in topic reconstruction")
+		}
+		field := value.Elem().FieldByName(capitalise(arg.Name))
+		if !field.IsValid() {
+			return fmt.Errorf("abi: no field %s for indexed input", capitalise(arg.Name))
+		}
+		topic := topics[i]
+
+		switch field.Kind() {
+		case reflect.Bool:
+			field.SetBool(topic[common.HashLength-1] == 1)
+
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			num := math.S256(new(big.Int).SetBytes(topic[:]))
+			field.SetInt(num.Int64())
+
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			num := new(big.Int).SetBytes(topic[:])
+			field.SetUint(num.Uint64())
+
+		default:
+			switch field.Type() {
+			case reflect.TypeOf(common.Hash{}):
+				field.Set(reflect.ValueOf(topic))
+
+			case reflect.TypeOf(common.Address{}):
+				field.Set(reflect.ValueOf(common.BytesToAddress(topic[:])))
+
+			case reflect.TypeOf(new(big.Int)):
+				num := new(big.Int).SetBytes(topic[:])
+				if arg.Type.T == abi.IntTy {
+					num = math.S256(num)
+				}
+				field.Set(reflect.ValueOf(num))
+
+			default:
+				// Ran out of plain primitive types, try fixed size byte arrays
+				if field.Kind() != reflect.Array || field.Type().Elem().Kind() != reflect.Uint8 || field.Len() > common.HashLength {
+					return fmt.Errorf("unsupported indexed type: %v", field.Type())
+				}
+				reflect.Copy(field, reflect.ValueOf(topic[:field.Len()]))
+			}
+		}
+	}
+	return nil
+}
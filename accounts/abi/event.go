@@ -34,6 +34,25 @@ type Event struct {
 	Inputs    []Argument
 }
 
+// String returns the Solidity declaration of the event.
+func (e Event) String() string {
+	inputs := make([]string, len(e.Inputs))
+	for i, input := range e.Inputs {
+		inputs[i] = input.Type.String()
+		if input.Indexed {
+			inputs[i] += " indexed"
+		}
+		if len(input.Name) > 0 {
+			inputs[i] += " " + input.Name
+		}
+	}
+	anonymous := ""
+	if e.Anonymous {
+		anonymous = " anonymous"
+	}
+	return fmt.Sprintf("event %v(%v)%s", e.Name, strings.Join(inputs, ", "), anonymous)
+}
+
 // Id returns the canonical representation of the event's signature used by the
 // abi definition to identify event names and types.
 func (e Event) Id() common.Hash {
@@ -65,20 +84,19 @@ func (e Event) tupleUnpack(v interface{}, output []byte) error {
 		return fmt.Errorf("abi: cannot unmarshal tuple in to %v", typ)
 	}
 
-	j := 0
+	offset := 0
 	for i := 0; i < len(e.Inputs); i++ {
 		input := e.Inputs[i]
 		if input.Indexed {
-			// can't read, continue
+			// indexed inputs are stored in the topics, not in the data
 			continue
-		} else if input.Type.T == ArrayTy {
-			// need to move this up because they read sequentially
-			j += input.Type.Size
 		}
-		marshalledValue, err := toGoType((i+j)*32, input.Type, output)
+		marshalledValue, err := toGoType(offset, input.Type, output)
 		if err != nil {
 			return err
 		}
+		// static arrays and tuples are stored in place, spanning multiple words
+		offset += getTypeSize(input.Type)
 		reflectValue := reflect.ValueOf(marshalledValue)
 
 		switch value.Kind() {
@@ -120,12 +138,16 @@ func (e Event) singleUnpack(v interface{}, output []byte) error {
 		return fmt.Errorf("abi: Unpack(non-pointer %T)", v)
 	}
 
+	value := valueOf.Elem()
+
+	// structs are filled by input name, as for tuples
+	if value.Kind() == reflect.Struct {
+		return e.tupleUnpack(v, output)
+	}
 	if e.Inputs[0].Indexed {
 		return fmt.Errorf("abi: attempting to unpack indexed variable into element.")
 	}
 
-	value := valueOf.Elem()
-
 	marshalledValue, err := toGoType(0, e.Inputs[0].Type, output)
 	if err != nil {
 		return err
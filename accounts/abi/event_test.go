@@ -17,6 +17,7 @@
 package abi
 
 import (
+	"math/big"
 	"strings"
 	"testing"
 
@@ -54,3 +55,46 @@ func TestEventId(t *testing.T) {
 		}
 	}
 }
+
+func TestEventString(t *testing.T) {
+	definition := `[{ "type" : "event", "name" : "Transfer", "anonymous" : true, "inputs": [{ "name" : "from", "type": "address", "indexed": true }, { "name" : "value", "type": "uint256" }] }]`
+
+	abi, err := JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "event Transfer(address indexed from, uint256 value) anonymous"
+	if have := abi.Events["Transfer"].String(); have != want {
+		t.Errorf("event string mismatch: have %q, want %q", have, want)
+	}
+}
+
+func TestEventUnpackSkipsIndexed(t *testing.T) {
+	definition := `[{ "type" : "event", "name" : "Transfer", "inputs": [
+		{ "name" : "from", "type": "address", "indexed": true },
+		{ "name" : "value", "type": "uint256" },
+		{ "name" : "to", "type": "address", "indexed": true },
+		{ "name" : "fee", "type": "uint256" }
+	] }]`
+
+	abi, err := JSON(strings.NewReader(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the non-indexed fields are present in the log data
+	data := append(common.LeftPadBytes(big.NewInt(1000).Bytes(), 32), common.LeftPadBytes(big.NewInt(7).Bytes(), 32)...)
+
+	var out struct {
+		Value *big.Int
+		Fee   *big.Int
+	}
+	if err := abi.Unpack(&out, "Transfer", data); err != nil {
+		t.Fatalf("failed to unpack event: %v", err)
+	}
+	if out.Value.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("value mismatch: have %v, want %v", out.Value, 1000)
+	}
+	if out.Fee.Cmp(big.NewInt(7)) != 0 {
+		t.Errorf("fee mismatch: have %v, want %v", out.Fee, 7)
+	}
+}
@@ -48,6 +48,12 @@ func (method Method) pack(args ...interface{}) ([]byte, error) {
 	// output. This is used for strings and bytes types input.
 	var variableInput []byte
 
+	// dynamic inputs are referenced by their offset after the static head
+	inputOffset := 0
+	for _, input := range method.Inputs {
+		inputOffset += getTypeSize(input.Type)
+	}
+
 	var ret []byte
 	for i, a := range args {
 		input := method.Inputs[i]
@@ -57,12 +63,10 @@ func (method Method) pack(args ...interface{}) ([]byte, error) {
 			return nil, fmt.Errorf("`%s` %v", method.Name, err)
 		}
 
-		// check for a slice type (string, bytes, slice)
-		if input.Type.requiresLengthPrefix() {
-			// calculate the offset
-			offset := len(method.Inputs)*32 + len(variableInput)
+		// check for a dynamic type (string, bytes, slice, dynamic array or tuple)
+		if isDynamicType(input.Type) {
 			// set the offset
-			ret = append(ret, packNum(reflect.ValueOf(offset))...)
+			ret = append(ret, packNum(reflect.ValueOf(inputOffset+len(variableInput)))...)
 			// Append the packed output to the variable input. The variable input
 			// will be appended at the end of the input.
 			variableInput = append(variableInput, packed...)
@@ -92,17 +96,15 @@ func (method Method) tupleUnpack(v interface{}, output []byte) error {
 		typ   = value.Type()
 	)
 
-	j := 0
+	offset := 0
 	for i := 0; i < len(method.Outputs); i++ {
 		toUnpack := method.Outputs[i]
-		if toUnpack.Type.T == ArrayTy {
-			// need to move this up because they read sequentially
-			j += toUnpack.Type.Size
-		}
-		marshalledValue, err := toGoType((i+j)*32, toUnpack.Type, output)
+		marshalledValue, err := toGoType(offset, toUnpack.Type, output)
 		if err != nil {
 			return err
 		}
+		// static arrays and tuples are stored in place, spanning multiple words
+		offset += getTypeSize(toUnpack.Type)
 		reflectValue := reflect.ValueOf(marshalledValue)
 
 		switch value.Kind() {
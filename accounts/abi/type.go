@@ -17,6 +17,7 @@
 package abi
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"regexp"
@@ -37,6 +38,7 @@ const (
 	HashTy
 	FixedPointTy
 	FunctionTy
+	TupleTy
 )
 
 // Type is the reflection of the supported argument type
@@ -49,6 +51,10 @@ type Type struct {
 	T    byte // Our own type checking
 
 	stringKind string // holds the unparsed string for deriving signatures
+
+	// Tuple relative fields
+	TupleElems    []*Type  // Type information of all tuple fields
+	TupleRawNames []string // Raw field name of all tuple fields
 }
 
 var (
@@ -58,6 +64,12 @@ var (
 
 // NewType creates a new reflection type of abi type given in t.
 func NewType(t string) (typ Type, err error) {
+	return newType(t, nil)
+}
+
+// newType creates a new reflection type of abi type given in t, using the
+// components to describe the fields of tuple types.
+func newType(t string, components []ArgumentMarshaling) (typ Type, err error) {
 	// check that array brackets are equal if they exist
 	if strings.Count(t, "[") != strings.Count(t, "]") {
 		return Type{}, fmt.Errorf("invalid arg type in abi")
@@ -70,12 +82,14 @@ func NewType(t string) (typ Type, err error) {
 	if strings.Count(t, "[") != 0 {
 		i := strings.LastIndex(t, "[")
 		// recursively embed the type
-		embeddedType, err := NewType(t[:i])
+		embeddedType, err := newType(t[:i], components)
 		if err != nil {
 			return Type{}, err
 		}
 		// grab the last cell and create a type from there
 		sliced := t[i:]
+		// tuples are named after their canonical expression, not the raw type
+		typ.stringKind = embeddedType.stringKind + sliced
 		// grab the slice size with regexp
 		re := regexp.MustCompile("[0-9]+")
 		intz := re.FindAllString(sliced, -1)
@@ -159,6 +173,39 @@ func NewType(t string) (typ Type, err error) {
 			typ.T = FunctionTy
 			typ.Size = 24
 			typ.Type = reflect.ArrayOf(24, reflect.TypeOf(byte(0)))
+		case "tuple":
+			var (
+				fields []reflect.StructField
+				elems  []*Type
+				names  []string
+				kinds  []string
+				used   = make(map[string]bool)
+			)
+			for _, c := range components {
+				cType, err := newType(c.Type, c.Components)
+				if err != nil {
+					return Type{}, err
+				}
+				name := ToCamelCase(c.Name)
+				if name == "" {
+					return Type{}, errors.New("abi: purely anonymous or underscored field is not supported")
+				}
+				if used[name] {
+					return Type{}, fmt.Errorf("abi: duplicate tuple field %s", name)
+				}
+				used[name] = true
+
+				fields = append(fields, reflect.StructField{Name: name, Type: cType.Type})
+				elems = append(elems, &cType)
+				names = append(names, c.Name)
+				kinds = append(kinds, cType.stringKind)
+			}
+			typ.Kind = reflect.Struct
+			typ.Type = reflect.StructOf(fields)
+			typ.TupleElems = elems
+			typ.TupleRawNames = names
+			typ.T = TupleTy
+			typ.stringKind = "(" + strings.Join(kinds, ",") + ")"
 		default:
 			return Type{}, fmt.Errorf("unsupported arg type: %s", t)
 		}
@@ -180,23 +227,60 @@ func (t Type) pack(v reflect.Value) ([]byte, error) {
 		return nil, err
 	}
 
-	if t.T == SliceTy || t.T == ArrayTy {
-		var packed []byte
+	switch t.T {
+	case SliceTy, ArrayTy:
+		var ret []byte
+		if t.requiresLengthPrefix() {
+			ret = append(ret, packNum(reflect.ValueOf(v.Len()))...)
+		}
+		// dynamic elements are referenced by offsets from the start of the content
+		dynamic := isDynamicType(*t.Elem)
+		offset := getTypeSize(*t.Elem) * v.Len()
 
+		var tail []byte
 		for i := 0; i < v.Len(); i++ {
 			val, err := t.Elem.pack(v.Index(i))
 			if err != nil {
 				return nil, err
 			}
-			packed = append(packed, val...)
+			if !dynamic {
+				ret = append(ret, val...)
+				continue
+			}
+			ret = append(ret, packNum(reflect.ValueOf(offset))...)
+			offset += len(val)
+			tail = append(tail, val...)
 		}
-		if t.T == SliceTy {
-			return packBytesSlice(packed, v.Len()), nil
-		} else if t.T == ArrayTy {
-			return packed, nil
+		return append(ret, tail...), nil
+
+	case TupleTy:
+		offset := 0
+		for _, elem := range t.TupleElems {
+			offset += getTypeSize(*elem)
+		}
+		var ret, tail []byte
+		for i, elem := range t.TupleElems {
+			field := v.FieldByName(ToCamelCase(t.TupleRawNames[i]))
+			if !field.IsValid() {
+				return nil, fmt.Errorf("abi: field %s can't be found in the given value", t.TupleRawNames[i])
+			}
+			val, err := elem.pack(field)
+			if err != nil {
+				return nil, err
+			}
+			if isDynamicType(*elem) {
+				ret = append(ret, packNum(reflect.ValueOf(offset))...)
+				offset += len(val)
+				tail = append(tail, val...)
+			} else {
+				ret = append(ret, val...)
+			}
 		}
+		return append(ret, tail...), nil
+
+	default:
+		return packElement(t, v), nil
 	}
-	return packElement(t, v), nil
 }
 
 // requireLengthPrefix returns whether the type requires any sort of length
@@ -204,3 +288,42 @@ func (t Type) pack(v reflect.Value) ([]byte, error) {
 func (t Type) requiresLengthPrefix() bool {
 	return t.T == StringTy || t.T == BytesTy || t.T == SliceTy
 }
+
+// isDynamicType returns whether the type is encoded out of place, referenced by
+// an offset: bytes, string, slices, arrays of dynamic types and tuples with at
+// least one dynamic field.
+func isDynamicType(t Type) bool {
+	switch t.T {
+	case StringTy, BytesTy, SliceTy:
+		return true
+	case ArrayTy:
+		return isDynamicType(*t.Elem)
+	case TupleTy:
+		for _, elem := range t.TupleElems {
+			if isDynamicType(*elem) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getTypeSize returns the number of bytes the type occupies in the head of an
+// encoding. Static arrays and tuples are stored in place, everything else takes
+// a single word, either the value itself or an offset to it.
+func getTypeSize(t Type) int {
+	if isDynamicType(t) {
+		return 32
+	}
+	switch t.T {
+	case ArrayTy:
+		return t.Size * getTypeSize(*t.Elem)
+	case TupleTy:
+		size := 0
+		for _, elem := range t.TupleElems {
+			size += getTypeSize(*elem)
+		}
+		return size
+	}
+	return 32
+}
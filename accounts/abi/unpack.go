@@ -139,6 +139,44 @@ func forEachUnpack(t Type, output []byte, start, size int) (interface{}, error)
 	return refSlice.Interface(), nil
 }
 
+// forEachTupleUnpack unpacks a slice or array of tuples, with the encoding of
+// the elements starting at the beginning of output. Dynamic elements are stored
+// as offsets relative to that beginning.
+func forEachTupleUnpack(t Type, output []byte, size int) (interface{}, error) {
+	var refSlice reflect.Value
+	if t.T == SliceTy {
+		refSlice = reflect.MakeSlice(t.Type, size, size)
+	} else {
+		refSlice = reflect.New(t.Type).Elem()
+	}
+	elemSize := getTypeSize(*t.Elem)
+	for i := 0; i < size; i++ {
+		inter, err := toGoType(i*elemSize, *t.Elem, output)
+		if err != nil {
+			return nil, err
+		}
+		refSlice.Index(i).Set(reflect.ValueOf(inter))
+	}
+	return refSlice.Interface(), nil
+}
+
+// forTupleUnpack unpacks a tuple whose encoding starts at the beginning of
+// output. Dynamic fields are stored as offsets relative to that beginning.
+func forTupleUnpack(t Type, output []byte) (interface{}, error) {
+	retval := reflect.New(t.Type).Elem()
+
+	offset := 0
+	for i, elem := range t.TupleElems {
+		marshalledValue, err := toGoType(offset, *elem, output)
+		if err != nil {
+			return nil, err
+		}
+		retval.Field(i).Set(reflect.ValueOf(marshalledValue))
+		offset += getTypeSize(*elem)
+	}
+	return retval.Interface(), nil
+}
+
 // toGoType parses the output bytes and recursively assigns the value of these bytes
 // into a go type with accordance with the ABI spec.
 func toGoType(index int, t Type, output []byte) (interface{}, error) {
@@ -152,6 +190,18 @@ func toGoType(index int, t Type, output []byte) (interface{}, error) {
 		err          error
 	)
 
+	// tuples are decoded in place when static, or from where their offset points
+	if t.T == TupleTy {
+		if isDynamicType(t) {
+			begin, err := tuplePointsTo(index, output)
+			if err != nil {
+				return nil, err
+			}
+			return forTupleUnpack(t, output[begin:])
+		}
+		return forTupleUnpack(t, output[index:])
+	}
+
 	// if we require a length prefix, find the beginning word and size returned.
 	if t.requiresLengthPrefix() {
 		begin, end, err = lengthPrefixPointsTo(index, output)
@@ -164,8 +214,21 @@ func toGoType(index int, t Type, output []byte) (interface{}, error) {
 
 	switch t.T {
 	case SliceTy:
+		if t.Elem.T == TupleTy {
+			return forEachTupleUnpack(t, output[begin:], end)
+		}
 		return forEachUnpack(t, output, begin, end)
 	case ArrayTy:
+		if t.Elem.T == TupleTy {
+			if isDynamicType(t) {
+				begin, err := tuplePointsTo(index, output)
+				if err != nil {
+					return nil, err
+				}
+				return forEachTupleUnpack(t, output[begin:], t.Size)
+			}
+			return forEachTupleUnpack(t, output[index:], t.Size)
+		}
 		return forEachUnpack(t, output, index, t.Size)
 	case StringTy: // variable arrays are written at the end of the return bytes
 		return string(output[begin : begin+end]), nil
@@ -204,6 +267,17 @@ func lengthPrefixPointsTo(index int, output []byte) (start int, length int, err
 	return
 }
 
+// tuplePointsTo resolves the location of a dynamic tuple from the offset stored
+// at index.
+func tuplePointsTo(index int, output []byte) (int, error) {
+	// Compare before converting, large offsets would wrap to negative ints
+	offset := binary.BigEndian.Uint64(output[index+24 : index+32])
+	if offset >= uint64(len(output)) {
+		return 0, fmt.Errorf("abi: cannot marshal in to go tuple: offset %d would go over slice boundary (len=%d)", offset, len(output))
+	}
+	return int(offset), nil
+}
+
 // checks for proper formatting of byte output
 func bytesAreProper(output []byte) error {
 	if len(output) == 0 {
@@ -0,0 +1,292 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package external implements an accounts.Backend delegating account listing and
+// signing to an external signer process, so the node itself never holds keys.
+//
+// The signer is reached over JSON-RPC, via IPC or HTTP, and is expected to serve
+// the following methods, applying its own approval rules to each request:
+//
+//	account_version() string
+//	account_list() []address
+//	account_signHash(address, hash) signature
+//	account_signTransaction(args) rawTransaction
+//
+// Signatures are in the [R || S || V] format where V is 0 or 1. The transaction
+// arguments carry the DPoS transaction type, and the signer returns the signed
+// transaction in its RLP encoding.
+package external
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	ethereum "github.com/meitu/go-ethereum"
+	"github.com/meitu/go-ethereum/accounts"
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/common/hexutil"
+	"github.com/meitu/go-ethereum/core/types"
+	"github.com/meitu/go-ethereum/event"
+	"github.com/meitu/go-ethereum/log"
+	"github.com/meitu/go-ethereum/rlp"
+	"github.com/meitu/go-ethereum/rpc"
+)
+
+// ExternalScheme is the protocol scheme prefixing account and wallet URLs.
+const ExternalScheme = "extapi"
+
+// requestTimeout is the maximum time to wait for the signer to serve a listing
+// or status request. Signing requests aren't limited as they may await manual
+// approval.
+const requestTimeout = 5 * time.Second
+
+var (
+	// ErrSignerMismatch is returned if the external signer signed a transaction
+	// with a different account or contents than requested.
+	ErrSignerMismatch = errors.New("external signer returned a mismatching transaction")
+
+	errInvalidSignature = errors.New("external signer returned an invalid signature")
+)
+
+// ExternalBackend is an accounts.Backend serving the single wallet of an external
+// signer.
+type ExternalBackend struct {
+	signers []accounts.Wallet
+}
+
+// NewExternalBackend connects to the external signer at the given endpoint, which
+// is either the path of an IPC socket or an HTTP URL.
+func NewExternalBackend(endpoint string) (*ExternalBackend, error) {
+	signer, err := NewExternalSigner(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &ExternalBackend{signers: []accounts.Wallet{signer}}, nil
+}
+
+// Wallets implements accounts.Backend, returning the external signer.
+func (eb *ExternalBackend) Wallets() []accounts.Wallet {
+	return eb.signers
+}
+
+// Subscribe implements accounts.Backend. The external signer never arrives or
+// departs, so no events are ever sent.
+func (eb *ExternalBackend) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
+// ExternalSigner is an accounts.Wallet whose accounts are managed by an external
+// signer process.
+type ExternalSigner struct {
+	client   *rpc.Client
+	endpoint string
+
+	cacheMu  sync.RWMutex
+	cache    []accounts.Account // Accounts last listed by the signer
+	cacheErr error              // Failure of the last listing
+}
+
+// NewExternalSigner connects to the external signer at the given endpoint.
+func NewExternalSigner(endpoint string) (*ExternalSigner, error) {
+	client, err := rpc.Dial(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return newExternalSigner(client, endpoint), nil
+}
+
+// newExternalSigner creates a wallet using an already established connection.
+func newExternalSigner(client *rpc.Client, endpoint string) *ExternalSigner {
+	return &ExternalSigner{client: client, endpoint: endpoint}
+}
+
+// URL implements accounts.Wallet, returning the endpoint of the signer.
+func (s *ExternalSigner) URL() accounts.URL {
+	return accounts.URL{Scheme: ExternalScheme, Path: s.endpoint}
+}
+
+// Status implements accounts.Wallet, returning the version reported by the signer.
+func (s *ExternalSigner) Status() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	var version string
+	if err := s.client.CallContext(ctx, &version, "account_version"); err != nil {
+		return "Unreachable", err
+	}
+	return fmt.Sprintf("Online, version %s", version), nil
+}
+
+// Open implements accounts.Wallet. The connection is established on creation,
+// so this is a noop.
+func (s *ExternalSigner) Open(passphrase string) error { return nil }
+
+// Close implements accounts.Wallet, closing the connection to the signer.
+func (s *ExternalSigner) Close() error {
+	s.client.Close()
+	return nil
+}
+
+// Accounts implements accounts.Wallet, returning the accounts listed by the
+// signer. If the signer can't be reached, the last successful listing is used.
+func (s *ExternalSigner) Accounts() []accounts.Account {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	var addrs []common.Address
+	err := s.client.CallContext(ctx, &addrs, "account_list")
+
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+
+	if err != nil {
+		if s.cacheErr == nil {
+			log.Warn("Failed to list external signer accounts", "url", s.endpoint, "err", err)
+		}
+		s.cacheErr = err
+	} else {
+		s.cache = make([]accounts.Account, len(addrs))
+		for i, addr := range addrs {
+			s.cache[i] = accounts.Account{Address: addr, URL: s.URL()}
+		}
+		s.cacheErr = nil
+	}
+	cpy := make([]accounts.Account, len(s.cache))
+	copy(cpy, s.cache)
+	return cpy
+}
+
+// Contains implements accounts.Wallet, returning whether the signer lists the
+// account. The last listing is consulted first and refreshed on a miss.
+func (s *ExternalSigner) Contains(account accounts.Account) bool {
+	s.cacheMu.RLock()
+	cached := contains(s.cache, account)
+	s.cacheMu.RUnlock()
+
+	if cached {
+		return true
+	}
+	return contains(s.Accounts(), account)
+}
+
+// contains returns whether the account is among the listed ones, comparing the
+// URL only if the account specifies one.
+func contains(listed []accounts.Account, account accounts.Account) bool {
+	for _, known := range listed {
+		if known.Address == account.Address && (account.URL == (accounts.URL{}) || account.URL == known.URL) {
+			return true
+		}
+	}
+	return false
+}
+
+// Derive implements accounts.Wallet, but is not supported by external signers.
+func (s *ExternalSigner) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	return accounts.Account{}, accounts.ErrNotSupported
+}
+
+// SelfDerive implements accounts.Wallet, but is a noop for external signers.
+func (s *ExternalSigner) SelfDerive(base accounts.DerivationPath, chain ethereum.ChainStateReader) {}
+
+// SignHash implements accounts.Wallet, requesting the signer to sign the hash
+// with the given account.
+func (s *ExternalSigner) SignHash(account accounts.Account, hash []byte) ([]byte, error) {
+	var signature hexutil.Bytes
+	if err := s.client.Call(&signature, "account_signHash", account.Address, hexutil.Bytes(hash)); err != nil {
+		return nil, err
+	}
+	if len(signature) != 65 || signature[64] > 1 {
+		return nil, errInvalidSignature
+	}
+	return signature, nil
+}
+
+// SignTransactionArgs are the fields of a transaction sent to the signer with an
+// account_signTransaction request.
+type SignTransactionArgs struct {
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to"`
+	Type     hexutil.Uint64  `json:"type"`
+	Typed    bool            `json:"typed,omitempty"`
+	Nonce    hexutil.Uint64  `json:"nonce"`
+	Gas      *hexutil.Big    `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	Value    *hexutil.Big    `json:"value"`
+	Data     hexutil.Bytes   `json:"data"`
+	ChainID  *hexutil.Big    `json:"chainId,omitempty"`
+
+	// Recipients of a multi-transfer transaction
+	Transfers []*types.Transfer `json:"transfers,omitempty"`
+}
+
+// SignTx implements accounts.Wallet, requesting the signer to sign the transaction
+// with the given account. The signed transaction is checked to be sent from the
+// account and to match the requested contents.
+func (s *ExternalSigner) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args := &SignTransactionArgs{
+		From:     account.Address,
+		To:       tx.To(),
+		Type:     hexutil.Uint64(tx.Type()),
+		Typed:    tx.Typed(),
+		Nonce:    hexutil.Uint64(tx.Nonce()),
+		Gas:      (*hexutil.Big)(tx.Gas()),
+		GasPrice: (*hexutil.Big)(tx.GasPrice()),
+		Value:    (*hexutil.Big)(tx.Value()),
+		Data:     tx.Data(),
+		ChainID:  (*hexutil.Big)(chainID),
+
+		Transfers: tx.Transfers(),
+	}
+	var raw hexutil.Bytes
+	if err := s.client.Call(&raw, "account_signTransaction", args); err != nil {
+		return nil, err
+	}
+	signed := new(types.Transaction)
+	if err := rlp.DecodeBytes(raw, signed); err != nil {
+		return nil, err
+	}
+	// Depending on the presence of the chain ID, verify with EIP155 or homestead
+	var signer types.Signer = types.HomesteadSigner{}
+	if chainID != nil {
+		signer = types.NewEIP155Signer(chainID)
+	}
+	if signed.Type() != tx.Type() || signer.Hash(signed) != signer.Hash(tx) {
+		return nil, ErrSignerMismatch
+	}
+	if from, err := types.Sender(signer, signed); err != nil || from != account.Address {
+		return nil, ErrSignerMismatch
+	}
+	return signed, nil
+}
+
+// SignHashWithPassphrase implements accounts.Wallet. The signer applies its own
+// approval rules, so the passphrase is ignored.
+func (s *ExternalSigner) SignHashWithPassphrase(account accounts.Account, passphrase string, hash []byte) ([]byte, error) {
+	return s.SignHash(account, hash)
+}
+
+// SignTxWithPassphrase implements accounts.Wallet. The signer applies its own
+// approval rules, so the passphrase is ignored.
+func (s *ExternalSigner) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return s.SignTx(account, tx, chainID)
+}
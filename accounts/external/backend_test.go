@@ -0,0 +1,170 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package external
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/meitu/go-ethereum/accounts"
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/common/hexutil"
+	"github.com/meitu/go-ethereum/core/types"
+	"github.com/meitu/go-ethereum/crypto"
+	"github.com/meitu/go-ethereum/rlp"
+	"github.com/meitu/go-ethereum/rpc"
+)
+
+// SignerService is an external signer holding a single key, which refuses to sign
+// candidate logouts and can be made to tamper with the transactions it signs.
+type SignerService struct {
+	key    *ecdsa.PrivateKey
+	tamper bool
+}
+
+func (s *SignerService) Version() string { return "1.0.0" }
+
+func (s *SignerService) List() []common.Address {
+	return []common.Address{crypto.PubkeyToAddress(s.key.PublicKey)}
+}
+
+func (s *SignerService) SignHash(addr common.Address, hash hexutil.Bytes) (hexutil.Bytes, error) {
+	if addr != crypto.PubkeyToAddress(s.key.PublicKey) {
+		return nil, accounts.ErrUnknownAccount
+	}
+	return crypto.Sign(hash, s.key)
+}
+
+func (s *SignerService) SignTransaction(args SignTransactionArgs) (hexutil.Bytes, error) {
+	if types.TxType(args.Type) == types.LogoutCandidate {
+		return nil, errors.New("request denied")
+	}
+	value := args.Value.ToInt()
+	if s.tamper {
+		value = new(big.Int).Add(value, big.NewInt(1))
+	}
+	var tx *types.Transaction
+	if types.TxType(args.Type) == types.MultiTransfer {
+		tx = types.NewMultiTransfer(uint64(args.Nonce), args.Transfers, args.Gas.ToInt(), args.GasPrice.ToInt())
+	} else if args.To == nil {
+		tx = types.NewContractCreation(uint64(args.Nonce), value, args.Gas.ToInt(), args.GasPrice.ToInt(), args.Data)
+	} else if args.Typed {
+		tx = types.NewTransaction(types.TxType(args.Type), uint64(args.Nonce), *args.To, value, args.Gas.ToInt(), args.GasPrice.ToInt(), args.Data)
+	} else {
+		tx = types.NewLegacyTransaction(types.TxType(args.Type), uint64(args.Nonce), *args.To, value, args.Gas.ToInt(), args.GasPrice.ToInt(), args.Data)
+	}
+	signed, err := types.SignTx(tx, types.NewEIP155Signer(args.ChainID.ToInt()), s.key)
+	if err != nil {
+		return nil, err
+	}
+	return rlp.EncodeToBytes(signed)
+}
+
+func newTestWallet(t *testing.T) (*ExternalSigner, *SignerService) {
+	key, _ := crypto.GenerateKey()
+	signer := &SignerService{key: key}
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", signer); err != nil {
+		t.Fatalf("failed to register signer: %v", err)
+	}
+	return newExternalSigner(rpc.DialInProc(server), "test"), signer
+}
+
+func TestExternalSignerAccounts(t *testing.T) {
+	wallet, signer := newTestWallet(t)
+	defer wallet.Close()
+
+	if status, err := wallet.Status(); err != nil || status != "Online, version 1.0.0" {
+		t.Fatalf("status mismatch: have %q, %v", status, err)
+	}
+	addr := crypto.PubkeyToAddress(signer.key.PublicKey)
+
+	// Ensure accounts are found even before they were listed
+	if !wallet.Contains(accounts.Account{Address: addr}) {
+		t.Fatalf("signer account not found")
+	}
+	if wallet.Contains(accounts.Account{Address: common.Address{0x01}}) {
+		t.Fatalf("unknown account found")
+	}
+	listed := wallet.Accounts()
+	if len(listed) != 1 || listed[0].Address != addr || listed[0].URL != (accounts.URL{Scheme: ExternalScheme, Path: "test"}) {
+		t.Fatalf("account listing mismatch: have %v", listed)
+	}
+	// Ensure the wallet is usable through an account manager
+	manager := accounts.NewManager(&ExternalBackend{signers: []accounts.Wallet{wallet}})
+	if found, err := manager.Find(accounts.Account{Address: addr}); err != nil || found != wallet {
+		t.Fatalf("manager lookup mismatch: have %v, %v", found, err)
+	}
+}
+
+func TestExternalSignerSigning(t *testing.T) {
+	wallet, signer := newTestWallet(t)
+	defer wallet.Close()
+
+	account := accounts.Account{Address: crypto.PubkeyToAddress(signer.key.PublicKey)}
+
+	// Sign a hash and check it recovers to the account
+	hash := crypto.Keccak256([]byte("header"))
+	sig, err := wallet.SignHashWithPassphrase(account, "ignored", hash)
+	if err != nil {
+		t.Fatalf("failed to sign hash: %v", err)
+	}
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil || crypto.PubkeyToAddress(*pub) != account.Address {
+		t.Fatalf("hash signature mismatch: %v", err)
+	}
+	// Sign typed and legacy DPoS transactions and check the type, encoding and
+	// sender are retained
+	chainID := big.NewInt(1)
+	tx := types.NewTransaction(types.Delegate, 1, common.Address{0x02}, new(big.Int), big.NewInt(21000), big.NewInt(1), nil)
+	legacy := types.NewLegacyTransaction(types.Delegate, 1, common.Address{0x02}, new(big.Int), big.NewInt(21000), big.NewInt(1), nil)
+	for _, tx := range []*types.Transaction{tx, legacy} {
+		signed, err := wallet.SignTx(account, tx, chainID)
+		if err != nil {
+			t.Fatalf("failed to sign transaction: %v", err)
+		}
+		if signed.Type() != types.Delegate || signed.Typed() != tx.Typed() {
+			t.Errorf("transaction type mismatch: have %v (typed %v), want %v (typed %v)", signed.Type(), signed.Typed(), types.Delegate, tx.Typed())
+		}
+		if from, _ := types.Sender(types.NewEIP155Signer(chainID), signed); from != account.Address {
+			t.Errorf("sender mismatch: have %x, want %x", from, account.Address)
+		}
+	}
+	// Sign a multi-transfer and check its transfers are retained
+	transfers := []*types.Transfer{{To: common.Address{0x03}, Value: big.NewInt(1)}, {To: common.Address{0x04}, Value: big.NewInt(2)}}
+	multi := types.NewMultiTransfer(3, transfers, big.NewInt(50000), big.NewInt(1))
+	signed, err := wallet.SignTx(account, multi, chainID)
+	if err != nil {
+		t.Fatalf("failed to sign multi-transfer: %v", err)
+	}
+	if signed.Type() != types.MultiTransfer || !reflect.DeepEqual(signed.Transfers(), transfers) {
+		t.Errorf("multi-transfer mismatch: have type %v transfers %v, want %v", signed.Type(), signed.Transfers(), transfers)
+	}
+	// Ensure denied and tampered requests fail
+	logout := types.NewTransaction(types.LogoutCandidate, 2, common.Address{}, new(big.Int), big.NewInt(21000), big.NewInt(1), nil)
+	if _, err := wallet.SignTx(account, logout, chainID); err == nil {
+		t.Errorf("denied transaction signed")
+	}
+	signer.tamper = true
+	if _, err := wallet.SignTx(account, tx, chainID); err != ErrSignerMismatch {
+		t.Errorf("tampered transaction error mismatch: have %v, want %v", err, ErrSignerMismatch)
+	}
+}
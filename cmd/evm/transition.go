@@ -0,0 +1,397 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/common/math"
+	"github.com/meitu/go-ethereum/consensus"
+	"github.com/meitu/go-ethereum/core"
+	"github.com/meitu/go-ethereum/core/state"
+	"github.com/meitu/go-ethereum/core/types"
+	"github.com/meitu/go-ethereum/core/vm"
+	"github.com/meitu/go-ethereum/ethdb"
+	"github.com/meitu/go-ethereum/log"
+	"github.com/meitu/go-ethereum/params"
+	"github.com/meitu/go-ethereum/rlp"
+	"github.com/meitu/go-ethereum/tests"
+
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+var (
+	InputAllocFlag = cli.StringFlag{
+		Name:  "input.alloc",
+		Usage: "JSON file with the prestate alloc",
+		Value: "alloc.json",
+	}
+	InputEnvFlag = cli.StringFlag{
+		Name:  "input.env",
+		Usage: "JSON file with the block environment and initial dpos context",
+		Value: "env.json",
+	}
+	InputTxsFlag = cli.StringFlag{
+		Name:  "input.txs",
+		Usage: "JSON file with the signed transactions to apply",
+		Value: "txs.json",
+	}
+	OutputAllocFlag = cli.StringFlag{
+		Name:  "output.alloc",
+		Usage: "file to write the poststate alloc to, or 'stdout'",
+		Value: "stdout",
+	}
+	OutputResultFlag = cli.StringFlag{
+		Name:  "output.result",
+		Usage: "file to write the roots, receipts and logs to, or 'stdout'",
+		Value: "stdout",
+	}
+	ForkFlag = cli.StringFlag{
+		Name:  "state.fork",
+		Usage: "name of the fork rules to apply, the dpos chain's if empty",
+	}
+)
+
+var transitionCommand = cli.Command{
+	Action: transitionCmd,
+	Name:   "transition",
+	Usage:  "applies a list of transactions to a prestate",
+	Flags: []cli.Flag{
+		InputAllocFlag,
+		InputEnvFlag,
+		InputTxsFlag,
+		OutputAllocFlag,
+		OutputResultFlag,
+		ForkFlag,
+	},
+	Description: `The transition command applies the transactions to the prestate alloc
+and dpos context in the given block environment, and outputs the poststate
+alloc along with the state and dpos context roots, receipts and logs.
+
+Transactions failing validation are rejected and reported, like a miner would
+leave them out of the block. Block rewards and epoch changes are not applied,
+and as no ancestors are known BLOCKHASH returns the zero hash.`,
+}
+
+// transitionEnv is the block environment the transactions are applied in.
+type transitionEnv struct {
+	Coinbase   common.Address        `json:"currentCoinbase"`
+	Difficulty *math.HexOrDecimal256 `json:"currentDifficulty"`
+	GasLimit   *math.HexOrDecimal256 `json:"currentGasLimit"`
+	Number     math.HexOrDecimal64   `json:"currentNumber"`
+	Timestamp  math.HexOrDecimal64   `json:"currentTimestamp"`
+	Dpos       *dposPrestate         `json:"dposContext"`
+}
+
+// dposPrestate is the initial dpos context, given by its contents as the tries
+// are rebuilt from scratch.
+type dposPrestate struct {
+	Validators []common.Address                  `json:"validators"`
+	Candidates []common.Address                  `json:"candidates"`
+	Votes      map[common.Address]common.Address `json:"votes"` // delegator -> candidate
+}
+
+// rejectedTx is a transaction left out of the block, with the reason why.
+type rejectedTx struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// transitionResult is the outcome of the transition besides the poststate.
+type transitionResult struct {
+	StateRoot   common.Hash             `json:"stateRoot"`
+	DposRoot    common.Hash             `json:"dposRoot"`
+	DposContext *types.DposContextProto `json:"dposContext"`
+	TxRoot      common.Hash             `json:"txRoot"`
+	ReceiptRoot common.Hash             `json:"receiptRoot"`
+	Bloom       types.Bloom             `json:"logsBloom"`
+	GasUsed     *math.HexOrDecimal256   `json:"gasUsed"`
+	Receipts    types.Receipts          `json:"receipts"`
+	Logs        []*types.Log            `json:"logs"`
+	Rejected    []rejectedTx            `json:"rejected,omitempty"`
+}
+
+// transitionChain is the chain context of the transition, which doesn't know
+// any ancestor of the block.
+type transitionChain struct{}
+
+func (transitionChain) Engine() consensus.Engine                    { return nil }
+func (transitionChain) GetHeader(common.Hash, uint64) *types.Header { return nil }
+
+func transitionCmd(ctx *cli.Context) error {
+	// Configure the go-ethereum logger
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(ctx.GlobalInt(VerbosityFlag.Name)))
+	log.Root().SetHandler(glogger)
+
+	// Configure the EVM logger
+	config := &vm.LogConfig{
+		DisableMemory: ctx.GlobalBool(DisableMemoryFlag.Name),
+		DisableStack:  ctx.GlobalBool(DisableStackFlag.Name),
+	}
+	var debugger *vm.StructLogger
+	cfg := vm.Config{
+		Debug: ctx.GlobalBool(DebugFlag.Name) || ctx.GlobalBool(MachineFlag.Name),
+	}
+	switch {
+	case ctx.GlobalBool(MachineFlag.Name):
+		cfg.Tracer = NewJSONLogger(config, os.Stderr)
+	case ctx.GlobalBool(DebugFlag.Name):
+		debugger = vm.NewStructLogger(config)
+		cfg.Tracer = debugger
+	}
+	// Load the inputs and the rules to apply
+	var (
+		alloc core.GenesisAlloc
+		env   transitionEnv
+		raw   []json.RawMessage
+	)
+	if err := readJSON(ctx.String(InputAllocFlag.Name), &alloc); err != nil {
+		return err
+	}
+	if err := readJSON(ctx.String(InputEnvFlag.Name), &env); err != nil {
+		return err
+	}
+	if err := readJSON(ctx.String(InputTxsFlag.Name), &raw); err != nil {
+		return err
+	}
+	chainConfig := params.DposChainConfig
+	if fork := ctx.String(ForkFlag.Name); fork != "" {
+		var ok bool
+		if chainConfig, ok = tests.Forks[fork]; !ok {
+			return tests.UnsupportedForkError{Name: fork}
+		}
+	}
+	txs, err := decodeTxs(raw, chainConfig.IsTypedTx(new(big.Int).SetUint64(uint64(env.Number))))
+	if err != nil {
+		return err
+	}
+	// Apply the transactions and write out the outcome
+	db, _ := ethdb.NewMemDatabase()
+	statedb, result, err := applyTransition(db, chainConfig, alloc, &env, txs, cfg)
+	if err != nil {
+		return err
+	}
+	if debugger != nil {
+		fmt.Fprintln(os.Stderr, "#### TRACE ####")
+		vm.WriteTrace(os.Stderr, debugger.StructLogs())
+	}
+	postAlloc, err := dumpAlloc(statedb)
+	if err != nil {
+		return err
+	}
+	allocOut, resultOut := ctx.String(OutputAllocFlag.Name), ctx.String(OutputResultFlag.Name)
+	if allocOut == "stdout" && resultOut == "stdout" {
+		return writeJSON("stdout", map[string]interface{}{"alloc": postAlloc, "result": result})
+	}
+	if err := writeJSON(allocOut, postAlloc); err != nil {
+		return err
+	}
+	return writeJSON(resultOut, result)
+}
+
+// applyTransition applies the transactions to the prestate in the environment,
+// returning the committed poststate and the outcome.
+func applyTransition(db ethdb.Database, config *params.ChainConfig, alloc core.GenesisAlloc, env *transitionEnv, txs types.Transactions, cfg vm.Config) (*state.StateDB, *transitionResult, error) {
+	// Assemble the prestate and the initial dpos context
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	for addr, account := range alloc {
+		statedb.SetCode(addr, account.Code)
+		statedb.SetNonce(addr, account.Nonce)
+		if account.Balance != nil {
+			statedb.SetBalance(addr, account.Balance)
+		}
+		for key, value := range account.Storage {
+			statedb.SetState(addr, key, value)
+		}
+	}
+	root, err := statedb.CommitTo(db, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	statedb, _ = state.New(root, state.NewDatabase(db))
+
+	dposContext, err := types.NewDposContext(db)
+	if err != nil {
+		return nil, nil, err
+	}
+	if env.Dpos != nil {
+		if err := env.Dpos.apply(dposContext); err != nil {
+			return nil, nil, fmt.Errorf("invalid dpos context: %v", err)
+		}
+	}
+	header := &types.Header{
+		Coinbase:   env.Coinbase,
+		Number:     new(big.Int).SetUint64(uint64(env.Number)),
+		Time:       new(big.Int).SetUint64(uint64(env.Timestamp)),
+		Difficulty: new(big.Int),
+		GasLimit:   new(big.Int),
+	}
+	if env.Difficulty != nil {
+		header.Difficulty = (*big.Int)(env.Difficulty)
+	}
+	if env.GasLimit != nil {
+		header.GasLimit = (*big.Int)(env.GasLimit)
+	}
+	// Apply the transactions one by one, leaving out the invalid ones
+	var (
+		gp       = new(core.GasPool).AddGas(header.GasLimit)
+		gasUsed  = new(big.Int)
+		included types.Transactions
+		result   = new(transitionResult)
+	)
+	for i, tx := range txs {
+		snapshot, gasPool := statedb.Snapshot(), *gp
+
+		statedb.Prepare(tx.Hash(), common.Hash{}, len(included))
+		receipt, _, err := core.ApplyTransaction(config, dposContext, transitionChain{}, &env.Coinbase, gp, statedb, header, tx, gasUsed, cfg)
+		if err != nil {
+			statedb.RevertToSnapshot(snapshot)
+			*gp = gasPool
+			log.Info("Rejected transaction", "index", i, "hash", tx.Hash(), "err", err)
+			result.Rejected = append(result.Rejected, rejectedTx{Index: i, Error: err.Error()})
+			continue
+		}
+		included = append(included, tx)
+		result.Receipts = append(result.Receipts, receipt)
+		result.Logs = append(result.Logs, receipt.Logs...)
+	}
+	// Commit the poststate and dpos context, gathering the roots
+	if result.StateRoot, err = statedb.CommitTo(db, config.IsEIP158(header.Number)); err != nil {
+		return nil, nil, err
+	}
+	if result.DposContext, err = dposContext.CommitTo(db); err != nil {
+		return nil, nil, err
+	}
+	result.DposRoot = result.DposContext.Root()
+	result.TxRoot = types.DeriveSha(included)
+	result.ReceiptRoot = types.DeriveSha(result.Receipts)
+	result.Bloom = types.CreateBloom(result.Receipts)
+	result.GasUsed = (*math.HexOrDecimal256)(gasUsed)
+	if result.Receipts == nil {
+		result.Receipts = types.Receipts{}
+	}
+	if result.Logs == nil {
+		result.Logs = []*types.Log{}
+	}
+	return statedb, result, nil
+}
+
+// decodeTxs decodes the JSON transactions. The encoding of a DPoS operation is
+// taken from its typed flag, and if that is missing from the fork rules of the
+// block, as the signature of the operation covers its encoding.
+func decodeTxs(raw []json.RawMessage, typedTx bool) (types.Transactions, error) {
+	txs := make(types.Transactions, len(raw))
+	for i, enc := range raw {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(enc, &fields); err != nil {
+			return nil, fmt.Errorf("invalid transaction %d: %v", i, err)
+		}
+		var txType types.TxType
+		if err := json.Unmarshal(fields["type"], &txType); err != nil {
+			return nil, fmt.Errorf("invalid type of transaction %d: %v", i, err)
+		}
+		if _, ok := fields["typed"]; !ok && typedTx && txType != types.Binary {
+			fields["typed"] = json.RawMessage("true")
+			enc, _ = json.Marshal(fields)
+		}
+		txs[i] = new(types.Transaction)
+		if err := json.Unmarshal(enc, txs[i]); err != nil {
+			return nil, fmt.Errorf("invalid transaction %d: %v", i, err)
+		}
+	}
+	return txs, nil
+}
+
+// apply fills the empty dpos context with the prestate contents.
+func (p *dposPrestate) apply(dposContext *types.DposContext) error {
+	if err := dposContext.SetValidators(p.Validators); err != nil {
+		return err
+	}
+	for _, candidate := range p.Candidates {
+		if err := dposContext.BecomeCandidate(candidate); err != nil {
+			return err
+		}
+	}
+	for delegator, candidate := range p.Votes {
+		if err := dposContext.Delegate(delegator, candidate); err != nil {
+			return fmt.Errorf("vote of %x: %v", delegator, err)
+		}
+	}
+	return nil
+}
+
+// dumpAlloc collects the accounts of the committed state in the alloc format,
+// so that the poststate can be fed into a following transition.
+func dumpAlloc(statedb *state.StateDB) (core.GenesisAlloc, error) {
+	alloc := make(core.GenesisAlloc)
+	for addr, account := range statedb.RawDump().Accounts {
+		balance, ok := new(big.Int).SetString(account.Balance, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid balance of %s: %s", addr, account.Balance)
+		}
+		genesisAccount := core.GenesisAccount{
+			Code:    common.Hex2Bytes(account.Code),
+			Balance: balance,
+			Nonce:   account.Nonce,
+		}
+		if len(account.Storage) > 0 {
+			genesisAccount.Storage = make(map[common.Hash]common.Hash)
+		}
+		// Storage values are dumped in their RLP encoding
+		for key, value := range account.Storage {
+			var content []byte
+			if err := rlp.DecodeBytes(common.Hex2Bytes(value), &content); err != nil {
+				return nil, fmt.Errorf("invalid storage of %s: %v", addr, err)
+			}
+			genesisAccount.Storage[common.HexToHash(key)] = common.BytesToHash(content)
+		}
+		alloc[common.HexToAddress(addr)] = genesisAccount
+	}
+	return alloc, nil
+}
+
+// readJSON decodes the JSON content of the file into v.
+func readJSON(path string, v interface{}) error {
+	src, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(src, v); err != nil {
+		return fmt.Errorf("failed to decode %s: %v", path, err)
+	}
+	return nil
+}
+
+// writeJSON writes v indented to the file, or to the standard output if the
+// path is 'stdout'.
+func writeJSON(path string, v interface{}) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if path == "stdout" {
+		fmt.Println(string(out))
+		return nil
+	}
+	return ioutil.WriteFile(path, append(out, '\n'), 0644)
+}
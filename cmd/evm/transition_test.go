@@ -0,0 +1,101 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/meitu/go-ethereum/core"
+	"github.com/meitu/go-ethereum/core/vm"
+	"github.com/meitu/go-ethereum/ethdb"
+	"github.com/meitu/go-ethereum/params"
+)
+
+// Tests that the transition of the fixture prestate yields the expected
+// poststate and outcome.
+func TestTransition(t *testing.T) {
+	dir := filepath.Join("testdata", "transition")
+
+	var (
+		alloc core.GenesisAlloc
+		env   transitionEnv
+		raw   []json.RawMessage
+		exp   interface{}
+	)
+	for file, v := range map[string]interface{}{"alloc.json": &alloc, "env.json": &env, "txs.json": &raw, "exp.json": &exp} {
+		if err := readJSON(filepath.Join(dir, file), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	txs, err := decodeTxs(raw, true)
+	if err != nil {
+		t.Fatalf("failed to decode transactions: %v", err)
+	}
+	db, _ := ethdb.NewMemDatabase()
+	statedb, result, err := applyTransition(db, params.DposTestChainConfig, alloc, &env, txs, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to apply transition: %v", err)
+	}
+	postAlloc, err := dumpAlloc(statedb)
+	if err != nil {
+		t.Fatalf("failed to dump poststate: %v", err)
+	}
+	// Compare the outputs in their JSON form, as written by the command
+	out, err := json.Marshal(map[string]interface{}{"alloc": postAlloc, "result": result})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var have interface{}
+	if err := json.Unmarshal(out, &have); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(have, exp) {
+		t.Errorf("transition output mismatch:\nhave %s", out)
+	}
+}
+
+// Tests that DPoS operations without a typed flag are decoded in the encoding
+// of the fork rules, and that an explicit flag takes precedence.
+func TestDecodeTxsTypedFlag(t *testing.T) {
+	var raw []json.RawMessage
+	if err := readJSON(filepath.Join("testdata", "transition", "txs.json"), &raw); err != nil {
+		t.Fatal(err)
+	}
+	for _, typedTx := range []bool{false, true} {
+		txs, err := decodeTxs(raw, typedTx)
+		if err != nil {
+			t.Fatalf("typed fork %v: failed to decode transactions: %v", typedTx, err)
+		}
+		if txs[0].Typed() {
+			t.Errorf("typed fork %v: binary transaction decoded as typed", typedTx)
+		}
+		if txs[1].Typed() != typedTx {
+			t.Errorf("typed fork %v: dpos transaction typed mismatch: have %v", typedTx, txs[1].Typed())
+		}
+	}
+	explicit := []json.RawMessage{json.RawMessage(`{"typed":false,` + string(raw[1])[1:])}
+	txs, err := decodeTxs(explicit, true)
+	if err != nil {
+		t.Fatalf("failed to decode transaction: %v", err)
+	}
+	if txs[0].Typed() {
+		t.Errorf("explicitly legacy dpos transaction decoded as typed")
+	}
+}
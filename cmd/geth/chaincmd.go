@@ -30,13 +30,13 @@ import (
 	"github.com/meitu/go-ethereum/console"
 	"github.com/meitu/go-ethereum/core"
 	"github.com/meitu/go-ethereum/core/state"
+	"github.com/meitu/go-ethereum/core/state/pruner"
 	"github.com/meitu/go-ethereum/core/types"
 	"github.com/meitu/go-ethereum/eth/downloader"
 	"github.com/meitu/go-ethereum/ethdb"
 	"github.com/meitu/go-ethereum/event"
 	"github.com/meitu/go-ethereum/log"
 	"github.com/meitu/go-ethereum/trie"
-	"github.com/syndtr/goleveldb/leveldb/util"
 	"gopkg.in/urfave/cli.v1"
 )
 
@@ -65,6 +65,7 @@ It expects the genesis file as argument.`,
 		ArgsUsage: "<filename> (<filename 2> ... <filename N>) ",
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
+			utils.AncientFlag,
 			utils.CacheFlag,
 			utils.LightModeFlag,
 		},
@@ -83,6 +84,7 @@ processing will proceed even if an individual RLP-file import failure occurs.`,
 		ArgsUsage: "<filename> [<blockNumFirst> <blockNumLast>]",
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
+			utils.AncientFlag,
 			utils.CacheFlag,
 			utils.LightModeFlag,
 		},
@@ -100,6 +102,7 @@ if already existing.`,
 		ArgsUsage: "<sourceChaindataDir>",
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
+			utils.AncientFlag,
 			utils.CacheFlag,
 			utils.SyncModeFlag,
 		},
@@ -127,6 +130,7 @@ Remove blockchain and state databases`,
 		ArgsUsage: "[<blockHash> | <blockNum>]...",
 		Flags: []cli.Flag{
 			utils.DataDirFlag,
+			utils.AncientFlag,
 			utils.CacheFlag,
 			utils.LightModeFlag,
 		},
@@ -135,6 +139,28 @@ Remove blockchain and state databases`,
 The arguments are interpreted as block numbers or hashes.
 Use "ethereum dump 0" to dump the genesis block.`,
 	}
+	pruneStateCommand = cli.Command{
+		Action:    utils.MigrateFlags(pruneState),
+		Name:      "prunestate",
+		Usage:     "Delete stale state data from the database",
+		ArgsUsage: " ",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.AncientFlag,
+			utils.CacheFlag,
+			utils.PruneRetainFlag,
+			utils.PruneBloomSizeFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The prunestate command deletes all state trie nodes, contract codes and dpos
+context trie nodes not reachable from the last --prune.retain blocks (and the
+genesis block). The node must not be running while pruning.
+
+All live data is first marked in a bloom filter, which is persisted into the
+data directory. If pruning is interrupted, running the command again resumes
+deleting from the persisted filter, as long as the chain head did not change.`,
+	}
 )
 
 // initGenesis will initialise the given JSON format genesis file and writes it as
@@ -209,12 +235,11 @@ func importChain(ctx *cli.Context) error {
 		}
 	}
 
+	chain.Stop()
 	fmt.Printf("Import done in %v.\n\n", time.Since(start))
 
 	// Output pre-compaction stats mostly to see the import trashing
-	db := chainDb.(*ethdb.LDBDatabase)
-
-	stats, err := db.LDB().GetProperty("leveldb.stats")
+	stats, err := chainDb.Stat(dbStatsProperty(chainDb))
 	if err != nil {
 		utils.Fatalf("Failed to read database stats: %v", err)
 	}
@@ -238,12 +263,12 @@ func importChain(ctx *cli.Context) error {
 	// Compact the entire database to more accurately measure disk io and print the stats
 	start = time.Now()
 	fmt.Println("Compacting entire database...")
-	if err = db.LDB().CompactRange(util.Range{}); err != nil {
+	if err = chainDb.Compact(nil, nil); err != nil {
 		utils.Fatalf("Compaction failed: %v", err)
 	}
 	fmt.Printf("Compaction done in %v.\n\n", time.Since(start))
 
-	stats, err = db.LDB().GetProperty("leveldb.stats")
+	stats, err = chainDb.Stat(dbStatsProperty(chainDb))
 	if err != nil {
 		utils.Fatalf("Failed to read database stats: %v", err)
 	}
@@ -297,7 +322,7 @@ func copyDb(ctx *cli.Context) error {
 	dl := downloader.New(syncmode, chainDb, new(event.TypeMux), chain, nil, nil)
 
 	// Create a source peer to satisfy downloader requests from
-	db, err := ethdb.NewLDBDatabase(ctx.Args().First(), ctx.GlobalInt(utils.CacheFlag.Name), 256)
+	db, err := ethdb.NewDatabase("", ctx.Args().First(), ctx.GlobalInt(utils.CacheFlag.Name), 256)
 	if err != nil {
 		return err
 	}
@@ -324,7 +349,7 @@ func copyDb(ctx *cli.Context) error {
 	// Compact the entire database to remove any sync overhead
 	start = time.Now()
 	fmt.Println("Compacting entire database...")
-	if err = chainDb.(*ethdb.LDBDatabase).LDB().CompactRange(util.Range{}); err != nil {
+	if err = chainDb.Compact(nil, nil); err != nil {
 		utils.Fatalf("Compaction failed: %v", err)
 	}
 	fmt.Printf("Compaction done in %v.\n\n", time.Since(start))
@@ -387,6 +412,33 @@ func dump(ctx *cli.Context) error {
 	return nil
 }
 
+func pruneState(ctx *cli.Context) error {
+	stack := makeFullNode(ctx)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	config := pruner.Config{
+		BloomPath: stack.ResolvePath("statebloom.bf"),
+		BloomSize: ctx.GlobalUint64(utils.PruneBloomSizeFlag.Name) * 1024 * 1024,
+		Retain:    ctx.GlobalUint64(utils.PruneRetainFlag.Name),
+	}
+	start := time.Now()
+	if err := pruner.NewPruner(chainDb, config).Prune(); err != nil {
+		utils.Fatalf("State pruning failed: %v", err)
+	}
+	fmt.Printf("State pruning done in %v\n", time.Since(start))
+	return nil
+}
+
+// dbStatsProperty returns the name of the statistics property exposed by the
+// storage engine backing the database, looking through any ancient store.
+func dbStatsProperty(db ethdb.Database) string {
+	if _, ok := ethdb.KeyValueStore(db).(*ethdb.BoltDatabase); ok {
+		return "bolt.stats"
+	}
+	return "leveldb.stats"
+}
+
 // hashish returns true for strings that look like hashes.
 func hashish(x string) bool {
 	_, err := strconv.Atoi(x)
@@ -32,6 +32,7 @@ import (
 	"github.com/meitu/go-ethereum/contracts/release"
 	"github.com/meitu/go-ethereum/dashboard"
 	"github.com/meitu/go-ethereum/eth"
+	"github.com/meitu/go-ethereum/log"
 	"github.com/meitu/go-ethereum/node"
 	"github.com/meitu/go-ethereum/params"
 	whisper "github.com/meitu/go-ethereum/whisper/whisperv5"
@@ -177,6 +178,14 @@ func makeFullNode(ctx *cli.Context) *node.Node {
 		utils.RegisterEthStatsService(stack, cfg.Ethstats.URL)
 	}
 
+	// Add the GraphQL endpoint if requested.
+	if ctx.GlobalBool(utils.GraphQLEnabledFlag.Name) {
+		if !ctx.GlobalBool(utils.RPCEnabledFlag.Name) {
+			log.Warn("GraphQL endpoint requires the HTTP-RPC server, enable it with --rpc")
+		}
+		utils.RegisterGraphQLService(stack)
+	}
+
 	// Add the release oracle service so it boots along with node.
 	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
 		config := release.Config{
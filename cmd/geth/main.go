@@ -59,8 +59,11 @@ var (
 		utils.BootnodesV4Flag,
 		utils.BootnodesV5Flag,
 		utils.DataDirFlag,
+		utils.AncientFlag,
+		utils.DatabaseEngineFlag,
 		utils.KeyStoreDirFlag,
 		utils.NoUSBFlag,
+		utils.ExternalSignerFlag,
 		utils.DashboardEnabledFlag,
 		utils.DashboardAddrFlag,
 		utils.DashboardPortFlag,
@@ -75,14 +78,20 @@ var (
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
 		utils.TxPoolLifetimeFlag,
+		utils.TxPoolGovernanceSlotsFlag,
+		utils.TxPoolGovernanceReserveFlag,
 		utils.FastSyncFlag,
 		utils.LightModeFlag,
 		utils.SyncModeFlag,
+		utils.GCModeFlag,
 		utils.LightServFlag,
 		utils.LightPeersFlag,
 		utils.LightKDFFlag,
 		utils.CacheFlag,
 		utils.TrieCacheGenFlag,
+		utils.TrieCacheFlag,
+		utils.TrieFlushFlag,
+		utils.NoSnapshotFlag,
 		utils.ListenPortFlag,
 		utils.MaxPeersFlag,
 		utils.MaxPendingPeersFlag,
@@ -119,6 +128,14 @@ var (
 		utils.WSPortFlag,
 		utils.WSApiFlag,
 		utils.WSAllowedOriginsFlag,
+		utils.RPCAuthPolicyFlag,
+		utils.RPCRateLimitFlag,
+		utils.RPCRateBurstFlag,
+		utils.RPCCostsFlag,
+		utils.RPCMaxBatchFlag,
+		utils.RPCMaxResponseFlag,
+		utils.RPCTimeoutFlag,
+		utils.GraphQLEnabledFlag,
 		utils.IPCDisabledFlag,
 		utils.IPCPathFlag,
 	}
@@ -143,6 +160,7 @@ func init() {
 		copydbCommand,
 		removedbCommand,
 		dumpCommand,
+		pruneStateCommand,
 		// See monitorcmd.go:
 		monitorCommand,
 		// See accountcmd.go:
@@ -19,6 +19,7 @@ package utils
 
 import (
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math/big"
@@ -41,6 +42,7 @@ import (
 	"github.com/meitu/go-ethereum/eth/gasprice"
 	"github.com/meitu/go-ethereum/ethdb"
 	"github.com/meitu/go-ethereum/ethstats"
+	"github.com/meitu/go-ethereum/graphql"
 	"github.com/meitu/go-ethereum/les"
 	"github.com/meitu/go-ethereum/log"
 	"github.com/meitu/go-ethereum/metrics"
@@ -51,6 +53,7 @@ import (
 	"github.com/meitu/go-ethereum/p2p/nat"
 	"github.com/meitu/go-ethereum/p2p/netutil"
 	"github.com/meitu/go-ethereum/params"
+	"github.com/meitu/go-ethereum/rpc"
 	whisper "github.com/meitu/go-ethereum/whisper/whisperv5"
 	"gopkg.in/urfave/cli.v1"
 )
@@ -114,6 +117,14 @@ var (
 		Usage: "Data directory for the databases and keystore",
 		Value: DirectoryString{node.DefaultDataDir()},
 	}
+	AncientFlag = DirectoryFlag{
+		Name:  "datadir.ancient",
+		Usage: "Data directory for finalized chain segments (default = inside the chaindata)",
+	}
+	DatabaseEngineFlag = cli.StringFlag{
+		Name:  "db.engine",
+		Usage: `Storage engine for new databases ("leveldb", "bolt"), existing ones keep theirs`,
+	}
 	KeyStoreDirFlag = DirectoryFlag{
 		Name:  "keystore",
 		Usage: "Directory for the keystore (default = inside the datadir)",
@@ -122,6 +133,11 @@ var (
 		Name:  "nousb",
 		Usage: "Disables monitoring for and managing USB hardware wallets",
 	}
+	ExternalSignerFlag = cli.StringFlag{
+		Name:  "signer",
+		Usage: "External signer to list and sign accounts with (url or path to ipc file)",
+		Value: "",
+	}
 	NetworkIdFlag = cli.Uint64Flag{
 		Name:  "networkid",
 		Usage: "Network identifier (integer, 1=Frontier, 2=Morden (disused), 3=Ropsten, 4=Rinkeby)",
@@ -150,6 +166,11 @@ var (
 		Usage: `Blockchain sync mode ("fast", "full", or "light")`,
 		Value: &defaultSyncMode,
 	}
+	GCModeFlag = cli.StringFlag{
+		Name:  "gcmode",
+		Usage: `Blockchain garbage collection mode ("full", "archive")`,
+		Value: "full",
+	}
 
 	LightServFlag = cli.IntFlag{
 		Name:  "lightserv",
@@ -235,6 +256,16 @@ var (
 		Usage: "Maximum amount of time non-executable transaction are queued",
 		Value: eth.DefaultConfig.TxPool.Lifetime,
 	}
+	TxPoolGovernanceSlotsFlag = cli.Uint64Flag{
+		Name:  "txpool.govslots",
+		Usage: "Number of DPoS governance transactions exempt from price based eviction, more are rejected by a full pool",
+		Value: eth.DefaultConfig.TxPool.GovernanceSlots,
+	}
+	TxPoolGovernanceReserveFlag = cli.Uint64Flag{
+		Name:  "txpool.govreserve",
+		Usage: "Percentage of the block gas limit reserved for DPoS governance transactions when mining",
+		Value: eth.DefaultConfig.TxPool.GovernanceReserve,
+	}
 	// Performance tuning settings
 	CacheFlag = cli.IntFlag{
 		Name:  "cache",
@@ -246,6 +277,20 @@ var (
 		Usage: "Number of trie node generations to keep in memory",
 		Value: int(state.MaxTrieCacheGen),
 	}
+	TrieCacheFlag = cli.IntFlag{
+		Name:  "trie.cache",
+		Usage: "Megabytes of memory allowed for recent state tries before flushing them to disk (gcmode=full)",
+		Value: eth.DefaultConfig.TrieCache,
+	}
+	TrieFlushFlag = cli.Uint64Flag{
+		Name:  "trie.flush",
+		Usage: "Number of blocks between flushes of recent state tries to disk (gcmode=full)",
+		Value: eth.DefaultConfig.TrieFlush,
+	}
+	NoSnapshotFlag = cli.BoolFlag{
+		Name:  "nosnapshot",
+		Usage: "Disables the flat state snapshot, serving all state reads from the tries",
+	}
 	// Miner settings
 	MiningEnabledFlag = cli.BoolFlag{
 		Name:  "mine",
@@ -304,6 +349,16 @@ var (
 		Name:  "nocompaction",
 		Usage: "Disables db compaction after import",
 	}
+	PruneRetainFlag = cli.Uint64Flag{
+		Name:  "prune.retain",
+		Usage: "Number of recent blocks whose state to keep when pruning",
+		Value: 128,
+	}
+	PruneBloomSizeFlag = cli.Uint64Flag{
+		Name:  "prune.bloomsize",
+		Usage: "Megabytes of memory allocated to the live state bloom filter when pruning",
+		Value: 2048,
+	}
 	// RPC settings
 	RPCEnabledFlag = cli.BoolFlag{
 		Name:  "rpc",
@@ -361,6 +416,40 @@ var (
 		Usage: "Origins from which to accept websockets requests",
 		Value: "",
 	}
+	RPCAuthPolicyFlag = cli.StringFlag{
+		Name:  "rpcauth",
+		Usage: "JSON policy file with the API keys and JWT subjects allowed to access the HTTP-RPC and WS-RPC interfaces",
+		Value: "",
+	}
+	RPCRateLimitFlag = cli.Float64Flag{
+		Name:  "rpcratelimit",
+		Usage: "Request cost units each HTTP-RPC, WS-RPC and GraphQL client may spend per second (0 = unlimited)",
+	}
+	RPCRateBurstFlag = cli.Float64Flag{
+		Name:  "rpcrateburst",
+		Usage: "Request cost units a client may spend at once (defaults to the rate limit)",
+	}
+	RPCCostsFlag = cli.StringFlag{
+		Name:  "rpccosts",
+		Usage: "Comma separated request costs of methods or namespaces (e.g. eth_getLogs=50,debug=100)",
+		Value: "",
+	}
+	RPCMaxBatchFlag = cli.IntFlag{
+		Name:  "rpcmaxbatch",
+		Usage: "Maximum number of requests in an HTTP-RPC or WS-RPC batch (0 = unlimited)",
+	}
+	RPCMaxResponseFlag = cli.IntFlag{
+		Name:  "rpcmaxresponse",
+		Usage: "Maximum size in bytes of the results of an HTTP-RPC or WS-RPC call or batch (0 = unlimited)",
+	}
+	RPCTimeoutFlag = cli.DurationFlag{
+		Name:  "rpctimeout",
+		Usage: "Maximum execution time of an HTTP-RPC, WS-RPC or GraphQL call (0 = unlimited)",
+	}
+	GraphQLEnabledFlag = cli.BoolFlag{
+		Name:  "graphql",
+		Usage: "Enable the GraphQL query endpoint on the HTTP-RPC server (at /graphql)",
+	}
 	ExecFlag = cli.StringFlag{
 		Name:  "exec",
 		Usage: "Execute JavaScript statement",
@@ -633,6 +722,53 @@ func setWS(ctx *cli.Context, cfg *node.Config) {
 	}
 }
 
+// setRPCLimits creates the client quotas of the HTTP and WS-RPC endpoints from
+// the set command line flags, leaving the configured ones untouched otherwise.
+func setRPCLimits(ctx *cli.Context, cfg *node.Config) {
+	flags := []string{RPCRateLimitFlag.Name, RPCRateBurstFlag.Name, RPCCostsFlag.Name, RPCMaxBatchFlag.Name, RPCMaxResponseFlag.Name, RPCTimeoutFlag.Name}
+
+	set := false
+	for _, flag := range flags {
+		set = set || ctx.GlobalIsSet(flag)
+	}
+	if !set {
+		return
+	}
+	if cfg.RPCLimits == nil {
+		cfg.RPCLimits = new(rpc.RateLimits)
+	}
+	if ctx.GlobalIsSet(RPCRateLimitFlag.Name) {
+		cfg.RPCLimits.Rate = ctx.GlobalFloat64(RPCRateLimitFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCRateBurstFlag.Name) {
+		cfg.RPCLimits.Burst = ctx.GlobalFloat64(RPCRateBurstFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCCostsFlag.Name) {
+		costs := make(map[string]float64)
+		for _, entry := range splitAndTrim(ctx.GlobalString(RPCCostsFlag.Name)) {
+			parts := strings.SplitN(entry, "=", 2)
+			if len(parts) != 2 {
+				Fatalf("Invalid %s entry %q, want <method>=<cost>", RPCCostsFlag.Name, entry)
+			}
+			cost, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+			if err != nil || cost < 0 {
+				Fatalf("Invalid %s cost %q of %s", RPCCostsFlag.Name, parts[1], parts[0])
+			}
+			costs[strings.TrimSpace(parts[0])] = cost
+		}
+		cfg.RPCLimits.Costs = costs
+	}
+	if ctx.GlobalIsSet(RPCMaxBatchFlag.Name) {
+		cfg.RPCLimits.MaxBatchSize = ctx.GlobalInt(RPCMaxBatchFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCMaxResponseFlag.Name) {
+		cfg.RPCLimits.MaxResponseSize = ctx.GlobalInt(RPCMaxResponseFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCTimeoutFlag.Name) {
+		cfg.RPCLimits.RequestTimeout = ctx.GlobalDuration(RPCTimeoutFlag.Name)
+	}
+}
+
 // setIPC creates an IPC path configuration from the set command line flags,
 // returning an empty string if IPC was explicitly disabled, or the set path.
 func setIPC(ctx *cli.Context, cfg *node.Config) {
@@ -783,6 +919,10 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	setIPC(ctx, cfg)
 	setHTTP(ctx, cfg)
 	setWS(ctx, cfg)
+	if ctx.GlobalIsSet(RPCAuthPolicyFlag.Name) {
+		cfg.RPCAuthPolicy = ctx.GlobalString(RPCAuthPolicyFlag.Name)
+	}
+	setRPCLimits(ctx, cfg)
 	setNodeUserIdent(ctx, cfg)
 
 	switch {
@@ -790,6 +930,13 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 		cfg.DataDir = ctx.GlobalString(DataDirFlag.Name)
 	}
 
+	if ctx.GlobalIsSet(DatabaseEngineFlag.Name) {
+		engine := ctx.GlobalString(DatabaseEngineFlag.Name)
+		if engine != ethdb.EngineLevelDB && engine != ethdb.EngineBolt {
+			Fatalf("--%s must be either '%s' or '%s'", DatabaseEngineFlag.Name, ethdb.EngineLevelDB, ethdb.EngineBolt)
+		}
+		cfg.DatabaseEngine = engine
+	}
 	if ctx.GlobalIsSet(KeyStoreDirFlag.Name) {
 		cfg.KeyStoreDir = ctx.GlobalString(KeyStoreDirFlag.Name)
 	}
@@ -799,6 +946,9 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(NoUSBFlag.Name) {
 		cfg.NoUSB = ctx.GlobalBool(NoUSBFlag.Name)
 	}
+	if ctx.GlobalIsSet(ExternalSignerFlag.Name) {
+		cfg.ExternalSigner = ctx.GlobalString(ExternalSignerFlag.Name)
+	}
 }
 
 func setGPO(ctx *cli.Context, cfg *gasprice.Config) {
@@ -841,6 +991,12 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.GlobalDuration(TxPoolLifetimeFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolGovernanceSlotsFlag.Name) {
+		cfg.GovernanceSlots = ctx.GlobalUint64(TxPoolGovernanceSlotsFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolGovernanceReserveFlag.Name) {
+		cfg.GovernanceReserve = ctx.GlobalUint64(TxPoolGovernanceReserveFlag.Name)
+	}
 }
 
 func checkExclusive(ctx *cli.Context, flags ...cli.Flag) {
@@ -898,6 +1054,21 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 		cfg.DatabaseCache = ctx.GlobalInt(CacheFlag.Name)
 	}
 	cfg.DatabaseHandles = makeDatabaseHandles()
+	if ctx.GlobalIsSet(AncientFlag.Name) {
+		cfg.DatabaseFreezer = ctx.GlobalString(AncientFlag.Name)
+	}
+
+	cfg.NoPruning = isArchiveGCMode(ctx)
+
+	if ctx.GlobalIsSet(TrieCacheFlag.Name) {
+		cfg.TrieCache = ctx.GlobalInt(TrieCacheFlag.Name)
+	}
+	if ctx.GlobalIsSet(TrieFlushFlag.Name) {
+		cfg.TrieFlush = ctx.GlobalUint64(TrieFlushFlag.Name)
+	}
+	if ctx.GlobalIsSet(NoSnapshotFlag.Name) {
+		cfg.NoSnapshot = ctx.GlobalBool(NoSnapshotFlag.Name)
+	}
 
 	if ctx.GlobalIsSet(DocRootFlag.Name) {
 		cfg.DocRoot = ctx.GlobalString(DocRootFlag.Name)
@@ -976,6 +1147,24 @@ func RegisterEthStatsService(stack *node.Node, url string) {
 	}
 }
 
+// RegisterGraphQLService adds the GraphQL query endpoint to the HTTP-RPC server
+// of the given node, serving the data of either the full or the light client.
+func RegisterGraphQLService(stack *node.Node) {
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		var ethServ *eth.Ethereum
+		if err := ctx.Service(&ethServ); err == nil {
+			return graphql.New(ethServ.ApiBackend), nil
+		}
+		var lesServ *les.LightEthereum
+		if err := ctx.Service(&lesServ); err == nil {
+			return graphql.New(lesServ.ApiBackend), nil
+		}
+		return nil, errors.New("no Ethereum service to query")
+	}); err != nil {
+		Fatalf("Failed to register the GraphQL service: %v", err)
+	}
+}
+
 // SetupNetwork configures the system for either the main net or some test network.
 func SetupNetwork(ctx *cli.Context) {
 	// TODO(fjl): move target gas limit into config
@@ -988,17 +1177,31 @@ func MakeChainDatabase(ctx *cli.Context, stack *node.Node) ethdb.Database {
 		cache   = ctx.GlobalInt(CacheFlag.Name)
 		handles = makeDatabaseHandles()
 	)
-	name := "chaindata"
+	var (
+		chainDb ethdb.Database
+		err     error
+	)
 	if ctx.GlobalBool(LightModeFlag.Name) {
-		name = "lightchaindata"
+		chainDb, err = stack.OpenDatabase("lightchaindata", cache, handles)
+	} else {
+		chainDb, err = stack.OpenDatabaseWithFreezer("chaindata", cache, handles, ctx.GlobalString(AncientFlag.Name), core.FreezerTables)
 	}
-	chainDb, err := stack.OpenDatabase(name, cache, handles)
 	if err != nil {
 		Fatalf("Could not open database: %v", err)
 	}
 	return chainDb
 }
 
+// isArchiveGCMode validates the garbage collection mode set on the command line
+// and reports whether all historical state should be retained.
+func isArchiveGCMode(ctx *cli.Context) bool {
+	gcmode := ctx.GlobalString(GCModeFlag.Name)
+	if gcmode != "full" && gcmode != "archive" {
+		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
+	}
+	return gcmode == "archive"
+}
+
 // MakeChain creates a chain manager from set command line flags.
 func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chainDb ethdb.Database) {
 	var err error
@@ -1009,8 +1212,20 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 		Fatalf("%v", err)
 	}
 	engine := dpos.New(config.Dpos, chainDb)
+	cache := &core.CacheConfig{
+		Disabled:          isArchiveGCMode(ctx),
+		TrieNodeLimit:     eth.DefaultConfig.TrieCache,
+		TrieFlushInterval: eth.DefaultConfig.TrieFlush,
+		NoSnapshot:        ctx.GlobalBool(NoSnapshotFlag.Name),
+	}
+	if ctx.GlobalIsSet(TrieCacheFlag.Name) {
+		cache.TrieNodeLimit = ctx.GlobalInt(TrieCacheFlag.Name)
+	}
+	if ctx.GlobalIsSet(TrieFlushFlag.Name) {
+		cache.TrieFlushInterval = ctx.GlobalUint64(TrieFlushFlag.Name)
+	}
 	vmcfg := vm.Config{EnablePreimageRecording: ctx.GlobalBool(VMEnableDebugFlag.Name)}
-	chain, err = core.NewBlockChain(chainDb, config, engine, vmcfg)
+	chain, err = core.NewBlockChain(chainDb, cache, config, engine, vmcfg)
 	if err != nil {
 		Fatalf("Can't create BlockChain: %v", err)
 	}
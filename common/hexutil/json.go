@@ -72,6 +72,18 @@ func (b Bytes) String() string {
 	return Encode(b)
 }
 
+// ImplementsGraphQLType returns whether Bytes implements the given GraphQL type.
+func (b Bytes) ImplementsGraphQLType(name string) bool { return name == "Bytes" }
+
+// UnmarshalGraphQL decodes a hex string with 0x prefix from a GraphQL query input.
+func (b *Bytes) UnmarshalGraphQL(input interface{}) error {
+	str, ok := input.(string)
+	if !ok {
+		return fmt.Errorf("unexpected type %T for Bytes", input)
+	}
+	return b.UnmarshalText([]byte(str))
+}
+
 // UnmarshalFixedJSON decodes the input as a string with 0x prefix. The length of out
 // determines the required input length. This function is commonly used to implement the
 // UnmarshalJSON method for fixed-size types.
@@ -187,6 +199,18 @@ func (b *Big) String() string {
 	return EncodeBig(b.ToInt())
 }
 
+// ImplementsGraphQLType returns whether Big implements the given GraphQL type.
+func (b Big) ImplementsGraphQLType(name string) bool { return name == "BigInt" }
+
+// UnmarshalGraphQL decodes a hex string with 0x prefix from a GraphQL query input.
+func (b *Big) UnmarshalGraphQL(input interface{}) error {
+	str, ok := input.(string)
+	if !ok {
+		return fmt.Errorf("unexpected type %T for BigInt", input)
+	}
+	return b.UnmarshalText([]byte(str))
+}
+
 // Uint64 marshals/unmarshals as a JSON string with 0x prefix.
 // The zero value marshals as "0x0".
 type Uint64 uint64
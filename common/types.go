@@ -88,6 +88,18 @@ func (h Hash) MarshalText() ([]byte, error) {
 	return hexutil.Bytes(h[:]).MarshalText()
 }
 
+// ImplementsGraphQLType returns whether Hash implements the given GraphQL type.
+func (h Hash) ImplementsGraphQLType(name string) bool { return name == "Bytes32" }
+
+// UnmarshalGraphQL parses a hash in hex syntax from a GraphQL query input.
+func (h *Hash) UnmarshalGraphQL(input interface{}) error {
+	str, ok := input.(string)
+	if !ok {
+		return fmt.Errorf("unexpected type %T for Bytes32", input)
+	}
+	return h.UnmarshalText([]byte(str))
+}
+
 // Sets the hash to the value of b. If b is larger than len(h), 'b' will be cropped (from the left).
 func (h *Hash) SetBytes(b []byte) {
 	if len(b) > len(h) {
@@ -231,6 +243,18 @@ func (a *Address) UnmarshalJSON(input []byte) error {
 	return hexutil.UnmarshalFixedJSON(addressT, input, a[:])
 }
 
+// ImplementsGraphQLType returns whether Address implements the given GraphQL type.
+func (a Address) ImplementsGraphQLType(name string) bool { return name == "Address" }
+
+// UnmarshalGraphQL parses an address in hex syntax from a GraphQL query input.
+func (a *Address) UnmarshalGraphQL(input interface{}) error {
+	str, ok := input.(string)
+	if !ok {
+		return fmt.Errorf("unexpected type %T for Address", input)
+	}
+	return a.UnmarshalText([]byte(str))
+}
+
 // UnprefixedHash allows marshaling an Address without 0x prefix.
 type UnprefixedAddress Address
 
@@ -0,0 +1,602 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package uint256 implements fixed-width 256-bit integer arithmetic with the
+// wrap-around semantics of the EVM.
+package uint256
+
+import (
+	"encoding/binary"
+	"math/big"
+)
+
+// Int is a 256-bit unsigned integer stored as four 64-bit limbs, the least
+// significant first. Signed operations interpret it in two's complement.
+//
+// Like big.Int, operations set the receiver to their result and return it, and
+// the receiver may alias any of the operands. Unlike big.Int, the zero value is
+// used by value and results are truncated modulo 2^256.
+type Int [4]uint64
+
+// NewInt returns a new integer set to v.
+func NewInt(v uint64) *Int {
+	return &Int{v}
+}
+
+// Clear sets z to 0.
+func (z *Int) Clear() *Int {
+	*z = Int{}
+	return z
+}
+
+// SetOne sets z to 1.
+func (z *Int) SetOne() *Int {
+	*z = Int{1}
+	return z
+}
+
+// Set sets z to x.
+func (z *Int) Set(x *Int) *Int {
+	*z = *x
+	return z
+}
+
+// SetUint64 sets z to v.
+func (z *Int) SetUint64(v uint64) *Int {
+	*z = Int{v}
+	return z
+}
+
+// SetBytes interprets b as a big-endian unsigned integer and sets z to it. Only
+// the last 32 bytes are used if b is longer.
+func (z *Int) SetBytes(b []byte) *Int {
+	if len(b) > 32 {
+		b = b[len(b)-32:]
+	}
+	var buf [32]byte
+	copy(buf[32-len(b):], b)
+
+	z[3] = binary.BigEndian.Uint64(buf[0:8])
+	z[2] = binary.BigEndian.Uint64(buf[8:16])
+	z[1] = binary.BigEndian.Uint64(buf[16:24])
+	z[0] = binary.BigEndian.Uint64(buf[24:32])
+	return z
+}
+
+// SetFromBig sets z to b modulo 2^256, negative numbers becoming their two's
+// complement, and returns whether b didn't fit in 256 bits.
+func (z *Int) SetFromBig(b *big.Int) bool {
+	z.SetBytes(b.Bytes())
+	if b.Sign() < 0 {
+		z.Neg(z)
+	}
+	return b.BitLen() > 256
+}
+
+// ToBig returns z as a new big.Int.
+func (z *Int) ToBig() *big.Int {
+	b := z.Bytes32()
+	return new(big.Int).SetBytes(b[:])
+}
+
+// Bytes32 returns z as a 32 byte big-endian array.
+func (z *Int) Bytes32() (b [32]byte) {
+	binary.BigEndian.PutUint64(b[0:8], z[3])
+	binary.BigEndian.PutUint64(b[8:16], z[2])
+	binary.BigEndian.PutUint64(b[16:24], z[1])
+	binary.BigEndian.PutUint64(b[24:32], z[0])
+	return b
+}
+
+// Bytes20 returns the lower 20 bytes of z as a big-endian array.
+func (z *Int) Bytes20() (b [20]byte) {
+	full := z.Bytes32()
+	copy(b[:], full[12:])
+	return b
+}
+
+// Uint64 returns the lower 64 bits of z.
+func (z *Int) Uint64() uint64 {
+	return z[0]
+}
+
+// IsUint64 reports whether z fits in 64 bits.
+func (z *Int) IsUint64() bool {
+	return z[1]|z[2]|z[3] == 0
+}
+
+// Uint64WithOverflow returns the lower 64 bits of z and whether z doesn't fit
+// in 64 bits.
+func (z *Int) Uint64WithOverflow() (uint64, bool) {
+	return z[0], !z.IsUint64()
+}
+
+// IsZero reports whether z is 0.
+func (z *Int) IsZero() bool {
+	return z[0]|z[1]|z[2]|z[3] == 0
+}
+
+// Sign returns 0 if z is 0 and 1 otherwise, interpreting z as unsigned.
+func (z *Int) Sign() int {
+	if z.IsZero() {
+		return 0
+	}
+	return 1
+}
+
+// isNeg reports whether z is negative in two's complement.
+func (z *Int) isNeg() bool {
+	return z[3]>>63 == 1
+}
+
+// BitLen returns the number of bits required to represent z.
+func (z *Int) BitLen() int {
+	for i := 3; i >= 0; i-- {
+		if z[i] != 0 {
+			return i*64 + bitLen64(z[i])
+		}
+	}
+	return 0
+}
+
+// Cmp compares z and x as unsigned integers, returning -1, 0 or +1.
+func (z *Int) Cmp(x *Int) int {
+	for i := 3; i >= 0; i-- {
+		switch {
+		case z[i] > x[i]:
+			return 1
+		case z[i] < x[i]:
+			return -1
+		}
+	}
+	return 0
+}
+
+// Eq reports whether z equals x.
+func (z *Int) Eq(x *Int) bool {
+	return *z == *x
+}
+
+// Lt reports whether z < x as unsigned integers.
+func (z *Int) Lt(x *Int) bool {
+	return z.Cmp(x) < 0
+}
+
+// Gt reports whether z > x as unsigned integers.
+func (z *Int) Gt(x *Int) bool {
+	return z.Cmp(x) > 0
+}
+
+// Slt reports whether z < x as two's complement signed integers.
+func (z *Int) Slt(x *Int) bool {
+	zneg, xneg := z.isNeg(), x.isNeg()
+	if zneg != xneg {
+		return zneg
+	}
+	return z.Lt(x)
+}
+
+// Sgt reports whether z > x as two's complement signed integers.
+func (z *Int) Sgt(x *Int) bool {
+	return x.Slt(z)
+}
+
+// Add sets z to x + y modulo 2^256.
+func (z *Int) Add(x, y *Int) *Int {
+	var carry uint64
+	z[0], carry = add64(x[0], y[0], 0)
+	z[1], carry = add64(x[1], y[1], carry)
+	z[2], carry = add64(x[2], y[2], carry)
+	z[3], _ = add64(x[3], y[3], carry)
+	return z
+}
+
+// Sub sets z to x - y modulo 2^256.
+func (z *Int) Sub(x, y *Int) *Int {
+	var borrow uint64
+	z[0], borrow = sub64(x[0], y[0], 0)
+	z[1], borrow = sub64(x[1], y[1], borrow)
+	z[2], borrow = sub64(x[2], y[2], borrow)
+	z[3], _ = sub64(x[3], y[3], borrow)
+	return z
+}
+
+// Neg sets z to -x modulo 2^256.
+func (z *Int) Neg(x *Int) *Int {
+	return z.Sub(&Int{}, x)
+}
+
+// Abs sets z to the absolute value of x as a two's complement signed integer.
+func (z *Int) Abs(x *Int) *Int {
+	if x.isNeg() {
+		return z.Neg(x)
+	}
+	return z.Set(x)
+}
+
+// Mul sets z to x * y modulo 2^256.
+func (z *Int) Mul(x, y *Int) *Int {
+	var res Int
+	for i := 0; i < 4; i++ {
+		if x[i] == 0 {
+			continue
+		}
+		var carry uint64
+		for j := 0; i+j < 4; j++ {
+			res[i+j], carry = mulAdd64(x[i], y[j], res[i+j], carry)
+		}
+	}
+	*z = res
+	return z
+}
+
+// Div sets z to x / y, or 0 if y is 0.
+func (z *Int) Div(x, y *Int) *Int {
+	switch {
+	case y.IsZero() || y.Gt(x):
+		return z.Clear()
+	case x.Eq(y):
+		return z.SetOne()
+	case x.IsUint64():
+		return z.SetUint64(x.Uint64() / y.Uint64())
+	}
+	var quot Int
+	udivrem(quot[:], x[:], y)
+	*z = quot
+	return z
+}
+
+// Mod sets z to x modulo y, or 0 if y is 0.
+func (z *Int) Mod(x, y *Int) *Int {
+	switch {
+	case y.IsZero() || x.Eq(y):
+		return z.Clear()
+	case x.Lt(y):
+		return z.Set(x)
+	case x.IsUint64():
+		return z.SetUint64(x.Uint64() % y.Uint64())
+	}
+	*z = udivrem(nil, x[:], y)
+	return z
+}
+
+// SDiv sets z to x / y as two's complement signed integers, rounding towards
+// zero, or 0 if y is 0.
+func (z *Int) SDiv(x, y *Int) *Int {
+	if y.IsZero() {
+		return z.Clear()
+	}
+	neg := x.isNeg() != y.isNeg()
+
+	var a, b Int
+	z.Div(a.Abs(x), b.Abs(y))
+	if neg {
+		z.Neg(z)
+	}
+	return z
+}
+
+// SMod sets z to x modulo y as two's complement signed integers, the result
+// taking the sign of x, or 0 if y is 0.
+func (z *Int) SMod(x, y *Int) *Int {
+	if y.IsZero() {
+		return z.Clear()
+	}
+	neg := x.isNeg()
+
+	var a, b Int
+	z.Mod(a.Abs(x), b.Abs(y))
+	if neg {
+		z.Neg(z)
+	}
+	return z
+}
+
+// AddMod sets z to (x + y) modulo m without truncating the sum, or 0 if m is 0.
+func (z *Int) AddMod(x, y, m *Int) *Int {
+	if m.IsZero() {
+		return z.Clear()
+	}
+	var sum [5]uint64
+	sum[0], sum[4] = add64(x[0], y[0], 0)
+	sum[1], sum[4] = add64(x[1], y[1], sum[4])
+	sum[2], sum[4] = add64(x[2], y[2], sum[4])
+	sum[3], sum[4] = add64(x[3], y[3], sum[4])
+	if sum[4] == 0 {
+		return z.Mod(&Int{sum[0], sum[1], sum[2], sum[3]}, m)
+	}
+	*z = udivrem(nil, sum[:], m)
+	return z
+}
+
+// MulMod sets z to (x * y) modulo m without truncating the product, or 0 if m
+// is 0.
+func (z *Int) MulMod(x, y, m *Int) *Int {
+	if m.IsZero() {
+		return z.Clear()
+	}
+	var prod [8]uint64
+	for i := 0; i < 4; i++ {
+		var carry uint64
+		for j := 0; j < 4; j++ {
+			prod[i+j], carry = mulAdd64(x[i], y[j], prod[i+j], carry)
+		}
+		prod[i+4] = carry
+	}
+	*z = udivrem(nil, prod[:], m)
+	return z
+}
+
+// Exp sets z to base^exponent modulo 2^256.
+func (z *Int) Exp(base, exponent *Int) *Int {
+	var (
+		res = Int{1}
+		b   = *base
+		e   = *exponent
+	)
+	for i, n := 0, e.BitLen(); i < n; i++ {
+		if e[i/64]>>(uint(i)%64)&1 == 1 {
+			res.Mul(&res, &b)
+		}
+		b.Mul(&b, &b)
+	}
+	*z = res
+	return z
+}
+
+// SignExtend sets z to num sign extended from its byte at position back,
+// counted from the least significant one. Nothing is extended if back is 31 or
+// larger.
+func (z *Int) SignExtend(back, num *Int) *Int {
+	*z = *num
+	if !back.Lt(&Int{31}) {
+		return z
+	}
+	bit := uint(back.Uint64()*8 + 7)
+	limb, shift := bit/64, bit%64
+
+	// Mask of the bits within the limb up to and including the sign bit
+	mask := uint64(1)<<shift<<1 - 1
+	if (z[limb]>>shift)&1 == 1 {
+		z[limb] |= ^mask
+		for i := limb + 1; i < 4; i++ {
+			z[i] = ^uint64(0)
+		}
+	} else {
+		z[limb] &= mask
+		for i := limb + 1; i < 4; i++ {
+			z[i] = 0
+		}
+	}
+	return z
+}
+
+// Byte sets z to its byte at position n, counted from the most significant one,
+// or 0 if n is 32 or larger.
+func (z *Int) Byte(n *Int) *Int {
+	if !n.IsUint64() || n.Uint64() >= 32 {
+		return z.Clear()
+	}
+	idx := n.Uint64()
+	limb := z[3-idx/8]
+	return z.SetUint64((limb >> (56 - (idx%8)*8)) & 0xff)
+}
+
+// Not sets z to the bitwise complement of x.
+func (z *Int) Not(x *Int) *Int {
+	z[0], z[1], z[2], z[3] = ^x[0], ^x[1], ^x[2], ^x[3]
+	return z
+}
+
+// And sets z to x & y.
+func (z *Int) And(x, y *Int) *Int {
+	z[0], z[1], z[2], z[3] = x[0]&y[0], x[1]&y[1], x[2]&y[2], x[3]&y[3]
+	return z
+}
+
+// Or sets z to x | y.
+func (z *Int) Or(x, y *Int) *Int {
+	z[0], z[1], z[2], z[3] = x[0]|y[0], x[1]|y[1], x[2]|y[2], x[3]|y[3]
+	return z
+}
+
+// Xor sets z to x ^ y.
+func (z *Int) Xor(x, y *Int) *Int {
+	z[0], z[1], z[2], z[3] = x[0]^y[0], x[1]^y[1], x[2]^y[2], x[3]^y[3]
+	return z
+}
+
+// String returns the decimal representation of z.
+func (z *Int) String() string {
+	return z.ToBig().String()
+}
+
+// add64 returns the sum of x, y and a carry of 0 or 1, along with the carry out.
+func add64(x, y, carry uint64) (sum, carryOut uint64) {
+	sum = x + y + carry
+	carryOut = ((x & y) | ((x | y) &^ sum)) >> 63
+	return sum, carryOut
+}
+
+// sub64 returns the difference of x, y and a borrow of 0 or 1, along with the
+// borrow out.
+func sub64(x, y, borrow uint64) (diff, borrowOut uint64) {
+	diff = x - y - borrow
+	borrowOut = ((^x & y) | (^(x ^ y) & diff)) >> 63
+	return diff, borrowOut
+}
+
+// mul64 returns the 128-bit product of x and y.
+func mul64(x, y uint64) (hi, lo uint64) {
+	const mask32 = 1<<32 - 1
+
+	x0, x1 := x&mask32, x>>32
+	y0, y1 := y&mask32, y>>32
+
+	w0 := x0 * y0
+	t := x1*y0 + w0>>32
+	w1, w2 := t&mask32, t>>32
+	w1 += x0 * y1
+
+	return x1*y1 + w2 + w1>>32, x * y
+}
+
+// mulAdd64 returns the lower 64 bits of x * y + z + carry, along with the upper
+// 64 bits as the carry out. The sum can't overflow 128 bits.
+func mulAdd64(x, y, z, carry uint64) (lo, carryOut uint64) {
+	hi, lo := mul64(x, y)
+	var c uint64
+	lo, c = add64(lo, z, 0)
+	hi += c
+	lo, c = add64(lo, carry, 0)
+	hi += c
+	return lo, hi
+}
+
+// udivrem divides the little-endian limbs u by the non-zero d, storing the
+// quotient in quot if it isn't nil and returning the remainder. The quotient
+// must fit in quot.
+func udivrem(quot, u []uint64, d *Int) (rem Int) {
+	var (
+		un [16]uint32
+		dn [8]uint32
+		qn [16]uint32
+		rn [8]uint32
+	)
+	m := toDigits(un[:], u)
+	n := toDigits(dn[:], d[:])
+	if n > m {
+		// The dividend is smaller than the divisor, so it fits in 256 bits
+		copy(rem[:], u)
+		return rem
+	}
+	divDigits(qn[:m-n+1], rn[:n], un[:m], dn[:n])
+
+	for i := range quot {
+		quot[i] = uint64(qn[2*i]) | uint64(qn[2*i+1])<<32
+	}
+	for i := range rem {
+		rem[i] = uint64(rn[2*i]) | uint64(rn[2*i+1])<<32
+	}
+	return rem
+}
+
+// toDigits splits the limbs into little-endian 32-bit digits, returning the
+// number of significant ones.
+func toDigits(digits []uint32, limbs []uint64) int {
+	for i, limb := range limbs {
+		digits[2*i], digits[2*i+1] = uint32(limb), uint32(limb>>32)
+	}
+	n := 2 * len(limbs)
+	for n > 0 && digits[n-1] == 0 {
+		n--
+	}
+	return n
+}
+
+// divDigits divides the little-endian 32-bit digits u by v, whose most
+// significant digit must be non-zero, using Knuth's algorithm D. The quotient
+// of len(u)-len(v)+1 digits is stored in q and the remainder of len(v) digits
+// in r.
+func divDigits(q, r, u, v []uint32) {
+	m, n := len(u), len(v)
+
+	// Short division by a single digit
+	if n == 1 {
+		var rem uint64
+		for j := m - 1; j >= 0; j-- {
+			cur := rem<<32 | uint64(u[j])
+			q[j] = uint32(cur / uint64(v[0]))
+			rem = cur % uint64(v[0])
+		}
+		r[0] = uint32(rem)
+		return
+	}
+	// Normalize the divisor to have its top bit set, shifting the dividend
+	// along into an extra digit
+	var (
+		vnBuf [8]uint32
+		unBuf [17]uint32
+	)
+	s := uint(32 - bitLen64(uint64(v[n-1])))
+
+	vn := vnBuf[:n]
+	for i := n - 1; i > 0; i-- {
+		vn[i] = v[i]<<s | v[i-1]>>(32-s)
+	}
+	vn[0] = v[0] << s
+
+	un := unBuf[:m+1]
+	un[m] = u[m-1] >> (32 - s)
+	for i := m - 1; i > 0; i-- {
+		un[i] = u[i]<<s | u[i-1]>>(32-s)
+	}
+	un[0] = u[0] << s
+
+	for j := m - n; j >= 0; j-- {
+		// Estimate the quotient digit, correcting it to be at most one too large
+		num := uint64(un[j+n])<<32 | uint64(un[j+n-1])
+		qhat := num / uint64(vn[n-1])
+		rhat := num % uint64(vn[n-1])
+		for qhat >= 1<<32 || qhat*uint64(vn[n-2]) > rhat<<32|uint64(un[j+n-2]) {
+			qhat--
+			rhat += uint64(vn[n-1])
+			if rhat >= 1<<32 {
+				break
+			}
+		}
+		// Multiply and subtract the divisor from the dividend
+		var carry, borrow uint64
+		for i := 0; i < n; i++ {
+			p := qhat*uint64(vn[i]) + carry
+			carry = p >> 32
+			t := uint64(un[i+j]) - p&(1<<32-1) - borrow
+			un[i+j] = uint32(t)
+			borrow = t >> 63
+		}
+		t := uint64(un[j+n]) - carry - borrow
+		un[j+n] = uint32(t)
+
+		// Add the divisor back if the estimate was one too large
+		q[j] = uint32(qhat)
+		if t>>63 == 1 {
+			q[j]--
+			var c uint64
+			for i := 0; i < n; i++ {
+				t := uint64(un[i+j]) + uint64(vn[i]) + c
+				un[i+j] = uint32(t)
+				c = t >> 32
+			}
+			un[j+n] += uint32(c)
+		}
+	}
+	// Denormalize the remainder
+	for i := 0; i < n-1; i++ {
+		r[i] = un[i]>>s | un[i+1]<<(32-s)
+	}
+	r[n-1] = un[n-1] >> s
+}
+
+// bitLen64 returns the number of bits required to represent x.
+func bitLen64(x uint64) (n int) {
+	for ; x >= 0x100; x >>= 8 {
+		n += 8
+	}
+	for ; x != 0; x >>= 1 {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,245 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package uint256
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+var (
+	tt255   = new(big.Int).Lsh(big.NewInt(1), 255)
+	tt256   = new(big.Int).Lsh(big.NewInt(1), 256)
+	tt256m1 = new(big.Int).Sub(tt256, big.NewInt(1))
+)
+
+// u256 truncates x to 256 bits, mapping negative numbers to two's complement.
+func u256(x *big.Int) *big.Int {
+	return x.And(x, tt256m1)
+}
+
+// s256 interprets the 256-bit x as a two's complement signed integer.
+func s256(x *big.Int) *big.Int {
+	if x.Cmp(tt255) < 0 {
+		return x
+	}
+	return new(big.Int).Sub(x, tt256)
+}
+
+// testValues returns values covering the limb boundaries, signed extremes and
+// random numbers of all magnitudes.
+func testValues(rnd *rand.Rand) []*big.Int {
+	vals := []*big.Int{
+		big.NewInt(0), big.NewInt(1), big.NewInt(2), big.NewInt(31), big.NewInt(32),
+		new(big.Int).Set(tt255), new(big.Int).Sub(tt255, big.NewInt(1)), new(big.Int).Set(tt256m1),
+	}
+	for _, shift := range []uint{31, 32, 63, 64, 127, 128, 191, 192} {
+		one := new(big.Int).Lsh(big.NewInt(1), shift)
+		vals = append(vals, one, new(big.Int).Sub(one, big.NewInt(1)))
+	}
+	for i := 0; i < 40; i++ {
+		vals = append(vals, new(big.Int).Rand(rnd, new(big.Int).Lsh(big.NewInt(1), uint(rnd.Intn(256)+1))))
+	}
+	return vals
+}
+
+func fromBig(x *big.Int) *Int {
+	z := new(Int)
+	z.SetFromBig(x)
+	return z
+}
+
+// Tests the binary operations against their big.Int equivalents.
+func TestBinaryOps(t *testing.T) {
+	ops := []struct {
+		name string
+		op   func(z, x, y *Int) *Int
+		ref  func(x, y *big.Int) *big.Int
+	}{
+		{"add", (*Int).Add, func(x, y *big.Int) *big.Int { return u256(new(big.Int).Add(x, y)) }},
+		{"sub", (*Int).Sub, func(x, y *big.Int) *big.Int { return u256(new(big.Int).Sub(x, y)) }},
+		{"mul", (*Int).Mul, func(x, y *big.Int) *big.Int { return u256(new(big.Int).Mul(x, y)) }},
+		{"div", (*Int).Div, func(x, y *big.Int) *big.Int {
+			if y.Sign() == 0 {
+				return new(big.Int)
+			}
+			return new(big.Int).Div(x, y)
+		}},
+		{"mod", (*Int).Mod, func(x, y *big.Int) *big.Int {
+			if y.Sign() == 0 {
+				return new(big.Int)
+			}
+			return new(big.Int).Mod(x, y)
+		}},
+		{"sdiv", (*Int).SDiv, func(x, y *big.Int) *big.Int {
+			if y.Sign() == 0 {
+				return new(big.Int)
+			}
+			return u256(new(big.Int).Quo(s256(x), s256(y)))
+		}},
+		{"smod", (*Int).SMod, func(x, y *big.Int) *big.Int {
+			if y.Sign() == 0 {
+				return new(big.Int)
+			}
+			return u256(new(big.Int).Rem(s256(x), s256(y)))
+		}},
+		{"exp", (*Int).Exp, func(x, y *big.Int) *big.Int { return new(big.Int).Exp(x, y, tt256) }},
+		{"and", (*Int).And, func(x, y *big.Int) *big.Int { return new(big.Int).And(x, y) }},
+		{"or", (*Int).Or, func(x, y *big.Int) *big.Int { return new(big.Int).Or(x, y) }},
+		{"xor", (*Int).Xor, func(x, y *big.Int) *big.Int { return new(big.Int).Xor(x, y) }},
+	}
+	vals := testValues(rand.New(rand.NewSource(1)))
+	for _, op := range ops {
+		for _, x := range vals {
+			for _, y := range vals {
+				want := op.ref(x, y)
+				if have := op.op(new(Int), fromBig(x), fromBig(y)).ToBig(); have.Cmp(want) != 0 {
+					t.Fatalf("%s(%x, %x) mismatch: have %x, want %x", op.name, x, y, have, want)
+				}
+				// Operations must be safe with the receiver aliasing an operand
+				z := fromBig(x)
+				if have := op.op(z, z, fromBig(y)).ToBig(); have.Cmp(want) != 0 {
+					t.Fatalf("aliased %s(%x, %x) mismatch: have %x, want %x", op.name, x, y, have, want)
+				}
+			}
+		}
+	}
+}
+
+// Tests the modular addition and multiplication against their big.Int
+// equivalents, which don't truncate the intermediate results.
+func TestModularOps(t *testing.T) {
+	vals := testValues(rand.New(rand.NewSource(2)))
+	for _, x := range vals {
+		for _, y := range vals {
+			for _, m := range vals[:20] {
+				wantAdd, wantMul := new(big.Int), new(big.Int)
+				if m.Sign() != 0 {
+					wantAdd.Mod(new(big.Int).Add(x, y), m)
+					wantMul.Mod(new(big.Int).Mul(x, y), m)
+				}
+				if have := new(Int).AddMod(fromBig(x), fromBig(y), fromBig(m)).ToBig(); have.Cmp(wantAdd) != 0 {
+					t.Fatalf("addmod(%x, %x, %x) mismatch: have %x, want %x", x, y, m, have, wantAdd)
+				}
+				if have := new(Int).MulMod(fromBig(x), fromBig(y), fromBig(m)).ToBig(); have.Cmp(wantMul) != 0 {
+					t.Fatalf("mulmod(%x, %x, %x) mismatch: have %x, want %x", x, y, m, have, wantMul)
+				}
+			}
+		}
+	}
+}
+
+// Tests the comparisons against their big.Int equivalents.
+func TestComparisons(t *testing.T) {
+	vals := testValues(rand.New(rand.NewSource(3)))
+	for _, x := range vals {
+		for _, y := range vals {
+			ux, uy := fromBig(x), fromBig(y)
+			if have, want := ux.Cmp(uy), x.Cmp(y); have != want {
+				t.Fatalf("cmp(%x, %x) mismatch: have %d, want %d", x, y, have, want)
+			}
+			if have, want := ux.Slt(uy), s256(x).Cmp(s256(y)) < 0; have != want {
+				t.Fatalf("slt(%x, %x) mismatch: have %v, want %v", x, y, have, want)
+			}
+			if have, want := ux.Sgt(uy), s256(x).Cmp(s256(y)) > 0; have != want {
+				t.Fatalf("sgt(%x, %x) mismatch: have %v, want %v", x, y, have, want)
+			}
+		}
+		if have, want := fromBig(x).BitLen(), x.BitLen(); have != want {
+			t.Fatalf("bitlen(%x) mismatch: have %d, want %d", x, have, want)
+		}
+	}
+}
+
+// Tests sign extension and byte extraction against their definitions.
+func TestSignExtendAndByte(t *testing.T) {
+	vals := testValues(rand.New(rand.NewSource(4)))
+	for _, x := range vals {
+		b := fromBig(x).Bytes32()
+		for i := uint64(0); i < 34; i++ {
+			// The byte at position i counted from the most significant one
+			want := uint64(0)
+			if i < 32 {
+				want = uint64(b[i])
+			}
+			if have := fromBig(x).Byte(NewInt(i)); !have.Eq(NewInt(want)) {
+				t.Fatalf("byte(%x, %d) mismatch: have %v, want %d", x, i, have, want)
+			}
+			// Sign extension from the byte at position i from the least significant one
+			wantExt := new(big.Int).Set(x)
+			if i < 31 {
+				bit := uint(i*8 + 7)
+				mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), bit), big.NewInt(1))
+				if x.Bit(int(bit)) == 1 {
+					wantExt = u256(new(big.Int).Or(x, new(big.Int).Not(mask)))
+				} else {
+					wantExt = new(big.Int).And(x, mask)
+				}
+			}
+			if have := new(Int).SignExtend(NewInt(i), fromBig(x)).ToBig(); have.Cmp(wantExt) != 0 {
+				t.Fatalf("signextend(%d, %x) mismatch: have %x, want %x", i, x, have, wantExt)
+			}
+		}
+	}
+}
+
+// Tests the conversions from and to big.Int and byte slices.
+func TestConversions(t *testing.T) {
+	x, _ := new(big.Int).SetString("0102030405060708091011121314151617181920212223242526272829303132", 16)
+	z := fromBig(x)
+	if z.ToBig().Cmp(x) != 0 {
+		t.Fatalf("round trip mismatch: have %x, want %x", z.ToBig(), x)
+	}
+	if b := z.Bytes20(); b[0] != 0x13 || b[19] != 0x32 {
+		t.Fatalf("lower 20 bytes mismatch: have %x", b)
+	}
+	if !new(Int).SetBytes(append([]byte{0xff}, x.Bytes()...)).Eq(z) {
+		t.Fatalf("long byte slice not truncated")
+	}
+	if overflow := new(Int).SetFromBig(tt256); !overflow {
+		t.Fatalf("overflow not reported")
+	}
+	if neg := fromBig(big.NewInt(-1)); !neg.Eq(fromBig(tt256m1)) {
+		t.Fatalf("negative conversion mismatch: have %v", neg)
+	}
+	if s := fromBig(tt256m1).String(); s != tt256m1.String() {
+		t.Fatalf("string mismatch: have %s, want %s", s, tt256m1)
+	}
+}
+
+func BenchmarkMul(b *testing.B) {
+	x, y := fromBig(new(big.Int).Sub(tt255, big.NewInt(3))), fromBig(new(big.Int).Sub(tt256m1, big.NewInt(7)))
+	for i := 0; i < b.N; i++ {
+		new(Int).Mul(x, y)
+	}
+}
+
+func BenchmarkDiv(b *testing.B) {
+	x, y := fromBig(new(big.Int).Sub(tt256m1, big.NewInt(7))), fromBig(new(big.Int).Rsh(tt255, 100))
+	for i := 0; i < b.N; i++ {
+		new(Int).Div(x, y)
+	}
+}
+
+func BenchmarkMulMod(b *testing.B) {
+	x, y := fromBig(new(big.Int).Sub(tt255, big.NewInt(3))), fromBig(new(big.Int).Sub(tt256m1, big.NewInt(7)))
+	m := fromBig(new(big.Int).Rsh(tt255, 17))
+	for i := 0; i < b.N; i++ {
+		new(Int).MulMod(x, y, m)
+	}
+}
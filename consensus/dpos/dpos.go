@@ -76,6 +76,13 @@ var (
 	ErrInvalidBlockValidator      = errors.New("invalid block validator")
 	ErrInvalidMintBlockTime       = errors.New("invalid time to mint the block")
 	ErrNilBlockHeader             = errors.New("nil block header returned")
+
+	// ErrNoCandidates is returned by the epoch election if nobody has logged in
+	// as a candidate.
+	ErrNoCandidates = errors.New("no candidates")
+	// ErrTooFewCandidates is returned by the epoch election if there aren't
+	// enough candidates to elect a safe number of validators.
+	ErrTooFewCandidates = errors.New("too few candidates")
 )
 var (
 	uncleHash = types.CalcUncleHash(nil) // Always Keccak256(RLP([])) as uncles are meaningless outside of PoW.
@@ -84,6 +91,7 @@ var (
 type Dpos struct {
 	config *params.DposConfig // Consensus engine configuration parameters
 	db     ethdb.Database     // Database to store and retrieve snapshot checkpoints
+	triedb trie.Database      // Database to retrieve the dpos context tries from
 
 	signer               common.Address
 	signFn               SignerFn
@@ -135,10 +143,50 @@ func New(config *params.DposConfig, db ethdb.Database) *Dpos {
 	return &Dpos{
 		config:     config,
 		db:         db,
+		triedb:     db,
 		signatures: signatures,
 	}
 }
 
+// SetTrieDB sets the database the dpos context tries are read from. It must be
+// set to the chain's trie node database if the tries are not committed to disk
+// with every block.
+func (d *Dpos) SetTrieDB(triedb trie.Database) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.triedb = triedb
+}
+
+// trieDB returns the database the dpos context tries are read from.
+func (d *Dpos) trieDB() trie.Database {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.triedb
+}
+
+// EpochID returns the epoch a block with the given timestamp belongs to, which
+// keys the mint counts of the validators.
+func EpochID(timestamp int64) int64 {
+	return timestamp / epochInterval
+}
+
+// EpochStart returns the timestamp the given epoch begins at.
+func EpochStart(epoch int64) int64 {
+	return epoch * epochInterval
+}
+
+// Elect runs the validator elections of all the epochs that begin between the
+// parent block and the given timestamp, as done when finalizing a block. The
+// votes are weighted by the delegator balances in statedb.
+func Elect(genesis, parent *types.Header, timestamp int64, statedb *state.StateDB, dposContext *types.DposContext) error {
+	epochContext := &EpochContext{
+		statedb:     statedb,
+		DposContext: dposContext,
+		TimeStamp:   timestamp,
+	}
+	return epochContext.tryElect(genesis, parent)
+}
+
 func (d *Dpos) Author(header *types.Header) (common.Address, error) {
 	return header.Validator, nil
 }
@@ -243,7 +291,7 @@ func (d *Dpos) verifySeal(chain consensus.ChainReader, header *types.Header, par
 	} else {
 		parent = chain.GetHeader(header.ParentHash, number-1)
 	}
-	dposContext, err := types.NewDposContextFromProto(d.db, parent.DposContext)
+	dposContext, err := types.NewDposContextFromProto(d.trieDB(), parent.DposContext)
 	if err != nil {
 		return err
 	}
@@ -330,6 +378,16 @@ func (s *Dpos) loadConfirmedBlockHeader(chain consensus.ChainReader) (*types.Hea
 	return header, nil
 }
 
+// ConfirmedBlockNumber retrieves the number of the latest irreversible block as
+// persisted by the engine. False is returned if no block was confirmed yet.
+func (d *Dpos) ConfirmedBlockNumber(chain consensus.ChainReader) (uint64, bool) {
+	header, err := d.loadConfirmedBlockHeader(chain)
+	if err != nil {
+		return 0, false
+	}
+	return header.Number.Uint64(), true
+}
+
 // store inserts the snapshot into the database.
 func (s *Dpos) storeConfirmedBlockHeader(db ethdb.Database) error {
 	return db.Put(confirmedBlockHead, s.confirmedBlockHeader.Hash().Bytes())
@@ -409,7 +467,7 @@ func (d *Dpos) CheckValidator(lastBlock *types.Block, now int64) error {
 	if err := d.checkDeadline(lastBlock, now); err != nil {
 		return err
 	}
-	dposContext, err := types.NewDposContextFromProto(d.db, lastBlock.Header().DposContext)
+	dposContext, err := types.NewDposContextFromProto(d.trieDB(), lastBlock.Header().DposContext)
 	if err != nil {
 		return err
 	}
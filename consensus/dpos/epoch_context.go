@@ -32,7 +32,7 @@ func (ec *EpochContext) countVotes() (votes map[common.Address]*big.Int, err err
 	iterCandidate := trie.NewIterator(candidateTrie.NodeIterator(nil))
 	existCandidate := iterCandidate.Next()
 	if !existCandidate {
-		return votes, errors.New("no candidates")
+		return votes, ErrNoCandidates
 	}
 	for existCandidate {
 		candidate := iterCandidate.Value
@@ -175,7 +175,7 @@ func (ec *EpochContext) tryElect(genesis, parent *types.Header) error {
 			candidates = append(candidates, &sortableAddress{candidate, cnt})
 		}
 		if len(candidates) < safeSize {
-			return errors.New("too few candidates")
+			return ErrTooFewCandidates
 		}
 		sort.Sort(candidates)
 		if len(candidates) > maxValidatorSize {
@@ -87,7 +87,7 @@ type ReleaseOracleTransactorRaw struct {
 
 // NewReleaseOracle creates a new instance of ReleaseOracle, bound to a specific deployed contract.
 func NewReleaseOracle(address common.Address, backend bind.ContractBackend) (*ReleaseOracle, error) {
-	contract, err := bindReleaseOracle(address, backend, backend)
+	contract, err := bindReleaseOracle(address, backend, backend, backend)
 	if err != nil {
 		return nil, err
 	}
@@ -96,7 +96,7 @@ func NewReleaseOracle(address common.Address, backend bind.ContractBackend) (*Re
 
 // NewReleaseOracleCaller creates a new read-only instance of ReleaseOracle, bound to a specific deployed contract.
 func NewReleaseOracleCaller(address common.Address, caller bind.ContractCaller) (*ReleaseOracleCaller, error) {
-	contract, err := bindReleaseOracle(address, caller, nil)
+	contract, err := bindReleaseOracle(address, caller, nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -105,7 +105,7 @@ func NewReleaseOracleCaller(address common.Address, caller bind.ContractCaller)
 
 // NewReleaseOracleTransactor creates a new write-only instance of ReleaseOracle, bound to a specific deployed contract.
 func NewReleaseOracleTransactor(address common.Address, transactor bind.ContractTransactor) (*ReleaseOracleTransactor, error) {
-	contract, err := bindReleaseOracle(address, nil, transactor)
+	contract, err := bindReleaseOracle(address, nil, transactor, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -113,12 +113,12 @@ func NewReleaseOracleTransactor(address common.Address, transactor bind.Contract
 }
 
 // bindReleaseOracle binds a generic wrapper to an already deployed contract.
-func bindReleaseOracle(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor) (*bind.BoundContract, error) {
+func bindReleaseOracle(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
 	parsed, err := abi.JSON(strings.NewReader(ReleaseOracleABI))
 	if err != nil {
 		return nil, err
 	}
-	return bind.NewBoundContract(address, parsed, caller, transactor), nil
+	return bind.NewBoundContract(address, parsed, caller, transactor, filterer), nil
 }
 
 // Call invokes the (constant) contract method with params as input values and
@@ -101,6 +101,9 @@ func (v *BlockValidator) ValidateState(block, parent *types.Block, statedb *stat
 }
 
 func (v *BlockValidator) ValidateDposState(block *types.Block) error {
+	if block.DposCtx() == nil {
+		return ErrNoDposContext
+	}
 	header := block.Header()
 	localRoot := block.DposCtx().Root()
 	remoteRoot := header.DposContext.Root()
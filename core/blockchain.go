@@ -32,6 +32,7 @@ import (
 	"github.com/meitu/go-ethereum/consensus"
 	"github.com/meitu/go-ethereum/consensus/dpos"
 	"github.com/meitu/go-ethereum/core/state"
+	"github.com/meitu/go-ethereum/core/state/snapshot"
 	"github.com/meitu/go-ethereum/core/types"
 	"github.com/meitu/go-ethereum/core/vm"
 	"github.com/meitu/go-ethereum/crypto"
@@ -43,6 +44,7 @@ import (
 	"github.com/meitu/go-ethereum/rlp"
 	"github.com/meitu/go-ethereum/trie"
 	"github.com/hashicorp/golang-lru"
+	"gopkg.in/karalabe/cookiejar.v2/collections/prque"
 )
 
 var (
@@ -57,11 +59,21 @@ const (
 	maxFutureBlocks     = 256
 	maxTimeFutureBlocks = 30
 	badBlockLimit       = 10
+	triesInMemory       = 128
 
 	// BlockChainVersion ensures that an incompatible database forces a resync from scratch.
 	BlockChainVersion = 3
 )
 
+// CacheConfig contains the configuration values for the trie caching/pruning
+// that's resident in a blockchain.
+type CacheConfig struct {
+	Disabled          bool   // Whether to disable trie write caching (archive node)
+	TrieNodeLimit     int    // Memory limit (MB) at which to flush the current in-memory trie to disk
+	TrieFlushInterval uint64 // Number of blocks after which to flush the current in-memory trie to disk
+	NoSnapshot        bool   // Whether to disable the flat state snapshot
+}
+
 // BlockChain represents the canonical chain given a database with a genesis
 // block. The Blockchain manages chain imports, reverts, chain reorganisations.
 //
@@ -77,10 +89,13 @@ const (
 // included in the canonical one where as GetBlockByNumber always represents the
 // canonical chain.
 type BlockChain struct {
-	config *params.ChainConfig // chain & network configuration
+	config      *params.ChainConfig // chain & network configuration
+	cacheConfig *CacheConfig        // Cache configuration for pruning
 
 	hc            *HeaderChain
 	chainDb       ethdb.Database
+	triegc        *prque.Prque // Priority queue mapping block numbers to tries to gc
+	lastWrite     uint64       // Number of the block whose tries were last flushed to disk
 	rmLogsFeed    event.Feed
 	chainFeed     event.Feed
 	chainSideFeed event.Feed
@@ -89,15 +104,18 @@ type BlockChain struct {
 	scope         event.SubscriptionScope
 	genesisBlock  *types.Block
 
-	mu      sync.RWMutex // global mutex for locking chain operations
-	chainmu sync.RWMutex // blockchain insertion lock
-	procmu  sync.RWMutex // block processor lock
+	mu       sync.RWMutex // global mutex for locking chain operations
+	chainmu  sync.RWMutex // blockchain insertion lock
+	procmu   sync.RWMutex // block processor lock
+	freezemu sync.Mutex   // ancient store migration lock
 
 	checkpoint       int          // checkpoint counts towards the new checkpoint
 	currentBlock     *types.Block // Current head of the block chain
 	currentFastBlock *types.Block // Current head of the fast-sync chain (may be above the block chain!)
 
 	stateCache   state.Database // State database to reuse between imports (contains state cache)
+	snaps        *snapshot.Tree // Flat state snapshot for fast state reads, nil if disabled
+	snapCapped   uint64         // Number of the block the state snapshot was last flattened at
 	bodyCache    *lru.Cache     // Cache for the most recent block bodies
 	bodyRLPCache *lru.Cache     // Cache for the most recent block bodies in RLP encoded format
 	blockCache   *lru.Cache     // Cache for the most recent entire blocks
@@ -119,8 +137,14 @@ type BlockChain struct {
 
 // NewBlockChain returns a fully initialised block chain using information
 // available in the database. It initialises the default Ethereum Validator and
-// Processor.
-func NewBlockChain(chainDb ethdb.Database, config *params.ChainConfig, engine consensus.Engine, vmConfig vm.Config) (*BlockChain, error) {
+// Processor. A nil cacheConfig selects the default pruning configuration.
+func NewBlockChain(chainDb ethdb.Database, cacheConfig *CacheConfig, config *params.ChainConfig, engine consensus.Engine, vmConfig vm.Config) (*BlockChain, error) {
+	if cacheConfig == nil {
+		cacheConfig = &CacheConfig{
+			TrieNodeLimit:     256,
+			TrieFlushInterval: 256,
+		}
+	}
 	bodyCache, _ := lru.New(bodyCacheLimit)
 	bodyRLPCache, _ := lru.New(bodyCacheLimit)
 	blockCache, _ := lru.New(blockCacheLimit)
@@ -129,7 +153,9 @@ func NewBlockChain(chainDb ethdb.Database, config *params.ChainConfig, engine co
 
 	bc := &BlockChain{
 		config:       config,
+		cacheConfig:  cacheConfig,
 		chainDb:      chainDb,
+		triegc:       prque.New(),
 		stateCache:   state.NewDatabase(chainDb),
 		quit:         make(chan struct{}),
 		bodyCache:    bodyCache,
@@ -140,6 +166,11 @@ func NewBlockChain(chainDb ethdb.Database, config *params.ChainConfig, engine co
 		vmConfig:     vmConfig,
 		badBlocks:    badBlocks,
 	}
+	// The dpos context tries are cached alongside the state, make sure the
+	// engine reads them through the same layer.
+	if dposEngine, ok := engine.(*dpos.Dpos); ok {
+		dposEngine.SetTrieDB(bc.stateCache.TrieDB())
+	}
 	bc.SetValidator(NewBlockValidator(config, bc, engine))
 	bc.SetProcessor(NewStateProcessor(config, bc, engine))
 
@@ -155,6 +186,9 @@ func NewBlockChain(chainDb ethdb.Database, config *params.ChainConfig, engine co
 	if err := bc.loadLastState(); err != nil {
 		return nil, err
 	}
+	if !cacheConfig.NoSnapshot {
+		bc.snaps = snapshot.New(chainDb, bc.stateCache.TrieDB(), bc.currentBlock.Root())
+	}
 	// Check the current state of the block hashes and make sure that we do not have any of the bad blocks in our chain
 	for hash := range BadHashes {
 		if header := bc.GetHeaderByHash(hash); header != nil {
@@ -168,6 +202,11 @@ func NewBlockChain(chainDb ethdb.Database, config *params.ChainConfig, engine co
 			}
 		}
 	}
+	// Move finalized chain segments into the ancient store, if there's one
+	if _, ok := chainDb.(ethdb.AncientStore); ok {
+		bc.wg.Add(1)
+		go bc.freezeLoop()
+	}
 	// Take ownership of this particular state
 	go bc.update()
 	return bc, nil
@@ -195,10 +234,12 @@ func (bc *BlockChain) loadLastState() error {
 		return bc.Reset()
 	}
 	// Make sure the state associated with the block is available
-	if _, err := state.New(currentBlock.Root(), bc.stateCache); err != nil {
+	if !bc.hasState(currentBlock) {
 		// Dangling block without a state associated, init from scratch
-		log.Warn("Head state missing, resetting chain", "number", currentBlock.Number(), "hash", currentBlock.Hash())
-		return bc.Reset()
+		log.Warn("Head state missing, repairing chain", "number", currentBlock.Number(), "hash", currentBlock.Hash())
+		if err := bc.repair(&currentBlock); err != nil {
+			return err
+		}
 	}
 	// Everything seems to be fine, set as the head block
 	bc.currentBlock = currentBlock
@@ -242,6 +283,10 @@ func (bc *BlockChain) SetHead(head uint64) error {
 	bc.mu.Lock()
 	defer bc.mu.Unlock()
 
+	// Prevent blocks from being frozen while rewinding the ancient store
+	bc.freezemu.Lock()
+	defer bc.freezemu.Unlock()
+
 	// Rewind the header chain, deleting all block bodies until then
 	delFn := func(hash common.Hash, num uint64) {
 		DeleteBody(bc.chainDb, hash, num)
@@ -259,11 +304,9 @@ func (bc *BlockChain) SetHead(head uint64) error {
 	if bc.currentBlock != nil && currentHeader.Number.Uint64() < bc.currentBlock.NumberU64() {
 		bc.currentBlock = bc.GetBlock(currentHeader.Hash(), currentHeader.Number.Uint64())
 	}
-	if bc.currentBlock != nil {
-		if _, err := state.New(bc.currentBlock.Root(), bc.stateCache); err != nil {
-			// Rewound state missing, rolled back to before pivot, reset to genesis
-			bc.currentBlock = nil
-		}
+	if bc.currentBlock != nil && !bc.hasState(bc.currentBlock) {
+		// Rewound state missing, rolled back to before pivot, reset to genesis
+		bc.currentBlock = nil
 	}
 	// Rewind the fast block in a simpleton way to the target head
 	if bc.currentFastBlock != nil && currentHeader.Number.Uint64() < bc.currentFastBlock.NumberU64() {
@@ -285,6 +328,42 @@ func (bc *BlockChain) SetHead(head uint64) error {
 	return bc.loadLastState()
 }
 
+// hasState checks whether both the account state and the dpos context of the
+// given block are available.
+func (bc *BlockChain) hasState(block *types.Block) bool {
+	if _, err := state.New(block.Root(), bc.stateCache); err != nil {
+		return false
+	}
+	if proto := block.Header().DposContext; proto != nil {
+		if _, err := types.NewDposContextFromProto(bc.stateCache.TrieDB(), proto); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// repair tries to repair the current blockchain by rolling back the current block
+// until one with associated state is found. This is needed to fix incomplete db
+// writes caused either by crashes/power outages, or simply non-committed tries.
+//
+// This method only rolls back the current block. The current header and current
+// fast block are left intact.
+func (bc *BlockChain) repair(head **types.Block) error {
+	for {
+		// Abort if we've rewound to a head block that does have associated state
+		if bc.hasState(*head) {
+			log.Info("Rewound blockchain to past state", "number", (*head).Number(), "hash", (*head).Hash())
+			return nil
+		}
+		// Otherwise rewind one block and recheck state availability there
+		parent := bc.GetBlock((*head).ParentHash(), (*head).NumberU64()-1)
+		if parent == nil {
+			return fmt.Errorf("missing block %d [%x]", (*head).NumberU64()-1, (*head).ParentHash())
+		}
+		*head = parent
+	}
+}
+
 // FastSyncCommitHead sets the current head block to the one defined by the hash
 // irrelevant what the chain contents were prior.
 func (bc *BlockChain) FastSyncCommitHead(hash common.Hash) error {
@@ -383,7 +462,31 @@ func (bc *BlockChain) State() (*state.StateDB, error) {
 
 // StateAt returns a new mutable state based on a particular point in time.
 func (bc *BlockChain) StateAt(root common.Hash) (*state.StateDB, error) {
-	return state.New(root, bc.stateCache)
+	return state.NewWithSnapshot(root, bc.stateCache, bc.snaps)
+}
+
+// DposContextAt returns a new mutable dpos context based on a particular point in
+// time. The tries are resolved through the trie node database, so contexts of
+// recent blocks not yet flushed to disk are reachable too.
+func (bc *BlockChain) DposContextAt(proto *types.DposContextProto) (*types.DposContext, error) {
+	return types.NewDposContextFromProto(bc.stateCache.TrieDB(), proto)
+}
+
+// Snapshots returns the flat state snapshot tree of the blockchain, or nil if
+// the snapshot is disabled.
+func (bc *BlockChain) Snapshots() *snapshot.Tree {
+	return bc.snaps
+}
+
+// StateCache returns the caching database underpinning the blockchain instance.
+func (bc *BlockChain) StateCache() state.Database {
+	return bc.stateCache
+}
+
+// TrieNode retrieves a blob of data associated with a trie node (or code hash)
+// either from ephemeral in-memory cache, or from persistent storage.
+func (bc *BlockChain) TrieNode(hash common.Hash) ([]byte, error) {
+	return bc.stateCache.TrieDB().Get(hash[:])
 }
 
 // Reset purges the entire blockchain, restoring it to its genesis state.
@@ -607,6 +710,42 @@ func (bc *BlockChain) Stop() {
 	atomic.StoreInt32(&bc.procInterrupt, 1)
 
 	bc.wg.Wait()
+
+	// Persist the snapshot diff layers so they don't need to be regenerated
+	if bc.snaps != nil {
+		if err := bc.snaps.Journal(bc.CurrentBlock().Root()); err != nil {
+			log.Error("Failed to journal state snapshot", "err", err)
+		}
+	}
+	// Ensure the state of a recent block is also stored to disk before exiting.
+	// It is fine if this state does not exist (fast start/stop cycle), but it is
+	// advisable to leave an N block gap from the head so on restart we do the
+	// heavy lifting of reprocessing the blocks, not of reorganising.
+	if !bc.cacheConfig.Disabled {
+		triedb := bc.stateCache.TrieDB()
+		for _, offset := range []uint64{0, 1, triesInMemory - 1} {
+			if number := bc.CurrentBlock().NumberU64(); number > offset {
+				recent := bc.GetBlockByNumber(number - offset)
+				if recent == nil {
+					continue
+				}
+				log.Info("Writing cached state to disk", "block", recent.Number(), "hash", recent.Hash(), "root", recent.Root())
+				for _, root := range trieRoots(recent.Header()) {
+					if err := triedb.Commit(root, true); err != nil {
+						log.Error("Failed to commit recent state trie", "err", err)
+					}
+				}
+			}
+		}
+		for !bc.triegc.Empty() {
+			for _, root := range bc.triegc.PopItem().([]common.Hash) {
+				triedb.Dereference(root)
+			}
+		}
+		if size := triedb.Size(); size != 0 {
+			log.Error("Dangling trie nodes after full cleanup", "size", size)
+		}
+	}
 	log.Info("Blockchain manager stopped")
 }
 
@@ -809,10 +948,15 @@ func (bc *BlockChain) WriteBlockAndState(block *types.Block, receipts []*types.R
 	if err := WriteBlock(batch, block); err != nil {
 		return NonStatTy, err
 	}
-	if _, err := block.DposContext.CommitTo(batch); err != nil {
-		return NonStatTy, err
-	}
-	if _, err := state.CommitTo(batch, bc.config.IsEIP158(block.Number())); err != nil {
+	if bc.cacheConfig.Disabled {
+		// Archive node, write the tries straight to disk alongside the block
+		if _, err := block.DposContext.CommitTo(batch); err != nil {
+			return NonStatTy, err
+		}
+		if _, err := state.CommitTo(batch, bc.config.IsEIP158(block.Number())); err != nil {
+			return NonStatTy, err
+		}
+	} else if err := bc.writeTries(block, state); err != nil {
 		return NonStatTy, err
 	}
 	if err := WriteBlockReceipts(batch, block.Hash(), block.NumberU64(), receipts); err != nil {
@@ -853,11 +997,125 @@ func (bc *BlockChain) WriteBlockAndState(block *types.Block, receipts []*types.R
 	// Set new head.
 	if status == CanonStatTy {
 		bc.insert(block)
+		bc.capSnapshots()
 	}
 	bc.futureBlocks.Remove(block.Hash())
 	return status, nil
 }
 
+// writeTries commits the state and dpos context tries of a block into the trie
+// node database, pins them in memory and garbage collects the tries that fell
+// out of the retention window. Every TrieFlushInterval blocks, or whenever the
+// memory allowance is exceeded, the cached nodes are flushed to disk.
+//
+// The method assumes the chain mutex is held.
+func (bc *BlockChain) writeTries(block *types.Block, state *state.StateDB) error {
+	triedb := bc.stateCache.TrieDB()
+
+	dposProto, err := block.DposContext.CommitTo(triedb)
+	if err != nil {
+		return err
+	}
+	root, err := state.CommitTo(triedb, bc.config.IsEIP158(block.Number()))
+	if err != nil {
+		return err
+	}
+	roots := append([]common.Hash{root}, dposRoots(dposProto)...)
+	for _, root := range roots {
+		triedb.Reference(root, common.Hash{}) // metadata reference to keep trie alive
+	}
+	bc.triegc.Push(roots, -float32(block.NumberU64()))
+
+	current := block.NumberU64()
+	if current <= triesInMemory {
+		return nil
+	}
+	// If we exceeded our memory allowance, flush matured singleton nodes to disk
+	limit := common.StorageSize(bc.cacheConfig.TrieNodeLimit) * 1024 * 1024
+	if size := triedb.Size(); size > limit {
+		if err := triedb.Cap(limit - ethdb.IdealBatchSize); err != nil {
+			return err
+		}
+	}
+	// Find the next state trie we need to commit, and flush it if the interval
+	// since the last write elapsed
+	chosen := current - triesInMemory
+	if chosen-bc.lastWrite >= bc.cacheConfig.TrieFlushInterval {
+		if header := bc.GetHeaderByNumber(chosen); header == nil {
+			log.Warn("Reorg in progress, trie commit postponed", "number", chosen)
+		} else {
+			for _, root := range trieRoots(header) {
+				if err := triedb.Commit(root, true); err != nil {
+					return err
+				}
+			}
+			bc.lastWrite = chosen
+		}
+	}
+	// Garbage collect anything below our required write retention
+	for !bc.triegc.Empty() {
+		roots, number := bc.triegc.Pop()
+		if uint64(-number) > chosen {
+			bc.triegc.Push(roots, number)
+			break
+		}
+		for _, root := range roots.([]common.Hash) {
+			triedb.Dereference(root)
+		}
+	}
+	return nil
+}
+
+// capSnapshots flattens the state snapshot diff layers into the disk layer up to
+// the last irreversible block. Without a finality notion, the disk layer tracks
+// the oldest block whose state is still retained in memory.
+//
+// The method assumes the chain mutex is held.
+func (bc *BlockChain) capSnapshots() {
+	if bc.snaps == nil {
+		return
+	}
+	var (
+		head   = bc.currentBlock.NumberU64()
+		target uint64
+	)
+	if engine, ok := bc.engine.(*dpos.Dpos); ok {
+		confirmed, ok := engine.ConfirmedBlockNumber(bc)
+		if !ok {
+			return
+		}
+		target = confirmed
+	} else if head > triesInMemory {
+		target = head - triesInMemory
+	}
+	if target > head || target <= bc.snapCapped {
+		return
+	}
+	header := bc.GetHeaderByNumber(target)
+	if header == nil {
+		return
+	}
+	if err := bc.snaps.Cap(header.Root); err != nil {
+		log.Debug("Failed to flatten state snapshot", "number", target, "root", header.Root, "err", err)
+		return
+	}
+	bc.snapCapped = target
+}
+
+// trieRoots returns the roots of all the tries a block header commits to.
+func trieRoots(header *types.Header) []common.Hash {
+	roots := []common.Hash{header.Root}
+	if header.DposContext != nil {
+		roots = append(roots, dposRoots(header.DposContext)...)
+	}
+	return roots
+}
+
+// dposRoots returns the roots of the individual dpos context tries.
+func dposRoots(proto *types.DposContextProto) []common.Hash {
+	return []common.Hash{proto.EpochHash, proto.DelegateHash, proto.CandidateHash, proto.VoteHash, proto.MintCntHash}
+}
+
 // InsertChain attempts to insert the given batch of blocks in to the canonical
 // chain or, otherwise, create a fork. If an error is returned it will return
 // the index number of the failing block as well an error describing what went
@@ -967,11 +1225,11 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*ty
 		} else {
 			parent = chain[i-1]
 		}
-		block.DposContext, err = types.NewDposContextFromProto(bc.chainDb, parent.Header().DposContext)
+		block.DposContext, err = bc.DposContextAt(parent.Header().DposContext)
 		if err != nil {
 			return i, events, coalescedLogs, err
 		}
-		state, err := state.New(parent.Root(), bc.stateCache)
+		state, err := state.NewWithSnapshot(parent.Root(), bc.stateCache, bc.snaps)
 		if err != nil {
 			return i, events, coalescedLogs, err
 		}
@@ -0,0 +1,198 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/consensus/dpos"
+	"github.com/meitu/go-ethereum/ethdb"
+	"github.com/meitu/go-ethereum/log"
+)
+
+const (
+	// freezerRecheckInterval is the frequency to check whether new blocks were
+	// finalized and can be moved into the ancient store.
+	freezerRecheckInterval = time.Minute
+
+	// freezerBatchLimit is the maximum number of blocks to move into the ancient
+	// store in one go, before checking for shutdown and yielding to rewinds.
+	freezerBatchLimit = 30000
+
+	// freezerFallbackDistance is the number of recent blocks kept in the key-value
+	// store for consensus engines without a notion of irreversible blocks.
+	freezerFallbackDistance = 90000
+)
+
+// freezeLoop periodically moves the finalized segment of the canonical chain
+// from the key-value store into the ancient store.
+func (bc *BlockChain) freezeLoop() {
+	defer bc.wg.Done()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			frozen, err := bc.freeze(bc.freezeThreshold())
+			if err != nil {
+				log.Error("Failed to freeze ancient blocks", "err", err)
+			}
+			// Keep going without delay if there's a backlog left
+			if err == nil && frozen == freezerBatchLimit {
+				timer.Reset(0)
+			} else {
+				timer.Reset(freezerRecheckInterval)
+			}
+		case <-bc.quit:
+			return
+		}
+	}
+}
+
+// freezeThreshold returns the number of the first block which may not be moved
+// into the ancient store yet. For DPoS it's the latest irreversible block, other
+// engines keep a fixed number of recent blocks around. Blocks above the current
+// head are never frozen, since they might still be missing data.
+func (bc *BlockChain) freezeThreshold() uint64 {
+	var (
+		head      = bc.CurrentBlock().NumberU64()
+		threshold uint64
+	)
+	if engine, ok := bc.engine.(*dpos.Dpos); ok {
+		if confirmed, ok := engine.ConfirmedBlockNumber(bc); ok {
+			threshold = confirmed
+		}
+	} else if head > freezerFallbackDistance {
+		threshold = head - freezerFallbackDistance
+	}
+	if threshold > head {
+		threshold = head
+	}
+	return threshold
+}
+
+// freeze moves the canonical blocks below the given threshold into the ancient
+// store, up to freezerBatchLimit of them, returning the number of blocks moved.
+// The frozen blocks and all side chain blocks at the same heights are deleted
+// from the key-value store afterwards. The genesis block is retained there too,
+// so tools opening the database without its ancient store can still find it.
+func (bc *BlockChain) freeze(threshold uint64) (int, error) {
+	ancients, ok := bc.chainDb.(ethdb.AncientStore)
+	if !ok {
+		return 0, nil
+	}
+	bc.freezemu.Lock()
+	defer bc.freezemu.Unlock()
+
+	frozen, err := ancients.Ancients()
+	if err != nil {
+		return 0, err
+	}
+	if frozen >= threshold {
+		return 0, nil
+	}
+	limit := threshold
+	if limit-frozen > freezerBatchLimit {
+		limit = frozen + freezerBatchLimit
+	}
+	// Append the canonical blocks to the ancient store
+	var (
+		start  = time.Now()
+		hashes []common.Hash
+	)
+	for number := frozen; number < limit; number++ {
+		if bc.getProcInterrupt() {
+			break
+		}
+		hash := GetCanonicalHash(bc.chainDb, number)
+		if hash == (common.Hash{}) {
+			err = fmt.Errorf("canonical hash missing, can't freeze block %d", number)
+			break
+		}
+		blobs := map[string][]byte{
+			freezerHashTable:       hash.Bytes(),
+			freezerHeaderTable:     GetHeaderRLP(bc.chainDb, hash, number),
+			freezerBodiesTable:     GetBodyRLP(bc.chainDb, hash, number),
+			freezerReceiptTable:    getBlockReceiptsRLP(bc.chainDb, hash, number),
+			freezerDifficultyTable: getTdRLP(bc.chainDb, hash, number),
+		}
+		for kind, blob := range blobs {
+			if len(blob) == 0 {
+				err = fmt.Errorf("block %d [%x…] %s missing, can't freeze", number, hash[:4], kind)
+				break
+			}
+		}
+		if err != nil {
+			break
+		}
+		if err = ancients.AppendAncient(number, blobs); err != nil {
+			break
+		}
+		hashes = append(hashes, hash)
+	}
+	if len(hashes) == 0 {
+		return 0, err
+	}
+	// Make sure the ancient store is persisted before deleting anything. A crash
+	// after this point at worst leaves some duplicates in the key-value store.
+	if err := ancients.Sync(); err != nil {
+		return 0, err
+	}
+	batch := bc.chainDb.NewBatch()
+	for i, hash := range hashes {
+		number := frozen + uint64(i)
+		if number == 0 {
+			continue
+		}
+		// Delete the canonical block, retaining its hash to number mapping
+		DeleteCanonicalHash(batch, number)
+		batch.Delete(headerKey(hash, number))
+		DeleteBody(batch, hash, number)
+		DeleteBlockReceipts(batch, hash, number)
+		DeleteTd(batch, hash, number)
+
+		// Delete all side chain blocks at the same height, they can't become
+		// canonical any more
+		prefix := append(append([]byte{}, headerPrefix...), encodeBlockNumber(number)...)
+		it := bc.chainDb.NewIteratorWithPrefix(prefix)
+		for it.Next() {
+			if key := it.Key(); len(key) == len(prefix)+common.HashLength {
+				if side := common.BytesToHash(key[len(prefix):]); side != hash {
+					DeleteBlock(batch, side, number)
+				}
+			}
+		}
+		it.Release()
+
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return 0, err
+			}
+			batch.Reset()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return 0, err
+	}
+	log.Info("Moved blocks into the ancient store", "count", len(hashes), "number", frozen+uint64(len(hashes))-1,
+		"hash", hashes[len(hashes)-1], "elapsed", common.PrettyDuration(time.Since(start)))
+	return len(hashes), err
+}
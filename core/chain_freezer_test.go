@@ -0,0 +1,129 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/consensus/ethash"
+	"github.com/meitu/go-ethereum/core/types"
+	"github.com/meitu/go-ethereum/core/vm"
+	"github.com/meitu/go-ethereum/crypto"
+	"github.com/meitu/go-ethereum/ethdb"
+	"github.com/meitu/go-ethereum/params"
+)
+
+// Tests that finalized blocks are moved into the ancient store, remaining
+// transparently accessible, while side chains at the same heights are dropped.
+func TestChainFreezer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		kvdb, _ = ethdb.NewMemDatabase()
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address = crypto.PubkeyToAddress(key.PublicKey)
+		gspec   = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  GenesisAlloc{address: {Balance: big.NewInt(1000000000)}},
+		}
+		signer = types.NewEIP155Signer(gspec.Config.ChainId)
+	)
+	db, err := ethdb.NewDatabaseWithFreezer(kvdb, dir, FreezerTables)
+	if err != nil {
+		t.Fatalf("failed to open freezer: %v", err)
+	}
+	defer db.Close()
+	genesis := gspec.MustCommit(db)
+
+	blocks, _ := GenerateChain(gspec.Config, genesis, db, 10, func(i int, block *BlockGen) {
+		tx, err := types.SignTx(types.NewTransaction(types.Binary, block.TxNonce(address), common.Address{0x00}, big.NewInt(1000), bigTxGas, nil, nil), signer, key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+	forks, _ := GenerateChain(gspec.Config, genesis, db, 3, func(i int, block *BlockGen) {
+		block.SetCoinbase(common.Address{0x01})
+	})
+	chain, err := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if n, err := chain.InsertChain(forks); err != nil {
+		t.Fatalf("failed to insert fork block %d: %v", n, err)
+	}
+	if n, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block %d: %v", n, err)
+	}
+	if n, err := chain.freeze(6); n != 6 || err != nil {
+		t.Fatalf("freezing result mismatch: have %d/%v, want %d/nil", n, err, 6)
+	}
+	if frozen, _ := db.(ethdb.AncientStore).Ancients(); frozen != 6 {
+		t.Fatalf("frozen block count mismatch: have %d, want %d", frozen, 6)
+	}
+	// Frozen blocks must be served from the ancient store
+	for _, block := range append([]*types.Block{genesis}, blocks...) {
+		hash, number := block.Hash(), block.NumberU64()
+		if have := GetCanonicalHash(db, number); have != hash {
+			t.Errorf("block #%d: canonical hash mismatch: have %x, want %x", number, have, hash)
+		}
+		if have := GetBlock(db, hash, number); have == nil || have.Hash() != hash {
+			t.Errorf("block #%d: block mismatch: have %v", number, have)
+		}
+		if GetTd(db, hash, number) == nil {
+			t.Errorf("block #%d: total difficulty missing", number)
+		}
+		if number > 0 {
+			if receipts := GetBlockReceipts(db, hash, number); len(receipts) != 1 {
+				t.Errorf("block #%d: receipt count mismatch: have %d, want %d", number, len(receipts), 1)
+			}
+			if tx, _, _, _ := GetTransaction(db, block.Transactions()[0].Hash()); tx == nil {
+				t.Errorf("block #%d: transaction lookup failed", number)
+			}
+		}
+		// Only the non-frozen ones and the genesis may remain in the key-value store
+		if stored := GetHeader(kvdb, hash, number) != nil; stored != (number == 0 || number >= 6) {
+			t.Errorf("block #%d: key-value store presence mismatch: have %v", number, stored)
+		}
+	}
+	// Side chain blocks at frozen heights must be gone
+	for _, block := range forks {
+		if GetHeader(db, block.Hash(), block.NumberU64()) != nil || GetBody(db, block.Hash(), block.NumberU64()) != nil {
+			t.Errorf("side block #%d: not deleted", block.NumberU64())
+		}
+	}
+	// Rewinding below the frozen blocks must truncate the ancient store
+	if err := chain.SetHead(3); err != nil {
+		t.Fatalf("failed to rewind chain: %v", err)
+	}
+	if frozen, _ := db.(ethdb.AncientStore).Ancients(); frozen != 4 {
+		t.Fatalf("frozen block count mismatch after rewind: have %d, want %d", frozen, 4)
+	}
+	if head := chain.CurrentBlock(); head.Hash() != blocks[2].Hash() {
+		t.Fatalf("head block mismatch after rewind: have #%d, want #%d", head.NumberU64(), blocks[2].NumberU64())
+	}
+}
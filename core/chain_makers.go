@@ -46,6 +46,8 @@ type BlockGen struct {
 	header  *types.Header
 	statedb *state.StateDB
 
+	dposContext *types.DposContext
+
 	gasPool  *GasPool
 	txs      []*types.Transaction
 	receipts []*types.Receipt
@@ -85,7 +87,7 @@ func (b *BlockGen) AddTx(tx *types.Transaction) {
 		b.SetCoinbase(common.Address{})
 	}
 	b.statedb.Prepare(tx.Hash(), common.Hash{}, len(b.txs))
-	receipt, _, err := ApplyTransaction(b.config, nil, nil, &b.header.Coinbase, b.gasPool, b.statedb, b.header, tx, b.header.GasUsed, vm.Config{})
+	receipt, _, err := ApplyTransaction(b.config, b.dposContext, nil, &b.header.Coinbase, b.gasPool, b.statedb, b.header, tx, b.header.GasUsed, vm.Config{})
 	if err != nil {
 		panic(err)
 	}
@@ -98,6 +100,19 @@ func (b *BlockGen) Number() *big.Int {
 	return new(big.Int).Set(b.header.Number)
 }
 
+// DposContext returns the dpos context of the block being generated, holding
+// the candidates and votes as changed by the transactions added so far.
+func (b *BlockGen) DposContext() *types.DposContext {
+	return b.dposContext
+}
+
+// Elect runs the dpos validator elections of the epochs beginning within the
+// generated block, weighting the votes with the current balances. The genesis
+// header must be the one the generated chain descends from.
+func (b *BlockGen) Elect(genesis *types.Header) error {
+	return dpos.Elect(genesis, b.parent.Header(), b.header.Time.Int64(), b.statedb, b.dposContext)
+}
+
 // AddUncheckedReceipt forcefully adds a receipts to the block without a
 // backing transaction.
 //
@@ -162,8 +177,8 @@ func GenerateChain(config *params.ChainConfig, parent *types.Block, db ethdb.Dat
 		config = params.DposChainConfig
 	}
 	blocks, receipts := make(types.Blocks, n), make([]types.Receipts, n)
-	genblock := func(i int, h *types.Header, statedb *state.StateDB) (*types.Block, types.Receipts) {
-		b := &BlockGen{parent: parent, i: i, chain: blocks, header: h, statedb: statedb, config: config}
+	genblock := func(i int, h *types.Header, statedb *state.StateDB, dposContext *types.DposContext) (*types.Block, types.Receipts) {
+		b := &BlockGen{parent: parent, i: i, chain: blocks, header: h, statedb: statedb, dposContext: dposContext, config: config}
 		// Mutate the state and block according to any hard-fork specs
 		if daoBlock := config.DAOForkBlock; daoBlock != nil {
 			limit := new(big.Int).Add(daoBlock, params.DAOForkExtraRange)
@@ -186,7 +201,10 @@ func GenerateChain(config *params.ChainConfig, parent *types.Block, db ethdb.Dat
 			panic(fmt.Sprintf("state write error: %v", err))
 		}
 		h.Root = root
-		h.DposContext = parent.Header().DposContext
+		h.DposContext, err = dposContext.CommitTo(db)
+		if err != nil {
+			panic(fmt.Sprintf("dpos context write error: %v", err))
+		}
 		return types.NewBlock(h, b.txs, b.uncles, b.receipts), b.receipts
 	}
 	for i := 0; i < n; i++ {
@@ -194,8 +212,12 @@ func GenerateChain(config *params.ChainConfig, parent *types.Block, db ethdb.Dat
 		if err != nil {
 			panic(err)
 		}
+		dposContext, err := types.NewDposContextFromProto(db, parent.Header().DposContext)
+		if err != nil {
+			panic(err)
+		}
 		header := makeHeader(config, parent, statedb)
-		block, receipt := genblock(i, header, statedb)
+		block, receipt := genblock(i, header, statedb, dposContext)
 		blocks[i] = block
 		receipts[i] = receipt
 		parent = block
@@ -233,7 +255,7 @@ func newCanonical(n int, full bool) (ethdb.Database, *BlockChain, error) {
 	db, _ := ethdb.NewMemDatabase()
 	genesis := gspec.MustCommit(db)
 
-	blockchain, _ := NewBlockChain(db, params.AllEthashProtocolChanges, ethash.NewFaker(), vm.Config{})
+	blockchain, _ := NewBlockChain(db, nil, params.AllEthashProtocolChanges, ethash.NewFaker(), vm.Config{})
 	// Create and inject the requested chain
 	if n == 0 {
 		return db, blockchain, nil
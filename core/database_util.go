@@ -74,6 +74,27 @@ var (
 	preimageHitCounter = metrics.NewCounter("db/preimage/hits")
 )
 
+// Ancient store tables holding the finalized segments of the chain, numbered by
+// block height.
+const (
+	freezerHashTable       = "hashes"   // canonical block hashes
+	freezerHeaderTable     = "headers"  // block headers
+	freezerBodiesTable     = "bodies"   // block bodies
+	freezerReceiptTable    = "receipts" // block receipts
+	freezerDifficultyTable = "diffs"    // block total difficulties
+)
+
+// FreezerTables lists the ancient store tables of the chain database, along with
+// whether their content is snappy compressed. Hashes and headers are mostly
+// random data, so they're stored as is.
+var FreezerTables = map[string]bool{
+	freezerHashTable:       false,
+	freezerHeaderTable:     false,
+	freezerBodiesTable:     true,
+	freezerReceiptTable:    true,
+	freezerDifficultyTable: false,
+}
+
 // TxLookupEntry is a positional metadata to help looking up the data content of
 // a transaction or receipt given only its hash.
 type TxLookupEntry struct {
@@ -89,9 +110,30 @@ func encodeBlockNumber(number uint64) []byte {
 	return enc
 }
 
+// getAncient retrieves a blob of the given kind from the ancient store of the
+// database, if it has one and the canonical block with the given hash and number
+// was already moved there.
+func getAncient(db DatabaseReader, kind string, hash common.Hash, number uint64) []byte {
+	ancients, ok := db.(ethdb.AncientStore)
+	if !ok {
+		return nil
+	}
+	data, err := ancients.Ancient(freezerHashTable, number)
+	if err != nil || common.BytesToHash(data) != hash {
+		return nil
+	}
+	data, _ = ancients.Ancient(kind, number)
+	return data
+}
+
 // GetCanonicalHash retrieves a hash assigned to a canonical block number.
 func GetCanonicalHash(db DatabaseReader, number uint64) common.Hash {
-	data, _ := db.Get(append(append(headerPrefix, encodeBlockNumber(number)...), numSuffix...))
+	if ancients, ok := db.(ethdb.AncientStore); ok {
+		if data, err := ancients.Ancient(freezerHashTable, number); err == nil {
+			return common.BytesToHash(data)
+		}
+	}
+	data, _ := db.Get(canonicalHashKey(number))
 	if len(data) == 0 {
 		return common.Hash{}
 	}
@@ -149,6 +191,9 @@ func GetHeadFastBlockHash(db DatabaseReader) common.Hash {
 // GetHeaderRLP retrieves a block header in its raw RLP database encoding, or nil
 // if the header's not found.
 func GetHeaderRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
+	if data := getAncient(db, freezerHeaderTable, hash, number); len(data) > 0 {
+		return data
+	}
 	data, _ := db.Get(headerKey(hash, number))
 	return data
 }
@@ -170,6 +215,9 @@ func GetHeader(db DatabaseReader, hash common.Hash, number uint64) *types.Header
 
 // GetBodyRLP retrieves the block body (transactions and uncles) in RLP encoding.
 func GetBodyRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
+	if data := getAncient(db, freezerBodiesTable, hash, number); len(data) > 0 {
+		return data
+	}
 	data, _ := db.Get(blockBodyKey(hash, number))
 	return data
 }
@@ -182,6 +230,18 @@ func blockBodyKey(hash common.Hash, number uint64) []byte {
 	return append(append(bodyPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
 }
 
+func tdKey(hash common.Hash, number uint64) []byte {
+	return append(headerKey(hash, number), tdSuffix...)
+}
+
+func blockReceiptsKey(hash common.Hash, number uint64) []byte {
+	return append(append(blockReceiptsPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+func canonicalHashKey(number uint64) []byte {
+	return append(append(headerPrefix, encodeBlockNumber(number)...), numSuffix...)
+}
+
 // GetBody retrieves the block body (transactons, uncles) corresponding to the
 // hash, nil if none found.
 func GetBody(db DatabaseReader, hash common.Hash, number uint64) *types.Body {
@@ -200,7 +260,7 @@ func GetBody(db DatabaseReader, hash common.Hash, number uint64) *types.Body {
 // GetTd retrieves a block's total difficulty corresponding to the hash, nil if
 // none found.
 func GetTd(db DatabaseReader, hash common.Hash, number uint64) *big.Int {
-	data, _ := db.Get(append(append(append(headerPrefix, encodeBlockNumber(number)...), hash[:]...), tdSuffix...))
+	data := getTdRLP(db, hash, number)
 	if len(data) == 0 {
 		return nil
 	}
@@ -212,6 +272,15 @@ func GetTd(db DatabaseReader, hash common.Hash, number uint64) *big.Int {
 	return td
 }
 
+// getTdRLP retrieves a block's total difficulty in its raw RLP database encoding.
+func getTdRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
+	if data := getAncient(db, freezerDifficultyTable, hash, number); len(data) > 0 {
+		return data
+	}
+	data, _ := db.Get(tdKey(hash, number))
+	return data
+}
+
 // GetBlock retrieves an entire block corresponding to the hash, assembling it
 // back from the stored header and body. If either the header or body could not
 // be retrieved nil is returned.
@@ -230,29 +299,13 @@ func GetBlock(db DatabaseReader, hash common.Hash, number uint64) *types.Block {
 	}
 
 	// Reassemble the block and return
-	block := types.NewBlockWithHeader(header).WithBody(body.Transactions, body.Uncles)
-
-	// add dposContext to block
-	block.DposContext = getDposContextTrie(db.(ethdb.Database), header)
-	return block
-}
-
-func getDposContextTrie(db ethdb.Database, header *types.Header) *types.DposContext {
-	dposContestProto := header.DposContext
-	if dposContestProto != nil {
-		dposContext, err := types.NewDposContextFromProto(db, dposContestProto)
-		if err != nil {
-			return nil
-		}
-		return dposContext
-	}
-	return nil
+	return types.NewBlockWithHeader(header).WithBody(body.Transactions, body.Uncles)
 }
 
 // GetBlockReceipts retrieves the receipts generated by the transactions included
 // in a block given by its hash.
 func GetBlockReceipts(db DatabaseReader, hash common.Hash, number uint64) types.Receipts {
-	data, _ := db.Get(append(append(blockReceiptsPrefix, encodeBlockNumber(number)...), hash[:]...))
+	data := getBlockReceiptsRLP(db, hash, number)
 	if len(data) == 0 {
 		return nil
 	}
@@ -268,6 +321,16 @@ func GetBlockReceipts(db DatabaseReader, hash common.Hash, number uint64) types.
 	return receipts
 }
 
+// getBlockReceiptsRLP retrieves the receipts of a block in their raw RLP
+// database encoding.
+func getBlockReceiptsRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
+	if data := getAncient(db, freezerReceiptTable, hash, number); len(data) > 0 {
+		return data
+	}
+	data, _ := db.Get(blockReceiptsKey(hash, number))
+	return data
+}
+
 // GetTxLookupEntry retrieves the positional metadata associated with a transaction
 // hash to allow retrieving the transaction or receipt by hash.
 func GetTxLookupEntry(db DatabaseReader, hash common.Hash) (common.Hash, uint64, uint64) {
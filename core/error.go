@@ -32,4 +32,12 @@ var (
 	// ErrNonceTooHigh is returned if the nonce of a transaction is higher than the
 	// next one expected based on the local chain.
 	ErrNonceTooHigh = errors.New("nonce too high")
+
+	// ErrNoDposContext is returned if a block is processed or validated without
+	// the dpos context of its parent attached.
+	ErrNoDposContext = errors.New("missing dpos context")
+
+	// ErrMultiTransferNotActive is returned if a multi-transfer transaction is
+	// included or sent before the multi-transfer fork.
+	ErrMultiTransferNotActive = errors.New("multi-transfer before the multi-transfer fork")
 )
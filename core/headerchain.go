@@ -421,6 +421,12 @@ func (hc *HeaderChain) SetHead(head uint64, delFn DeleteCallback) {
 	for i := height; i > head; i-- {
 		DeleteCanonicalHash(hc.chainDb, i)
 	}
+	// Discard any finalized segment above the new head from the ancient store
+	if ancients, ok := hc.chainDb.(ethdb.AncientStore); ok {
+		if err := ancients.TruncateAncients(head + 1); err != nil {
+			log.Crit("Failed to truncate ancient store", "err", err)
+		}
+	}
 	// Clear out any stale content from the caches
 	hc.headerCache.Purge()
 	hc.tdCache.Purge()
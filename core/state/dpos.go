@@ -0,0 +1,99 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"errors"
+
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/core/types"
+	"github.com/meitu/go-ethereum/trie"
+)
+
+// ErrNoDposContext is returned by the dpos operations of a state without a dpos
+// context attached.
+var ErrNoDposContext = errors.New("no dpos context attached to the state")
+
+// dposTrie gives access to one of the tries of a dpos context.
+type dposTrie struct {
+	get func(*types.DposContext) *trie.Trie
+	set func(*types.DposContext, *trie.Trie)
+}
+
+var (
+	candidateTrie = dposTrie{(*types.DposContext).CandidateTrie, (*types.DposContext).SetCandidate}
+	delegateTrie  = dposTrie{(*types.DposContext).DelegateTrie, (*types.DposContext).SetDelegate}
+	voteTrie      = dposTrie{(*types.DposContext).VoteTrie, (*types.DposContext).SetVote}
+)
+
+// DposContext returns the dpos context attached to the state, or nil if none.
+func (self *StateDB) DposContext() *types.DposContext {
+	return self.dposContext
+}
+
+// SetDposContext attaches the dpos context of the block being processed, whose
+// changes made through the state are reverted along with the state's own.
+func (self *StateDB) SetDposContext(dposContext *types.DposContext) {
+	self.dposContext = dposContext
+}
+
+// BecomeCandidate logs the address in as a candidate.
+func (self *StateDB) BecomeCandidate(candidate common.Address) error {
+	if err := self.journalDposTries(candidateTrie); err != nil {
+		return err
+	}
+	return self.dposContext.BecomeCandidate(candidate)
+}
+
+// KickoutCandidate logs the candidate out, withdrawing all votes for it.
+func (self *StateDB) KickoutCandidate(candidate common.Address) error {
+	if err := self.journalDposTries(candidateTrie, delegateTrie, voteTrie); err != nil {
+		return err
+	}
+	return self.dposContext.KickoutCandidate(candidate)
+}
+
+// Delegate votes for the candidate on behalf of the delegator, replacing any
+// earlier vote.
+func (self *StateDB) Delegate(delegator, candidate common.Address) error {
+	if err := self.journalDposTries(delegateTrie, voteTrie); err != nil {
+		return err
+	}
+	return self.dposContext.Delegate(delegator, candidate)
+}
+
+// UnDelegate withdraws the vote of the delegator from the candidate.
+func (self *StateDB) UnDelegate(delegator, candidate common.Address) error {
+	if err := self.journalDposTries(delegateTrie, voteTrie); err != nil {
+		return err
+	}
+	return self.dposContext.UnDelegate(delegator, candidate)
+}
+
+// journalDposTries records the given tries of the dpos context in the journal
+// ahead of a change to them, so that reverting to an earlier snapshot restores
+// them. Tries copy their nodes on write, so a shallow copy is enough.
+func (self *StateDB) journalDposTries(tries ...dposTrie) error {
+	if self.dposContext == nil {
+		return ErrNoDposContext
+	}
+	for _, t := range tries {
+		prev := *t.get(self.dposContext)
+		self.journal = append(self.journal, dposTrieChange{set: t.set, prev: &prev})
+	}
+	return nil
+}
@@ -20,6 +20,8 @@ import (
 	"math/big"
 
 	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/core/types"
+	"github.com/meitu/go-ethereum/trie"
 )
 
 type journalEntry interface {
@@ -75,6 +77,12 @@ type (
 		prev      bool
 		prevDirty bool
 	}
+
+	// Changes to the tries of the dpos context.
+	dposTrieChange struct {
+		set  func(*types.DposContext, *trie.Trie)
+		prev *trie.Trie
+	}
 )
 
 func (ch createObjectChange) undo(s *StateDB) {
@@ -138,3 +146,7 @@ func (ch addLogChange) undo(s *StateDB) {
 func (ch addPreimageChange) undo(s *StateDB) {
 	delete(s.preimages, ch.hash)
 }
+
+func (ch dposTrieChange) undo(s *StateDB) {
+	ch.set(s.dposContext, ch.prev)
+}
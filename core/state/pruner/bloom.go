@@ -0,0 +1,146 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pruner
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/meitu/go-ethereum/common"
+)
+
+// bloomHashes is the number of bit positions set for each inserted hash.
+const bloomHashes = 4
+
+// errBloomCorrupted is returned if a persisted state bloom cannot be parsed.
+var errBloomCorrupted = errors.New("state bloom corrupted")
+
+// stateBloom is a bloom filter tracking the hashes of all trie nodes and
+// contract codes that must survive pruning. False positives only result in some
+// garbage being retained, so the filter may be sized well below the number of
+// items it holds.
+//
+// Since all tracked keys are keccak256 hashes, the bit positions are taken
+// directly from the hash instead of rehashing it.
+type stateBloom struct {
+	head common.Hash // Chain head the bloom was generated against
+	bits []byte      // Bit vector of the filter
+}
+
+// newStateBloom creates an empty state bloom of the given size in bytes.
+func newStateBloom(head common.Hash, size uint64) *stateBloom {
+	if size < 1 {
+		size = 1
+	}
+	return &stateBloom{
+		head: head,
+		bits: make([]byte, size),
+	}
+}
+
+// positions returns the bit indexes a hash maps to in the filter.
+func (b *stateBloom) positions(hash []byte) [bloomHashes]uint64 {
+	var (
+		pos  [bloomHashes]uint64
+		size = uint64(len(b.bits)) * 8
+	)
+	for i := 0; i < bloomHashes; i++ {
+		pos[i] = binary.BigEndian.Uint64(hash[i*8:]) % size
+	}
+	return pos
+}
+
+// add inserts a hash into the bloom filter.
+func (b *stateBloom) add(hash common.Hash) {
+	for _, pos := range b.positions(hash[:]) {
+		b.bits[pos/8] |= 1 << (pos % 8)
+	}
+}
+
+// contains reports whether a hash might have been added to the filter. The key
+// must be exactly one hash long.
+func (b *stateBloom) contains(hash []byte) bool {
+	for _, pos := range b.positions(hash) {
+		if b.bits[pos/8]&(1<<(pos%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// commit writes the bloom filter into the given file. The content is first
+// written into a temporary file which is atomically moved into place, so a
+// crash will never leave a partially written filter behind.
+func (b *stateBloom) commit(path string) error {
+	tmp := path + ".tmp"
+
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	if _, err := w.Write(b.head[:]); err != nil {
+		file.Close()
+		return err
+	}
+	if _, err := w.Write(b.bits); err != nil {
+		file.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadStateBloom reads a previously committed bloom filter from disk.
+func loadStateBloom(path string) (*stateBloom, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() <= common.HashLength {
+		return nil, errBloomCorrupted
+	}
+	bloom := &stateBloom{bits: make([]byte, info.Size()-common.HashLength)}
+
+	r := bufio.NewReader(file)
+	if _, err := io.ReadFull(r, bloom.head[:]); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(r, bloom.bits); err != nil {
+		return nil, err
+	}
+	return bloom, nil
+}
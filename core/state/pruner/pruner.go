@@ -0,0 +1,276 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package pruner implements offline removal of stale state data.
+package pruner
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/core"
+	"github.com/meitu/go-ethereum/core/state"
+	"github.com/meitu/go-ethereum/core/types"
+	"github.com/meitu/go-ethereum/ethdb"
+	"github.com/meitu/go-ethereum/log"
+	"github.com/meitu/go-ethereum/trie"
+)
+
+// logInterval is the time between two progress reports.
+const logInterval = 8 * time.Second
+
+var (
+	// errNoHead is returned if the database has no chain head to prune against.
+	errNoHead = errors.New("chain head not found")
+
+	// errNoState is returned if none of the retained blocks have their state
+	// available in the database.
+	errNoState = errors.New("no state available for the retained blocks")
+
+	// errHeadChanged is returned when resuming a pruning whose chain head has
+	// moved since the state bloom was generated.
+	errHeadChanged = errors.New("chain head changed since pruning started")
+)
+
+// Config contains the tunables of the state pruner.
+type Config struct {
+	BloomPath string // File to persist the state bloom into for crash recovery
+	BloomSize uint64 // Size of the state bloom in bytes
+	Retain    uint64 // Number of recent blocks whose state to keep
+}
+
+// Pruner deletes every trie node and contract code from the database that is
+// not reachable from the state or dpos context roots of the most recent blocks.
+//
+// Pruning runs in two phases. First all live data is marked in a bloom filter,
+// which is persisted to disk. Afterwards the database is swept and all unmarked
+// entries are deleted. If the process is interrupted during the sweep, the next
+// run reloads the bloom and continues deleting where it left off.
+type Pruner struct {
+	db     ethdb.Database
+	config Config
+}
+
+// NewPruner creates a state pruner operating on the given database.
+func NewPruner(db ethdb.Database, config Config) *Pruner {
+	if config.Retain == 0 {
+		config.Retain = 1
+	}
+	return &Pruner{
+		db:     db,
+		config: config,
+	}
+}
+
+// Prune removes all stale state data from the database, resuming a previously
+// interrupted run if a persisted state bloom is found.
+func (p *Pruner) Prune() error {
+	head := core.GetHeadBlockHash(p.db)
+	if head == (common.Hash{}) {
+		return errNoHead
+	}
+	var bloom *stateBloom
+	if common.FileExist(p.config.BloomPath) {
+		loaded, err := loadStateBloom(p.config.BloomPath)
+		if err != nil {
+			return fmt.Errorf("failed to load state bloom: %v", err)
+		}
+		if loaded.head != head {
+			return fmt.Errorf("%v: have %x, bloom %x", errHeadChanged, head, loaded.head)
+		}
+		log.Info("Resuming interrupted state pruning", "head", head, "bloom", p.config.BloomPath)
+		bloom = loaded
+	} else {
+		bloom = newStateBloom(head, p.config.BloomSize)
+		if err := p.mark(bloom); err != nil {
+			return err
+		}
+		if err := bloom.commit(p.config.BloomPath); err != nil {
+			return fmt.Errorf("failed to persist state bloom: %v", err)
+		}
+	}
+	if err := p.sweep(bloom); err != nil {
+		return err
+	}
+	if err := p.compact(); err != nil {
+		return err
+	}
+	return os.Remove(p.config.BloomPath)
+}
+
+// mark adds every trie node and contract code reachable from the retained
+// blocks into the state bloom. The genesis state is always retained so that
+// the chain can be rewound fully if need be.
+func (p *Pruner) mark(bloom *stateBloom) error {
+	var (
+		start  = time.Now()
+		logged = time.Now()
+		nodes  int
+	)
+	number := core.GetBlockNumber(p.db, bloom.head)
+	if number == math.MaxUint64 {
+		return errNoHead
+	}
+	var headers []*types.Header
+	for i := uint64(0); i < p.config.Retain && i <= number; i++ {
+		if header := core.GetHeader(p.db, core.GetCanonicalHash(p.db, number-i), number-i); header != nil {
+			headers = append(headers, header)
+		}
+	}
+	if number >= p.config.Retain {
+		if genesis := core.GetHeader(p.db, core.GetCanonicalHash(p.db, 0), 0); genesis != nil {
+			headers = append(headers, genesis)
+		}
+	}
+	var retained int
+	for _, header := range headers {
+		// Skip any block whose state was garbage collected by the live node
+		if ok, _ := p.db.Has(header.Root[:]); !ok && header.Root != types.EmptyRootHash {
+			log.Debug("Skipping block without state", "number", header.Number, "hash", header.Hash())
+			continue
+		}
+		statedb, err := state.New(header.Root, state.NewDatabase(p.db))
+		if err != nil {
+			return err
+		}
+		it := state.NewNodeIterator(statedb)
+		for it.Next() {
+			if it.Hash != (common.Hash{}) {
+				bloom.add(it.Hash)
+				nodes++
+			}
+			if time.Since(logged) > logInterval {
+				log.Info("Marking live state", "block", header.Number, "nodes", nodes, "elapsed", common.PrettyDuration(time.Since(start)))
+				logged = time.Now()
+			}
+		}
+		if it.Error != nil {
+			return fmt.Errorf("state %x of block #%d: %v", header.Root, header.Number, it.Error)
+		}
+		if header.DposContext != nil {
+			for _, root := range dposRoots(header.DposContext) {
+				tr, err := trie.New(root, p.db)
+				if err != nil {
+					return err
+				}
+				it := tr.NodeIterator(nil)
+				for it.Next(true) {
+					if it.Hash() != (common.Hash{}) {
+						bloom.add(it.Hash())
+						nodes++
+					}
+				}
+				if it.Error() != nil {
+					return fmt.Errorf("dpos context %x of block #%d: %v", root, header.Number, it.Error())
+				}
+			}
+		}
+		retained++
+	}
+	if retained == 0 {
+		return errNoState
+	}
+	log.Info("Marked live state", "blocks", retained, "nodes", nodes, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// sweep deletes all trie nodes and contract codes not present in the bloom.
+func (p *Pruner) sweep(bloom *stateBloom) error {
+	var (
+		start  = time.Now()
+		logged = time.Now()
+		batch  = p.db.NewBatch()
+
+		count int
+		size  common.StorageSize
+	)
+	it := p.db.NewIteratorWithStart(nil)
+	defer func() { it.Release() }()
+
+	for it.Next() {
+		// Trie nodes and contract codes are the only entries keyed by a bare hash
+		key := it.Key()
+		if len(key) != common.HashLength || bloom.contains(key) {
+			continue
+		}
+		count++
+		size += common.StorageSize(len(key) + len(it.Value()))
+
+		if err := batch.Delete(key); err != nil {
+			return err
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			// Release the iterator while writing, some engines can't do both at once
+			key = common.CopyBytes(key)
+			it.Release()
+
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+			it = p.db.NewIteratorWithStart(key)
+		}
+		if time.Since(logged) > logInterval {
+			// Hashes are uniformly distributed, so the key reveals the progress
+			done := float64(binary.BigEndian.Uint64(key[:8])) / math.MaxUint64
+			eta := time.Duration(float64(time.Since(start)) / done * (1 - done))
+
+			log.Info("Pruning stale state", "count", count, "size", size, "progress", fmt.Sprintf("%.2f%%", done*100),
+				"elapsed", common.PrettyDuration(time.Since(start)), "eta", common.PrettyDuration(eta))
+			logged = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Info("Pruned stale state", "count", count, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// compact reclaims the disk space freed up by the sweep, one key range at a
+// time so that progress can be reported.
+func (p *Pruner) compact() error {
+	start := time.Now()
+	for b := 0x00; b <= 0xf0; b += 0x10 {
+		var (
+			from = []byte{byte(b)}
+			to   = []byte{byte(b + 0x10)}
+		)
+		if b == 0xf0 {
+			to = nil
+		}
+		cstart := time.Now()
+		if err := p.db.Compact(from, to); err != nil {
+			return err
+		}
+		log.Info("Compacted database range", "from", fmt.Sprintf("%#x", from), "to", fmt.Sprintf("%#x", to), "elapsed", common.PrettyDuration(time.Since(cstart)))
+	}
+	log.Info("Database compaction finished", "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// dposRoots returns the roots of the individual dpos context tries.
+func dposRoots(proto *types.DposContextProto) []common.Hash {
+	return []common.Hash{proto.EpochHash, proto.DelegateHash, proto.CandidateHash, proto.VoteHash, proto.MintCntHash}
+}
@@ -0,0 +1,142 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pruner
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/core"
+	"github.com/meitu/go-ethereum/core/state"
+	"github.com/meitu/go-ethereum/core/types"
+	"github.com/meitu/go-ethereum/ethdb"
+)
+
+// newTestChain creates a disk database containing a chain of n blocks, each of
+// them rewarding a different coinbase so that all blocks have distinct states.
+func newTestChain(t *testing.T, n int) (string, *ethdb.LDBDatabase, []*types.Block) {
+	dir, err := ioutil.TempDir("", "pruner-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	db, err := ethdb.NewLDBDatabase(filepath.Join(dir, "chaindata"), 16, 16)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	gspec := &core.Genesis{Alloc: core.GenesisAlloc{common.Address{0xff}: {Balance: big.NewInt(1)}}}
+	genesis := gspec.MustCommit(db)
+
+	blocks, _ := core.GenerateChain(nil, genesis, db, n, func(i int, gen *core.BlockGen) {
+		gen.SetCoinbase(common.Address{byte(i + 1)})
+	})
+	for _, block := range blocks {
+		if err := core.WriteBlock(db, block); err != nil {
+			t.Fatalf("failed to write block: %v", err)
+		}
+		if err := core.WriteCanonicalHash(db, block.Hash(), block.NumberU64()); err != nil {
+			t.Fatalf("failed to write canonical hash: %v", err)
+		}
+	}
+	if err := core.WriteHeadBlockHash(db, blocks[len(blocks)-1].Hash()); err != nil {
+		t.Fatalf("failed to write head block: %v", err)
+	}
+	return dir, db, append([]*types.Block{genesis}, blocks...)
+}
+
+// hasFullState checks whether the entire state of a block is available.
+func hasFullState(db ethdb.Database, block *types.Block) bool {
+	statedb, err := state.New(block.Root(), state.NewDatabase(db))
+	if err != nil {
+		return false
+	}
+	it := state.NewNodeIterator(statedb)
+	for it.Next() {
+	}
+	return it.Error == nil
+}
+
+// Tests that pruning retains the state of the most recent blocks and the
+// genesis, while deleting everything else.
+func TestPrune(t *testing.T) {
+	dir, db, blocks := newTestChain(t, 10)
+	defer os.RemoveAll(dir)
+	defer db.Close()
+
+	bloomPath := filepath.Join(dir, "statebloom")
+	if err := NewPruner(db, Config{BloomPath: bloomPath, BloomSize: 1024 * 1024, Retain: 3}).Prune(); err != nil {
+		t.Fatalf("failed to prune state: %v", err)
+	}
+	for i, block := range blocks {
+		retained := i == 0 || i >= len(blocks)-3
+		if have := hasFullState(db, block); have != retained {
+			t.Errorf("block #%d: state availability mismatch: have %v, want %v", i, have, retained)
+		}
+		if core.GetHeader(db, block.Hash(), block.NumberU64()) == nil {
+			t.Errorf("block #%d: header deleted", i)
+		}
+	}
+	if common.FileExist(bloomPath) {
+		t.Errorf("state bloom not removed after pruning")
+	}
+}
+
+// Tests that an interrupted pruning is resumed from the persisted bloom, and
+// that it is refused if the chain progressed in the meantime.
+func TestPruneResume(t *testing.T) {
+	dir, db, blocks := newTestChain(t, 10)
+	defer os.RemoveAll(dir)
+	defer db.Close()
+
+	// Simulate a crash right after the marking phase
+	bloomPath := filepath.Join(dir, "statebloom")
+	pruner := NewPruner(db, Config{BloomPath: bloomPath, BloomSize: 1024 * 1024, Retain: 3})
+
+	bloom := newStateBloom(blocks[len(blocks)-1].Hash(), 1024*1024)
+	if err := pruner.mark(bloom); err != nil {
+		t.Fatalf("failed to mark state: %v", err)
+	}
+	if err := bloom.commit(bloomPath); err != nil {
+		t.Fatalf("failed to commit bloom: %v", err)
+	}
+	// Moving the head must prevent resuming, as new states would be lost
+	if err := core.WriteHeadBlockHash(db, blocks[len(blocks)-2].Hash()); err != nil {
+		t.Fatalf("failed to write head block: %v", err)
+	}
+	if err := pruner.Prune(); err == nil {
+		t.Fatalf("resumed pruning with a changed chain head")
+	}
+	if !hasFullState(db, blocks[1]) {
+		t.Fatalf("state deleted by refused pruning")
+	}
+	// Restoring the head must resume with the persisted bloom
+	if err := core.WriteHeadBlockHash(db, blocks[len(blocks)-1].Hash()); err != nil {
+		t.Fatalf("failed to write head block: %v", err)
+	}
+	if err := pruner.Prune(); err != nil {
+		t.Fatalf("failed to resume pruning: %v", err)
+	}
+	if hasFullState(db, blocks[1]) {
+		t.Errorf("stale state not pruned")
+	}
+	if !hasFullState(db, blocks[len(blocks)-1]) {
+		t.Errorf("head state pruned")
+	}
+}
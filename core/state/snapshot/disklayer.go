@@ -0,0 +1,180 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/ethdb"
+	"github.com/meitu/go-ethereum/log"
+	"github.com/meitu/go-ethereum/trie"
+)
+
+// diskLayer is a low level persistent snapshot built on top of a key-value store.
+type diskLayer struct {
+	diskdb ethdb.Database     // Key-value store containing the base snapshot
+	triedb *trie.NodeDatabase // Trie node cache for reconstructing the snapshot from
+	root   common.Hash        // Root hash of the base snapshot
+	stale  bool               // Signals that the layer became stale (state progressed)
+
+	genMarker []byte        // Last account hash generated, nil if the snapshot is complete
+	genFailed bool          // Whether generation aborted due to missing state
+	genAbort  chan struct{} // Channel closed to abort generating the snapshot in this layer
+	genDone   chan struct{} // Channel closed when the generator goroutine terminates
+
+	lock sync.RWMutex
+}
+
+// Root returns root hash for which this snapshot was made.
+func (dl *diskLayer) Root() common.Hash {
+	return dl.root
+}
+
+// Parent always returns nil as there's no layer below the disk.
+func (dl *diskLayer) Parent() snapshot {
+	return nil
+}
+
+// Stale return whether this layer has become stale (was flattened across) or if
+// it's still live.
+func (dl *diskLayer) Stale() bool {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	return dl.stale
+}
+
+// markStale flags the layer as stale, failing all further data accesses.
+func (dl *diskLayer) markStale() {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	dl.stale = true
+}
+
+// generating returns whether the disk layer is still being generated.
+func (dl *diskLayer) generating() bool {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	return dl.genMarker != nil
+}
+
+// failed returns whether generating the disk layer was aborted due to an error.
+func (dl *diskLayer) failed() bool {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	return dl.genFailed
+}
+
+// covered checks whether an account was already generated into the disk layer.
+// The caller must hold the read lock.
+func (dl *diskLayer) covered(hash common.Hash) bool {
+	return dl.genMarker == nil || bytes.Compare(hash[:], dl.genMarker) <= 0
+}
+
+// AccountRLP directly retrieves the account RLP associated with a particular
+// hash in its consensus RLP encoding.
+func (dl *diskLayer) AccountRLP(hash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	if dl.stale {
+		return nil, ErrSnapshotStale
+	}
+	if !dl.covered(hash) {
+		return nil, ErrNotCoveredYet
+	}
+	blob, _ := dl.diskdb.Get(accountKey(hash))
+	return blob, nil
+}
+
+// Storage directly retrieves the storage data associated with a particular hash,
+// within a particular account.
+func (dl *diskLayer) Storage(accountHash, storageHash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	if dl.stale {
+		return nil, ErrSnapshotStale
+	}
+	if !dl.covered(accountHash) {
+		return nil, ErrNotCoveredYet
+	}
+	blob, _ := dl.diskdb.Get(storageKey(accountHash, storageHash))
+	return blob, nil
+}
+
+// flatten writes a chain of diff layers, ordered from the oldest one built on
+// top of this disk layer, into the database, returning the new disk layer. This
+// disk layer and all flattened diff layers become stale.
+func (dl *diskLayer) flatten(chain []*diffLayer) (*diskLayer, error) {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	// Drop the root marker while the layers are written one by one, so a crash in
+	// between can't leave a half updated snapshot behind
+	if err := dl.diskdb.Delete(snapshotRootKey); err != nil {
+		return nil, err
+	}
+	batch := dl.diskdb.NewBatch()
+	for _, diff := range chain {
+		// Wipe the deleted accounts along with all their storage slots
+		for hash := range diff.destructs {
+			batch.Delete(accountKey(hash))
+
+			it := dl.diskdb.NewIteratorWithPrefix(append(append([]byte{}, storagePrefix...), hash[:]...))
+			for it.Next() {
+				batch.Delete(common.CopyBytes(it.Key()))
+			}
+			it.Release()
+		}
+		for hash, data := range diff.accounts {
+			batch.Put(accountKey(hash), data)
+		}
+		for account, slots := range diff.storage {
+			for hash, data := range slots {
+				if len(data) == 0 {
+					batch.Delete(storageKey(account, hash))
+				} else {
+					batch.Put(storageKey(account, hash), data)
+				}
+			}
+		}
+		// Later layers must see the wipes of this one when iterating the storage
+		if err := batch.Write(); err != nil {
+			return nil, err
+		}
+		batch.Reset()
+		diff.markStale()
+	}
+	root := chain[len(chain)-1].root
+	if err := dl.diskdb.Put(snapshotRootKey, root[:]); err != nil {
+		return nil, err
+	}
+	log.Debug("Flattened snapshot diff layers", "layers", len(chain), "root", root)
+
+	dl.stale = true
+	return &diskLayer{
+		diskdb: dl.diskdb,
+		triedb: dl.triedb,
+		root:   root,
+	}, nil
+}
@@ -0,0 +1,242 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/core/types"
+	"github.com/meitu/go-ethereum/ethdb"
+	"github.com/meitu/go-ethereum/log"
+	"github.com/meitu/go-ethereum/rlp"
+	"github.com/meitu/go-ethereum/trie"
+)
+
+// errGenerationAborted is returned if snapshot generation was interrupted.
+var errGenerationAborted = errors.New("generation aborted")
+
+// account is the consensus representation of an account, as stored in the leaves
+// of the account trie. Only the storage root is needed for generation.
+type account struct {
+	Nonce    uint64
+	Balance  *big.Int
+	Root     common.Hash
+	CodeHash []byte
+}
+
+// generateSnapshot discards any previously persisted snapshot and starts to
+// regenerate the disk layer for the given state root in the background.
+func generateSnapshot(diskdb ethdb.Database, triedb *trie.NodeDatabase, root common.Hash) *diskLayer {
+	// Drop the markers first, a crash while wiping the old data restarts from scratch
+	diskdb.Delete(snapshotRootKey)
+	diskdb.Delete(snapshotGeneratorKey)
+	diskdb.Delete(snapshotJournalKey)
+
+	dl := &diskLayer{
+		diskdb:    diskdb,
+		triedb:    triedb,
+		root:      root,
+		genMarker: []byte{},
+	}
+	dl.startGeneration(true)
+	return dl
+}
+
+// startGeneration launches the background generator goroutine of the layer,
+// optionally wiping all leftover snapshot data first.
+func (dl *diskLayer) startGeneration(wipe bool) {
+	dl.genAbort, dl.genDone = make(chan struct{}), make(chan struct{})
+	go dl.generate(dl.genAbort, dl.genDone, wipe)
+}
+
+// stopGeneration aborts the background generator, if it's still running, and
+// waits for it to persist its progress.
+func (dl *diskLayer) stopGeneration() {
+	dl.lock.Lock()
+	abort, done := dl.genAbort, dl.genDone
+	dl.genAbort = nil
+	dl.lock.Unlock()
+
+	if abort != nil {
+		close(abort)
+		<-done
+	}
+}
+
+// generate iterates the account trie of the disk layer's root, writing all the
+// accounts and storage slots into the database in hash order. Progress is
+// persisted in batches, so an interrupted generation resumes where it stopped.
+func (dl *diskLayer) generate(abort chan struct{}, done chan struct{}, wipe bool) {
+	defer close(done)
+
+	start := time.Now()
+	if wipe {
+		if err := wipeSnapshot(dl.diskdb, abort); err != nil {
+			log.Debug("Snapshot wiping interrupted", "err", err)
+			return
+		}
+		batch := dl.diskdb.NewBatch()
+		batch.Put(snapshotRootKey, dl.root[:])
+		batch.Put(snapshotGeneratorKey, []byte{})
+		if err := batch.Write(); err != nil {
+			dl.fail(err)
+			return
+		}
+	}
+	dl.lock.RLock()
+	marker := dl.genMarker
+	dl.lock.RUnlock()
+
+	log.Info("Generating state snapshot", "root", dl.root, "at", common.BytesToHash(marker))
+	accTrie, err := trie.NewSecure(dl.root, dl.triedb, 0)
+	if err != nil {
+		dl.fail(err)
+		return
+	}
+	var (
+		batch    = dl.diskdb.NewBatch()
+		accounts int
+		slots    int
+		logged   = time.Now()
+		it       = trie.NewIterator(accTrie.NodeIterator(marker))
+	)
+	for it.Next() {
+		if len(marker) > 0 && bytes.Equal(it.Key, marker) {
+			continue
+		}
+		select {
+		case <-abort:
+			log.Debug("Snapshot generation aborted", "at", common.BytesToHash(marker))
+			return
+		default:
+		}
+		accHash := common.BytesToHash(it.Key)
+		batch.Put(accountKey(accHash), common.CopyBytes(it.Value))
+
+		var acc account
+		if err := rlp.DecodeBytes(it.Value, &acc); err != nil {
+			dl.fail(err)
+			return
+		}
+		if acc.Root != types.EmptyRootHash {
+			storeTrie, err := trie.NewSecure(acc.Root, dl.triedb, 0)
+			if err != nil {
+				dl.fail(err)
+				return
+			}
+			storeIt := trie.NewIterator(storeTrie.NodeIterator(nil))
+			for storeIt.Next() {
+				batch.Put(storageKey(accHash, common.BytesToHash(storeIt.Key)), common.CopyBytes(storeIt.Value))
+				slots++
+
+				if batch.ValueSize() > ethdb.IdealBatchSize {
+					if err := batch.Write(); err != nil {
+						dl.fail(err)
+						return
+					}
+					batch.Reset()
+				}
+			}
+			if storeIt.Err != nil {
+				dl.fail(storeIt.Err)
+				return
+			}
+		}
+		accounts++
+
+		// Persist the progress once enough data accumulated, accounts are only
+		// considered covered after all their storage slots were written
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			marker = accHash.Bytes()
+			batch.Put(snapshotGeneratorKey, marker)
+			if err := batch.Write(); err != nil {
+				dl.fail(err)
+				return
+			}
+			batch.Reset()
+
+			dl.lock.Lock()
+			dl.genMarker = marker
+			dl.lock.Unlock()
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Generating state snapshot", "at", accHash, "accounts", accounts, "slots", slots, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if it.Err != nil {
+		dl.fail(it.Err)
+		return
+	}
+	batch.Delete(snapshotGeneratorKey)
+	if err := batch.Write(); err != nil {
+		dl.fail(err)
+		return
+	}
+	dl.lock.Lock()
+	dl.genMarker = nil
+	dl.lock.Unlock()
+
+	log.Info("Generated state snapshot", "root", dl.root, "accounts", accounts, "slots", slots, "elapsed", common.PrettyDuration(time.Since(start)))
+}
+
+// fail marks the generation of the disk layer as failed, leaving all accounts
+// not yet generated uncovered.
+func (dl *diskLayer) fail(err error) {
+	log.Warn("Snapshot generation failed", "root", dl.root, "err", err)
+
+	dl.lock.Lock()
+	dl.genFailed = true
+	dl.lock.Unlock()
+}
+
+// wipeSnapshot deletes all snapshot accounts and storage slots from the database.
+func wipeSnapshot(db ethdb.Database, abort chan struct{}) error {
+	for _, prefix := range [][]byte{accountPrefix, storagePrefix} {
+		for {
+			// Release the iterator around each write, some engines block writes
+			// while it's open
+			var (
+				it    = db.NewIteratorWithPrefix(prefix)
+				batch = db.NewBatch()
+			)
+			for it.Next() && batch.ValueSize() < ethdb.IdealBatchSize {
+				if len(it.Key()) == len(prefix)+common.HashLength || len(it.Key()) == len(prefix)+2*common.HashLength {
+					batch.Delete(common.CopyBytes(it.Key()))
+				}
+			}
+			it.Release()
+
+			if batch.ValueSize() == 0 {
+				break
+			}
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			select {
+			case <-abort:
+				return errGenerationAborted
+			default:
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/ethdb"
+	"github.com/meitu/go-ethereum/rlp"
+	"github.com/meitu/go-ethereum/trie"
+)
+
+// journal is the persisted form of the diff layers on top of the disk layer.
+type journal struct {
+	Base   common.Hash // Root of the disk layer the diff layers were built on
+	Layers []journalLayer
+}
+
+// journalLayer is the persisted form of a single diff layer.
+type journalLayer struct {
+	Root      common.Hash
+	Destructs []common.Hash
+	Accounts  []journalAccount
+	Storage   []journalStorage
+}
+
+// journalAccount is an account entry in a diff layer journal.
+type journalAccount struct {
+	Hash common.Hash
+	Blob []byte
+}
+
+// journalStorage is the set of storage slots of an account in a diff layer journal.
+type journalStorage struct {
+	Hash  common.Hash
+	Keys  []common.Hash
+	Blobs [][]byte
+}
+
+// writeJournal persists a chain of diff layers, ordered from the oldest one, on
+// top of the disk layer with the given root.
+func writeJournal(db ethdb.Database, base common.Hash, chain []*diffLayer) error {
+	enc := journal{Base: base}
+	for _, diff := range chain {
+		layer := journalLayer{Root: diff.root}
+		for hash := range diff.destructs {
+			layer.Destructs = append(layer.Destructs, hash)
+		}
+		for hash, blob := range diff.accounts {
+			layer.Accounts = append(layer.Accounts, journalAccount{Hash: hash, Blob: blob})
+		}
+		for hash, slots := range diff.storage {
+			entry := journalStorage{Hash: hash}
+			for key, blob := range slots {
+				entry.Keys = append(entry.Keys, key)
+				entry.Blobs = append(entry.Blobs, blob)
+			}
+			layer.Storage = append(layer.Storage, entry)
+		}
+		enc.Layers = append(enc.Layers, layer)
+	}
+	blob, err := rlp.EncodeToBytes(enc)
+	if err != nil {
+		return err
+	}
+	return db.Put(snapshotJournalKey, blob)
+}
+
+// loadSnapshot loads a pre-existing state snapshot backed by a key-value store,
+// along with its journaled diff layers. The head of the loaded layers must be
+// the given root, otherwise the snapshot is considered unusable.
+func loadSnapshot(diskdb ethdb.Database, triedb *trie.NodeDatabase, root common.Hash) (snapshot, error) {
+	blob, err := diskdb.Get(snapshotRootKey)
+	if err != nil || len(blob) != common.HashLength {
+		return nil, errors.New("missing or corrupted snapshot")
+	}
+	base := &diskLayer{
+		diskdb: diskdb,
+		triedb: triedb,
+		root:   common.BytesToHash(blob),
+	}
+	var head snapshot = base
+	if blob, err := diskdb.Get(snapshotJournalKey); err == nil && len(blob) > 0 {
+		var dec journal
+		if err := rlp.DecodeBytes(blob, &dec); err != nil {
+			return nil, fmt.Errorf("corrupted snapshot journal: %v", err)
+		}
+		if dec.Base != base.root {
+			return nil, fmt.Errorf("snapshot journal base mismatch: have %x, want %x", dec.Base, base.root)
+		}
+		for _, layer := range dec.Layers {
+			destructs := make(map[common.Hash]struct{})
+			for _, hash := range layer.Destructs {
+				destructs[hash] = struct{}{}
+			}
+			accounts := make(map[common.Hash][]byte)
+			for _, entry := range layer.Accounts {
+				accounts[entry.Hash] = entry.Blob
+			}
+			storage := make(map[common.Hash]map[common.Hash][]byte)
+			for _, entry := range layer.Storage {
+				if len(entry.Keys) != len(entry.Blobs) {
+					return nil, errors.New("corrupted snapshot journal storage")
+				}
+				slots := make(map[common.Hash][]byte)
+				for i, key := range entry.Keys {
+					slots[key] = entry.Blobs[i]
+				}
+				storage[entry.Hash] = slots
+			}
+			head = newDiffLayer(head, layer.Root, destructs, accounts, storage)
+		}
+	}
+	if head.Root() != root {
+		return nil, fmt.Errorf("snapshot head mismatch: have %x, want %x", head.Root(), root)
+	}
+	// Resume generating the disk layer if it was interrupted
+	if marker, err := diskdb.Get(snapshotGeneratorKey); err == nil {
+		base.genMarker = append([]byte{}, marker...)
+		base.startGeneration(false)
+	}
+	return head, nil
+}
@@ -0,0 +1,286 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package snapshot implements a flat, hash keyed view of the account state,
+// allowing accounts and storage slots to be read without walking the tries.
+//
+// The snapshot is made up of a persistent disk layer, tracking the state of an
+// irreversible block, with in-memory diff layers on top of it for every block
+// imported since. Diff layers are flattened into the disk layer as blocks get
+// confirmed and journaled to disk on shutdown.
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/ethdb"
+	"github.com/meitu/go-ethereum/log"
+	"github.com/meitu/go-ethereum/trie"
+)
+
+var (
+	// ErrSnapshotStale is returned from data accessors if the underlying snapshot
+	// layer had been invalidated due to the chain progressing forward far enough
+	// to not maintain the layer's original state.
+	ErrSnapshotStale = errors.New("snapshot stale")
+
+	// ErrNotCoveredYet is returned from data accessors if the underlying snapshot
+	// is being generated currently and the requested data item is not yet in the
+	// range of accounts covered.
+	ErrNotCoveredYet = errors.New("not covered yet")
+)
+
+var (
+	snapshotRootKey      = []byte("SnapshotRoot")      // Root of the state tracked by the disk layer
+	snapshotGeneratorKey = []byte("SnapshotGenerator") // Last account hash generated into the disk layer
+	snapshotJournalKey   = []byte("SnapshotJournal")   // Diff layers persisted across restarts
+
+	accountPrefix = []byte("a") // accountPrefix + account hash -> account RLP
+	storagePrefix = []byte("o") // storagePrefix + account hash + slot hash -> slot RLP
+)
+
+// accountKey returns the database key of an account in the disk layer.
+func accountKey(hash common.Hash) []byte {
+	return append(append([]byte{}, accountPrefix...), hash[:]...)
+}
+
+// storageKey returns the database key of a storage slot in the disk layer.
+func storageKey(account common.Hash, slot common.Hash) []byte {
+	return append(append(append([]byte{}, storagePrefix...), account[:]...), slot[:]...)
+}
+
+// Snapshot represents the functionality supported by a snapshot layer.
+type Snapshot interface {
+	// Root returns the root hash of the state this snapshot layer represents.
+	Root() common.Hash
+
+	// AccountRLP directly retrieves the account RLP associated with a particular
+	// hash in its consensus RLP encoding. Nil is returned for missing accounts.
+	AccountRLP(hash common.Hash) ([]byte, error)
+
+	// Storage directly retrieves the storage slot RLP associated with a particular
+	// account and slot hash. Nil is returned for missing slots.
+	Storage(accountHash, storageHash common.Hash) ([]byte, error)
+}
+
+// snapshot is the internal version of the snapshot layer, with a few extra
+// methods needed to maintain the tree.
+type snapshot interface {
+	Snapshot
+
+	// Parent returns the subsequent layer of a snapshot, or nil if the base was
+	// reached.
+	Parent() snapshot
+
+	// Stale returns whether this layer has become stale (was flattened across) or
+	// if it's still live.
+	Stale() bool
+}
+
+// Tree is an Ethereum state snapshot tree. It consists of one persistent base
+// layer backed by a key-value store, on top of which arbitrarily many in-memory
+// diff layers are topped. The memory diffs can form a tree with branching, but
+// the disk layer is singleton and common to all.
+type Tree struct {
+	diskdb ethdb.Database           // Persistent database to store the snapshot
+	triedb *trie.NodeDatabase       // In-memory cache to access the trie through
+	layers map[common.Hash]snapshot // Collection of all known layers
+	lock   sync.RWMutex
+}
+
+// New attempts to load an already existing snapshot from a persistent key-value
+// store, along with the journaled diff layers on top of it. If the snapshot is
+// missing or doesn't reach the given head root, it is rebuilt in the background
+// at the head root. Until then, data accessors report it as not covered yet.
+func New(diskdb ethdb.Database, triedb *trie.NodeDatabase, root common.Hash) *Tree {
+	snap := &Tree{
+		diskdb: diskdb,
+		triedb: triedb,
+		layers: make(map[common.Hash]snapshot),
+	}
+	head, err := loadSnapshot(diskdb, triedb, root)
+	if err != nil {
+		log.Warn("Failed to load snapshot, regenerating", "err", err)
+		snap.rebuild(root)
+		return snap
+	}
+	for head != nil {
+		snap.layers[head.Root()] = head
+		head = head.Parent()
+	}
+	return snap
+}
+
+// Snapshot retrieves a snapshot belonging to the given block root, or nil if no
+// snapshot is maintained for that block.
+func (t *Tree) Snapshot(blockRoot common.Hash) Snapshot {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if layer, ok := t.layers[blockRoot]; ok {
+		return layer
+	}
+	return nil
+}
+
+// Update adds a new snapshot into the tree, if that can be linked to an existing
+// old parent. If the parent is unknown, or the disk layer failed to generate, the
+// snapshot is rebuilt at the new root instead.
+func (t *Tree) Update(blockRoot common.Hash, parentRoot common.Hash, destructs map[common.Hash]struct{}, accounts map[common.Hash][]byte, storage map[common.Hash]map[common.Hash][]byte) error {
+	// Reject noop updates to avoid self-loops in the snapshot tree
+	if blockRoot == parentRoot {
+		return errors.New("snapshot cycle")
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if _, ok := t.layers[blockRoot]; ok {
+		return nil
+	}
+	parent, ok := t.layers[parentRoot]
+	if !ok || t.disk().failed() {
+		log.Warn("Snapshot unusable, regenerating", "root", blockRoot, "parent", parentRoot)
+		t.rebuildLocked(blockRoot)
+		return nil
+	}
+	t.layers[blockRoot] = newDiffLayer(parent, blockRoot, destructs, accounts, storage)
+	return nil
+}
+
+// Cap flattens all the diff layers below the given root into the disk layer, so
+// that it tracks the state of that block. Diff layers on other branches can never
+// be reached again and are discarded. While the disk layer is being generated,
+// flattening is postponed.
+func (t *Tree) Cap(root common.Hash) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	layer, ok := t.layers[root]
+	if !ok {
+		return fmt.Errorf("snapshot [%#x] missing", root)
+	}
+	diff, ok := layer.(*diffLayer)
+	if !ok {
+		return nil
+	}
+	base := t.disk()
+	if base.generating() {
+		return nil
+	}
+	// Collect the diff layers to flatten, from the disk layer upwards
+	var chain []*diffLayer
+	for layer := snapshot(diff); layer != snapshot(base); layer = layer.Parent() {
+		chain = append([]*diffLayer{layer.(*diffLayer)}, chain...)
+	}
+	disk, err := base.flatten(chain)
+	if err != nil {
+		return err
+	}
+	// Rebuild the layer set, dropping everything not descending from the new disk
+	// layer. Children are only re-parented afterwards, as that cuts their link to
+	// the flattened layer.
+	var (
+		layers   = map[common.Hash]snapshot{root: disk}
+		children []*diffLayer
+	)
+	for hash, layer := range t.layers {
+		child, ok := layer.(*diffLayer)
+		if !ok || child.Stale() {
+			continue
+		}
+		if descendsFrom(child, diff) {
+			if child.Parent() == snapshot(diff) {
+				children = append(children, child)
+			}
+			layers[hash] = child
+		} else {
+			child.markStale()
+		}
+	}
+	for _, child := range children {
+		child.setParent(disk)
+	}
+	t.layers = layers
+	return nil
+}
+
+// descendsFrom checks whether a diff layer is built on top of the given ancestor.
+func descendsFrom(layer snapshot, ancestor snapshot) bool {
+	for parent := layer.Parent(); parent != nil; parent = parent.Parent() {
+		if parent == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// Journal persists the diff layers leading up to the given root, so they can be
+// restored on the next startup, and stops any background generation.
+func (t *Tree) Journal(root common.Hash) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	disk := t.disk()
+	disk.stopGeneration()
+
+	layer, ok := t.layers[root]
+	if !ok {
+		return fmt.Errorf("snapshot [%#x] missing", root)
+	}
+	var chain []*diffLayer
+	for ; layer != snapshot(disk); layer = layer.Parent() {
+		chain = append([]*diffLayer{layer.(*diffLayer)}, chain...)
+	}
+	return writeJournal(t.diskdb, disk.root, chain)
+}
+
+// disk returns the disk layer of the tree. The caller must hold the lock.
+func (t *Tree) disk() *diskLayer {
+	for _, layer := range t.layers {
+		for layer.Parent() != nil {
+			layer = layer.Parent()
+		}
+		return layer.(*diskLayer)
+	}
+	return nil
+}
+
+// rebuild discards all layers and regenerates the disk layer at the given root.
+func (t *Tree) rebuild(root common.Hash) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.rebuildLocked(root)
+}
+
+// rebuildLocked is the lock free version of rebuild. The caller must hold the lock.
+func (t *Tree) rebuildLocked(root common.Hash) {
+	if disk := t.disk(); disk != nil {
+		disk.stopGeneration()
+	}
+	for _, layer := range t.layers {
+		switch layer := layer.(type) {
+		case *diskLayer:
+			layer.markStale()
+		case *diffLayer:
+			layer.markStale()
+		}
+	}
+	t.layers = map[common.Hash]snapshot{root: generateSnapshot(t.diskdb, t.triedb, root)}
+}
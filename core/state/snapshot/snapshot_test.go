@@ -0,0 +1,257 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/core/types"
+	"github.com/meitu/go-ethereum/crypto"
+	"github.com/meitu/go-ethereum/ethdb"
+	"github.com/meitu/go-ethereum/rlp"
+	"github.com/meitu/go-ethereum/trie"
+)
+
+// newTestTree creates a snapshot tree with an empty, fully generated disk layer.
+func newTestTree(root common.Hash) *Tree {
+	db, _ := ethdb.NewMemDatabase()
+	db.Put(snapshotRootKey, root[:])
+
+	return &Tree{
+		diskdb: db,
+		triedb: trie.NewNodeDatabase(db),
+		layers: map[common.Hash]snapshot{root: &diskLayer{diskdb: db, root: root}},
+	}
+}
+
+// Tests that accounts and storage slots are resolved through the diff layers,
+// honouring overrides and deletions, before hitting the disk layer.
+func TestDiffLayerLookup(t *testing.T) {
+	var (
+		tree = newTestTree(common.HexToHash("0x01"))
+		acc1 = common.HexToHash("0xa1")
+		acc2 = common.HexToHash("0xa2")
+		slot = common.HexToHash("0x51")
+	)
+	tree.diskdb.Put(accountKey(acc1), []byte("disk-1"))
+	tree.diskdb.Put(accountKey(acc2), []byte("disk-2"))
+	tree.diskdb.Put(storageKey(acc2, slot), []byte("disk-slot"))
+
+	tree.Update(common.HexToHash("0x02"), common.HexToHash("0x01"), nil,
+		map[common.Hash][]byte{acc1: []byte("diff-1")}, nil)
+	tree.Update(common.HexToHash("0x03"), common.HexToHash("0x02"),
+		map[common.Hash]struct{}{acc2: {}},
+		map[common.Hash][]byte{acc2: []byte("recreated-2")}, nil)
+
+	tests := []struct {
+		root common.Hash
+		acc  common.Hash
+		want []byte
+		slot []byte
+	}{
+		{common.HexToHash("0x01"), acc1, []byte("disk-1"), nil},
+		{common.HexToHash("0x02"), acc1, []byte("diff-1"), nil},
+		{common.HexToHash("0x02"), acc2, []byte("disk-2"), []byte("disk-slot")},
+		{common.HexToHash("0x03"), acc1, []byte("diff-1"), nil},
+		{common.HexToHash("0x03"), acc2, []byte("recreated-2"), nil},
+	}
+	for i, tt := range tests {
+		snap := tree.Snapshot(tt.root)
+		if snap == nil {
+			t.Fatalf("test %d: snapshot %x missing", i, tt.root)
+		}
+		if blob, err := snap.AccountRLP(tt.acc); err != nil || !bytes.Equal(blob, tt.want) {
+			t.Errorf("test %d: account mismatch: have %q/%v, want %q", i, blob, err, tt.want)
+		}
+		if blob, err := snap.Storage(tt.acc, slot); err != nil || !bytes.Equal(blob, tt.slot) {
+			t.Errorf("test %d: slot mismatch: have %q/%v, want %q", i, blob, err, tt.slot)
+		}
+	}
+}
+
+// Tests that capping the tree flattens the diff layers into the disk layer and
+// discards the branches that can't be reached anymore.
+func TestCapFlatten(t *testing.T) {
+	var (
+		tree = newTestTree(common.HexToHash("0x01"))
+		acc  = common.HexToHash("0xa1")
+		slot = common.HexToHash("0x51")
+	)
+	tree.diskdb.Put(storageKey(acc, slot), []byte("old"))
+
+	// Build a chain 0x01 <- ... <- 0x06 and a fork 0x02 <- 0x13
+	tree.Update(common.HexToHash("0x02"), common.HexToHash("0x01"), nil,
+		map[common.Hash][]byte{acc: []byte("acc-2")},
+		map[common.Hash]map[common.Hash][]byte{acc: {slot: nil}})
+	tree.Update(common.HexToHash("0x03"), common.HexToHash("0x02"), nil,
+		map[common.Hash][]byte{acc: []byte("acc-3")},
+		map[common.Hash]map[common.Hash][]byte{acc: {slot: []byte("new")}})
+	tree.Update(common.HexToHash("0x04"), common.HexToHash("0x03"), nil,
+		map[common.Hash][]byte{acc: []byte("acc-4")}, nil)
+	tree.Update(common.HexToHash("0x05"), common.HexToHash("0x04"), nil, nil, nil)
+	tree.Update(common.HexToHash("0x06"), common.HexToHash("0x05"), nil, nil, nil)
+	tree.Update(common.HexToHash("0x13"), common.HexToHash("0x02"), nil,
+		map[common.Hash][]byte{acc: []byte("acc-13")}, nil)
+
+	fork := tree.Snapshot(common.HexToHash("0x13"))
+	if err := tree.Cap(common.HexToHash("0x03")); err != nil {
+		t.Fatalf("failed to cap tree: %v", err)
+	}
+	if n := len(tree.layers); n != 4 {
+		t.Fatalf("layer count mismatch: have %d, want %d", n, 4)
+	}
+	if root := tree.disk().Root(); root != common.HexToHash("0x03") {
+		t.Fatalf("disk root mismatch: have %x, want %x", root, common.HexToHash("0x03"))
+	}
+	if blob, _ := tree.diskdb.Get(snapshotRootKey); !bytes.Equal(blob, common.HexToHash("0x03").Bytes()) {
+		t.Errorf("persisted root mismatch: have %x", blob)
+	}
+	if blob, _ := tree.diskdb.Get(accountKey(acc)); !bytes.Equal(blob, []byte("acc-3")) {
+		t.Errorf("flattened account mismatch: have %q, want %q", blob, "acc-3")
+	}
+	if blob, _ := tree.diskdb.Get(storageKey(acc, slot)); !bytes.Equal(blob, []byte("new")) {
+		t.Errorf("flattened slot mismatch: have %q, want %q", blob, "new")
+	}
+	if _, err := fork.AccountRLP(acc); err != ErrSnapshotStale {
+		t.Errorf("fork layer error mismatch: have %v, want %v", err, ErrSnapshotStale)
+	}
+	if blob, err := tree.Snapshot(common.HexToHash("0x06")).AccountRLP(acc); err != nil || !bytes.Equal(blob, []byte("acc-4")) {
+		t.Errorf("head account mismatch: have %q/%v, want %q", blob, err, "acc-4")
+	}
+}
+
+// Tests that destructed accounts get their storage wiped when flattened.
+func TestCapDestruct(t *testing.T) {
+	var (
+		tree = newTestTree(common.HexToHash("0x01"))
+		acc  = common.HexToHash("0xa1")
+	)
+	tree.diskdb.Put(accountKey(acc), []byte("acc"))
+	for i := byte(0); i < 10; i++ {
+		tree.diskdb.Put(storageKey(acc, common.Hash{i}), []byte{i})
+	}
+	tree.Update(common.HexToHash("0x02"), common.HexToHash("0x01"), map[common.Hash]struct{}{acc: {}}, nil, nil)
+	if err := tree.Cap(common.HexToHash("0x02")); err != nil {
+		t.Fatalf("failed to cap tree: %v", err)
+	}
+	if has, _ := tree.diskdb.Has(accountKey(acc)); has {
+		t.Errorf("destructed account still present")
+	}
+	it := tree.diskdb.NewIteratorWithPrefix(storagePrefix)
+	defer it.Release()
+	if it.Next() {
+		t.Errorf("destructed storage still present: %x", it.Key())
+	}
+}
+
+// Tests that journaled diff layers are restored on top of the disk layer, and
+// that a journal not leading to the requested head is rejected.
+func TestJournal(t *testing.T) {
+	var (
+		tree = newTestTree(common.HexToHash("0x01"))
+		acc  = common.HexToHash("0xa1")
+		slot = common.HexToHash("0x51")
+	)
+	tree.Update(common.HexToHash("0x02"), common.HexToHash("0x01"), map[common.Hash]struct{}{acc: {}},
+		map[common.Hash][]byte{acc: []byte("acc-2")},
+		map[common.Hash]map[common.Hash][]byte{acc: {slot: []byte("slot-2")}})
+	tree.Update(common.HexToHash("0x03"), common.HexToHash("0x02"), nil,
+		map[common.Hash][]byte{acc: []byte("acc-3")}, nil)
+
+	if err := tree.Journal(common.HexToHash("0x03")); err != nil {
+		t.Fatalf("failed to journal tree: %v", err)
+	}
+	head, err := loadSnapshot(tree.diskdb, tree.triedb, common.HexToHash("0x03"))
+	if err != nil {
+		t.Fatalf("failed to load journal: %v", err)
+	}
+	if blob, _ := head.AccountRLP(acc); !bytes.Equal(blob, []byte("acc-3")) {
+		t.Errorf("account mismatch: have %q, want %q", blob, "acc-3")
+	}
+	parent := head.Parent()
+	if parent.Root() != common.HexToHash("0x02") {
+		t.Fatalf("parent root mismatch: have %x, want %x", parent.Root(), common.HexToHash("0x02"))
+	}
+	if blob, _ := parent.Storage(acc, slot); !bytes.Equal(blob, []byte("slot-2")) {
+		t.Errorf("slot mismatch: have %q, want %q", blob, "slot-2")
+	}
+	if _, ok := parent.(*diffLayer).destructs[acc]; !ok {
+		t.Errorf("destruct not restored")
+	}
+	if _, err := loadSnapshot(tree.diskdb, tree.triedb, common.HexToHash("0x02")); err == nil {
+		t.Errorf("journal with mismatching head loaded")
+	}
+}
+
+// Tests that the disk layer is generated from the state trie.
+func TestGeneration(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	triedb := trie.NewNodeDatabase(db)
+
+	// Create a storage trie and an account trie referencing it
+	storage, _ := trie.NewSecure(common.Hash{}, triedb, 0)
+	for i := byte(1); i <= 3; i++ {
+		storage.Update([]byte{i}, []byte{i})
+	}
+	storageRoot, _ := storage.CommitTo(triedb)
+
+	accounts, _ := trie.NewSecure(common.Hash{}, triedb, 0)
+	for i := byte(1); i <= 3; i++ {
+		acc := account{Nonce: uint64(i), Balance: big.NewInt(int64(i)), Root: types.EmptyRootHash, CodeHash: crypto.Keccak256(nil)}
+		if i == 2 {
+			acc.Root = storageRoot
+		}
+		blob, _ := rlp.EncodeToBytes(acc)
+		accounts.Update([]byte{i}, blob)
+	}
+	root, _ := accounts.CommitTo(triedb)
+
+	// Leave a stale entry behind to ensure the old snapshot is wiped
+	stale := common.HexToHash("0xdead")
+	db.Put(accountKey(stale), []byte("stale"))
+
+	tree := New(db, triedb, root)
+	<-tree.disk().genDone
+
+	if tree.disk().generating() {
+		t.Fatalf("snapshot generation not finished")
+	}
+	snap := tree.Snapshot(root)
+	for i := byte(1); i <= 3; i++ {
+		hash := crypto.Keccak256Hash([]byte{i})
+		blob, err := snap.AccountRLP(hash)
+		if err != nil || !bytes.Equal(blob, accounts.Get([]byte{i})) {
+			t.Errorf("account %d mismatch: have %x/%v", i, blob, err)
+		}
+	}
+	accHash := crypto.Keccak256Hash([]byte{2})
+	for i := byte(1); i <= 3; i++ {
+		blob, err := snap.Storage(accHash, crypto.Keccak256Hash([]byte{i}))
+		if err != nil || !bytes.Equal(blob, []byte{i}) {
+			t.Errorf("slot %d mismatch: have %x/%v", i, blob, err)
+		}
+	}
+	if blob, _ := snap.AccountRLP(stale); blob != nil {
+		t.Errorf("stale account not wiped: %q", blob)
+	}
+	if blob, _ := db.Get(snapshotRootKey); !bytes.Equal(blob, root[:]) {
+		t.Errorf("persisted root mismatch: have %x, want %x", blob, root)
+	}
+}
@@ -78,13 +78,15 @@ type stateObject struct {
 	trie Trie // storage trie, which becomes non-nil on first access
 	code Code // contract bytecode, which gets set when code is loaded
 
-	cachedStorage Storage // Storage entry cache to avoid duplicate reads
-	dirtyStorage  Storage // Storage entries that need to be flushed to disk
+	cachedStorage Storage                // Storage entry cache to avoid duplicate reads
+	dirtyStorage  Storage                // Storage entries that need to be flushed to disk
+	snapStorage   map[common.Hash][]byte // Storage slots flushed since the last commit, keyed by slot hash
 
 	// Cache flags.
 	// When an object is marked suicided it will be delete from the trie
 	// during the "update" phase of the state transition.
 	dirtyCode bool // true if the code was updated
+	created   bool // true if the account was (re)created since the last commit
 	suicided  bool
 	touched   bool
 	deleted   bool
@@ -175,11 +177,21 @@ func (self *stateObject) GetState(db Database, key common.Hash) common.Hash {
 	if exists {
 		return value
 	}
-	// Load from DB in case it is missing.
-	enc, err := self.getTrie(db).TryGet(key[:])
-	if err != nil {
-		self.setError(err)
-		return common.Hash{}
+	// Load from the snapshot if it covers the slot, or the DB in case it is missing.
+	// Accounts created since the last commit have no storage in the snapshot.
+	var (
+		enc []byte
+		err error
+	)
+	if snap := self.db.snap; snap != nil && !self.created {
+		enc, err = snap.Storage(self.addrHash, crypto.Keccak256Hash(key[:]))
+	}
+	if self.db.snap == nil || self.created || err != nil {
+		enc, err = self.getTrie(db).TryGet(key[:])
+		if err != nil {
+			self.setError(err)
+			return common.Hash{}
+		}
 	}
 	if len(enc) > 0 {
 		_, content, _, err := rlp.Split(enc)
@@ -219,13 +231,21 @@ func (self *stateObject) updateTrie(db Database) Trie {
 	tr := self.getTrie(db)
 	for key, value := range self.dirtyStorage {
 		delete(self.dirtyStorage, key)
+
+		var v []byte
 		if (value == common.Hash{}) {
 			self.setError(tr.TryDelete(key[:]))
-			continue
+		} else {
+			// Encoding []byte cannot fail, ok to ignore the error.
+			v, _ = rlp.EncodeToBytes(bytes.TrimLeft(value[:], "\x00"))
+			self.setError(tr.TryUpdate(key[:], v))
+		}
+		if self.db.snaps != nil {
+			if self.snapStorage == nil {
+				self.snapStorage = make(map[common.Hash][]byte)
+			}
+			self.snapStorage[crypto.Keccak256Hash(key[:])] = v
 		}
-		// Encoding []byte cannot fail, ok to ignore the error.
-		v, _ := rlp.EncodeToBytes(bytes.TrimLeft(value[:], "\x00"))
-		self.setError(tr.TryUpdate(key[:], v))
 	}
 	return tr
 }
@@ -301,6 +321,13 @@ func (self *stateObject) deepCopy(db *StateDB, onDirty func(addr common.Address)
 	stateObject.code = self.code
 	stateObject.dirtyStorage = self.dirtyStorage.Copy()
 	stateObject.cachedStorage = self.dirtyStorage.Copy()
+	if self.snapStorage != nil {
+		stateObject.snapStorage = make(map[common.Hash][]byte, len(self.snapStorage))
+		for hash, blob := range self.snapStorage {
+			stateObject.snapStorage[hash] = blob
+		}
+	}
+	stateObject.created = self.created
 	stateObject.suicided = self.suicided
 	stateObject.dirtyCode = self.dirtyCode
 	stateObject.deleted = self.deleted
@@ -24,6 +24,7 @@ import (
 	"sync"
 
 	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/core/state/snapshot"
 	"github.com/meitu/go-ethereum/core/types"
 	"github.com/meitu/go-ethereum/crypto"
 	"github.com/meitu/go-ethereum/log"
@@ -31,6 +32,14 @@ import (
 	"github.com/meitu/go-ethereum/trie"
 )
 
+var (
+	// emptyState is the known root hash of an empty state trie.
+	emptyState = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+	// emptyCode is the known hash of the empty EVM bytecode.
+	emptyCode = crypto.Keccak256Hash(nil)
+)
+
 type revision struct {
 	id           int
 	journalIndex int
@@ -42,8 +51,13 @@ type revision struct {
 // * Contracts
 // * Accounts
 type StateDB struct {
-	db   Database
-	trie Trie
+	db           Database
+	trie         Trie
+	originalRoot common.Hash // The pre-state root, before any changes were made
+
+	// Flat state snapshot, used to short circuit trie lookups if available.
+	snaps *snapshot.Tree
+	snap  snapshot.Snapshot
 
 	// This map holds 'live' objects, which will get modified while processing a state transition.
 	stateObjects      map[common.Address]*stateObject
@@ -66,6 +80,10 @@ type StateDB struct {
 
 	preimages map[common.Hash][]byte
 
+	// The dpos context of the block, whose changes are journaled along with
+	// the state's.
+	dposContext *types.DposContext
+
 	// Journal of state modifications. This is the backbone of
 	// Snapshot and RevertToSnapshot.
 	journal        journal
@@ -77,19 +95,32 @@ type StateDB struct {
 
 // Create a new state from a given trie
 func New(root common.Hash, db Database) (*StateDB, error) {
+	return NewWithSnapshot(root, db, nil)
+}
+
+// NewWithSnapshot creates a new state from a given trie, serving account and
+// storage reads from the state snapshot tree whenever it covers the root. The
+// snapshot tree is also updated with the changes of every commit.
+func NewWithSnapshot(root common.Hash, db Database, snaps *snapshot.Tree) (*StateDB, error) {
 	tr, err := db.OpenTrie(root)
 	if err != nil {
 		return nil, err
 	}
-	return &StateDB{
+	sdb := &StateDB{
 		db:                db,
 		trie:              tr,
+		originalRoot:      root,
+		snaps:             snaps,
 		stateObjects:      make(map[common.Address]*stateObject),
 		stateObjectsDirty: make(map[common.Address]struct{}),
 		refund:            new(big.Int),
 		logs:              make(map[common.Hash][]*types.Log),
 		preimages:         make(map[common.Hash][]byte),
-	}, nil
+	}
+	if snaps != nil {
+		sdb.snap = snaps.Snapshot(root)
+	}
+	return sdb, nil
 }
 
 // setError remembers the first non-nil error it is called with.
@@ -111,6 +142,10 @@ func (self *StateDB) Reset(root common.Hash) error {
 		return err
 	}
 	self.trie = tr
+	self.originalRoot = root
+	if self.snaps != nil {
+		self.snap = self.snaps.Snapshot(root)
+	}
 	self.stateObjects = make(map[common.Address]*stateObject)
 	self.stateObjectsDirty = make(map[common.Address]struct{})
 	self.thash = common.Hash{}
@@ -355,11 +390,24 @@ func (self *StateDB) getStateObject(addr common.Address) (stateObject *stateObje
 		return obj
 	}
 
-	// Load the object from the database.
-	enc, err := self.trie.TryGet(addr[:])
-	if len(enc) == 0 {
-		self.setError(err)
-		return nil
+	// If the snapshot covers the account, read it without walking the trie
+	var (
+		enc []byte
+		err error
+	)
+	if self.snap != nil {
+		enc, err = self.snap.AccountRLP(crypto.Keccak256Hash(addr[:]))
+		if err == nil && len(enc) == 0 {
+			return nil
+		}
+	}
+	// Otherwise load the object from the database.
+	if self.snap == nil || err != nil {
+		enc, err = self.trie.TryGet(addr[:])
+		if len(enc) == 0 {
+			self.setError(err)
+			return nil
+		}
 	}
 	var data Account
 	if err := rlp.DecodeBytes(enc, &data); err != nil {
@@ -396,6 +444,7 @@ func (self *StateDB) MarkStateObjectDirty(addr common.Address) {
 func (self *StateDB) createObject(addr common.Address) (newobj, prev *stateObject) {
 	prev = self.getStateObject(addr)
 	newobj = newObject(self, addr, Account{}, self.MarkStateObjectDirty)
+	newobj.created = true
 	newobj.setNonce(0) // sets the object to dirty
 	if prev == nil {
 		self.journal = append(self.journal, createObjectChange{account: &addr})
@@ -454,6 +503,9 @@ func (self *StateDB) Copy() *StateDB {
 	state := &StateDB{
 		db:                self.db,
 		trie:              self.trie,
+		originalRoot:      self.originalRoot,
+		snaps:             self.snaps,
+		snap:              self.snap,
 		stateObjects:      make(map[common.Address]*stateObject, len(self.stateObjectsDirty)),
 		stateObjectsDirty: make(map[common.Address]struct{}, len(self.stateObjectsDirty)),
 		refund:            new(big.Int).Set(self.refund),
@@ -473,6 +525,9 @@ func (self *StateDB) Copy() *StateDB {
 	for hash, preimage := range self.preimages {
 		state.preimages[hash] = preimage
 	}
+	if self.dposContext != nil {
+		state.dposContext = self.dposContext.Copy()
+	}
 	return state
 }
 
@@ -575,6 +630,17 @@ func (s *StateDB) clearJournalAndRefund() {
 func (s *StateDB) CommitTo(dbw trie.DatabaseWriter, deleteEmptyObjects bool) (root common.Hash, err error) {
 	defer s.clearJournalAndRefund()
 
+	// Collect the flat changes of the commit if a snapshot is maintained
+	var (
+		destructs map[common.Hash]struct{}
+		accounts  map[common.Hash][]byte
+		storage   map[common.Hash]map[common.Hash][]byte
+	)
+	if s.snaps != nil {
+		destructs = make(map[common.Hash]struct{})
+		accounts = make(map[common.Hash][]byte)
+		storage = make(map[common.Hash]map[common.Hash][]byte)
+	}
 	// Commit objects to the trie.
 	for addr, stateObject := range s.stateObjects {
 		_, isDirty := s.stateObjectsDirty[addr]
@@ -583,6 +649,9 @@ func (s *StateDB) CommitTo(dbw trie.DatabaseWriter, deleteEmptyObjects bool) (ro
 			// If the object has been removed, don't bother syncing it
 			// and just mark it for deletion in the trie.
 			s.deleteStateObject(stateObject)
+			if s.snaps != nil {
+				destructs[stateObject.addrHash] = struct{}{}
+			}
 		case isDirty:
 			// Write any contract code associated with the state object
 			if stateObject.code != nil && stateObject.dirtyCode {
@@ -597,11 +666,54 @@ func (s *StateDB) CommitTo(dbw trie.DatabaseWriter, deleteEmptyObjects bool) (ro
 			}
 			// Update the object in the main account trie.
 			s.updateStateObject(stateObject)
+
+			if s.snaps != nil {
+				// Recreated accounts lose all the storage of their predecessor
+				if stateObject.created {
+					destructs[stateObject.addrHash] = struct{}{}
+				}
+				accounts[stateObject.addrHash], _ = rlp.EncodeToBytes(stateObject)
+				if len(stateObject.snapStorage) > 0 {
+					storage[stateObject.addrHash] = stateObject.snapStorage
+				}
+			}
+			stateObject.created = false
+			stateObject.snapStorage = nil
 		}
 		delete(s.stateObjectsDirty, addr)
 	}
-	// Write trie changes.
-	root, err = s.trie.CommitTo(dbw)
+	// Write trie changes. If the changes are accumulated in a trie node database,
+	// track the references from the account leaves to storage tries and code.
+	var onleaf trie.LeafCallback
+	if triedb, ok := dbw.(*trie.NodeDatabase); ok {
+		onleaf = func(leaf []byte, parent common.Hash) error {
+			var account Account
+			if err := rlp.DecodeBytes(leaf, &account); err != nil {
+				return nil
+			}
+			if account.Root != emptyState {
+				triedb.Reference(account.Root, parent)
+			}
+			if code := common.BytesToHash(account.CodeHash); code != emptyCode {
+				triedb.Reference(code, parent)
+			}
+			return nil
+		}
+	}
+	root, err = s.trie.CommitToWithCallback(dbw, onleaf)
 	log.Debug("Trie cache stats after commit", "misses", trie.CacheMisses(), "unloads", trie.CacheUnloads())
-	return root, err
+	if err != nil {
+		return root, err
+	}
+	// Link the changes into the snapshot tree on top of the pre-state
+	if s.snaps != nil {
+		if root != s.originalRoot {
+			if err := s.snaps.Update(root, s.originalRoot, destructs, accounts, storage); err != nil {
+				log.Warn("Failed to update snapshot tree", "from", s.originalRoot, "to", root, "err", err)
+			}
+		}
+		s.snap = s.snaps.Snapshot(root)
+	}
+	s.originalRoot = root
+	return root, nil
 }
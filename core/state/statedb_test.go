@@ -31,6 +31,7 @@ import (
 	check "gopkg.in/check.v1"
 
 	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/core/state/snapshot"
 	"github.com/meitu/go-ethereum/core/types"
 	"github.com/meitu/go-ethereum/ethdb"
 )
@@ -372,3 +373,104 @@ func (s *StateSuite) TestTouchDelete(c *check.C) {
 		c.Fatal("expected no dirty state object")
 	}
 }
+
+// Tests that committed state changes are tracked by the snapshot tree, and that
+// states opened on top of it read the same data as through the tries.
+func TestSnapshotReads(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	sdb := NewDatabase(db)
+	snaps := snapshot.New(db, sdb.TrieDB(), emptyState)
+
+	var (
+		addr1 = common.BytesToAddress([]byte{0x01})
+		addr2 = common.BytesToAddress([]byte{0x02})
+		key   = common.BytesToHash([]byte{0x0a})
+	)
+	state, _ := NewWithSnapshot(emptyState, sdb, snaps)
+	state.AddBalance(addr1, big.NewInt(42))
+	state.SetState(addr1, key, common.BytesToHash([]byte{0x01}))
+	state.AddBalance(addr2, big.NewInt(1))
+	root1, _ := state.CommitTo(sdb.TrieDB(), false)
+
+	if snaps.Snapshot(root1) == nil {
+		t.Fatalf("snapshot for committed root missing")
+	}
+	// Wipe addr1 and recreate it without the storage slot
+	state, _ = NewWithSnapshot(root1, sdb, snaps)
+	state.Suicide(addr1)
+	state.Finalise(false)
+	state.AddBalance(addr1, big.NewInt(7))
+	state.SetState(addr2, key, common.BytesToHash([]byte{0x02}))
+	root2, _ := state.CommitTo(sdb.TrieDB(), false)
+
+	for _, root := range []common.Hash{root1, root2} {
+		snapState, _ := NewWithSnapshot(root, sdb, snaps)
+		trieState, _ := New(root, sdb)
+		for _, addr := range []common.Address{addr1, addr2} {
+			if have, want := snapState.GetBalance(addr), trieState.GetBalance(addr); have.Cmp(want) != 0 {
+				t.Errorf("root %x: balance mismatch for %x: have %v, want %v", root, addr, have, want)
+			}
+			if have, want := snapState.GetState(addr, key), trieState.GetState(addr, key); have != want {
+				t.Errorf("root %x: slot mismatch for %x: have %x, want %x", root, addr, have, want)
+			}
+		}
+	}
+}
+
+// Tests that changes to the dpos context made through the state are reverted
+// along with the state by a single snapshot.
+func TestDposSnapshot(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	state, _ := New(common.Hash{}, NewDatabase(db))
+
+	var (
+		candidate = common.BytesToAddress([]byte{0x01})
+		delegator = common.BytesToAddress([]byte{0x02})
+	)
+	if err := state.BecomeCandidate(candidate); err != ErrNoDposContext {
+		t.Fatalf("missing dpos context error mismatch: have %v, want %v", err, ErrNoDposContext)
+	}
+	dposContext, _ := types.NewDposContext(db)
+	state.SetDposContext(dposContext)
+	empty := dposContext.Root()
+
+	snap := state.Snapshot()
+	state.AddBalance(delegator, big.NewInt(1))
+	if err := state.BecomeCandidate(candidate); err != nil {
+		t.Fatalf("failed to login candidate: %v", err)
+	}
+	loggedIn := dposContext.Root()
+	epoch := dposContext.EpochTrie()
+
+	nested := state.Snapshot()
+	if err := state.Delegate(delegator, candidate); err != nil {
+		t.Fatalf("failed to delegate: %v", err)
+	}
+	if err := state.KickoutCandidate(candidate); err != nil {
+		t.Fatalf("failed to kick out candidate: %v", err)
+	}
+	if err := state.UnDelegate(delegator, delegator); err == nil {
+		t.Fatalf("undelegated from non-candidate")
+	}
+	if dposContext.Root() == loggedIn {
+		t.Fatalf("delegation not applied")
+	}
+	state.RevertToSnapshot(nested)
+	if root := dposContext.Root(); root != loggedIn {
+		t.Fatalf("nested revert mismatch: have %x, want %x", root, loggedIn)
+	}
+	if vote := dposContext.VoteTrie().Get(delegator.Bytes()); vote != nil {
+		t.Fatalf("reverted vote persisted: %x", vote)
+	}
+	// Only the changed tries are journaled and restored
+	if dposContext.EpochTrie() != epoch {
+		t.Fatalf("unchanged epoch trie replaced")
+	}
+	state.RevertToSnapshot(snap)
+	if root := dposContext.Root(); root != empty {
+		t.Fatalf("revert mismatch: have %x, want %x", root, empty)
+	}
+	if balance := state.GetBalance(delegator); balance.Sign() != 0 {
+		t.Fatalf("reverted balance persisted: %v", balance)
+	}
+}
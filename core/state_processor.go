@@ -67,7 +67,10 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 	if p.config.DAOForkSupport && p.config.DAOForkBlock != nil && p.config.DAOForkBlock.Cmp(block.Number()) == 0 {
 		misc.ApplyDAOHardFork(statedb)
 	}
-	// Set block dpos context
+	// The dpos context of the parent must be attached by the caller
+	if block.DposCtx() == nil {
+		return nil, nil, nil, ErrNoDposContext
+	}
 	// Iterate over and process the individual transactions
 	for i, tx := range block.Transactions() {
 		statedb.Prepare(tx.Hash(), block.Hash(), i)
@@ -88,7 +91,7 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB, cfg
 // and uses the input parameters for its environment. It returns the receipt
 // for the transaction, gas used and an error if the transaction failed,
 // indicating the block was invalid.
-func ApplyTransaction(config *params.ChainConfig, dposContext *types.DposContext, bc *BlockChain, coinbase *common.Address, gp *GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, usedGas *big.Int, cfg vm.Config) (*types.Receipt, *big.Int, error) {
+func ApplyTransaction(config *params.ChainConfig, dposContext *types.DposContext, bc ChainContext, coinbase *common.Address, gp *GasPool, statedb *state.StateDB, header *types.Header, tx *types.Transaction, usedGas *big.Int, cfg vm.Config) (*types.Receipt, *big.Int, error) {
 	msg, err := tx.AsMessage(types.MakeSigner(config, header.Number))
 	if err != nil {
 		return nil, nil, err
@@ -98,6 +101,15 @@ func ApplyTransaction(config *params.ChainConfig, dposContext *types.DposContext
 	if err != nil {
 		return nil, nil, err
 	}
+	if err = types.ValidateEncoding(config, header.Number, tx); err != nil {
+		return nil, nil, err
+	}
+	if tx.Type() == types.MultiTransfer && !config.IsMultiTransfer(header.Number) {
+		return nil, nil, ErrMultiTransferNotActive
+	}
+
+	// Attach the dpos context to the state so that both are reverted together
+	statedb.SetDposContext(dposContext)
 
 	// Create a new context to be used in the EVM environment
 	context := NewEVMContext(msg, header, bc, coinbase)
@@ -109,9 +121,22 @@ func ApplyTransaction(config *params.ChainConfig, dposContext *types.DposContext
 	if err != nil {
 		return nil, nil, err
 	}
-	if msg.Type() != types.Binary {
-		if err = applyDposMessage(dposContext, msg); err != nil {
-			return nil, nil, err
+	if msg.Type() != types.Binary && msg.Type() != types.MultiTransfer {
+		if config.IsDposRevert(header.Number) {
+			// Apply the dpos operation unless the transaction failed, failing it
+			// instead of leaving the operation half done if it's invalid
+			if !failed {
+				snapshot := statedb.Snapshot()
+				if err := applyDposMessage(statedb, msg); err != nil {
+					statedb.RevertToSnapshot(snapshot)
+					failed = true
+				}
+			}
+		} else {
+			// Before the fork, operations apply whatever the outcome of their
+			// transaction and their errors are ignored. Unknown types were
+			// rejected by Validate before execution.
+			applyDposMessage(statedb, msg)
 		}
 	}
 
@@ -130,7 +155,7 @@ func ApplyTransaction(config *params.ChainConfig, dposContext *types.DposContext
 	receipt.TxHash = tx.Hash()
 	receipt.GasUsed = new(big.Int).Set(gas)
 	// if the transaction created a contract, store the creation address in the receipt.
-	if msg.To() == nil {
+	if msg.To() == nil && msg.Type() != types.MultiTransfer {
 		receipt.ContractAddress = crypto.CreateAddress(vmenv.Context.Origin, tx.Nonce())
 	}
 
@@ -141,18 +166,17 @@ func ApplyTransaction(config *params.ChainConfig, dposContext *types.DposContext
 	return receipt, gas, err
 }
 
-func applyDposMessage(dposContext *types.DposContext, msg types.Message) error {
+func applyDposMessage(statedb *state.StateDB, msg types.Message) error {
 	switch msg.Type() {
 	case types.LoginCandidate:
-		dposContext.BecomeCandidate(msg.From())
+		return statedb.BecomeCandidate(msg.From())
 	case types.LogoutCandidate:
-		dposContext.KickoutCandidate(msg.From())
+		return statedb.KickoutCandidate(msg.From())
 	case types.Delegate:
-		dposContext.Delegate(msg.From(), *(msg.To()))
+		return statedb.Delegate(msg.From(), *(msg.To()))
 	case types.UnDelegate:
-		dposContext.UnDelegate(msg.From(), *(msg.To()))
+		return statedb.UnDelegate(msg.From(), *(msg.To()))
 	default:
 		return types.ErrInvalidType
 	}
-	return nil
 }
@@ -0,0 +1,164 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/core/state"
+	"github.com/meitu/go-ethereum/core/types"
+	"github.com/meitu/go-ethereum/core/vm"
+	"github.com/meitu/go-ethereum/crypto"
+	"github.com/meitu/go-ethereum/ethdb"
+	"github.com/meitu/go-ethereum/params"
+)
+
+// Tests that failed and reverted dpos transactions are included as failed ones,
+// leaving the dpos context untouched, while successful ones are applied.
+func TestApplyDposTransactions(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	signer := types.HomesteadSigner{}
+
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	statedb.AddBalance(sender, big.NewInt(1000000000))
+
+	// Create a plain candidate and a contract one reverting all calls
+	var (
+		candidate = common.Address{0x01}
+		reverter  = common.Address{0x02}
+		stranger  = common.Address{0x03}
+	)
+	statedb.SetCode(reverter, common.Hex2Bytes("60006000fd"))
+
+	dposContext, _ := types.NewDposContext(db)
+	dposContext.BecomeCandidate(candidate)
+	dposContext.BecomeCandidate(reverter)
+
+	tests := []struct {
+		txType types.TxType
+		to     common.Address
+		failed bool
+		vote   common.Address
+	}{
+		{types.Delegate, candidate, false, candidate}, // Vote for a candidate
+		{types.Delegate, stranger, true, candidate},   // Vote for a non-candidate fails
+		{types.Delegate, reverter, true, candidate},   // Vote reverted by the candidate contract
+		{types.UnDelegate, reverter, true, candidate}, // Withdraw a vote never cast fails
+		{types.UnDelegate, candidate, false, common.Address{}},
+	}
+	header := &types.Header{Number: big.NewInt(1), Time: new(big.Int), Difficulty: new(big.Int), GasLimit: big.NewInt(1000000)}
+	for i, tt := range tests {
+		tx, _ := types.SignTx(types.NewTransaction(tt.txType, uint64(i), tt.to, new(big.Int), big.NewInt(100000), common.Big1, nil), signer, key)
+
+		root := dposContext.Root()
+		statedb.Prepare(tx.Hash(), common.Hash{}, i)
+		receipt, gas, err := ApplyTransaction(params.TestChainConfig, dposContext, nil, &common.Address{}, new(GasPool).AddGas(header.GasLimit), statedb, header, tx, new(big.Int), vm.Config{})
+		if err != nil {
+			t.Fatalf("test %d: failed to apply transaction: %v", i, err)
+		}
+		if failed := receipt.Status == types.ReceiptStatusFailed; failed != tt.failed {
+			t.Errorf("test %d: failure mismatch: have %v, want %v", i, failed, tt.failed)
+		}
+		if gas.Uint64() < params.TxGas {
+			t.Errorf("test %d: gas use too low: have %v, want at least %d", i, gas, params.TxGas)
+		}
+		if vote := dposContext.VoteTrie().Get(sender.Bytes()); common.BytesToAddress(vote) != tt.vote {
+			t.Errorf("test %d: vote mismatch: have %x, want %x", i, vote, tt.vote)
+		}
+		if tt.failed && dposContext.Root() != root {
+			t.Errorf("test %d: failed transaction modified the dpos context", i)
+		}
+	}
+}
+
+// Tests that before the dpos revert fork, dpos operations are applied whatever
+// the outcome of their transactions, their errors ignored.
+func TestApplyDposTransactionsBeforeFork(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	signer := types.HomesteadSigner{}
+
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	statedb.AddBalance(sender, big.NewInt(1000000000))
+
+	var (
+		reverter = common.Address{0x02}
+		stranger = common.Address{0x03}
+	)
+	statedb.SetCode(reverter, common.Hex2Bytes("60006000fd"))
+
+	dposContext, _ := types.NewDposContext(db)
+	dposContext.BecomeCandidate(reverter)
+
+	config := *params.TestChainConfig
+	config.DposRevertBlock = big.NewInt(2)
+
+	tests := []struct {
+		to     common.Address
+		failed bool
+		vote   common.Address
+	}{
+		{reverter, true, reverter},  // Vote applied although reverted by the candidate contract
+		{stranger, false, reverter}, // Vote for a non-candidate ignored
+	}
+	header := &types.Header{Number: big.NewInt(1), Time: new(big.Int), Difficulty: new(big.Int), GasLimit: big.NewInt(1000000)}
+	for i, tt := range tests {
+		tx, _ := types.SignTx(types.NewTransaction(types.Delegate, uint64(i), tt.to, new(big.Int), big.NewInt(100000), common.Big1, nil), signer, key)
+
+		statedb.Prepare(tx.Hash(), common.Hash{}, i)
+		receipt, _, err := ApplyTransaction(&config, dposContext, nil, &common.Address{}, new(GasPool).AddGas(header.GasLimit), statedb, header, tx, new(big.Int), vm.Config{})
+		if err != nil {
+			t.Fatalf("test %d: failed to apply transaction: %v", i, err)
+		}
+		if failed := receipt.Status == types.ReceiptStatusFailed; failed != tt.failed {
+			t.Errorf("test %d: failure mismatch: have %v, want %v", i, failed, tt.failed)
+		}
+		if vote := dposContext.VoteTrie().Get(sender.Bytes()); common.BytesToAddress(vote) != tt.vote {
+			t.Errorf("test %d: vote mismatch: have %x, want %x", i, vote, tt.vote)
+		}
+	}
+}
+
+// Tests that transactions of an unknown type invalidate the block both before
+// and after the dpos revert fork, instead of turning into failed receipts.
+func TestApplyUnknownTransactionType(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+
+	tx, _ := types.SignTx(types.NewTransaction(types.MultiTransfer+1, 0, common.Address{0x01}, new(big.Int), big.NewInt(100000), common.Big1, nil), types.HomesteadSigner{}, key)
+	header := &types.Header{Number: big.NewInt(1), Time: new(big.Int), Difficulty: new(big.Int), GasLimit: big.NewInt(1000000)}
+
+	for _, revertBlock := range []*big.Int{big.NewInt(0), big.NewInt(2)} {
+		db, _ := ethdb.NewMemDatabase()
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+		statedb.AddBalance(sender, big.NewInt(1000000000))
+		dposContext, _ := types.NewDposContext(db)
+
+		config := *params.TestChainConfig
+		config.DposRevertBlock = revertBlock
+
+		_, _, err := ApplyTransaction(&config, dposContext, nil, &common.Address{}, new(GasPool).AddGas(header.GasLimit), statedb, header, tx, new(big.Int), vm.Config{})
+		if err != types.ErrInvalidType {
+			t.Errorf("revert fork at %v: error mismatch: have %v, want %v", revertBlock, err, types.ErrInvalidType)
+		}
+	}
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/meitu/go-ethereum/common"
 	"github.com/meitu/go-ethereum/common/math"
+	"github.com/meitu/go-ethereum/core/types"
 	"github.com/meitu/go-ethereum/core/vm"
 	"github.com/meitu/go-ethereum/log"
 	"github.com/meitu/go-ethereum/params"
@@ -57,6 +58,7 @@ type StateTransition struct {
 	initialGas *big.Int
 	value      *big.Int
 	data       []byte
+	transfers  []*types.Transfer
 	state      vm.StateDB
 	evm        *vm.EVM
 }
@@ -76,17 +78,28 @@ type Message interface {
 	Data() []byte
 }
 
+// transferMessage is implemented by messages paying several recipients at once,
+// such as multi-transfer transactions.
+type transferMessage interface {
+	Transfers() []*types.Transfer
+}
+
 // IntrinsicGas computes the 'intrinsic gas' for a message
-// with the given data.
+// with the given data, paying the given number of transfer
+// recipients.
 //
 // TODO convert to uint64
-func IntrinsicGas(data []byte, contractCreation, homestead bool) *big.Int {
+func IntrinsicGas(data []byte, transfers int, contractCreation, homestead bool) *big.Int {
 	igas := new(big.Int)
 	if contractCreation && homestead {
 		igas.SetUint64(params.TxGasContractCreation)
 	} else {
 		igas.SetUint64(params.TxGas)
 	}
+	if transfers > 0 {
+		m := new(big.Int).SetUint64(params.TxTransferGas)
+		igas.Add(igas, m.Mul(m, big.NewInt(int64(transfers))))
+	}
 	if len(data) > 0 {
 		var nz int64
 		for _, byt := range data {
@@ -106,6 +119,10 @@ func IntrinsicGas(data []byte, contractCreation, homestead bool) *big.Int {
 
 // NewStateTransition initialises and returns a new state transition object.
 func NewStateTransition(evm *vm.EVM, msg Message, gp *GasPool) *StateTransition {
+	var transfers []*types.Transfer
+	if m, ok := msg.(transferMessage); ok {
+		transfers = m.Transfers()
+	}
 	return &StateTransition{
 		gp:         gp,
 		evm:        evm,
@@ -114,6 +131,7 @@ func NewStateTransition(evm *vm.EVM, msg Message, gp *GasPool) *StateTransition
 		initialGas: new(big.Int),
 		value:      msg.Value(),
 		data:       msg.Data(),
+		transfers:  transfers,
 		state:      evm.StateDB,
 	}
 }
@@ -217,11 +235,11 @@ func (st *StateTransition) TransitionDb() (ret []byte, requiredGas, usedGas *big
 	sender := st.from() // err checked in preCheck
 
 	homestead := st.evm.ChainConfig().IsHomestead(st.evm.BlockNumber)
-	contractCreation := msg.To() == nil
+	contractCreation := msg.To() == nil && len(st.transfers) == 0
 
 	// Pay intrinsic gas
 	// TODO convert to uint64
-	intrinsicGas := IntrinsicGas(st.data, contractCreation, homestead)
+	intrinsicGas := IntrinsicGas(st.data, len(st.transfers), contractCreation, homestead)
 	if intrinsicGas.BitLen() > 64 {
 		return nil, nil, nil, false, vm.ErrOutOfGas
 	}
@@ -236,7 +254,11 @@ func (st *StateTransition) TransitionDb() (ret []byte, requiredGas, usedGas *big
 		// error.
 		vmerr error
 	)
-	if contractCreation {
+	if len(st.transfers) > 0 {
+		// Increment the nonce for the next transaction
+		st.state.SetNonce(sender.Address(), st.state.GetNonce(sender.Address())+1)
+		vmerr = st.transfer(sender)
+	} else if contractCreation {
 		ret, _, st.gas, vmerr = evm.Create(sender, st.data, st.gas, st.value)
 	} else {
 		// Increment the nonce for the next transaction
@@ -260,6 +282,37 @@ func (st *StateTransition) TransitionDb() (ret []byte, requiredGas, usedGas *big
 	return ret, requiredGas, st.gasUsed(), vmerr != nil, err
 }
 
+// transfer pays all the recipients of a multi-transfer message atomically. The
+// recipients' code isn't run, but creating an account costs as much as for a
+// call, and as for a call zero transfers to missing accounts create none. If any
+// transfer fails, all of them are reverted and the gas consumed.
+func (st *StateTransition) transfer(sender vm.AccountRef) error {
+	// The whole amount must be available, as with the value of a message
+	total := new(big.Int)
+	for _, t := range st.transfers {
+		total.Add(total, t.Value)
+	}
+	if !st.evm.Context.CanTransfer(st.state, sender.Address(), total) {
+		return vm.ErrInsufficientBalance
+	}
+	snapshot := st.state.Snapshot()
+	for _, t := range st.transfers {
+		if !st.state.Exist(t.To) {
+			if t.Value.Sign() == 0 {
+				continue
+			}
+			if err := st.useGas(params.CallNewAccountGas); err != nil {
+				st.state.RevertToSnapshot(snapshot)
+				st.gas = 0
+				return err
+			}
+			st.state.CreateAccount(t.To)
+		}
+		st.evm.Context.Transfer(st.state, sender.Address(), t.To, t.Value)
+	}
+	return nil
+}
+
 func (st *StateTransition) refundGas() {
 	// Return eth for remaining gas to the sender account,
 	// exchanged at the original rate.
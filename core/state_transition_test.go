@@ -0,0 +1,130 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/core/state"
+	"github.com/meitu/go-ethereum/core/types"
+	"github.com/meitu/go-ethereum/core/vm"
+	"github.com/meitu/go-ethereum/crypto"
+	"github.com/meitu/go-ethereum/ethdb"
+	"github.com/meitu/go-ethereum/params"
+)
+
+// Tests that multi-transfer messages pay all their recipients, or none of them
+// if running out of gas.
+func TestMultiTransfer(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	signer := types.HomesteadSigner{}
+
+	existing, fresh := common.Address{1}, common.Address{2}
+	transfers := []*types.Transfer{
+		{To: existing, Value: big.NewInt(100)},
+		{To: fresh, Value: big.NewInt(200)},
+	}
+	intrinsic := params.TxGas + 2*params.TxTransferGas
+
+	tests := []struct {
+		gas    uint64
+		funds  int64
+		failed bool
+		err    error
+		used   uint64
+	}{
+		// Enough gas to create the fresh recipient
+		{gas: intrinsic + params.CallNewAccountGas, funds: 1000000, used: intrinsic + params.CallNewAccountGas},
+		// Not enough gas to create the fresh recipient, all gas is consumed
+		{gas: intrinsic + 1000, funds: 1000000, failed: true, used: intrinsic + 1000},
+		// Not enough balance for the transferred amounts
+		{gas: intrinsic + params.CallNewAccountGas, funds: int64(intrinsic+params.CallNewAccountGas) + 299, err: vm.ErrInsufficientBalance},
+	}
+	for i, tt := range tests {
+		db, _ := ethdb.NewMemDatabase()
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+		statedb.AddBalance(sender, big.NewInt(tt.funds))
+		statedb.AddBalance(existing, big.NewInt(1))
+
+		tx, _ := types.SignTx(types.NewMultiTransfer(0, transfers, new(big.Int).SetUint64(tt.gas), common.Big1), signer, key)
+		msg, _ := tx.AsMessage(signer)
+
+		coinbase := common.Address{0xff}
+		header := &types.Header{Number: big.NewInt(1), Time: new(big.Int), Difficulty: new(big.Int), GasLimit: big.NewInt(1000000)}
+		evm := vm.NewEVM(NewEVMContext(msg, header, nil, &coinbase), statedb, params.TestChainConfig, vm.Config{})
+
+		_, used, failed, err := ApplyMessage(evm, msg, new(GasPool).AddGas(header.GasLimit))
+		if err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if failed != tt.failed || used.Uint64() != tt.used {
+			t.Errorf("test %d: result mismatch: have failed %v, used %v, want %v, %d", i, failed, used, tt.failed, tt.used)
+		}
+		want := map[common.Address]int64{existing: 101, fresh: 200}
+		if tt.failed {
+			want = map[common.Address]int64{existing: 1, fresh: 0}
+		}
+		for addr, balance := range want {
+			if have := statedb.GetBalance(addr); have.Int64() != balance {
+				t.Errorf("test %d: balance mismatch for %x: have %v, want %d", i, addr, have, balance)
+			}
+		}
+		if nonce := statedb.GetNonce(sender); nonce != 1 {
+			t.Errorf("test %d: nonce mismatch: have %d, want 1", i, nonce)
+		}
+	}
+}
+
+// Tests that zero transfers to missing accounts neither create them nor pay for
+// their creation, as with calls.
+func TestMultiTransferZeroValue(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	signer := types.HomesteadSigner{}
+
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	statedb.AddBalance(sender, big.NewInt(1000000))
+
+	fresh := common.Address{2}
+	intrinsic := params.TxGas + params.TxTransferGas
+
+	tx, _ := types.SignTx(types.NewMultiTransfer(0, []*types.Transfer{{To: fresh, Value: new(big.Int)}}, new(big.Int).SetUint64(intrinsic), common.Big1), signer, key)
+	msg, _ := tx.AsMessage(signer)
+
+	coinbase := common.Address{0xff}
+	header := &types.Header{Number: big.NewInt(1), Time: new(big.Int), Difficulty: new(big.Int), GasLimit: big.NewInt(1000000)}
+	evm := vm.NewEVM(NewEVMContext(msg, header, nil, &coinbase), statedb, params.TestChainConfig, vm.Config{})
+
+	_, used, failed, err := ApplyMessage(evm, msg, new(GasPool).AddGas(header.GasLimit))
+	if err != nil {
+		t.Fatalf("failed to apply message: %v", err)
+	}
+	if failed || used.Uint64() != intrinsic {
+		t.Errorf("result mismatch: have failed %v, used %v, want %v, %d", failed, used, false, intrinsic)
+	}
+	if statedb.Exist(fresh) {
+		t.Errorf("zero transfer created the missing recipient")
+	}
+}
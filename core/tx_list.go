@@ -456,13 +456,16 @@ func (l *txPricedList) Cap(threshold *big.Int, local *accountSet) types.Transact
 }
 
 // Underpriced checks whether a transaction is cheaper than (or as cheap as) the
-// lowest priced transaction currently being tracked.
-func (l *txPricedList) Underpriced(tx *types.Transaction, local *accountSet) bool {
+// lowest priced transaction currently being tracked. If governance is set, DPoS
+// governance transactions are disregarded as they cannot be evicted.
+func (l *txPricedList) Underpriced(tx *types.Transaction, local *accountSet, governance bool) bool {
 	// Local transactions cannot be underpriced
 	if local.containsTx(tx) {
 		return false
 	}
-	// Discard stale price points if found at the heap start
+	// Discard stale price points if found at the heap start, setting aside the
+	// protected governance transactions
+	var save types.Transactions
 	for len(*l.items) > 0 {
 		head := []*types.Transaction(*l.items)[0]
 		if _, ok := (*l.all)[head.Hash()]; !ok {
@@ -470,10 +473,22 @@ func (l *txPricedList) Underpriced(tx *types.Transaction, local *accountSet) boo
 			heap.Pop(l.items)
 			continue
 		}
+		if governance && IsGovernanceTx(head) {
+			save = append(save, heap.Pop(l.items).(*types.Transaction))
+			continue
+		}
 		break
 	}
+	defer func() {
+		for _, tx := range save {
+			heap.Push(l.items, tx)
+		}
+	}()
 	// Check if the transaction is underpriced or not
 	if len(*l.items) == 0 {
+		if len(save) > 0 {
+			return true // Only protected transactions left, nothing to make room with
+		}
 		log.Error("Pricing query for empty pool") // This cannot happen, print to catch programming errors
 		return false
 	}
@@ -482,10 +497,11 @@ func (l *txPricedList) Underpriced(tx *types.Transaction, local *accountSet) boo
 }
 
 // Discard finds a number of most underpriced transactions, removes them from the
-// priced list and returns them for further removal from the entire pool.
-func (l *txPricedList) Discard(count int, local *accountSet) types.Transactions {
+// priced list and returns them for further removal from the entire pool. If
+// governance is set, DPoS governance transactions are kept just as local ones.
+func (l *txPricedList) Discard(count int, local *accountSet, governance bool) types.Transactions {
 	drop := make(types.Transactions, 0, count) // Remote underpriced transactions to drop
-	save := make(types.Transactions, 0, 64)    // Local underpriced transactions to keep
+	save := make(types.Transactions, 0, 64)    // Local or governance underpriced transactions to keep
 
 	for len(*l.items) > 0 && count > 0 {
 		// Discard stale transactions if found during cleanup
@@ -494,8 +510,8 @@ func (l *txPricedList) Discard(count int, local *accountSet) types.Transactions
 			l.stales--
 			continue
 		}
-		// Non stale transaction found, discard unless local
-		if local.containsTx(tx) {
+		// Non stale transaction found, discard unless local or protected
+		if local.containsTx(tx) || (governance && IsGovernanceTx(tx)) {
 			save = append(save, tx)
 		} else {
 			drop = append(drop, tx)
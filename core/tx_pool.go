@@ -78,6 +78,10 @@ var (
 	// than some meaningful limit a user might use. This is not a consensus error
 	// making the transaction invalid, rather a DOS protection.
 	ErrOversizedData = errors.New("oversized data")
+
+	// ErrGovernanceSlotsFull is returned if a remote DPoS governance transaction
+	// arrives at a full pool with all the slots reserved for them already taken.
+	ErrGovernanceSlotsFull = errors.New("governance transaction slots full")
 )
 
 var (
@@ -101,6 +105,10 @@ var (
 	// General tx metrics
 	invalidTxCounter     = metrics.NewCounter("txpool/invalid")
 	underpricedTxCounter = metrics.NewCounter("txpool/underpriced")
+
+	// Metrics for the DPoS governance transactions
+	governanceTxCounter        = metrics.NewCounter("txpool/governance/accepted")
+	governanceProtectedCounter = metrics.NewCounter("txpool/governance/protected") // Kept despite being underpriced
 )
 
 // TxStatus is the current status of a transaction as seen py the pool.
@@ -138,6 +146,9 @@ type TxPoolConfig struct {
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+
+	GovernanceSlots   uint64 // Number of DPoS governance transactions exempt from price based eviction, more are rejected by a full pool
+	GovernanceReserve uint64 // Percentage of the block gas limit reserved for DPoS governance transactions
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -155,6 +166,9 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	GlobalQueue:  1024,
 
 	Lifetime: 3 * time.Hour,
+
+	GovernanceSlots:   256,
+	GovernanceReserve: 10,
 }
 
 // sanitize checks the provided user configurations and changes anything that's
@@ -173,6 +187,10 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool price bump", "provided", conf.PriceBump, "updated", DefaultTxPoolConfig.PriceBump)
 		conf.PriceBump = DefaultTxPoolConfig.PriceBump
 	}
+	if conf.GovernanceReserve > 100 {
+		log.Warn("Sanitizing invalid txpool governance reserve", "provided", conf.GovernanceReserve, "updated", DefaultTxPoolConfig.GovernanceReserve)
+		conf.GovernanceReserve = DefaultTxPoolConfig.GovernanceReserve
+	}
 	return conf
 }
 
@@ -198,6 +216,7 @@ type TxPool struct {
 	currentState  *state.StateDB      // Current state in the blockchain head
 	pendingState  *state.ManagedState // Pending state tracking virtual nonces
 	currentMaxGas *big.Int            // Current gas limit for transaction caps
+	pendingNumber *big.Int            // Number of the block the pending transactions go in
 
 	locals  *accountSet // Set of local transaction to exepmt from evicion rules
 	journal *txJournal  // Journal of local transaction to back up to disk
@@ -208,6 +227,8 @@ type TxPool struct {
 	all     map[common.Hash]*types.Transaction // All transactions to allow lookups
 	priced  *txPricedList                      // All transactions sorted by price
 
+	governance uint64 // Number of DPoS governance transactions among all the tracked ones
+
 	wg sync.WaitGroup // for shutdown sync
 
 	homestead bool
@@ -330,9 +351,7 @@ func (pool *TxPool) loop() {
 		case <-journal.C:
 			if pool.journal != nil {
 				pool.mu.Lock()
-				if err := pool.journal.rotate(pool.local()); err != nil {
-					log.Warn("Failed to rotate local tx journal", "err", err)
-				}
+				pool.rejournal()
 				pool.mu.Unlock()
 			}
 		}
@@ -410,6 +429,7 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	pool.currentState = statedb
 	pool.pendingState = state.ManageState(statedb)
 	pool.currentMaxGas = newHead.GasLimit
+	pool.pendingNumber = new(big.Int).Add(newHead.Number, big.NewInt(1))
 
 	// Inject any transactions discarded due to reorgs
 	log.Debug("Reinjecting stale transactions", "count", len(reinject))
@@ -504,6 +524,69 @@ func (pool *TxPool) stats() (int, int) {
 	return pending, queued
 }
 
+// StatsByType retrieves the number of pending and queued (non-executable)
+// transactions of each transaction type.
+func (pool *TxPool) StatsByType() (map[types.TxType]int, map[types.TxType]int) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	pending := make(map[types.TxType]int)
+	for _, list := range pool.pending {
+		for _, tx := range list.txs.items {
+			pending[tx.Type()]++
+		}
+	}
+	queued := make(map[types.TxType]int)
+	for _, list := range pool.queue {
+		for _, tx := range list.txs.items {
+			queued[tx.Type()]++
+		}
+	}
+	return pending, queued
+}
+
+// GovernanceReserve returns the percentage of the block gas limit reserved for
+// DPoS governance transactions.
+func (pool *TxPool) GovernanceReserve() uint64 {
+	return pool.config.GovernanceReserve
+}
+
+// track adds a transaction to the set of all known ones, counting the DPoS
+// governance transactions along.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) track(tx *types.Transaction) {
+	hash := tx.Hash()
+	if pool.all[hash] == nil && IsGovernanceTx(tx) {
+		pool.governance++
+	}
+	pool.all[hash] = tx
+}
+
+// untrack removes a transaction from the set of all known ones, uncounting it if
+// it's a DPoS governance transaction.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) untrack(hash common.Hash) {
+	if tx := pool.all[hash]; tx != nil {
+		if IsGovernanceTx(tx) {
+			pool.governance--
+		}
+		delete(pool.all, hash)
+	}
+}
+
+// IsGovernanceTx checks whether a transaction is a DPoS governance operation,
+// i.e. a candidate login or logout or a (un)delegation, as opposed to a plain
+// transfer or contract call.
+func IsGovernanceTx(tx *types.Transaction) bool {
+	switch tx.Type() {
+	case types.LoginCandidate, types.LogoutCandidate, types.Delegate, types.UnDelegate:
+		return true
+	}
+	return false
+}
+
 // Content retrieves the data content of the transaction pool, returning all the
 // pending as well as queued transactions, grouped by account and sorted by nonce.
 func (pool *TxPool) Content() (map[common.Address]types.Transactions, map[common.Address]types.Transactions) {
@@ -567,6 +650,14 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if pool.currentMaxGas.Cmp(tx.Gas()) < 0 {
 		return ErrGasLimit
 	}
+	// Reject the unknown transaction types, which invalidate any block
+	if !tx.Type().Valid() {
+		return types.ErrInvalidType
+	}
+	// Make sure the transaction is encoded the way the next block expects
+	if err := types.ValidateEncoding(pool.chainconfig, pool.pendingNumber, tx); err != nil {
+		return err
+	}
 	// Make sure the transaction is signed properly
 	from, err := types.Sender(pool.signer, tx)
 	if err != nil {
@@ -581,12 +672,23 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if pool.currentState.GetNonce(from) > tx.Nonce() {
 		return ErrNonceTooLow
 	}
+	// Multi-transfer transactions must be enabled, pay at least one recipient and
+	// carry no value of their own
+	if tx.Type() == types.MultiTransfer {
+		if !pool.chainconfig.IsMultiTransfer(pool.pendingNumber) {
+			return ErrMultiTransferNotActive
+		}
+		if err := tx.Validate(); err != nil {
+			return err
+		}
+	}
 	// Transactor should have enough funds to cover the costs
-	// cost == V + GP * GL
+	// cost == V + GP * GL + sum of the transferred amounts
 	if pool.currentState.GetBalance(from).Cmp(tx.Cost()) < 0 {
 		return ErrInsufficientFunds
 	}
-	intrGas := IntrinsicGas(tx.Data(), tx.To() == nil, pool.homestead)
+	transfers := len(tx.Transfers())
+	intrGas := IntrinsicGas(tx.Data(), transfers, tx.To() == nil && transfers == 0, pool.homestead)
 	if tx.Gas().Cmp(intrGas) < 0 {
 		return ErrIntrinsicGas
 	}
@@ -616,14 +718,29 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 	}
 	// If the transaction pool is full, discard underpriced transactions
 	if uint64(len(pool.all)) >= pool.config.GlobalSlots+pool.config.GlobalQueue {
+		// Governance transactions are exempt from pricing as long as they fit
+		// into their reserved slots. Once those are full, new ones are rejected
+		// as the protected ones can't make room for them.
+		var (
+			governance = pool.governance
+			protect    = governance <= pool.config.GovernanceSlots
+			reserved   = IsGovernanceTx(tx) && governance+1 <= pool.config.GovernanceSlots
+		)
+		if IsGovernanceTx(tx) && !reserved && !local {
+			log.Trace("Discarding governance transaction beyond reserved slots", "hash", hash)
+			return false, ErrGovernanceSlotsFull
+		}
 		// If the new transaction is underpriced, don't accept it
-		if pool.priced.Underpriced(tx, pool.locals) {
-			log.Trace("Discarding underpriced transaction", "hash", hash, "price", tx.GasPrice())
-			underpricedTxCounter.Inc(1)
-			return false, ErrUnderpriced
+		if pool.priced.Underpriced(tx, pool.locals, protect) {
+			if !reserved {
+				log.Trace("Discarding underpriced transaction", "hash", hash, "price", tx.GasPrice())
+				underpricedTxCounter.Inc(1)
+				return false, ErrUnderpriced
+			}
+			governanceProtectedCounter.Inc(1)
 		}
 		// New transaction is better than our worse ones, make room for it
-		drop := pool.priced.Discard(len(pool.all)-int(pool.config.GlobalSlots+pool.config.GlobalQueue-1), pool.locals)
+		drop := pool.priced.Discard(len(pool.all)-int(pool.config.GlobalSlots+pool.config.GlobalQueue-1), pool.locals, protect)
 		for _, tx := range drop {
 			log.Trace("Discarding freshly underpriced transaction", "hash", tx.Hash(), "price", tx.GasPrice())
 			underpricedTxCounter.Inc(1)
@@ -641,14 +758,18 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		}
 		// New transaction is better, replace old one
 		if old != nil {
-			delete(pool.all, old.Hash())
+			pool.untrack(old.Hash())
 			pool.priced.Removed()
 			pendingReplaceCounter.Inc(1)
 		}
-		pool.all[tx.Hash()] = tx
+		pool.track(tx)
 		pool.priced.Put(tx)
 		pool.journalTx(from, tx)
 
+		if IsGovernanceTx(tx) {
+			governanceTxCounter.Inc(1)
+		}
+
 		log.Trace("Pooled new executable transaction", "hash", hash, "from", from, "to", tx.To())
 
 		// We've directly injected a replacement transaction, notify subsystems
@@ -667,6 +788,9 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 	}
 	pool.journalTx(from, tx)
 
+	if IsGovernanceTx(tx) {
+		governanceTxCounter.Inc(1)
+	}
 	log.Trace("Pooled new future transaction", "hash", hash, "from", from, "to", tx.To())
 	return replace, nil
 }
@@ -688,12 +812,15 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 	}
 	// Discard any previous transaction and mark this
 	if old != nil {
-		delete(pool.all, old.Hash())
+		pool.untrack(old.Hash())
 		pool.priced.Removed()
 		queuedReplaceCounter.Inc(1)
 	}
-	pool.all[hash] = tx
-	pool.priced.Put(tx)
+	// Demoted pending transactions are already tracked
+	if pool.all[hash] == nil {
+		pool.track(tx)
+		pool.priced.Put(tx)
+	}
 	return old != nil, nil
 }
 
@@ -722,7 +849,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	inserted, old := list.Add(tx, pool.config.PriceBump)
 	if !inserted {
 		// An older transaction was better, discard this
-		delete(pool.all, hash)
+		pool.untrack(hash)
 		pool.priced.Removed()
 
 		pendingDiscardCounter.Inc(1)
@@ -730,14 +857,14 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	}
 	// Otherwise discard any previous transaction and mark this
 	if old != nil {
-		delete(pool.all, old.Hash())
+		pool.untrack(old.Hash())
 		pool.priced.Removed()
 
 		pendingReplaceCounter.Inc(1)
 	}
 	// Failsafe to work around direct pending inserts (tests)
 	if pool.all[hash] == nil {
-		pool.all[hash] = tx
+		pool.track(tx)
 		pool.priced.Put(tx)
 	}
 	// Set the potentially new pending nonce and notify any subsystems of the new tx
@@ -857,6 +984,99 @@ func (pool *TxPool) Get(hash common.Hash) *types.Transaction {
 	return pool.all[hash]
 }
 
+// Lookup returns a transaction contained in the pool along with whether it is
+// pending or queued and a short human readable reason for the latter. If the
+// pool doesn't contain the transaction, nil and TxStatusUnknown are returned.
+func (pool *TxPool) Lookup(hash common.Hash) (*types.Transaction, TxStatus, string) {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	tx := pool.all[hash]
+	if tx == nil {
+		return nil, TxStatusUnknown, ""
+	}
+	from, _ := types.Sender(pool.signer, tx) // already validated
+	if list := pool.pending[from]; list != nil && list.txs.items[tx.Nonce()] != nil {
+		return tx, TxStatusPending, "executable"
+	}
+	// The transaction is queued, find out what's holding it back
+	if next := pool.pendingState.GetNonce(from); tx.Nonce() > next {
+		return tx, TxStatusQueued, fmt.Sprintf("nonce gap, next executable nonce is %d", next)
+	}
+	if pool.currentState.GetBalance(from).Cmp(tx.Cost()) < 0 {
+		return tx, TxStatusQueued, ErrInsufficientFunds.Error()
+	}
+	return tx, TxStatusQueued, "awaiting promotion"
+}
+
+// ContentFrom retrieves the data content of the transaction pool of a single
+// account, returning its pending as well as queued transactions sorted by nonce.
+func (pool *TxPool) ContentFrom(addr common.Address) (types.Transactions, types.Transactions) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	var pending, queued types.Transactions
+	if list := pool.pending[addr]; list != nil {
+		pending = list.Flatten()
+	}
+	if list := pool.queue[addr]; list != nil {
+		queued = list.Flatten()
+	}
+	return pending, queued
+}
+
+// RemoveTx drops a single transaction from the pool, moving all subsequent
+// transactions of the account back to the future queue, and regenerates the
+// local transaction journal. It returns whether the transaction was found.
+func (pool *TxPool) RemoveTx(hash common.Hash) bool {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if pool.all[hash] == nil {
+		return false
+	}
+	pool.removeTx(hash)
+	pool.rejournal()
+
+	return true
+}
+
+// RemoveFrom drops all the pending and queued transactions of an account from
+// the pool and regenerates the local transaction journal. The dropped
+// transactions are returned.
+func (pool *TxPool) RemoveFrom(addr common.Address) types.Transactions {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	var removed types.Transactions
+	if list := pool.queue[addr]; list != nil {
+		removed = append(removed, list.Flatten()...)
+	}
+	if list := pool.pending[addr]; list != nil {
+		removed = append(removed, list.Flatten()...)
+	}
+	for _, tx := range removed {
+		pool.removeTx(tx.Hash())
+	}
+	if len(removed) > 0 {
+		pool.rejournal()
+	}
+	return removed
+}
+
+// rejournal regenerates the local transaction journal, if enabled, so manually
+// removed transactions aren't resurrected on restart.
+//
+// The caller must hold pool.mu.
+func (pool *TxPool) rejournal() {
+	if pool.journal == nil {
+		return
+	}
+	if err := pool.journal.rotate(pool.local()); err != nil {
+		log.Warn("Failed to rotate local tx journal", "err", err)
+	}
+}
+
 // removeTx removes a single transaction from the queue, moving all subsequent
 // transactions back to the future queue.
 func (pool *TxPool) removeTx(hash common.Hash) {
@@ -868,7 +1088,7 @@ func (pool *TxPool) removeTx(hash common.Hash) {
 	addr, _ := types.Sender(pool.signer, tx) // already validated during insertion
 
 	// Remove it from the list of known transactions
-	delete(pool.all, hash)
+	pool.untrack(hash)
 	pool.priced.Removed()
 
 	// Remove the transaction from the pending lists and reset the account nonce
@@ -878,11 +1098,10 @@ func (pool *TxPool) removeTx(hash common.Hash) {
 			if pending.Empty() {
 				delete(pool.pending, addr)
 				delete(pool.beats, addr)
-			} else {
-				// Otherwise postpone any invalidated transactions
-				for _, tx := range invalids {
-					pool.enqueueTx(tx.Hash(), tx)
-				}
+			}
+			// Postpone any invalidated transactions
+			for _, tx := range invalids {
+				pool.enqueueTx(tx.Hash(), tx)
 			}
 			// Update the account nonce if needed
 			if nonce := tx.Nonce(); pool.pendingState.GetNonce(addr) > nonce {
@@ -921,7 +1140,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 		for _, tx := range list.Forward(pool.currentState.GetNonce(addr)) {
 			hash := tx.Hash()
 			log.Trace("Removed old queued transaction", "hash", hash)
-			delete(pool.all, hash)
+			pool.untrack(hash)
 			pool.priced.Removed()
 		}
 		// Drop all transactions that are too costly (low balance or out of gas)
@@ -929,7 +1148,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 		for _, tx := range drops {
 			hash := tx.Hash()
 			log.Trace("Removed unpayable queued transaction", "hash", hash)
-			delete(pool.all, hash)
+			pool.untrack(hash)
 			pool.priced.Removed()
 			queuedNofundsCounter.Inc(1)
 		}
@@ -943,7 +1162,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 		if !pool.locals.contains(addr) {
 			for _, tx := range list.Cap(int(pool.config.AccountQueue)) {
 				hash := tx.Hash()
-				delete(pool.all, hash)
+				pool.untrack(hash)
 				pool.priced.Removed()
 				queuedRateLimitCounter.Inc(1)
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
@@ -988,7 +1207,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 						for _, tx := range list.Cap(list.Len() - 1) {
 							// Drop the transaction from the global pools too
 							hash := tx.Hash()
-							delete(pool.all, hash)
+							pool.untrack(hash)
 							pool.priced.Removed()
 
 							// Update the account nonce to the dropped transaction
@@ -1010,7 +1229,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 					for _, tx := range list.Cap(list.Len() - 1) {
 						// Drop the transaction from the global pools too
 						hash := tx.Hash()
-						delete(pool.all, hash)
+						pool.untrack(hash)
 						pool.priced.Removed()
 
 						// Update the account nonce to the dropped transaction
@@ -1079,7 +1298,7 @@ func (pool *TxPool) demoteUnexecutables() {
 		for _, tx := range list.Forward(nonce) {
 			hash := tx.Hash()
 			log.Trace("Removed old pending transaction", "hash", hash)
-			delete(pool.all, hash)
+			pool.untrack(hash)
 			pool.priced.Removed()
 		}
 		// Drop all transactions that are too costly (low balance or out of gas), and queue any invalids back for later
@@ -1087,7 +1306,7 @@ func (pool *TxPool) demoteUnexecutables() {
 		for _, tx := range drops {
 			hash := tx.Hash()
 			log.Trace("Removed unpayable pending transaction", "hash", hash)
-			delete(pool.all, hash)
+			pool.untrack(hash)
 			pool.priced.Removed()
 			pendingNofundsCounter.Inc(1)
 		}
@@ -101,6 +101,16 @@ func validateTxPoolInternals(pool *TxPool) error {
 	if priced := pool.priced.items.Len() - pool.priced.stales; priced != pending+queued {
 		return fmt.Errorf("total priced transaction count %d != %d pending + %d queued", priced, pending, queued)
 	}
+	// Ensure the governance transaction counter is consistent with the tracked set
+	governance := uint64(0)
+	for _, tx := range pool.all {
+		if IsGovernanceTx(tx) {
+			governance++
+		}
+	}
+	if governance != pool.governance {
+		return fmt.Errorf("governance transaction count %d != %d tracked", pool.governance, governance)
+	}
 	// Ensure the next nonce to assign is the correct one
 	for addr, txs := range pool.pending {
 		// Find the last transaction
@@ -261,6 +271,73 @@ func TestInvalidTransactions(t *testing.T) {
 	}
 }
 
+// Tests that DPoS operations are only accepted in the encoding of the next block,
+// legacy ones being rejected after the typed transaction fork.
+func TestInvalidDposEncoding(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000))
+
+	legacy, _ := types.SignTx(types.NewLegacyTransaction(types.Delegate, 0, common.Address{0x01}, big.NewInt(0), big.NewInt(100000), big.NewInt(1), nil), types.HomesteadSigner{}, key)
+	if err := pool.AddRemote(legacy); err != types.ErrLegacyDposTx {
+		t.Error("expected", types.ErrLegacyDposTx, "got", err)
+	}
+	unknown, _ := types.SignTx(types.NewTransaction(types.MultiTransfer+1, 0, common.Address{0x01}, big.NewInt(0), big.NewInt(100000), big.NewInt(1), nil), types.HomesteadSigner{}, key)
+	if err := pool.AddRemote(unknown); err != types.ErrInvalidType {
+		t.Error("expected", types.ErrInvalidType, "got", err)
+	}
+	typed, _ := types.SignTx(types.NewTransaction(types.Delegate, 0, common.Address{0x01}, big.NewInt(0), big.NewInt(100000), big.NewInt(1), nil), types.HomesteadSigner{}, key)
+	if err := pool.AddRemote(typed); err != nil {
+		t.Error("expected", nil, "got", err)
+	}
+}
+
+// Tests that multi-transfer transactions are validated against the sum of their
+// transfers and pay intrinsic gas for each recipient.
+func TestInvalidMultiTransfers(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	transfers := []*types.Transfer{
+		{To: common.Address{1}, Value: big.NewInt(1000)},
+		{To: common.Address{2}, Value: big.NewInt(2000)},
+	}
+	gas := new(big.Int).SetUint64(params.TxGas + 2*params.TxTransferGas)
+
+	sign := func(transfers []*types.Transfer, gas *big.Int) *types.Transaction {
+		tx, _ := types.SignTx(types.NewMultiTransfer(0, transfers, gas, big.NewInt(1)), types.HomesteadSigner{}, key)
+		return tx
+	}
+	pool.currentState.AddBalance(from, new(big.Int).Add(gas, big.NewInt(2999)))
+	if err := pool.AddRemote(sign(transfers, gas)); err != ErrInsufficientFunds {
+		t.Error("expected", ErrInsufficientFunds, "got", err)
+	}
+	pool.currentState.AddBalance(from, big.NewInt(1))
+	if err := pool.AddRemote(sign(nil, gas)); err != types.ErrNoTransfers {
+		t.Error("expected", types.ErrNoTransfers, "got", err)
+	}
+	if err := pool.AddRemote(sign(transfers, new(big.Int).Sub(gas, common.Big1))); err != ErrIntrinsicGas {
+		t.Error("expected", ErrIntrinsicGas, "got", err)
+	}
+	// Multi-transfers are only accepted from the block of their fork on
+	config := *params.TestChainConfig
+	config.MultiTransferBlock = big.NewInt(2)
+	pool.chainconfig = &config
+	if err := pool.AddRemote(sign(transfers, gas)); err != ErrMultiTransferNotActive {
+		t.Error("expected", ErrMultiTransferNotActive, "got", err)
+	}
+	config.MultiTransferBlock = big.NewInt(1)
+	if err := pool.AddRemote(sign(transfers, gas)); err != nil {
+		t.Error("expected", nil, "got", err)
+	}
+}
+
 func TestTransactionQueue(t *testing.T) {
 	t.Parallel()
 
@@ -1369,6 +1446,133 @@ func TestTransactionPoolUnderpricing(t *testing.T) {
 	}
 }
 
+// Tests that DPoS governance transactions are exempt from price based eviction
+// as long as they fit into their reserved slots.
+func TestTransactionPoolGovernanceUnderpricing(t *testing.T) {
+	t.Parallel()
+
+	// Create the pool to test the pricing enforcement with
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	blockchain := &testBlockChain{statedb, big.NewInt(1000000), new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.GlobalSlots = 2
+	config.GlobalQueue = 2
+	config.GovernanceSlots = 2
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	keys := make([]*ecdsa.PrivateKey, 7)
+	for i := 0; i < len(keys); i++ {
+		keys[i], _ = crypto.GenerateKey()
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(100000000))
+	}
+	delegation := func(nonce uint64, gasprice int64, key *ecdsa.PrivateKey) *types.Transaction {
+		tx, _ := types.SignTx(types.NewTransaction(types.Delegate, nonce, common.Address{0x01}, big.NewInt(0), big.NewInt(100000), big.NewInt(gasprice), nil), types.HomesteadSigner{}, key)
+		return tx
+	}
+	// Fill the pool with well priced transfers
+	for i := 0; i < 4; i++ {
+		if err := pool.AddRemote(pricedTransaction(0, big.NewInt(100000), big.NewInt(10), keys[3+i])); err != nil {
+			t.Fatalf("failed to add transfer %d: %v", i, err)
+		}
+	}
+	// Cheap transfers are rejected, cheap governance transactions evict transfers
+	if err := pool.AddRemote(pricedTransaction(0, big.NewInt(100000), big.NewInt(1), keys[1])); err != ErrUnderpriced {
+		t.Fatalf("adding underpriced transfer error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+	if err := pool.AddRemote(delegation(0, 1, keys[1])); err != nil {
+		t.Fatalf("failed to add underpriced governance transaction: %v", err)
+	}
+	if err := pool.AddRemote(delegation(0, 1, keys[2])); err != nil {
+		t.Fatalf("failed to add underpriced governance transaction: %v", err)
+	}
+	// The governance transactions can't evict each other within their slots
+	if err := pool.AddRemote(pricedTransaction(0, big.NewInt(100000), big.NewInt(20), keys[0])); err != nil {
+		t.Fatalf("failed to add well priced transfer: %v", err)
+	}
+	pendingByType, queuedByType := pool.StatsByType()
+	if pendingByType[types.Delegate] != 2 {
+		t.Fatalf("pending governance transactions mismatched: have %d, want %d", pendingByType[types.Delegate], 2)
+	}
+	if pendingByType[types.Binary]+queuedByType[types.Binary] != 2 {
+		t.Fatalf("transfers mismatched: have %d, want %d", pendingByType[types.Binary]+queuedByType[types.Binary], 2)
+	}
+	// Beyond the reserved slots governance transactions are rejected, however priced
+	if err := pool.AddRemote(delegation(1, 1, keys[2])); err != ErrGovernanceSlotsFull {
+		t.Fatalf("adding governance transaction beyond slots error mismatch: have %v, want %v", err, ErrGovernanceSlotsFull)
+	}
+	if err := pool.AddRemote(delegation(1, 100, keys[2])); err != ErrGovernanceSlotsFull {
+		t.Fatalf("adding well priced governance transaction beyond slots error mismatch: have %v, want %v", err, ErrGovernanceSlotsFull)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests the boundaries of the governance slots: the last free slot still admits
+// an underpriced governance transaction, which stays protected once the slots
+// are exactly full, while further ones are rejected.
+func TestTransactionPoolGovernanceSlotsBoundary(t *testing.T) {
+	t.Parallel()
+
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	blockchain := &testBlockChain{statedb, big.NewInt(1000000), new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.GlobalSlots = 1
+	config.GlobalQueue = 1
+	config.GovernanceSlots = 1
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	keys := make([]*ecdsa.PrivateKey, 5)
+	for i := 0; i < len(keys); i++ {
+		keys[i], _ = crypto.GenerateKey()
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(100000000))
+	}
+	delegation, _ := types.SignTx(types.NewTransaction(types.Delegate, 0, common.Address{0x01}, big.NewInt(0), big.NewInt(100000), big.NewInt(1), nil), types.HomesteadSigner{}, keys[0])
+	for i := 1; i <= 2; i++ {
+		if err := pool.AddRemote(pricedTransaction(0, big.NewInt(100000), big.NewInt(10), keys[i])); err != nil {
+			t.Fatalf("failed to add transfer %d: %v", i, err)
+		}
+	}
+	// The last free slot admits an underpriced governance transaction
+	if err := pool.AddRemote(delegation); err != nil {
+		t.Fatalf("failed to add governance transaction into last slot: %v", err)
+	}
+	// With the slots exactly full, it's protected from eviction
+	if err := pool.AddRemote(pricedTransaction(0, big.NewInt(100000), big.NewInt(20), keys[3])); err != nil {
+		t.Fatalf("failed to add well priced transfer: %v", err)
+	}
+	if pool.all[delegation.Hash()] == nil {
+		t.Fatalf("governance transaction evicted from full slots")
+	}
+	// But no more governance transactions are admitted
+	rejected, _ := types.SignTx(types.NewTransaction(types.Delegate, 0, common.Address{0x01}, big.NewInt(0), big.NewInt(100000), big.NewInt(100), nil), types.HomesteadSigner{}, keys[4])
+	if err := pool.AddRemote(rejected); err != ErrGovernanceSlotsFull {
+		t.Fatalf("adding governance transaction into full slots error mismatch: have %v, want %v", err, ErrGovernanceSlotsFull)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// Removing the governance transaction releases its slot
+	pool.mu.Lock()
+	pool.removeTx(delegation.Hash())
+	pool.mu.Unlock()
+
+	if pool.governance != 0 {
+		t.Fatalf("governance transaction count mismatch: have %d, want %d", pool.governance, 0)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // Tests that the pool rejects replacement transactions that don't meet the minimum
 // price bump required.
 func TestTransactionReplacement(t *testing.T) {
@@ -1563,6 +1767,131 @@ func testTransactionJournaling(t *testing.T, nolocals bool) {
 	pool.Stop()
 }
 
+// Tests that removing a pending transaction demotes all the subsequent ones of
+// the account into the queue, even if none are left pending, without tracking
+// the demoted ones a second time.
+func TestTransactionRemovalDemotion(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	account, _ := deriveSender(transaction(0, big.NewInt(0), key))
+	pool.currentState.AddBalance(account, big.NewInt(1000000))
+
+	txs := make([]*types.Transaction, 4)
+	for i := range txs {
+		txs[i] = transaction(uint64(i), big.NewInt(100000), key)
+		pool.enqueueTx(txs[i].Hash(), txs[i])
+	}
+	pool.promoteExecutables([]common.Address{account})
+
+	// Removing a middle transaction keeps the account pending
+	pool.removeTx(txs[2].Hash())
+	if pending, queued := pool.stats(); pending != 2 || queued != 1 {
+		t.Fatalf("pool stats mismatch after middle removal: have %d/%d, want 2/1", pending, queued)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// Removing the first transaction empties the pending list
+	pool.removeTx(txs[0].Hash())
+	if pending, queued := pool.stats(); pending != 0 || queued != 2 {
+		t.Fatalf("pool stats mismatch after head removal: have %d/%d, want 0/2", pending, queued)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that transactions can be looked up and manually removed from the pool,
+// and that removed local transactions don't survive a restart.
+func TestTransactionManualRemoval(t *testing.T) {
+	t.Parallel()
+
+	// Create a temporary file for the journal
+	file, err := ioutil.TempFile("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary journal: %v", err)
+	}
+	journal := file.Name()
+	defer os.Remove(journal)
+
+	file.Close()
+	os.Remove(journal)
+
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	blockchain := &testBlockChain{statedb, big.NewInt(1000000), new(event.Feed)}
+
+	config := testTxPoolConfig
+	config.Journal = journal
+
+	pool := NewTxPool(config, params.TestChainConfig, blockchain)
+
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.AddBalance(addr, big.NewInt(1000000000))
+
+	// Add two executable and one gapped local transaction
+	txs := types.Transactions{
+		pricedTransaction(0, big.NewInt(100000), big.NewInt(1), key),
+		pricedTransaction(1, big.NewInt(100000), big.NewInt(1), key),
+		pricedTransaction(3, big.NewInt(100000), big.NewInt(1), key),
+	}
+	for i, tx := range txs {
+		if err := pool.AddLocal(tx); err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	if tx, status, _ := pool.Lookup(txs[1].Hash()); tx != txs[1] || status != TxStatusPending {
+		t.Fatalf("pending lookup mismatch: have %v/%d, want %v/%d", tx, status, txs[1], TxStatusPending)
+	}
+	if tx, status, reason := pool.Lookup(txs[2].Hash()); tx != txs[2] || status != TxStatusQueued || reason != "nonce gap, next executable nonce is 2" {
+		t.Fatalf("queued lookup mismatch: have %v/%d/%q, want %v/%d", tx, status, reason, txs[2], TxStatusQueued)
+	}
+	if tx, status, _ := pool.Lookup(common.Hash{}); tx != nil || status != TxStatusUnknown {
+		t.Fatalf("unknown lookup mismatch: have %v/%d", tx, status)
+	}
+	if pending, queued := pool.ContentFrom(addr); len(pending) != 2 || len(queued) != 1 {
+		t.Fatalf("account content mismatch: have %d/%d, want 2/1", len(pending), len(queued))
+	}
+	// Remove the head transaction and ensure the next one is demoted
+	if !pool.RemoveTx(txs[0].Hash()) {
+		t.Fatalf("failed to remove pooled transaction")
+	}
+	if pool.RemoveTx(txs[0].Hash()) {
+		t.Fatalf("removed transaction not in the pool")
+	}
+	if pending, queued := pool.Stats(); pending != 0 || queued != 2 {
+		t.Fatalf("pool stats mismatch after removal: have %d/%d, want 0/2", pending, queued)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// Restart the pool and ensure the removed transaction wasn't resurrected
+	pool.Stop()
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+
+	if pending, queued := pool.Stats(); pending != 0 || queued != 2 {
+		t.Fatalf("pool stats mismatch after restart: have %d/%d, want 0/2", pending, queued)
+	}
+	// Remove everything from the account and ensure the journal is emptied
+	if removed := pool.RemoveFrom(addr); len(removed) != 2 {
+		t.Fatalf("removed transaction count mismatch: have %d, want 2", len(removed))
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	pool.Stop()
+	pool = NewTxPool(config, params.TestChainConfig, blockchain)
+	defer pool.Stop()
+
+	if pending, queued := pool.Stats(); pending != 0 || queued != 0 {
+		t.Fatalf("pool stats mismatch after account removal: have %d/%d, want 0/0", pending, queued)
+	}
+}
+
 // Benchmarks the speed of validating the contents of the pending queue of the
 // transaction pool.
 func BenchmarkPendingDemotion100(b *testing.B)   { benchmarkPendingDemotion(b, 100) }
@@ -7,7 +7,6 @@ import (
 
 	"github.com/meitu/go-ethereum/common"
 	"github.com/meitu/go-ethereum/crypto/sha3"
-	"github.com/meitu/go-ethereum/ethdb"
 	"github.com/meitu/go-ethereum/rlp"
 	"github.com/meitu/go-ethereum/trie"
 )
@@ -19,7 +18,7 @@ type DposContext struct {
 	candidateTrie *trie.Trie
 	mintCntTrie   *trie.Trie
 
-	db ethdb.Database
+	db trie.Database
 }
 
 var (
@@ -30,27 +29,27 @@ var (
 	mintCntPrefix   = []byte("mintCnt-")
 )
 
-func NewEpochTrie(root common.Hash, db ethdb.Database) (*trie.Trie, error) {
+func NewEpochTrie(root common.Hash, db trie.Database) (*trie.Trie, error) {
 	return trie.NewTrieWithPrefix(root, epochPrefix, db)
 }
 
-func NewDelegateTrie(root common.Hash, db ethdb.Database) (*trie.Trie, error) {
+func NewDelegateTrie(root common.Hash, db trie.Database) (*trie.Trie, error) {
 	return trie.NewTrieWithPrefix(root, delegatePrefix, db)
 }
 
-func NewVoteTrie(root common.Hash, db ethdb.Database) (*trie.Trie, error) {
+func NewVoteTrie(root common.Hash, db trie.Database) (*trie.Trie, error) {
 	return trie.NewTrieWithPrefix(root, votePrefix, db)
 }
 
-func NewCandidateTrie(root common.Hash, db ethdb.Database) (*trie.Trie, error) {
+func NewCandidateTrie(root common.Hash, db trie.Database) (*trie.Trie, error) {
 	return trie.NewTrieWithPrefix(root, candidatePrefix, db)
 }
 
-func NewMintCntTrie(root common.Hash, db ethdb.Database) (*trie.Trie, error) {
+func NewMintCntTrie(root common.Hash, db trie.Database) (*trie.Trie, error) {
 	return trie.NewTrieWithPrefix(root, mintCntPrefix, db)
 }
 
-func NewDposContext(db ethdb.Database) (*DposContext, error) {
+func NewDposContext(db trie.Database) (*DposContext, error) {
 	epochTrie, err := NewEpochTrie(common.Hash{}, db)
 	if err != nil {
 		return nil, err
@@ -81,7 +80,7 @@ func NewDposContext(db ethdb.Database) (*DposContext, error) {
 	}, nil
 }
 
-func NewDposContextFromProto(db ethdb.Database, ctxProto *DposContextProto) (*DposContext, error) {
+func NewDposContextFromProto(db trie.Database, ctxProto *DposContextProto) (*DposContext, error) {
 	epochTrie, err := NewEpochTrie(ctxProto.EpochHash, db)
 	if err != nil {
 		return nil, err
@@ -124,6 +123,7 @@ func (d *DposContext) Copy() *DposContext {
 		voteTrie:      &voteTrie,
 		candidateTrie: &candidateTrie,
 		mintCntTrie:   &mintCntTrie,
+		db:            d.db,
 	}
 }
 
@@ -138,8 +138,11 @@ func (d *DposContext) Root() (h common.Hash) {
 	return h
 }
 
+// Snapshot copies the tries of the context, the only part restored on revert.
 func (d *DposContext) Snapshot() *DposContext {
-	return d.Copy()
+	snapshot := d.Copy()
+	snapshot.db = nil
+	return snapshot
 }
 
 func (d *DposContext) RevertToSnapShot(snapshot *DposContext) {
@@ -331,7 +334,7 @@ func (d *DposContext) DelegateTrie() *trie.Trie           { return d.delegateTri
 func (d *DposContext) VoteTrie() *trie.Trie               { return d.voteTrie }
 func (d *DposContext) EpochTrie() *trie.Trie              { return d.epochTrie }
 func (d *DposContext) MintCntTrie() *trie.Trie            { return d.mintCntTrie }
-func (d *DposContext) DB() ethdb.Database                 { return d.db }
+func (d *DposContext) DB() trie.Database                  { return d.db }
 func (dc *DposContext) SetEpoch(epoch *trie.Trie)         { dc.epochTrie = epoch }
 func (dc *DposContext) SetDelegate(delegate *trie.Trie)   { dc.delegateTrie = delegate }
 func (dc *DposContext) SetVote(vote *trie.Trie)           { dc.voteTrie = vote }
@@ -31,6 +31,7 @@ import (
 )
 
 //go:generate gencodec -type txdata -field-override txdataMarshaling -out gen_tx_json.go
+//go:generate gencodec -type Transfer -field-override transferMarshaling -out gen_transfer_json.go
 
 // transaction type
 type TxType uint8
@@ -41,14 +42,19 @@ const (
 	LogoutCandidate
 	Delegate
 	UnDelegate
+	MultiTransfer
 )
 
+// Valid returns whether the type is one of the known transaction types.
+func (t TxType) Valid() bool { return t <= MultiTransfer }
+
 var (
 	ErrInvalidSig     = errors.New("invalid transaction v, r, s values")
 	errNoSigner       = errors.New("missing signing methods")
 	ErrInvalidType    = errors.New("invalid transaction type")
 	ErrInvalidAddress = errors.New("invalid transaction payload address")
 	ErrInvalidAction  = errors.New("invalid transaction payload action")
+	ErrNoTransfers    = errors.New("multi-transfer transaction without transfers")
 )
 
 // deriveSigner makes a *best* guess about which signer to use.
@@ -61,7 +67,8 @@ func deriveSigner(V *big.Int) Signer {
 }
 
 type Transaction struct {
-	data txdata
+	data  txdata
+	typed bool // Whether the transaction is encoded as a typed envelope
 	// caches
 	hash atomic.Value
 	size atomic.Value
@@ -77,6 +84,12 @@ type txdata struct {
 	Amount       *big.Int        `json:"value"    gencodec:"required"`
 	Payload      []byte          `json:"input"    gencodec:"required"`
 
+	// Recipients of a multi-transfer transaction, carried by its envelope
+	Transfers []*Transfer `json:"transfers,omitempty" rlp:"-"`
+
+	// Whether the transaction is encoded in an envelope, only used in JSON.
+	Typed bool `json:"typed,omitempty" rlp:"-"`
+
 	// Signature values
 	V *big.Int `json:"v" gencodec:"required"`
 	R *big.Int `json:"r" gencodec:"required"`
@@ -86,6 +99,17 @@ type txdata struct {
 	Hash *common.Hash `json:"hash" rlp:"-"`
 }
 
+// Transfer is a value transfer to one of the recipients of a multi-transfer
+// transaction.
+type Transfer struct {
+	To    common.Address `json:"to"    gencodec:"required"`
+	Value *big.Int       `json:"value" gencodec:"required"`
+}
+
+type transferMarshaling struct {
+	Value *hexutil.Big
+}
+
 type txdataMarshaling struct {
 	AccountNonce hexutil.Uint64
 	Price        *hexutil.Big
@@ -106,10 +130,41 @@ func NewTransaction(txType TxType, nonce uint64, to common.Address, amount, gasL
 	return newTransaction(txType, nonce, &to, amount, gasLimit, gasPrice, data)
 }
 
+// NewLegacyTransaction creates a transaction encoded as an RLP list whatever its
+// type, as DPoS operations are before the typed transaction fork.
+func NewLegacyTransaction(txType TxType, nonce uint64, to common.Address, amount, gasLimit, gasPrice *big.Int, data []byte) *Transaction {
+	tx := NewTransaction(txType, nonce, to, amount, gasLimit, gasPrice, data)
+	tx.typed = false
+	return tx
+}
+
 func NewContractCreation(nonce uint64, amount, gasLimit, gasPrice *big.Int, data []byte) *Transaction {
 	return newTransaction(Binary, nonce, nil, amount, gasLimit, gasPrice, data)
 }
 
+// NewMultiTransfer creates a transaction paying each of the given recipients
+// atomically, the whole transaction failing if any transfer does.
+func NewMultiTransfer(nonce uint64, transfers []*Transfer, gasLimit, gasPrice *big.Int) *Transaction {
+	tx := newTransaction(MultiTransfer, nonce, nil, nil, gasLimit, gasPrice, nil)
+	tx.data.Transfers = copyTransfers(transfers)
+	return tx
+}
+
+// copyTransfers deep copies a transfer list.
+func copyTransfers(transfers []*Transfer) []*Transfer {
+	if transfers == nil {
+		return nil
+	}
+	cpy := make([]*Transfer, len(transfers))
+	for i, t := range transfers {
+		cpy[i] = &Transfer{To: t.To, Value: new(big.Int)}
+		if t.Value != nil {
+			cpy[i].Value.Set(t.Value)
+		}
+	}
+	return cpy
+}
+
 func newTransaction(txType TxType, nonce uint64, to *common.Address, amount, gasLimit, gasPrice *big.Int, data []byte) *Transaction {
 	if len(data) > 0 {
 		data = common.CopyBytes(data)
@@ -136,7 +191,7 @@ func newTransaction(txType TxType, nonce uint64, to *common.Address, amount, gas
 		d.Price.Set(gasPrice)
 	}
 
-	return &Transaction{data: d}
+	return &Transaction{data: d, typed: hasTxCodec(txType)}
 }
 
 // ChainId returns which chain id this transaction was signed for (if at all)
@@ -146,6 +201,15 @@ func (tx *Transaction) ChainId() *big.Int {
 
 // Valid the transaction when the type isn't the binary
 func (tx *Transaction) Validate() error {
+	if !tx.Type().Valid() {
+		return ErrInvalidType
+	}
+	if tx.Type() == MultiTransfer {
+		return tx.validateTransfers()
+	}
+	if len(tx.data.Transfers) > 0 {
+		return errors.New("transfers are only allowed in multi-transfer transactions")
+	}
 	if tx.Type() != Binary {
 		if tx.Value().Uint64() != 0 {
 			return errors.New("transaction value should be 0")
@@ -153,13 +217,38 @@ func (tx *Transaction) Validate() error {
 		if tx.To() == nil && tx.Type() != LoginCandidate && tx.Type() != LogoutCandidate {
 			return errors.New("receipient was required")
 		}
-		if tx.Data() != nil {
+		// Decoding leaves an empty, but not nil payload, which is only accepted
+		// from typed transactions as legacy ones always required a nil one
+		if tx.Data() != nil && (!tx.typed || len(tx.Data()) != 0) {
 			return errors.New("payload should be empty")
 		}
 	}
 	return nil
 }
 
+// validateTransfers checks the shape of a multi-transfer transaction, whose
+// value is only carried by its transfers.
+func (tx *Transaction) validateTransfers() error {
+	if len(tx.data.Transfers) == 0 {
+		return ErrNoTransfers
+	}
+	for _, t := range tx.data.Transfers {
+		if t == nil || t.Value == nil || t.Value.Sign() < 0 {
+			return errors.New("transfer value should be non-negative")
+		}
+	}
+	if tx.Value().Sign() != 0 {
+		return errors.New("transaction value should be 0")
+	}
+	if tx.To() != nil {
+		return errors.New("recipient should be empty")
+	}
+	if len(tx.Data()) != 0 {
+		return errors.New("payload should be empty")
+	}
+	return nil
+}
+
 // Protected returns whether the transaction is protected from replay protection.
 func (tx *Transaction) Protected() bool {
 	return isProtectedV(tx.data.V)
@@ -174,19 +263,40 @@ func isProtectedV(V *big.Int) bool {
 	return true
 }
 
-// DecodeRLP implements rlp.Encoder
+// EncodeRLP implements rlp.Encoder. Legacy transactions are encoded as an RLP
+// list, typed ones as an RLP string wrapping their envelope.
 func (tx *Transaction) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, &tx.data)
+	if !tx.typed {
+		return rlp.Encode(w, &tx.data)
+	}
+	envelope, err := encodeEnvelope(&tx.data)
+	if err != nil {
+		return err
+	}
+	return rlp.Encode(w, envelope)
 }
 
-// DecodeRLP implements rlp.Decoder
+// DecodeRLP implements rlp.Decoder, accepting both legacy and typed transactions.
 func (tx *Transaction) DecodeRLP(s *rlp.Stream) error {
-	_, size, _ := s.Kind()
-	err := s.Decode(&tx.data)
-	if err == nil {
+	kind, size, err := s.Kind()
+	if err != nil {
+		return err
+	}
+	if kind == rlp.List {
+		if err = s.Decode(&tx.data); err == nil {
+			tx.typed = false
+			tx.size.Store(common.StorageSize(rlp.ListSize(size)))
+		}
+		return err
+	}
+	envelope, err := s.Bytes()
+	if err != nil {
+		return err
+	}
+	if err = decodeEnvelope(envelope, &tx.data); err == nil {
+		tx.typed = true
 		tx.size.Store(common.StorageSize(rlp.ListSize(size)))
 	}
-
 	return err
 }
 
@@ -194,6 +304,7 @@ func (tx *Transaction) MarshalJSON() ([]byte, error) {
 	hash := tx.Hash()
 	data := tx.data
 	data.Hash = &hash
+	data.Typed = tx.typed
 	return data.MarshalJSON()
 }
 
@@ -213,7 +324,14 @@ func (tx *Transaction) UnmarshalJSON(input []byte) error {
 	if !crypto.ValidateSignatureValues(V, dec.R, dec.S, false) {
 		return ErrInvalidSig
 	}
-	*tx = Transaction{data: dec}
+	// Only typed transactions carry the typed flag, and multi-transfers have no
+	// legacy encoding
+	if dec.Typed && !hasTxCodec(dec.Type) || !dec.Typed && dec.Type == MultiTransfer {
+		return ErrInvalidType
+	}
+	typed := dec.Typed
+	dec.Typed = false
+	*tx = Transaction{data: dec, typed: typed}
 	return nil
 }
 
@@ -225,6 +343,27 @@ func (tx *Transaction) Nonce() uint64      { return tx.data.AccountNonce }
 func (tx *Transaction) CheckNonce() bool   { return true }
 func (tx *Transaction) Type() TxType       { return tx.data.Type }
 
+// Typed returns whether the transaction is encoded in an envelope.
+func (tx *Transaction) Typed() bool { return tx.typed }
+
+// Transfers returns the recipients and amounts of a multi-transfer transaction.
+func (tx *Transaction) Transfers() []*Transfer { return copyTransfers(tx.data.Transfers) }
+
+// TransferValue returns the sum of the amounts transferred by a multi-transfer
+// transaction.
+func (tx *Transaction) TransferValue() *big.Int { return transferValue(tx.data.Transfers) }
+
+// transferValue sums the amounts of a transfer list.
+func transferValue(transfers []*Transfer) *big.Int {
+	total := new(big.Int)
+	for _, t := range transfers {
+		if t != nil && t.Value != nil {
+			total.Add(total, t.Value)
+		}
+	}
+	return total
+}
+
 // To returns the recipient address of the transaction.
 // It returns nil if the transaction is a contract creation.
 func (tx *Transaction) To() *common.Address {
@@ -242,7 +381,13 @@ func (tx *Transaction) Hash() common.Hash {
 	if hash := tx.hash.Load(); hash != nil {
 		return hash.(common.Hash)
 	}
-	v := rlpHash(tx)
+	var v common.Hash
+	if tx.typed {
+		envelope, _ := encodeEnvelope(&tx.data)
+		v = crypto.Keccak256Hash(envelope)
+	} else {
+		v = rlpHash(tx)
+	}
 	tx.hash.Store(v)
 	return v
 }
@@ -252,7 +397,7 @@ func (tx *Transaction) Size() common.StorageSize {
 		return size.(common.StorageSize)
 	}
 	c := writeCounter(0)
-	rlp.Encode(&c, &tx.data)
+	rlp.Encode(&c, tx)
 	tx.size.Store(common.StorageSize(c))
 	return common.StorageSize(c)
 }
@@ -271,6 +416,7 @@ func (tx *Transaction) AsMessage(s Signer) (Message, error) {
 		amount:     tx.data.Amount,
 		data:       tx.data.Payload,
 		txType:     tx.data.Type,
+		transfers:  tx.data.Transfers,
 		checkNonce: true,
 	}
 
@@ -286,15 +432,17 @@ func (tx *Transaction) WithSignature(signer Signer, sig []byte) (*Transaction, e
 	if err != nil {
 		return nil, err
 	}
-	cpy := &Transaction{data: tx.data}
+	cpy := &Transaction{data: tx.data, typed: tx.typed}
 	cpy.data.R, cpy.data.S, cpy.data.V = r, s, v
 	return cpy, nil
 }
 
-// Cost returns amount + gasprice * gaslimit.
+// Cost returns amount + gasprice * gaslimit, including the transferred amounts
+// of multi-transfer transactions.
 func (tx *Transaction) Cost() *big.Int {
 	total := new(big.Int).Mul(tx.data.Price, tx.data.GasLimit)
 	total.Add(total, tx.data.Amount)
+	total.Add(total, transferValue(tx.data.Transfers))
 	return total
 }
 
@@ -322,7 +470,7 @@ func (tx *Transaction) String() string {
 	} else {
 		to = fmt.Sprintf("%x", tx.data.Recipient[:])
 	}
-	enc, _ := rlp.EncodeToBytes(&tx.data)
+	enc, _ := rlp.EncodeToBytes(tx)
 	return fmt.Sprintf(`
 	TX(%x)
 	Type:	  %d
@@ -488,6 +636,7 @@ type Message struct {
 	data                    []byte
 	checkNonce              bool
 	txType                  TxType
+	transfers               []*Transfer
 }
 
 func NewMessage(from common.Address, to *common.Address, nonce uint64, amount, gasLimit, price *big.Int, data []byte, checkNonce bool) Message {
@@ -512,3 +661,6 @@ func (m Message) Nonce() uint64        { return m.nonce }
 func (m Message) Data() []byte         { return m.data }
 func (m Message) CheckNonce() bool     { return m.checkNonce }
 func (m Message) Type() TxType         { return m.txType }
+
+// Transfers returns the recipients and amounts of a multi-transfer message.
+func (m Message) Transfers() []*Transfer { return m.transfers }
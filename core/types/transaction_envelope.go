@@ -0,0 +1,242 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/crypto/sha3"
+	"github.com/meitu/go-ethereum/params"
+	"github.com/meitu/go-ethereum/rlp"
+)
+
+// Transactions are encoded in one of two ways:
+//
+//  - Legacy transactions are an RLP list of the txdata fields, the type being
+//    the first one. All binary transactions and the DPoS operations of existing
+//    chain data use this encoding, and their signatures don't cover the type.
+//  - Typed transactions are an envelope made of the type byte followed by the
+//    payload encoded by the codec registered for the type. Within RLP structures
+//    such as blocks, the envelope is wrapped in an RLP string. Their signatures
+//    cover the type byte.
+//
+// New transactions of a type with a registered codec are created as typed ones,
+// NewLegacyTransaction creating the DPoS operations of chains which didn't reach
+// the typed transaction fork yet. Adding a transaction kind consists of defining
+// its TxType and registering the codec of its payload in txCodecs.
+
+var (
+	// ErrTypedTxNotActive is returned if a typed transaction is included before
+	// the typed transaction fork.
+	ErrTypedTxNotActive = errors.New("typed transaction before the typed transaction fork")
+
+	// ErrLegacyDposTx is returned if a DPoS operation is legacy encoded after the
+	// typed transaction fork.
+	ErrLegacyDposTx = errors.New("legacy dpos transaction after the typed transaction fork")
+)
+
+// ValidateEncoding checks that the transaction is encoded the way the chain
+// expects at the given block: before the typed transaction fork, all of them are
+// legacy transactions; after it, only binary transactions are.
+func ValidateEncoding(config *params.ChainConfig, num *big.Int, tx *Transaction) error {
+	if !config.IsTypedTx(num) {
+		if tx.typed {
+			return ErrTypedTxNotActive
+		}
+		return nil
+	}
+	if !tx.typed && hasTxCodec(tx.Type()) {
+		return ErrLegacyDposTx
+	}
+	return nil
+}
+
+// txCodec encodes and decodes the envelope payload of a transaction type.
+type txCodec interface {
+	// encode returns the RLP encodable payload of the transaction, including
+	// its signature values.
+	encode(d *txdata) interface{}
+
+	// decode fills the transaction fields from an RLP encoded payload.
+	decode(payload []byte, d *txdata) error
+
+	// sigFields returns the transaction fields covered by its signature, in the
+	// order they are hashed after the type byte.
+	sigFields(d *txdata) []interface{}
+}
+
+// txCodecs is the registry of the typed transaction codecs.
+var txCodecs = map[TxType]txCodec{
+	LoginCandidate:  dposCodec{},
+	LogoutCandidate: dposCodec{},
+	Delegate:        dposCodec{},
+	UnDelegate:      dposCodec{},
+	MultiTransfer:   multiTransferCodec{},
+}
+
+// dposPayload is the envelope payload of the DPoS operations.
+type dposPayload struct {
+	AccountNonce uint64
+	Price        *big.Int
+	GasLimit     *big.Int
+	Recipient    *common.Address `rlp:"nil"`
+	Amount       *big.Int
+	Payload      []byte
+	V, R, S      *big.Int
+}
+
+// dposCodec encodes the DPoS operations with the legacy fields minus the type,
+// which is carried by the envelope.
+type dposCodec struct{}
+
+func (dposCodec) encode(d *txdata) interface{} {
+	return &dposPayload{
+		AccountNonce: d.AccountNonce,
+		Price:        d.Price,
+		GasLimit:     d.GasLimit,
+		Recipient:    d.Recipient,
+		Amount:       d.Amount,
+		Payload:      d.Payload,
+		V:            d.V,
+		R:            d.R,
+		S:            d.S,
+	}
+}
+
+func (dposCodec) decode(payload []byte, d *txdata) error {
+	var dec dposPayload
+	if err := rlp.DecodeBytes(payload, &dec); err != nil {
+		return err
+	}
+	d.AccountNonce, d.Price, d.GasLimit = dec.AccountNonce, dec.Price, dec.GasLimit
+	d.Recipient, d.Amount, d.Payload = dec.Recipient, dec.Amount, dec.Payload
+	d.V, d.R, d.S = dec.V, dec.R, dec.S
+	return nil
+}
+
+func (dposCodec) sigFields(d *txdata) []interface{} {
+	return []interface{}{
+		d.AccountNonce,
+		d.Price,
+		d.GasLimit,
+		d.Recipient,
+		d.Amount,
+		d.Payload,
+	}
+}
+
+// multiTransferPayload is the envelope payload of the multi-transfer
+// transactions, which carry their recipients and amounts as a transfer list.
+type multiTransferPayload struct {
+	AccountNonce uint64
+	Price        *big.Int
+	GasLimit     *big.Int
+	Transfers    []*Transfer
+	V, R, S      *big.Int
+}
+
+// multiTransferCodec encodes the multi-transfer transactions, which have no
+// single recipient, value nor payload.
+type multiTransferCodec struct{}
+
+func (multiTransferCodec) encode(d *txdata) interface{} {
+	return &multiTransferPayload{
+		AccountNonce: d.AccountNonce,
+		Price:        d.Price,
+		GasLimit:     d.GasLimit,
+		Transfers:    d.Transfers,
+		V:            d.V,
+		R:            d.R,
+		S:            d.S,
+	}
+}
+
+func (multiTransferCodec) decode(payload []byte, d *txdata) error {
+	var dec multiTransferPayload
+	if err := rlp.DecodeBytes(payload, &dec); err != nil {
+		return err
+	}
+	d.AccountNonce, d.Price, d.GasLimit = dec.AccountNonce, dec.Price, dec.GasLimit
+	d.Recipient, d.Amount, d.Payload, d.Transfers = nil, new(big.Int), nil, dec.Transfers
+	d.V, d.R, d.S = dec.V, dec.R, dec.S
+	return nil
+}
+
+func (multiTransferCodec) sigFields(d *txdata) []interface{} {
+	return []interface{}{
+		d.AccountNonce,
+		d.Price,
+		d.GasLimit,
+		d.Transfers,
+	}
+}
+
+// hasTxCodec returns whether transactions of the given type are created as typed
+// envelopes.
+func hasTxCodec(txType TxType) bool {
+	_, ok := txCodecs[txType]
+	return ok
+}
+
+// encodeEnvelope returns the type byte prefixed payload of a typed transaction.
+func encodeEnvelope(d *txdata) ([]byte, error) {
+	codec, ok := txCodecs[d.Type]
+	if !ok {
+		return nil, ErrInvalidType
+	}
+	buf := new(bytes.Buffer)
+	buf.WriteByte(byte(d.Type))
+	if err := rlp.Encode(buf, codec.encode(d)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeEnvelope fills the transaction fields from a type byte prefixed payload.
+func decodeEnvelope(envelope []byte, d *txdata) error {
+	if len(envelope) == 0 {
+		return ErrInvalidType
+	}
+	codec, ok := txCodecs[TxType(envelope[0])]
+	if !ok {
+		return ErrInvalidType
+	}
+	d.Type = TxType(envelope[0])
+	return codec.decode(envelope[1:], d)
+}
+
+// prefixedRlpHash hashes the type byte followed by the RLP encoding of x.
+func prefixedRlpHash(prefix byte, x interface{}) (h common.Hash) {
+	hw := sha3.NewKeccak256()
+	hw.Write([]byte{prefix})
+	rlp.Encode(hw, x)
+	hw.Sum(h[:0])
+	return h
+}
+
+// sigHash returns the hash signed by the sender of a typed transaction, with the
+// chain ID appended to the signed fields if given.
+func (tx *Transaction) sigHash(chainID *big.Int) common.Hash {
+	fields := txCodecs[tx.data.Type].sigFields(&tx.data)
+	if chainID != nil {
+		fields = append(fields, chainID, uint(0), uint(0))
+	}
+	return prefixedRlpHash(byte(tx.data.Type), fields)
+}
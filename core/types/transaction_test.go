@@ -25,6 +25,7 @@ import (
 
 	"github.com/meitu/go-ethereum/common"
 	"github.com/meitu/go-ethereum/crypto"
+	"github.com/meitu/go-ethereum/params"
 	"github.com/meitu/go-ethereum/rlp"
 )
 
@@ -205,6 +206,8 @@ func TestTransactionValidate(t *testing.T) {
 		newTransaction(LoginCandidate, 0, nil, common.Big0, common.Big1, common.Big2, nil),
 		newTransaction(LogoutCandidate, 0, &common.Address{1}, common.Big0, common.Big1, common.Big2, nil),
 		newTransaction(UnDelegate, 0, &common.Address{1}, common.Big0, common.Big1, common.Big2, nil),
+		// decoding leaves an empty, but not nil payload of typed transactions
+		newTransaction(Delegate, 0, &common.Address{1}, common.Big0, common.Big1, common.Big2, []byte{}),
 	}
 	invalidTransactions := []*Transaction{
 		// value = 0 is invalid when the type isn't binary
@@ -213,6 +216,8 @@ func TestTransactionValidate(t *testing.T) {
 		newTransaction(Delegate, 0, nil, common.Big0, common.Big1, common.Big2, nil),
 		// payload != nil is invalid when the type isn't binary
 		newTransaction(UnDelegate, 0, &common.Address{1}, common.Big0, common.Big1, common.Big2, []byte("abcddf")),
+		// payload != nil is invalid for legacy transactions, even if empty
+		NewLegacyTransaction(Delegate, 0, common.Address{1}, common.Big0, common.Big1, common.Big2, []byte{}),
 	}
 	for _, tx := range validTransactions {
 		if err := tx.Validate(); err != nil {
@@ -225,3 +230,181 @@ func TestTransactionValidate(t *testing.T) {
 		}
 	}
 }
+
+// Tests that DPoS operations are encoded as typed envelopes whose signatures
+// cover the type, while legacy encoded ones of existing chain data still decode
+// and verify.
+func TestTypedTransactionEncoding(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	signer := NewEIP155Signer(common.Big1)
+
+	tx, err := SignTx(NewTransaction(Delegate, 1, common.Address{1}, common.Big0, common.Big1, common.Big2, nil), signer, key)
+	if err != nil {
+		t.Fatalf("could not sign transaction: %v", err)
+	}
+	enc, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	// Typed transactions are wrapped in an RLP string prefixed with the type
+	envelope, _, err := rlp.SplitString(enc)
+	if err != nil || envelope[0] != byte(Delegate) {
+		t.Fatalf("typed envelope mismatch: %x, %v", enc, err)
+	}
+	if tx.Hash() != crypto.Keccak256Hash(envelope) {
+		t.Errorf("typed hash mismatch: have %x, want %x", tx.Hash(), crypto.Keccak256Hash(envelope))
+	}
+	dec, err := decodeTx(enc)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if dec.Type() != Delegate || dec.Hash() != tx.Hash() || dec.Size() != common.StorageSize(len(enc)) {
+		t.Errorf("decoded typed transaction mismatch: have %v", dec)
+	}
+	if from, err := Sender(signer, dec); err != nil || from != addr {
+		t.Errorf("typed sender mismatch: have %x, %v, want %x", from, err, addr)
+	}
+	// Changing the type must invalidate the signature
+	forged := &Transaction{data: dec.data, typed: true}
+	forged.data.Type = UnDelegate
+	if from, err := Sender(signer, forged); err == nil && from == addr {
+		t.Errorf("signature doesn't cover the transaction type")
+	}
+	// Legacy encoded DPoS operations decode with their original hash and sender
+	legacy, err := SignTx(NewLegacyTransaction(Delegate, 1, common.Address{1}, common.Big0, common.Big1, common.Big2, nil), signer, key)
+	if err != nil {
+		t.Fatalf("could not sign legacy transaction: %v", err)
+	}
+	enc, _ = rlp.EncodeToBytes(legacy)
+	if dec, err = decodeTx(enc); err != nil {
+		t.Fatalf("legacy decode error: %v", err)
+	}
+	if dec.typed || dec.Type() != Delegate || dec.Hash() != rlpHash(&legacy.data) {
+		t.Errorf("decoded legacy transaction mismatch: have %v", dec)
+	}
+	if from, err := Sender(signer, dec); err != nil || from != addr {
+		t.Errorf("legacy sender mismatch: have %x, %v, want %x", from, err, addr)
+	}
+	// JSON round trips retain the encoding, as told by the typed flag, even
+	// without the hash
+	for _, want := range []*Transaction{tx, legacy} {
+		data, _ := json.Marshal(want)
+		var fields map[string]interface{}
+		if err := json.Unmarshal(data, &fields); err != nil {
+			t.Fatalf("json.Unmarshal failed: %v", err)
+		}
+		delete(fields, "hash")
+		data, _ = json.Marshal(fields)
+
+		var have *Transaction
+		if err := json.Unmarshal(data, &have); err != nil {
+			t.Fatalf("json.Unmarshal failed: %v", err)
+		}
+		if have.Typed() != want.Typed() || have.Hash() != want.Hash() {
+			t.Errorf("json round trip mismatch: have typed %v hash %x, want typed %v hash %x", have.Typed(), have.Hash(), want.Typed(), want.Hash())
+		}
+	}
+	// Binary transactions can't be flagged as typed
+	binary, _ := SignTx(NewTransaction(Binary, 1, common.Address{1}, common.Big0, common.Big1, common.Big2, nil), signer, key)
+	data, _ := json.Marshal(binary)
+	data = bytes.Replace(data, []byte(`"type":0,`), []byte(`"type":0,"typed":true,`), 1)
+	if err := json.Unmarshal(data, new(Transaction)); err != ErrInvalidType {
+		t.Errorf("typed binary transaction error mismatch: have %v, want %v", err, ErrInvalidType)
+	}
+	// Envelopes of types without a codec are rejected
+	if _, err := decodeTx(common.FromHex("0x820001")); err != ErrInvalidType {
+		t.Errorf("unknown envelope type error mismatch: have %v, want %v", err, ErrInvalidType)
+	}
+}
+
+// Tests that DPoS operations must be legacy transactions before the typed
+// transaction fork and typed ones after it.
+func TestValidateEncoding(t *testing.T) {
+	config := &params.ChainConfig{TypedTxBlock: big.NewInt(10)}
+
+	var (
+		binary = NewTransaction(Binary, 0, common.Address{1}, common.Big0, common.Big1, common.Big2, nil)
+		legacy = NewLegacyTransaction(Delegate, 0, common.Address{1}, common.Big0, common.Big1, common.Big2, nil)
+		typed  = NewTransaction(Delegate, 0, common.Address{1}, common.Big0, common.Big1, common.Big2, nil)
+	)
+	tests := []struct {
+		tx     *Transaction
+		number int64
+		err    error
+	}{
+		{binary, 9, nil},
+		{legacy, 9, nil},
+		{typed, 9, ErrTypedTxNotActive},
+		{binary, 10, nil},
+		{legacy, 10, ErrLegacyDposTx},
+		{typed, 10, nil},
+	}
+	for i, tt := range tests {
+		if err := ValidateEncoding(config, big.NewInt(tt.number), tt.tx); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+// Tests that multi-transfer transactions carry their transfers through encoding
+// and signing, and that their cost and validity account for them.
+func TestMultiTransferTransaction(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	signer := NewEIP155Signer(common.Big1)
+
+	transfers := []*Transfer{
+		{To: common.Address{1}, Value: big.NewInt(10)},
+		{To: common.Address{2}, Value: big.NewInt(20)},
+	}
+	tx, err := SignTx(NewMultiTransfer(1, transfers, big.NewInt(50000), common.Big2), signer, key)
+	if err != nil {
+		t.Fatalf("could not sign transaction: %v", err)
+	}
+	// Transfers are copied on creation
+	transfers[0].Value.SetInt64(1000)
+	if have := tx.TransferValue(); have.Int64() != 30 {
+		t.Errorf("transfer value mismatch: have %v, want 30", have)
+	}
+	if have := tx.Cost(); have.Int64() != 100030 {
+		t.Errorf("cost mismatch: have %v, want 100030", have)
+	}
+	if err := tx.Validate(); err != nil {
+		t.Errorf("valid transaction rejected: %v", err)
+	}
+	enc, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	dec, err := decodeTx(enc)
+	if err != nil {
+		t.Fatalf("decode error: %v", err)
+	}
+	if dec.Type() != MultiTransfer || dec.Hash() != tx.Hash() || dec.To() != nil || len(dec.Transfers()) != 2 {
+		t.Fatalf("decoded transaction mismatch: have %v", dec)
+	}
+	if from, err := Sender(signer, dec); err != nil || from != addr {
+		t.Errorf("sender mismatch: have %x, %v, want %x", from, err, addr)
+	}
+	// Changing a transfer must invalidate the signature
+	forged := &Transaction{data: dec.data, typed: true}
+	forged.data.Transfers = dec.Transfers()
+	forged.data.Transfers[1].Value.SetInt64(2000)
+	if from, err := Sender(signer, forged); err == nil && from == addr {
+		t.Errorf("signature doesn't cover the transfers")
+	}
+	// JSON round trips retain the transfers
+	data, _ := json.Marshal(tx)
+	var have *Transaction
+	if err := json.Unmarshal(data, &have); err != nil {
+		t.Fatalf("json.Unmarshal failed: %v", err)
+	}
+	if have.Hash() != tx.Hash() || have.TransferValue().Int64() != 30 {
+		t.Errorf("json round trip mismatch: have %v", have)
+	}
+	// Transactions without transfers are invalid
+	if err := NewMultiTransfer(1, nil, big.NewInt(50000), common.Big2).Validate(); err != ErrNoTransfers {
+		t.Errorf("empty transfer list error mismatch: have %v, want %v", err, ErrNoTransfers)
+	}
+}
@@ -17,33 +17,56 @@
 package vm
 
 import (
-	"math/big"
-
+	lru "github.com/hashicorp/golang-lru"
 	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/common/uint256"
 )
 
+// Number of codehash->bitmap associations to keep across transactions.
+const codeBitmapCacheSize = 4096
+
+// codeBitmapCache holds the JUMPDEST analysis of recently executed contracts,
+// sparing hot contracts from being re-analysed by every call tree. The cached
+// bitmaps are never modified, so they are safe to share between EVMs.
+var codeBitmapCache, _ = lru.New(codeBitmapCacheSize)
+
 // destinations stores one map per contract (keyed by hash of code).
 // The maps contain an entry for each location of a JUMPDEST
 // instruction.
 type destinations map[common.Hash]bitvec
 
 // has checks whether code has a JUMPDEST at dest.
-func (d destinations) has(codehash common.Hash, code []byte, dest *big.Int) bool {
-	// PC cannot go beyond len(code) and certainly can't be bigger than 63bits.
+func (d destinations) has(codehash common.Hash, code []byte, dest *uint256.Int) bool {
+	// PC cannot go beyond len(code) and certainly can't be bigger than 64bits.
 	// Don't bother checking for JUMPDEST in that case.
-	udest := dest.Uint64()
-	if dest.BitLen() >= 63 || udest >= uint64(len(code)) {
+	udest, overflow := dest.Uint64WithOverflow()
+	if overflow || udest >= uint64(len(code)) {
 		return false
 	}
 
 	m, analysed := d[codehash]
 	if !analysed {
-		m = codeBitmap(code)
+		m = cachedCodeBitmap(codehash, code)
 		d[codehash] = m
 	}
 	return OpCode(code[udest]) == JUMPDEST && m.codeSegment(udest)
 }
 
+// cachedCodeBitmap returns the bitmap of the code from the process-wide cache,
+// analysing and caching it if missing. Code without a known hash (e.g. the
+// init code of contracts being created by tests) is analysed but not cached.
+func cachedCodeBitmap(codehash common.Hash, code []byte) bitvec {
+	if codehash == (common.Hash{}) {
+		return codeBitmap(code)
+	}
+	if bits, ok := codeBitmapCache.Get(codehash); ok {
+		return bits.(bitvec)
+	}
+	bits := codeBitmap(code)
+	codeBitmapCache.Add(codehash, bits)
+	return bits
+}
+
 // bitvec is a bit vector which maps bytes in a program.
 // An unset bit means the byte is an opcode, a set bit means
 // it's data (i.e. argument of PUSHxx).
@@ -16,7 +16,13 @@
 
 package vm
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/common/uint256"
+	"github.com/meitu/go-ethereum/crypto"
+)
 
 func TestJumpDestAnalysis(t *testing.T) {
 	tests := []struct {
@@ -51,3 +57,71 @@ func TestJumpDestAnalysis(t *testing.T) {
 	}
 
 }
+
+// Tests that the analysis of a contract is shared between call trees through
+// the code hash keyed cache, while code without a hash is never cached.
+func TestJumpDestCache(t *testing.T) {
+	code := []byte{byte(PUSH1), byte(JUMPDEST), byte(JUMPDEST), byte(PUSH2), byte(JUMPDEST), byte(JUMPDEST), byte(JUMPDEST)}
+	hash := crypto.Keccak256Hash(code)
+
+	tests := []struct {
+		dest uint64
+		want bool
+	}{
+		{0, false}, {1, false}, {2, true}, {4, false}, {5, false}, {6, true}, {7, false},
+	}
+	for i, tt := range tests {
+		if have := make(destinations).has(hash, code, uint256.NewInt(tt.dest)); have != tt.want {
+			t.Errorf("test %d: jumpdest mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+	cached, ok := codeBitmapCache.Get(hash)
+	if !ok {
+		t.Fatalf("code bitmap not cached")
+	}
+	if bits := make(destinations); !bits.has(hash, code, uint256.NewInt(2)) || &bits[hash][0] != &cached.(bitvec)[0] {
+		t.Errorf("cached code bitmap not reused")
+	}
+	// Init code has no hash yet, so must not pollute the cache
+	if !make(destinations).has(common.Hash{}, code, uint256.NewInt(2)) {
+		t.Errorf("jumpdest of unhashed code not found")
+	}
+	if codeBitmapCache.Contains(common.Hash{}) {
+		t.Errorf("code bitmap of unhashed code cached")
+	}
+}
+
+// benchmarkCode returns a contract full of pushes and jump destinations, as
+// large as allowed by EIP-170.
+func benchmarkCode() []byte {
+	code := make([]byte, 0, 24576)
+	for len(code)+34 <= cap(code) {
+		code = append(code, byte(PUSH32))
+		code = append(code, make([]byte, 32)...)
+		code = append(code, byte(JUMPDEST))
+	}
+	return code
+}
+
+// Benchmarks the analysis of a hot contract repeatedly called by transactions,
+// each starting with a fresh call tree, without and with the code hash known.
+func BenchmarkJumpDestAnalysis(b *testing.B) {
+	code := benchmarkCode()
+	dest := uint256.NewInt(uint64(len(code) - 1))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		make(destinations).has(common.Hash{}, code, dest)
+	}
+}
+
+func BenchmarkJumpDestCached(b *testing.B) {
+	code := benchmarkCode()
+	hash := crypto.Keccak256Hash(code)
+	dest := uint256.NewInt(uint64(len(code) - 1))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		make(destinations).has(hash, code, dest)
+	}
+}
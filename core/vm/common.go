@@ -17,19 +17,34 @@
 package vm
 
 import (
-	"math/big"
-
 	"github.com/meitu/go-ethereum/common"
 	"github.com/meitu/go-ethereum/common/math"
+	"github.com/meitu/go-ethereum/common/uint256"
 )
 
-// calculates the memory size required for a step
-func calcMemSize(off, l *big.Int) *big.Int {
-	if l.Sign() == 0 {
-		return common.Big0
+// calcMemSize64 calculates the memory size required for a step, returning
+// whether it overflowed 64 bits.
+func calcMemSize64(off, l *uint256.Int) (uint64, bool) {
+	length, overflow := l.Uint64WithOverflow()
+	if overflow {
+		return 0, true
 	}
+	return calcMemSize64WithUint(off, length)
+}
 
-	return new(big.Int).Add(off, l)
+// calcMemSize64WithUint calculates the memory size required for a step of a
+// constant length, returning whether it overflowed 64 bits.
+func calcMemSize64WithUint(off *uint256.Int, length uint64) (uint64, bool) {
+	// A zero length access doesn't expand the memory, whatever the offset
+	if length == 0 {
+		return 0, false
+	}
+	offset, overflow := off.Uint64WithOverflow()
+	if overflow {
+		return 0, true
+	}
+	size := offset + length
+	return size, size < offset
 }
 
 // getData returns a slice from the data based on the start and size and pads
@@ -48,18 +63,12 @@ func getData(data []byte, start uint64, size uint64) []byte {
 
 // getDataBig returns a slice from the data based on the start and size and pads
 // up to size with zero's. This function is overflow safe.
-func getDataBig(data []byte, start *big.Int, size *big.Int) []byte {
-	dlen := big.NewInt(int64(len(data)))
-
-	s := math.BigMin(start, dlen)
-	e := math.BigMin(new(big.Int).Add(s, size), dlen)
-	return common.RightPadBytes(data[s.Uint64():e.Uint64()], int(size.Uint64()))
-}
-
-// bigUint64 returns the integer casted to a uint64 and returns whether it
-// overflowed in the process.
-func bigUint64(v *big.Int) (uint64, bool) {
-	return v.Uint64(), v.BitLen() > 64
+func getDataBig(data []byte, start *uint256.Int, size uint64) []byte {
+	start64, overflow := start.Uint64WithOverflow()
+	if overflow {
+		start64 = math.MaxUint64
+	}
+	return getData(data, start64, size)
 }
 
 // toWordSize returns the ceiled word size required for memory expansion.
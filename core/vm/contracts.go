@@ -59,6 +59,27 @@ var PrecompiledContractsByzantium = map[common.Address]PrecompiledContract{
 	common.BytesToAddress([]byte{8}): &bn256Pairing{},
 }
 
+// StatefulPrecompiledContract is a native Go contract which, unlike the plain
+// precompiles, needs access to the EVM it's called from. Besides the upfront
+// RequiredGas, it may use additional gas from the contract while running.
+type StatefulPrecompiledContract interface {
+	PrecompiledContract
+	RunStateful(evm *EVM, contract *Contract, input []byte) ([]byte, error) // RunStateful runs the contract within the evm
+}
+
+// PrecompiledContractsDpos contains the pre-compiled contracts added by the dpos
+// state fork on top of the ones of the active release, which expose the dpos
+// election to the EVM.
+var PrecompiledContractsDpos = map[common.Address]PrecompiledContract{
+	DposStateAddress: &dposState{},
+}
+
+// PrecompiledContractsDposStaking contains the pre-compiled contracts added by
+// the dpos staking fork, which let contracts stake on their own behalf.
+var PrecompiledContractsDposStaking = map[common.Address]PrecompiledContract{
+	DposStakingAddress: &dposStaking{},
+}
+
 // RunPrecompiledContract runs and evaluates the output of a precompiled contract.
 func RunPrecompiledContract(p PrecompiledContract, input []byte, contract *Contract) (ret []byte, err error) {
 	gas := p.RequiredGas(input)
@@ -68,6 +89,16 @@ func RunPrecompiledContract(p PrecompiledContract, input []byte, contract *Contr
 	return nil, ErrOutOfGas
 }
 
+// RunStatefulPrecompiledContract runs and evaluates the output of a precompiled
+// contract needing access to the evm.
+func RunStatefulPrecompiledContract(evm *EVM, p StatefulPrecompiledContract, input []byte, contract *Contract) (ret []byte, err error) {
+	gas := p.RequiredGas(input)
+	if contract.UseGas(gas) {
+		return p.RunStateful(evm, contract, input)
+	}
+	return nil, ErrOutOfGas
+}
+
 // ECRECOVER implemented as a native contract.
 type ecrecover struct{}
 
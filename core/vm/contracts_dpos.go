@@ -0,0 +1,223 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/common/math"
+	"github.com/meitu/go-ethereum/crypto"
+	"github.com/meitu/go-ethereum/params"
+	"github.com/meitu/go-ethereum/trie"
+)
+
+// DposStateAddress is the address of the precompiled contract answering queries
+// about the dpos election state of the block being executed.
+var DposStateAddress = common.BytesToAddress([]byte{1, 0})
+
+// DposStakingAddress is the address of the precompiled contract through which
+// contracts perform dpos staking operations for their own address.
+var DposStakingAddress = common.BytesToAddress([]byte{1, 1})
+
+var (
+	errStatefulPrecompile = errors.New("precompiled contract requires an evm")
+	errNoDposContext      = errors.New("dpos context unavailable")
+	errUnknownDposQuery   = errors.New("unknown dpos state query")
+
+	errUnknownDposOperation = errors.New("unknown dpos staking operation")
+	errDposStakingContext   = errors.New("dpos staking must be called directly without value")
+)
+
+// dposStateQuery answers a single ABI encoded query of the dpos state, charging
+// the contract for the trie entries it reads.
+type dposStateQuery func(evm *EVM, contract *Contract, args []byte) ([]byte, error)
+
+// dposStateQueries maps the ABI method selectors to the queries implementing
+// them, mirroring the following Solidity interface:
+//
+//	interface DposState {
+//	    function validators() external view returns (address[]);
+//	    function isCandidate(address candidate) external view returns (bool);
+//	    function voteOf(address delegator) external view returns (address);
+//	    function votesOf(address candidate) external view returns (uint256);
+//	}
+var dposStateQueries = map[string]dposStateQuery{
+	string(crypto.Keccak256([]byte("validators()"))[:4]):         queryValidators,
+	string(crypto.Keccak256([]byte("isCandidate(address)"))[:4]): queryIsCandidate,
+	string(crypto.Keccak256([]byte("voteOf(address)"))[:4]):      queryVoteOf,
+	string(crypto.Keccak256([]byte("votesOf(address)"))[:4]):     queryVotesOf,
+}
+
+// dposState implemented as a native contract, exposing the dpos election state
+// read only to the EVM.
+type dposState struct{}
+
+func (c *dposState) RequiredGas(input []byte) uint64 {
+	return params.DposStateQueryGas
+}
+
+func (c *dposState) Run(input []byte) ([]byte, error) {
+	return nil, errStatefulPrecompile
+}
+
+func (c *dposState) RunStateful(evm *EVM, contract *Contract, input []byte) ([]byte, error) {
+	if evm.StateDB.DposContext() == nil {
+		return nil, errNoDposContext
+	}
+	if len(input) < 4 {
+		return nil, errUnknownDposQuery
+	}
+	query, ok := dposStateQueries[string(input[:4])]
+	if !ok {
+		return nil, errUnknownDposQuery
+	}
+	return query(evm, contract, input[4:])
+}
+
+// queryValidators returns the validators of the current epoch.
+func queryValidators(evm *EVM, contract *Contract, args []byte) ([]byte, error) {
+	// The validators are unset until the first epoch is elected
+	validators, err := evm.StateDB.DposContext().GetValidators()
+	if err != nil {
+		validators = nil
+	}
+	if !contract.UseGas(uint64(len(validators)) * params.DposStateItemGas) {
+		return nil, ErrOutOfGas
+	}
+	ret := make([]byte, 0, 64+32*len(validators))
+	ret = append(ret, math.PaddedBigBytes(big.NewInt(32), 32)...)
+	ret = append(ret, math.PaddedBigBytes(big.NewInt(int64(len(validators))), 32)...)
+	for _, validator := range validators {
+		ret = append(ret, common.LeftPadBytes(validator.Bytes(), 32)...)
+	}
+	return ret, nil
+}
+
+// queryIsCandidate returns whether the address is logged in as a candidate.
+func queryIsCandidate(evm *EVM, contract *Contract, args []byte) ([]byte, error) {
+	if !contract.UseGas(params.DposStateItemGas) {
+		return nil, ErrOutOfGas
+	}
+	candidate := dposAddressArg(args)
+	if evm.StateDB.DposContext().CandidateTrie().Get(candidate.Bytes()) != nil {
+		return common.LeftPadBytes([]byte{1}, 32), nil
+	}
+	return make([]byte, 32), nil
+}
+
+// queryVoteOf returns the candidate the address delegated to, or the zero
+// address if it hasn't voted.
+func queryVoteOf(evm *EVM, contract *Contract, args []byte) ([]byte, error) {
+	if !contract.UseGas(params.DposStateItemGas) {
+		return nil, ErrOutOfGas
+	}
+	delegator := dposAddressArg(args)
+	candidate := evm.StateDB.DposContext().VoteTrie().Get(delegator.Bytes())
+	return common.LeftPadBytes(candidate, 32), nil
+}
+
+// queryVotesOf returns the total votes of a candidate, being the sum of the
+// balances of its delegators as counted in the elections.
+func queryVotesOf(evm *EVM, contract *Contract, args []byte) ([]byte, error) {
+	candidate := dposAddressArg(args)
+
+	votes := new(big.Int)
+	it := trie.NewIterator(evm.StateDB.DposContext().DelegateTrie().PrefixIterator(candidate.Bytes()))
+	for it.Next() {
+		if !contract.UseGas(params.DposStateItemGas) {
+			return nil, ErrOutOfGas
+		}
+		votes.Add(votes, evm.StateDB.GetBalance(common.BytesToAddress(it.Value)))
+	}
+	return math.PaddedBigBytes(votes, 32), nil
+}
+
+// dposAddressArg decodes the address argument of a call, padding missing
+// input with zeroes.
+func dposAddressArg(args []byte) common.Address {
+	return common.BytesToAddress(getData(args, 12, 20))
+}
+
+// dposStakingOperation performs a single ABI encoded staking operation for the
+// given staker.
+type dposStakingOperation func(db StateDB, staker common.Address, args []byte) error
+
+// dposStakingOperations maps the ABI method selectors to the operations
+// implementing them, mirroring the following Solidity interface:
+//
+//	interface DposStaking {
+//	    function loginCandidate() external;
+//	    function delegate(address candidate) external;
+//	    function undelegate(address candidate) external;
+//	}
+var dposStakingOperations = map[string]dposStakingOperation{
+	string(crypto.Keccak256([]byte("loginCandidate()"))[:4]):    stakeLoginCandidate,
+	string(crypto.Keccak256([]byte("delegate(address)"))[:4]):   stakeDelegate,
+	string(crypto.Keccak256([]byte("undelegate(address)"))[:4]): stakeUnDelegate,
+}
+
+// dposStaking implemented as a native contract, performing the operations of the
+// dpos transaction types for the calling contract. Changes are journaled by the
+// state, so they are reverted along with the rest of failed calls.
+type dposStaking struct{}
+
+func (c *dposStaking) RequiredGas(input []byte) uint64 {
+	return params.DposStakingGas
+}
+
+func (c *dposStaking) Run(input []byte) ([]byte, error) {
+	return nil, errStatefulPrecompile
+}
+
+func (c *dposStaking) RunStateful(evm *EVM, contract *Contract, input []byte) ([]byte, error) {
+	if evm.interpreter.readOnly {
+		return nil, errWriteProtection
+	}
+	// Only stake for the caller itself: delegated calls would act on behalf of
+	// the caller's caller and value sent along would be lost.
+	if contract.Address() != DposStakingAddress || contract.Value().Sign() != 0 {
+		return nil, errDposStakingContext
+	}
+	if evm.StateDB.DposContext() == nil {
+		return nil, errNoDposContext
+	}
+	if len(input) < 4 {
+		return nil, errUnknownDposOperation
+	}
+	operation, ok := dposStakingOperations[string(input[:4])]
+	if !ok {
+		return nil, errUnknownDposOperation
+	}
+	return nil, operation(evm.StateDB, contract.Caller(), input[4:])
+}
+
+// stakeLoginCandidate logs the staker in as a candidate.
+func stakeLoginCandidate(db StateDB, staker common.Address, args []byte) error {
+	return db.BecomeCandidate(staker)
+}
+
+// stakeDelegate votes for a candidate with the staker's balance.
+func stakeDelegate(db StateDB, staker common.Address, args []byte) error {
+	return db.Delegate(staker, dposAddressArg(args))
+}
+
+// stakeUnDelegate withdraws the staker's vote from a candidate.
+func stakeUnDelegate(db StateDB, staker common.Address, args []byte) error {
+	return db.UnDelegate(staker, dposAddressArg(args))
+}
@@ -0,0 +1,237 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/core/state"
+	"github.com/meitu/go-ethereum/core/types"
+	"github.com/meitu/go-ethereum/crypto"
+	"github.com/meitu/go-ethereum/ethdb"
+	"github.com/meitu/go-ethereum/params"
+)
+
+// newDposStateEVM creates an EVM on a chain activating the dpos state and staking
+// precompiles at the given blocks, with two candidates, the first one voted for by two
+// funded delegators and elected as the only validator.
+func newDposStateEVM(t *testing.T, stateFork, stakingFork int64) (*EVM, []common.Address) {
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	dposContext, err := types.NewDposContext(db)
+	if err != nil {
+		t.Fatalf("failed to create dpos context: %v", err)
+	}
+	addrs := []common.Address{{0x01}, {0x02}, {0x03}, {0x04}}
+	for _, candidate := range addrs[:2] {
+		if err := dposContext.BecomeCandidate(candidate); err != nil {
+			t.Fatalf("failed to add candidate: %v", err)
+		}
+	}
+	for i, delegator := range addrs[2:] {
+		statedb.AddBalance(delegator, big.NewInt(int64(100*(i+1))))
+		if err := dposContext.Delegate(delegator, addrs[0]); err != nil {
+			t.Fatalf("failed to delegate: %v", err)
+		}
+	}
+	dposContext.SetValidators(addrs[:1])
+	statedb.SetDposContext(dposContext)
+
+	config := *params.TestChainConfig
+	config.DposStateBlock = big.NewInt(stateFork)
+	config.DposStakingBlock = big.NewInt(stakingFork)
+
+	ctx := Context{
+		CanTransfer: func(StateDB, common.Address, *big.Int) bool { return true },
+		Transfer:    func(StateDB, common.Address, common.Address, *big.Int) {},
+		BlockNumber: big.NewInt(1),
+	}
+	return NewEVM(ctx, statedb, &config, Config{}), addrs
+}
+
+// dposCall encodes a call of a dpos precompile method taking optional addresses.
+func dposCall(method string, args ...common.Address) []byte {
+	input := crypto.Keccak256([]byte(method))[:4]
+	for _, arg := range args {
+		input = append(input, common.LeftPadBytes(arg.Bytes(), 32)...)
+	}
+	return input
+}
+
+func TestDposStatePrecompile(t *testing.T) {
+	evm, addrs := newDposStateEVM(t, 0, 0)
+
+	word := func(b []byte) []byte { return common.LeftPadBytes(b, 32) }
+	tests := []struct {
+		input []byte
+		want  []byte
+	}{
+		{dposCall("validators()"), append(append(word([]byte{32}), word([]byte{1})...), word(addrs[0].Bytes())...)},
+		{dposCall("isCandidate(address)", addrs[1]), word([]byte{1})},
+		{dposCall("isCandidate(address)", addrs[2]), word(nil)},
+		{dposCall("voteOf(address)", addrs[3]), word(addrs[0].Bytes())},
+		{dposCall("voteOf(address)", addrs[0]), word(nil)},
+		{dposCall("votesOf(address)", addrs[0]), word([]byte{1, 44})}, // 300 wei
+		{dposCall("votesOf(address)", addrs[1]), word(nil)},
+	}
+	for i, tt := range tests {
+		ret, _, err := evm.StaticCall(AccountRef(addrs[2]), DposStateAddress, tt.input, 100000)
+		if err != nil {
+			t.Errorf("test %d: query failed: %v", i, err)
+			continue
+		}
+		if !bytes.Equal(ret, tt.want) {
+			t.Errorf("test %d: result mismatch: have %x, want %x", i, ret, tt.want)
+		}
+	}
+	// Unknown queries and insufficient gas must fail
+	if _, _, err := evm.StaticCall(AccountRef(addrs[2]), DposStateAddress, []byte{1, 2, 3, 4}, 100000); err != errUnknownDposQuery {
+		t.Errorf("unknown query error mismatch: have %v, want %v", err, errUnknownDposQuery)
+	}
+	gas := params.DposStateQueryGas + params.DposStateItemGas
+	if _, _, err := evm.StaticCall(AccountRef(addrs[2]), DposStateAddress, dposCall("votesOf(address)", addrs[0]), gas); err != ErrOutOfGas {
+		t.Errorf("out of gas error mismatch: have %v, want %v", err, ErrOutOfGas)
+	}
+}
+
+func TestDposStatePrecompileFork(t *testing.T) {
+	evm, addrs := newDposStateEVM(t, 2, 2)
+
+	// Before the fork the precompile address is a plain empty account
+	ret, gas, err := evm.Call(AccountRef(addrs[2]), DposStateAddress, dposCall("validators()"), 100000, new(big.Int))
+	if err != nil || len(ret) != 0 || gas != 100000 {
+		t.Fatalf("call before fork mismatch: ret %x, gas %d, err %v", ret, gas, err)
+	}
+	// Without a dpos context the queries can't be answered
+	evm, addrs = newDposStateEVM(t, 0, 0)
+	evm.StateDB.(*state.StateDB).SetDposContext(nil)
+	if _, _, err := evm.Call(AccountRef(addrs[2]), DposStateAddress, dposCall("validators()"), 100000, new(big.Int)); err != errNoDposContext {
+		t.Fatalf("missing context error mismatch: have %v, want %v", err, errNoDposContext)
+	}
+}
+
+// Tests that the dpos precompiles are added to the contracts of the active
+// release instead of replacing them.
+func TestDposPrecompilesReleaseSet(t *testing.T) {
+	modExp := common.BytesToAddress([]byte{5})
+
+	for _, byzantium := range []bool{false, true} {
+		config := *params.TestChainConfig
+		config.DposStateBlock = big.NewInt(0)
+		config.DposStakingBlock = big.NewInt(0)
+		if !byzantium {
+			config.ByzantiumBlock = nil
+		}
+		evm := NewEVM(Context{BlockNumber: big.NewInt(1)}, nil, &config, Config{})
+
+		precompiles := evm.precompiles()
+		if precompiles[DposStateAddress] == nil || precompiles[DposStakingAddress] == nil {
+			t.Errorf("byzantium %v: dpos precompiles missing", byzantium)
+		}
+		if (precompiles[modExp] != nil) != byzantium {
+			t.Errorf("byzantium %v: modexp precompile presence mismatch", byzantium)
+		}
+		if precompiles[common.BytesToAddress([]byte{1})] == nil {
+			t.Errorf("byzantium %v: ecrecover precompile missing", byzantium)
+		}
+	}
+}
+
+// Tests that contracts can stake for their own address through the precompile,
+// and that their operations are reverted along with the calls performing them.
+func TestDposStakingPrecompile(t *testing.T) {
+	evm, addrs := newDposStateEVM(t, 0, 0)
+
+	// Contracts forwarding their input to the staking precompile, either
+	// returning the success of a call or delegated call, or reverting after it
+	var (
+		caller   = common.Address{0xc0}
+		reverter = common.Address{0xc1}
+		delegate = common.Address{0xc2}
+	)
+	evm.StateDB.SetCode(caller, common.Hex2Bytes("3660006000376000600036600060006101015af160005260206000f3"))
+	evm.StateDB.SetCode(reverter, common.Hex2Bytes("3660006000376000600036600060006101015af160006000fd"))
+	evm.StateDB.SetCode(delegate, common.Hex2Bytes("366000600037600060003660006101015af460005260206000f3"))
+
+	success := common.LeftPadBytes([]byte{1}, 32)
+	call := func(contract common.Address, input []byte) ([]byte, error) {
+		ret, _, err := evm.Call(AccountRef(addrs[2]), contract, input, 100000, new(big.Int))
+		return ret, err
+	}
+	// Contracts may log in and vote for candidates
+	if ret, err := call(caller, dposCall("loginCandidate()")); err != nil || !bytes.Equal(ret, success) {
+		t.Fatalf("login failed: ret %x, err %v", ret, err)
+	}
+	if evm.StateDB.DposContext().CandidateTrie().Get(caller.Bytes()) == nil {
+		t.Fatalf("contract not logged in as candidate")
+	}
+	if ret, err := call(caller, dposCall("delegate(address)", addrs[0])); err != nil || !bytes.Equal(ret, success) {
+		t.Fatalf("delegation failed: ret %x, err %v", ret, err)
+	}
+	if vote := evm.StateDB.DposContext().VoteTrie().Get(caller.Bytes()); common.BytesToAddress(vote) != addrs[0] {
+		t.Fatalf("contract vote mismatch: have %x, want %x", vote, addrs[0])
+	}
+	// Invalid operations fail the inner call only
+	if ret, err := call(caller, dposCall("undelegate(address)", addrs[1])); err != nil || bytes.Equal(ret, success) {
+		t.Fatalf("mismatching undelegation succeeded: ret %x, err %v", ret, err)
+	}
+	if ret, err := call(caller, dposCall("undelegate(address)", addrs[0])); err != nil || !bytes.Equal(ret, success) {
+		t.Fatalf("undelegation failed: ret %x, err %v", ret, err)
+	}
+	if vote := evm.StateDB.DposContext().VoteTrie().Get(caller.Bytes()); vote != nil {
+		t.Fatalf("contract vote not withdrawn: have %x", vote)
+	}
+	// Operations are reverted along with the calls performing them
+	if _, err := call(reverter, dposCall("loginCandidate()")); err != errExecutionReverted {
+		t.Fatalf("reverting call error mismatch: have %v, want %v", err, errExecutionReverted)
+	}
+	if evm.StateDB.DposContext().CandidateTrie().Get(reverter.Bytes()) != nil {
+		t.Fatalf("reverted login persisted")
+	}
+	// Contracts can't stake on behalf of their callers
+	if ret, err := call(delegate, dposCall("loginCandidate()")); err != nil || bytes.Equal(ret, success) {
+		t.Fatalf("delegated login succeeded: ret %x, err %v", ret, err)
+	}
+	if evm.StateDB.DposContext().CandidateTrie().Get(addrs[2].Bytes()) != nil {
+		t.Fatalf("caller logged in by delegated call")
+	}
+	if _, _, err := evm.StaticCall(AccountRef(caller), DposStakingAddress, dposCall("loginCandidate()"), 100000); err != errWriteProtection {
+		t.Fatalf("static call error mismatch: have %v, want %v", err, errWriteProtection)
+	}
+}
+
+// Tests that the staking precompile is activated by its own fork, independently
+// of the dpos state one.
+func TestDposStakingPrecompileFork(t *testing.T) {
+	evm, addrs := newDposStateEVM(t, 0, 2)
+
+	// Before the fork the precompile address is a plain empty account
+	ret, gas, err := evm.Call(AccountRef(addrs[2]), DposStakingAddress, dposCall("loginCandidate()"), 100000, new(big.Int))
+	if err != nil || len(ret) != 0 || gas != 100000 {
+		t.Fatalf("call before fork mismatch: ret %x, gas %d, err %v", ret, gas, err)
+	}
+	if evm.StateDB.DposContext().CandidateTrie().Get(addrs[2].Bytes()) != nil {
+		t.Fatalf("caller logged in before the staking fork")
+	}
+	// While the state precompile is already active
+	if _, _, err := evm.Call(AccountRef(addrs[2]), DposStateAddress, dposCall("validators()"), 100000, new(big.Int)); err != nil {
+		t.Fatalf("state precompile call failed: %v", err)
+	}
+}
@@ -40,11 +40,10 @@ type (
 // run runs the given contract and takes care of running precompiles with a fallback to the byte code interpreter.
 func run(evm *EVM, snapshot int, contract *Contract, input []byte) ([]byte, error) {
 	if contract.CodeAddr != nil {
-		precompiles := PrecompiledContractsHomestead
-		if evm.ChainConfig().IsByzantium(evm.BlockNumber) {
-			precompiles = PrecompiledContractsByzantium
-		}
-		if p := precompiles[*contract.CodeAddr]; p != nil {
+		if p := evm.precompiled[*contract.CodeAddr]; p != nil {
+			if sp, ok := p.(StatefulPrecompiledContract); ok {
+				return RunStatefulPrecompiledContract(evm, sp, input, contract)
+			}
 			return RunPrecompiledContract(p, input, contract)
 		}
 	}
@@ -95,6 +94,8 @@ type EVM struct {
 	chainConfig *params.ChainConfig
 	// chain rules contains the chain rules for the current epoch
 	chainRules params.Rules
+	// precompiled contracts active under the chain rules
+	precompiled map[common.Address]PrecompiledContract
 	// virtual machine configuration options used to initialise the
 	// evm.
 	vmConfig Config
@@ -116,11 +117,40 @@ func NewEVM(ctx Context, statedb StateDB, chainConfig *params.ChainConfig, vmCon
 		chainConfig: chainConfig,
 		chainRules:  chainConfig.Rules(ctx.BlockNumber),
 	}
+	evm.precompiled = evm.precompiles()
 
 	evm.interpreter = NewInterpreter(evm, vmConfig)
 	return evm
 }
 
+// precompiles assembles the set of precompiled contracts active in the block
+// being processed: the ones of the Homestead or Byzantium release, plus the dpos
+// contracts of the dpos state and staking forks.
+func (evm *EVM) precompiles() map[common.Address]PrecompiledContract {
+	base := PrecompiledContractsHomestead
+	if evm.chainRules.IsByzantium {
+		base = PrecompiledContractsByzantium
+	}
+	if !evm.chainRules.IsDposState && !evm.chainRules.IsDposStaking {
+		return base
+	}
+	contracts := make(map[common.Address]PrecompiledContract, len(base)+len(PrecompiledContractsDpos)+len(PrecompiledContractsDposStaking))
+	for addr, p := range base {
+		contracts[addr] = p
+	}
+	if evm.chainRules.IsDposState {
+		for addr, p := range PrecompiledContractsDpos {
+			contracts[addr] = p
+		}
+	}
+	if evm.chainRules.IsDposStaking {
+		for addr, p := range PrecompiledContractsDposStaking {
+			contracts[addr] = p
+		}
+	}
+	return contracts
+}
+
 // Cancel cancels any running EVM operation. This may be called concurrently and
 // it's safe to be called multiple times.
 func (evm *EVM) Cancel() {
@@ -150,11 +180,7 @@ func (evm *EVM) Call(caller ContractRef, addr common.Address, input []byte, gas
 		snapshot = evm.StateDB.Snapshot()
 	)
 	if !evm.StateDB.Exist(addr) {
-		precompiles := PrecompiledContractsHomestead
-		if evm.ChainConfig().IsByzantium(evm.BlockNumber) {
-			precompiles = PrecompiledContractsByzantium
-		}
-		if precompiles[addr] == nil && evm.ChainConfig().IsEIP158(evm.BlockNumber) && value.Sign() == 0 {
+		if evm.precompiled[addr] == nil && evm.ChainConfig().IsEIP158(evm.BlockNumber) && value.Sign() == 0 {
 			return nil, gas, nil
 		}
 		evm.StateDB.CreateAccount(addr)
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/common/uint256"
 	"github.com/meitu/go-ethereum/params"
 )
 
@@ -29,14 +30,14 @@ func TestByteOp(t *testing.T) {
 	}
 	pc := uint64(0)
 	for _, test := range tests {
-		val := new(big.Int).SetBytes(common.Hex2Bytes(test.v))
-		th := new(big.Int).SetUint64(test.th)
+		val := new(uint256.Int).SetBytes(common.Hex2Bytes(test.v))
+		th := new(uint256.Int).SetUint64(test.th)
 		stack.push(val)
 		stack.push(th)
 		opByte(&pc, env, nil, nil, stack)
 		actual := stack.pop()
-		if actual.Cmp(test.expected) != 0 {
-			t.Fatalf("Expected  [%v] %v:th byte to be %v, was %v.", test.v, test.th, test.expected, actual)
+		if actual.ToBig().Cmp(test.expected) != 0 {
+			t.Fatalf("Expected  [%v] %v:th byte to be %v, was %v.", test.v, test.th, test.expected, &actual)
 		}
 	}
 }
@@ -55,7 +56,7 @@ func opBenchmark(bench *testing.B, op func(pc *uint64, evm *EVM, contract *Contr
 	bench.ResetTimer()
 	for i := 0; i < bench.N; i++ {
 		for _, arg := range byteArgs {
-			a := new(big.Int).SetBytes(arg)
+			a := new(uint256.Int).SetBytes(arg)
 			stack.push(a)
 		}
 		op(&pc, env, nil, nil, stack)
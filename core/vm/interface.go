@@ -62,6 +62,13 @@ type StateDB interface {
 	AddPreimage(common.Hash, []byte)
 
 	ForEachStorage(common.Address, func(common.Hash, common.Hash) bool)
+
+	// DposContext returns the dpos election state, or nil if unavailable. It
+	// must only be modified through the journaled operations below.
+	DposContext() *types.DposContext
+	BecomeCandidate(common.Address) error
+	Delegate(common.Address, common.Address) error
+	UnDelegate(common.Address, common.Address) error
 }
 
 // CallContext provides a basic interface for the EVM calling conventions. The EVM EVM
@@ -22,6 +22,7 @@ import (
 
 	"github.com/meitu/go-ethereum/common"
 	"github.com/meitu/go-ethereum/common/math"
+	"github.com/meitu/go-ethereum/common/uint256"
 	"github.com/meitu/go-ethereum/crypto"
 	"github.com/meitu/go-ethereum/params"
 )
@@ -57,7 +58,6 @@ type Interpreter struct {
 	evm      *EVM
 	cfg      Config
 	gasTable params.GasTable
-	intPool  *intPool
 
 	readOnly   bool   // Whether to throw on stateful modifications
 	returnData []byte // Last CALL's return data for subsequent reuse
@@ -83,7 +83,6 @@ func NewInterpreter(evm *EVM, cfg Config) *Interpreter {
 		evm:      evm,
 		cfg:      cfg,
 		gasTable: evm.ChainConfig().GasTable(evm.BlockNumber),
-		intPool:  newIntPool(),
 	}
 }
 
@@ -95,7 +94,7 @@ func (in *Interpreter) enforceRestrictions(op OpCode, operation operation, stack
 			// for a call operation is the value. Transferring value from one
 			// account to the others means the state is modified and should also
 			// return with an error.
-			if operation.writes || (op == CALL && stack.Back(2).BitLen() > 0) {
+			if operation.writes || (op == CALL && stack.Back(2).Sign() != 0) {
 				return errWriteProtection
 			}
 		}
@@ -138,12 +137,13 @@ func (in *Interpreter) Run(snapshot int, contract *Contract, input []byte) (ret
 		pc   = uint64(0) // program counter
 		cost uint64
 		// copies used by tracer
-		stackCopy = newstack() // stackCopy needed for Tracer since stack is mutated by 63/64 gas rule
+		stackCopy = new(Stack) // stackCopy needed for Tracer since stack is mutated by 63/64 gas rule
 		pcCopy    uint64       // needed for the deferred Tracer
 		gasCopy   uint64       // for Tracer to log gas remaining before execution
 		logged    bool         // deferred Tracer should ignore already logged steps
 	)
 	contract.Input = input
+	defer returnStack(stack)
 
 	defer func() {
 		if err != nil && !logged && in.cfg.Debug {
@@ -163,10 +163,7 @@ func (in *Interpreter) Run(snapshot int, contract *Contract, input []byte) (ret
 			logged = false
 			pcCopy = pc
 			gasCopy = contract.Gas
-			stackCopy = newstack()
-			for _, val := range stack.data {
-				stackCopy.push(val)
-			}
+			stackCopy = &Stack{data: append([]uint256.Int(nil), stack.data...)}
 		}
 
 		// Get the operation from the jump table matching the opcode and validate the
@@ -187,7 +184,7 @@ func (in *Interpreter) Run(snapshot int, contract *Contract, input []byte) (ret
 		// calculate the new memory size and expand the memory to fit
 		// the operation
 		if operation.memorySize != nil {
-			memSize, overflow := bigUint64(operation.memorySize(stack))
+			memSize, overflow := operation.memorySize(stack)
 			if overflow {
 				return nil, errGasUintOverflow
 			}
@@ -217,11 +214,6 @@ func (in *Interpreter) Run(snapshot int, contract *Contract, input []byte) (ret
 
 		// execute the operation
 		res, err := operation.execute(&pc, in.evm, contract, mem, stack)
-		// verifyPool is a build flag. Pool verification makes sure the integrity
-		// of the integer pool by comparing values to a default value.
-		if verifyPool {
-			verifyIntegerPool(in.intPool)
-		}
 		// if the operation clears the return data (e.g. it has returning data)
 		// set the last return to the result of the operation.
 		if operation.returns {
@@ -130,8 +130,8 @@ func (l *StructLogger) CaptureState(env *EVM, pc uint64, op OpCode, gas, cost ui
 	// it in the local storage container.
 	if op == SSTORE && stack.len() >= 2 {
 		var (
-			value   = common.BigToHash(stack.data[stack.len()-2])
-			address = common.BigToHash(stack.data[stack.len()-1])
+			value   = common.Hash(stack.Back(1).Bytes32())
+			address = common.Hash(stack.Back(0).Bytes32())
 		)
 		l.changedValues[contract.Address()][address] = value
 	}
@@ -145,8 +145,8 @@ func (l *StructLogger) CaptureState(env *EVM, pc uint64, op OpCode, gas, cost ui
 	var stck []*big.Int
 	if !l.cfg.DisableStack {
 		stck = make([]*big.Int, len(stack.Data()))
-		for i, item := range stack.Data() {
-			stck[i] = new(big.Int).Set(item)
+		for i := range stack.Data() {
+			stck[i] = stack.data[i].ToBig()
 		}
 	}
 	// Copy a snapshot of the current storage to a new container
@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/common/uint256"
 	"github.com/meitu/go-ethereum/params"
 )
 
@@ -54,8 +55,8 @@ func TestStoreCapture(t *testing.T) {
 		stack    = newstack()
 		contract = NewContract(&dummyContractRef{}, &dummyContractRef{}, new(big.Int), 0)
 	)
-	stack.push(big.NewInt(1))
-	stack.push(big.NewInt(0))
+	stack.push(uint256.NewInt(1))
+	stack.push(uint256.NewInt(0))
 
 	var index common.Hash
 
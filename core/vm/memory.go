@@ -16,7 +16,11 @@
 
 package vm
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/meitu/go-ethereum/common/uint256"
+)
 
 // Memory implements a simple memory model for the ethereum virtual machine.
 type Memory struct {
@@ -43,6 +47,18 @@ func (m *Memory) Set(offset, size uint64, value []byte) {
 	}
 }
 
+// Set32 sets the 32 bytes starting at offset to the value of val, left-padded
+// with zeroes.
+func (m *Memory) Set32(offset uint64, val *uint256.Int) {
+	// length of store may never be less than offset + size.
+	// The store should be resized PRIOR to setting the memory
+	if offset+32 > uint64(len(m.store)) {
+		panic("invalid memory: store empty")
+	}
+	b := val.Bytes32()
+	copy(m.store[offset:offset+32], b[:])
+}
+
 // Resize resizes the memory to size
 func (m *Memory) Resize(size uint64) {
 	if uint64(m.Len()) < size {
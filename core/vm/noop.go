@@ -68,3 +68,7 @@ func (NoopStateDB) Snapshot() int
 func (NoopStateDB) AddLog(*types.Log)                                                  {}
 func (NoopStateDB) AddPreimage(common.Hash, []byte)                                    {}
 func (NoopStateDB) ForEachStorage(common.Address, func(common.Hash, common.Hash) bool) {}
+func (NoopStateDB) DposContext() *types.DposContext                                    { return nil }
+func (NoopStateDB) BecomeCandidate(common.Address) error                               { return nil }
+func (NoopStateDB) Delegate(common.Address, common.Address) error                      { return nil }
+func (NoopStateDB) UnDelegate(common.Address, common.Address) error                    { return nil }
@@ -420,16 +420,27 @@ func (api *PrivateDebugAPI) traceBlock(block *types.Block, logConfig *vm.LogConf
 	if err := api.eth.engine.VerifyHeader(blockchain, block.Header(), true); err != nil {
 		return false, structLogger.StructLogs(), err
 	}
-	statedb, err := blockchain.StateAt(blockchain.GetBlock(block.ParentHash(), block.NumberU64()-1).Root())
+	parent := blockchain.GetBlock(block.ParentHash(), block.NumberU64()-1)
+	if parent == nil {
+		return false, structLogger.StructLogs(), fmt.Errorf("block parent %x not found", block.ParentHash())
+	}
+	statedb, err := blockchain.StateAt(parent.Root())
+	if err != nil {
+		return false, structLogger.StructLogs(), err
+	}
+	// Process on a copy of the block, the cached one is shared with the chain
+	dposContext, err := blockchain.DposContextAt(parent.Header().DposContext)
 	if err != nil {
 		return false, structLogger.StructLogs(), err
 	}
+	block = block.WithSeal(block.Header())
+	block.DposContext = dposContext
 
 	receipts, _, usedGas, err := processor.Process(block, statedb, config)
 	if err != nil {
 		return false, structLogger.StructLogs(), err
 	}
-	if err := validator.ValidateState(block, blockchain.GetBlock(block.ParentHash(), block.NumberU64()-1), statedb, receipts, usedGas); err != nil {
+	if err := validator.ValidateState(block, parent, statedb, receipts, usedGas); err != nil {
 		return false, structLogger.StructLogs(), err
 	}
 	return true, structLogger.StructLogs(), nil
@@ -667,11 +678,11 @@ func (api *PrivateDebugAPI) getModifiedAccounts(startBlock, endBlock *types.Bloc
 		return nil, fmt.Errorf("start block height (%d) must be less than end block height (%d)", startBlock.Number().Uint64(), endBlock.Number().Uint64())
 	}
 
-	oldTrie, err := trie.NewSecure(startBlock.Root(), api.eth.chainDb, 0)
+	oldTrie, err := trie.NewSecure(startBlock.Root(), api.eth.blockchain.StateCache().TrieDB(), 0)
 	if err != nil {
 		return nil, err
 	}
-	newTrie, err := trie.NewSecure(endBlock.Root(), api.eth.chainDb, 0)
+	newTrie, err := trie.NewSecure(endBlock.Root(), api.eth.blockchain.StateCache().TrieDB(), 0)
 	if err != nil {
 		return nil, err
 	}
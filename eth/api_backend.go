@@ -108,10 +108,17 @@ func (b *EthApiBackend) GetTd(blockHash common.Hash) *big.Int {
 	return b.eth.blockchain.GetTdByHash(blockHash)
 }
 
+func (b *EthApiBackend) DposContextAt(ctx context.Context, header *types.Header) (*types.DposContext, error) {
+	return b.eth.blockchain.DposContextAt(header.DposContext)
+}
+
 func (b *EthApiBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmCfg vm.Config) (*vm.EVM, func() error, error) {
 	state.SetBalance(msg.From(), math.MaxBig256)
 	vmError := func() error { return nil }
 
+	if dposContext, err := b.DposContextAt(ctx, header); err == nil {
+		state.SetDposContext(dposContext)
+	}
 	context := core.NewEVMContext(msg, header, b.eth.BlockChain(), nil)
 	return vm.NewEVM(context, state, b.eth.chainConfig, vmCfg), vmError, nil
 }
@@ -160,10 +167,30 @@ func (b *EthApiBackend) Stats() (pending int, queued int) {
 	return b.eth.txPool.Stats()
 }
 
+func (b *EthApiBackend) StatsByType() (pending map[types.TxType]int, queued map[types.TxType]int) {
+	return b.eth.txPool.StatsByType()
+}
+
 func (b *EthApiBackend) TxPoolContent() (map[common.Address]types.Transactions, map[common.Address]types.Transactions) {
 	return b.eth.TxPool().Content()
 }
 
+func (b *EthApiBackend) TxPoolContentFrom(addr common.Address) (types.Transactions, types.Transactions) {
+	return b.eth.TxPool().ContentFrom(addr)
+}
+
+func (b *EthApiBackend) TxPoolLookup(hash common.Hash) (*types.Transaction, core.TxStatus, string) {
+	return b.eth.TxPool().Lookup(hash)
+}
+
+func (b *EthApiBackend) RemovePoolTransaction(hash common.Hash) bool {
+	return b.eth.TxPool().RemoveTx(hash)
+}
+
+func (b *EthApiBackend) RemovePoolTransactionsFrom(addr common.Address) types.Transactions {
+	return b.eth.TxPool().RemoveFrom(addr)
+}
+
 func (b *EthApiBackend) SubscribeTxPreEvent(ch chan<- core.TxPreEvent) event.Subscription {
 	return b.eth.TxPool().SubscribeTxPreEvent(ch)
 }
@@ -180,6 +207,18 @@ func (b *EthApiBackend) SuggestPrice(ctx context.Context) (*big.Int, error) {
 	return b.gpo.SuggestPrice(ctx)
 }
 
+func (b *EthApiBackend) SuggestPriceTiers(ctx context.Context) (*big.Int, *big.Int, *big.Int, error) {
+	tiers, err := b.gpo.SuggestTiers(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return tiers.Low, tiers.Standard, tiers.Fast, nil
+}
+
+func (b *EthApiBackend) FeeHistory(ctx context.Context, blocks int, lastBlock rpc.BlockNumber, percentiles []float64) (*big.Int, [][]*big.Int, []float64, error) {
+	return b.gpo.FeeHistory(ctx, blocks, lastBlock, percentiles)
+}
+
 func (b *EthApiBackend) ChainDb() ethdb.Database {
 	return b.eth.ChainDb()
 }
@@ -108,7 +108,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	if !config.SyncMode.IsValid() {
 		return nil, fmt.Errorf("invalid sync mode %d", config.SyncMode)
 	}
-	chainDb, err := CreateDB(ctx, config, "chaindata")
+	chainDb, err := CreateDBWithFreezer(ctx, config, "chaindata")
 	if err != nil {
 		return nil, err
 	}
@@ -145,8 +145,11 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		}
 		core.WriteBlockChainVersion(chainDb, core.BlockChainVersion)
 	}
-	vmConfig := vm.Config{EnablePreimageRecording: config.EnablePreimageRecording}
-	eth.blockchain, err = core.NewBlockChain(chainDb, eth.chainConfig, eth.engine, vmConfig)
+	var (
+		vmConfig    = vm.Config{EnablePreimageRecording: config.EnablePreimageRecording}
+		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieNodeLimit: config.TrieCache, TrieFlushInterval: config.TrieFlush, NoSnapshot: config.NoSnapshot}
+	)
+	eth.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, eth.chainConfig, eth.engine, vmConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -202,12 +205,31 @@ func CreateDB(ctx *node.ServiceContext, config *Config, name string) (ethdb.Data
 	if err != nil {
 		return nil, err
 	}
-	if db, ok := db.(*ethdb.LDBDatabase); ok {
-		db.Meter("eth/db/chaindata/")
+	meterDatabase(db)
+	return db, nil
+}
+
+// CreateDBWithFreezer creates the chain database, backed by an ancient store
+// holding the finalized segments of the chain.
+func CreateDBWithFreezer(ctx *node.ServiceContext, config *Config, name string) (ethdb.Database, error) {
+	db, err := ctx.OpenDatabaseWithFreezer(name, config.DatabaseCache, config.DatabaseHandles, config.DatabaseFreezer, core.FreezerTables)
+	if err != nil {
+		return nil, err
 	}
+	meterDatabase(db)
 	return db, nil
 }
 
+// meterDatabase starts collecting metrics of the chain database, if supported
+// by its storage engine.
+func meterDatabase(db ethdb.Database) {
+	if db, ok := db.(interface {
+		Meter(prefix string)
+	}); ok {
+		db.Meter("eth/db/chaindata/")
+	}
+}
+
 // APIs returns the collection of RPC services the ethereum package offers.
 // NOTE, some of these services probably need to be moved to somewhere else.
 func (s *Ethereum) APIs() []rpc.API {
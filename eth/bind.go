@@ -18,12 +18,15 @@ package eth
 
 import (
 	"context"
+	"errors"
 	"math/big"
 
 	"github.com/meitu/go-ethereum"
 	"github.com/meitu/go-ethereum/common"
 	"github.com/meitu/go-ethereum/common/hexutil"
 	"github.com/meitu/go-ethereum/core/types"
+	"github.com/meitu/go-ethereum/eth/filters"
+	"github.com/meitu/go-ethereum/event"
 	"github.com/meitu/go-ethereum/internal/ethapi"
 	"github.com/meitu/go-ethereum/rlp"
 	"github.com/meitu/go-ethereum/rpc"
@@ -40,16 +43,31 @@ type ContractBackend struct {
 	eapi  *ethapi.PublicEthereumAPI        // Wrapper around the Ethereum object to access metadata
 	bcapi *ethapi.PublicBlockChainAPI      // Wrapper around the blockchain to access chain data
 	txapi *ethapi.PublicTransactionPoolAPI // Wrapper around the transaction pool to access transaction data
+
+	fbackend filters.Backend      // Log retrieval backend, nil if the API backend doesn't support filtering
+	events   *filters.EventSystem // Event system feeding live log subscriptions
 }
 
+// errNoFiltering is returned if logs are requested from an API backend that is
+// unable to serve log filters.
+var errNoFiltering = errors.New("backend does not support log filtering")
+
 // NewContractBackend creates a new native contract backend using an existing
 // Ethereum object.
 func NewContractBackend(apiBackend ethapi.Backend) *ContractBackend {
-	return &ContractBackend{
+	backend := &ContractBackend{
 		eapi:  ethapi.NewPublicEthereumAPI(apiBackend),
 		bcapi: ethapi.NewPublicBlockChainAPI(apiBackend),
 		txapi: ethapi.NewPublicTransactionPoolAPI(apiBackend, new(ethapi.AddrLocker)),
 	}
+	if fbackend, ok := apiBackend.(filters.Backend); ok {
+		// Only the full node backend has the logs locally, anything else is light
+		_, full := apiBackend.(*EthApiBackend)
+
+		backend.fbackend = fbackend
+		backend.events = filters.NewEventSystem(apiBackend.EventMux(), fbackend, !full)
+	}
+	return backend
 }
 
 // CodeAt retrieves any code associated with the contract from the local API.
@@ -136,3 +154,62 @@ func (b *ContractBackend) SendTransaction(ctx context.Context, tx *types.Transac
 	_, err := b.txapi.SendRawTransaction(ctx, raw)
 	return err
 }
+
+// FilterLogs implements bind.ContractFilterer, executing a log filter query
+// against the local chain.
+func (b *ContractBackend) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	if b.fbackend == nil {
+		return nil, errNoFiltering
+	}
+	from, to := rpc.LatestBlockNumber.Int64(), rpc.LatestBlockNumber.Int64()
+	if query.FromBlock != nil {
+		from = query.FromBlock.Int64()
+	}
+	if query.ToBlock != nil {
+		to = query.ToBlock.Int64()
+	}
+	logs, err := filters.New(b.fbackend, from, to, query.Addresses, query.Topics).Logs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]types.Log, len(logs))
+	for i, log := range logs {
+		res[i] = *log
+	}
+	return res, nil
+}
+
+// SubscribeFilterLogs implements bind.ContractFilterer, creating a background
+// log filtering operation, returning a subscription to feed new matches into.
+func (b *ContractBackend) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	if b.events == nil {
+		return nil, errNoFiltering
+	}
+	sink := make(chan []*types.Log)
+	sub, err := b.events.SubscribeLogs(filters.FilterCriteria(query), sink)
+	if err != nil {
+		return nil, err
+	}
+	// Since we're getting logs in batches, we need to flatten them into a plain stream
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case logs := <-sink:
+				for _, log := range logs {
+					select {
+					case ch <- *log:
+					case err := <-sub.Err():
+						return err
+					case <-quit:
+						return nil
+					}
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
@@ -35,6 +35,8 @@ var DefaultConfig = Config{
 	NetworkId:     1357,
 	LightPeers:    20,
 	DatabaseCache: 128,
+	TrieCache:     256,
+	TrieFlush:     256,
 	GasPrice:      big.NewInt(18 * params.Shannon),
 
 	TxPool: core.DefaultTxPoolConfig,
@@ -72,6 +74,11 @@ type Config struct {
 	SkipBcVersionCheck bool `toml:"-"`
 	DatabaseHandles    int  `toml:"-"`
 	DatabaseCache      int
+	DatabaseFreezer    string // Directory of the ancient store for finalized chain segments
+	NoPruning          bool   // Whether to disable pruning and flush everything to disk
+	TrieCache          int    // Memory allowance (MB) for the in-memory trie node cache
+	TrieFlush          uint64 // Number of blocks between flushes of the in-memory tries to disk
+	NoSnapshot         bool   // Whether to disable the flat state snapshot
 
 	// Mining-related options
 	Validator    common.Address `toml:",omitempty"`
@@ -50,7 +50,7 @@ func upgradeDeduplicateData(db ethdb.Database) func() error {
 
 	go func() {
 		// Create an iterator to read the entire database and covert old lookup entires
-		it := db.(*ethdb.LDBDatabase).NewIterator()
+		it := db.NewIteratorWithStart(nil)
 		defer func() {
 			if it != nil {
 				it.Release()
@@ -59,6 +59,7 @@ func upgradeDeduplicateData(db ethdb.Database) func() error {
 
 		var (
 			converted uint64
+			batch     = db.NewBatch()
 			failed    error
 		)
 		for failed == nil && it.Next() {
@@ -86,43 +87,50 @@ func upgradeDeduplicateData(db ethdb.Database) func() error {
 				}
 			}
 			// Convert the old metadata to a new lookup entry, delete duplicate data
-			if failed = db.Put(append([]byte("l"), hash...), it.Value()); failed == nil { // Write the new looku entry
-				if failed = db.Delete(hash); failed == nil { // Delete the duplicate transaction data
-					if failed = db.Delete(append([]byte("receipts-"), hash...)); failed == nil { // Delete the duplicate receipt data
-						if failed = db.Delete(key); failed != nil { // Delete the old transaction metadata
-							break
-						}
-					}
+			batch.Put(append([]byte("l"), hash...), it.Value()) // Write the new looku entry
+			batch.Delete(hash)                                  // Delete the duplicate transaction data
+			batch.Delete(append([]byte("receipts-"), hash...))  // Delete the duplicate receipt data
+			batch.Delete(key)                                   // Delete the old transaction metadata
+
+			// Flush the conversions occasionally. The iterator is released first and
+			// recreated afterwards, as some backends can't write while it's open.
+			converted++
+			if batch.ValueSize() >= ethdb.IdealBatchSize {
+				next := common.CopyBytes(key)
+
+				it.Release()
+				if failed = batch.Write(); failed == nil {
+					batch.Reset()
 				}
+				it = db.NewIteratorWithStart(next)
 			}
-			// Bump the conversion counter, and recreate the iterator occasionally to
-			// avoid too high memory consumption.
-			converted++
 			if converted%100000 == 0 {
-				it.Release()
-				it = db.(*ethdb.LDBDatabase).NewIterator()
-				it.Seek(key)
-
 				log.Info("Deduplicating database entries", "deduped", converted)
 			}
 			// Check for termination, or continue after a bit of a timeout
 			select {
 			case errc := <-stop:
-				errc <- nil
+				it.Release()
+				it = nil
+
+				errc <- batch.Write()
 				return
 			case <-time.After(time.Microsecond * 100):
 			}
 		}
+		it.Release()
+		it = nil
+
 		// Upgrade finished, mark a such and terminate
+		if failed == nil {
+			failed = batch.Write()
+		}
 		if failed == nil {
 			log.Info("Database deduplication successful", "deduped", converted)
 			db.Put(deduplicateData, []byte{42})
 		} else {
 			log.Error("Database deduplication failed", "deduped", converted, "err", failed)
 		}
-		it.Release()
-		it = nil
-
 		errc := <-stop
 		errc <- failed
 	}()
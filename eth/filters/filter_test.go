@@ -239,4 +239,13 @@ func TestFilters(t *testing.T) {
 	if len(logs) != 0 {
 		t.Error("expected 0 log, got", len(logs))
 	}
+
+	// filtering must stop once the context is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	filter = New(backend, 0, -1, []common.Address{addr}, [][]common.Hash{{hash1, hash2, hash3, hash4}})
+	if _, err := filter.Logs(ctx); err != context.Canceled {
+		t.Errorf("cancelled filter error mismatch: have %v, want %v", err, context.Canceled)
+	}
 }
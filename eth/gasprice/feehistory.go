@@ -0,0 +1,170 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gasprice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/meitu/go-ethereum/core/types"
+	"github.com/meitu/go-ethereum/rpc"
+)
+
+const (
+	// maxFeeHistory is the maximum number of blocks a fee history can span.
+	maxFeeHistory = 1024
+
+	// maxRewardPercentiles is the maximum number of reward percentiles a fee
+	// history can be requested for.
+	maxRewardPercentiles = 100
+)
+
+var (
+	errInvalidPercentile  = errors.New("invalid reward percentile")
+	errTooManyPercentiles = fmt.Errorf("too many reward percentiles, maximum is %d", maxRewardPercentiles)
+	errMissingHistory     = errors.New("requested block history is not available")
+)
+
+// txGasAndReward is the gas used and the gas price paid by a transaction.
+type txGasAndReward struct {
+	gasUsed uint64
+	reward  *big.Int
+}
+
+type sortGasAndReward []txGasAndReward
+
+func (s sortGasAndReward) Len() int           { return len(s) }
+func (s sortGasAndReward) Less(i, j int) bool { return s[i].reward.Cmp(s[j].reward) < 0 }
+func (s sortGasAndReward) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// FeeHistory returns the gas used ratios of at most blocks consecutive blocks
+// ending with lastBlock, and for each block the gas prices paid at the given
+// percentiles of its gas usage. The returned slices start with the oldest block,
+// whose number is returned too.
+//
+// The percentiles must be monotonically increasing values in the [0, 100]
+// range. If no percentiles are requested, no receipts are retrieved and the
+// returned rewards are nil.
+func (gpo *Oracle) FeeHistory(ctx context.Context, blocks int, lastBlock rpc.BlockNumber, percentiles []float64) (*big.Int, [][]*big.Int, []float64, error) {
+	if blocks < 1 {
+		return new(big.Int), nil, nil, nil
+	}
+	if blocks > maxFeeHistory {
+		blocks = maxFeeHistory
+	}
+	if len(percentiles) > maxRewardPercentiles {
+		return nil, nil, nil, errTooManyPercentiles
+	}
+	for i, p := range percentiles {
+		if p < 0 || p > 100 || (i > 0 && p < percentiles[i-1]) {
+			return nil, nil, nil, fmt.Errorf("%v: %f", errInvalidPercentile, p)
+		}
+	}
+	// Pending blocks have no receipts, resolve the history from the head
+	if lastBlock == rpc.PendingBlockNumber {
+		lastBlock = rpc.LatestBlockNumber
+	}
+	head, err := gpo.backend.HeaderByNumber(ctx, lastBlock)
+	if head == nil {
+		if err == nil {
+			err = errMissingHistory
+		}
+		return nil, nil, nil, err
+	}
+	last := head.Number.Uint64()
+	if uint64(blocks) > last+1 {
+		blocks = int(last + 1)
+	}
+	oldest := last + 1 - uint64(blocks)
+
+	var (
+		reward       [][]*big.Int
+		gasUsedRatio = make([]float64, blocks)
+	)
+	if len(percentiles) > 0 {
+		reward = make([][]*big.Int, blocks)
+	}
+	for i := 0; i < blocks; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, nil, err
+		}
+		block, err := gpo.backend.BlockByNumber(ctx, rpc.BlockNumber(oldest+uint64(i)))
+		if block == nil {
+			if err == nil {
+				err = errMissingHistory
+			}
+			return nil, nil, nil, err
+		}
+		if limit := block.GasLimit(); limit.Sign() > 0 {
+			gasUsedRatio[i], _ = new(big.Rat).SetFrac(block.GasUsed(), limit).Float64()
+		}
+		if reward != nil {
+			if reward[i], err = gpo.blockRewards(ctx, block, percentiles); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+	}
+	return new(big.Int).SetUint64(oldest), reward, gasUsedRatio, nil
+}
+
+// blockRewards calculates the gas prices paid at the given percentiles of the
+// gas used by a block. Empty blocks have zero rewards.
+func (gpo *Oracle) blockRewards(ctx context.Context, block *types.Block, percentiles []float64) ([]*big.Int, error) {
+	rewards := make([]*big.Int, len(percentiles))
+	txs := block.Transactions()
+	if len(txs) == 0 {
+		for i := range rewards {
+			rewards[i] = new(big.Int)
+		}
+		return rewards, nil
+	}
+	receipts, err := gpo.backend.GetReceipts(ctx, block.Hash())
+	if err != nil {
+		return nil, err
+	}
+	if len(receipts) != len(txs) {
+		return nil, errMissingHistory
+	}
+	// Derive the gas used from the cumulative one, which the receipts retrieved
+	// by light clients are guaranteed to contain
+	sorter := make(sortGasAndReward, len(txs))
+	prev := new(big.Int)
+	for i, tx := range txs {
+		used := new(big.Int).Sub(receipts[i].CumulativeGasUsed, prev)
+		sorter[i] = txGasAndReward{gasUsed: used.Uint64(), reward: tx.GasPrice()}
+		prev = receipts[i].CumulativeGasUsed
+	}
+	sort.Sort(sorter)
+
+	var (
+		txIndex    int
+		sumGasUsed = sorter[0].gasUsed
+		gasUsed    = float64(block.GasUsed().Uint64())
+	)
+	for i, p := range percentiles {
+		threshold := uint64(gasUsed * p / 100)
+		for sumGasUsed < threshold && txIndex < len(sorter)-1 {
+			txIndex++
+			sumGasUsed += sorter[txIndex].gasUsed
+		}
+		rewards[i] = sorter[txIndex].reward
+	}
+	return rewards, nil
+}
@@ -30,6 +30,10 @@ import (
 
 var maxPrice = big.NewInt(500 * params.Shannon)
 
+// standardDepth is the number of blocks the pending transactions outbid by a
+// standard price suggestion would take to include.
+const standardDepth = 3
+
 type Config struct {
 	Blocks     int
 	Percentile int
@@ -97,10 +101,100 @@ func (gpo *Oracle) SuggestPrice(ctx context.Context) (*big.Int, error) {
 		return lastPrice, nil
 	}
 
-	blockNum := head.Number.Uint64()
+	txPrices, _, err := gpo.recentPrices(ctx, head.Number.Uint64())
+	if err != nil {
+		return lastPrice, err
+	}
+	price := lastPrice
+	if len(txPrices) > 0 {
+		price = txPrices[(len(txPrices)-1)*gpo.percentile/100]
+	}
+	if price.Cmp(maxPrice) > 0 {
+		price = new(big.Int).Set(maxPrice)
+	}
+
+	gpo.cacheLock.Lock()
+	gpo.lastHead = headHash
+	gpo.lastPrice = price
+	gpo.cacheLock.Unlock()
+	return price, nil
+}
+
+// PriceTiers are gas price suggestions for transactions of different urgency.
+type PriceTiers struct {
+	Low      *big.Int // Price likely to be included once the pool drains
+	Standard *big.Int // Price likely to be included within a few blocks
+	Fast     *big.Int // Price likely to be included in the next block
+}
+
+// SuggestTiers returns low, standard and fast gas price suggestions. They are
+// picked around the configured percentile of the recent block prices and the
+// standard and fast ones are raised to compete with the pending transactions
+// of the pool that would otherwise fill the upcoming blocks.
+func (gpo *Oracle) SuggestTiers(ctx context.Context) (*PriceTiers, error) {
+	head, err := gpo.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if head == nil {
+		return nil, err
+	}
+	txPrices, blocks, err := gpo.recentPrices(ctx, head.Number.Uint64())
+	if err != nil {
+		return nil, err
+	}
+	gpo.cacheLock.RLock()
+	lastPrice := gpo.lastPrice
+	gpo.cacheLock.RUnlock()
+
+	tiers := &PriceTiers{Low: lastPrice, Standard: lastPrice, Fast: lastPrice}
+	if len(txPrices) > 0 {
+		pick := func(percentile int) *big.Int {
+			return txPrices[(len(txPrices)-1)*percentile/100]
+		}
+		tiers.Low = pick(gpo.percentile / 2)
+		tiers.Standard = pick(gpo.percentile)
+		tiers.Fast = pick((gpo.percentile + 100) / 2)
+	}
+	// Match the pending transactions that would fill the upcoming blocks, using
+	// the average transaction count of the recent blocks as their capacity
+	if blocks > 0 {
+		pending, err := gpo.backend.GetPoolTransactions()
+		if err != nil {
+			return nil, err
+		}
+		pendingPrices := make([]*big.Int, len(pending))
+		for i, tx := range pending {
+			pendingPrices[i] = tx.GasPrice()
+		}
+		sort.Sort(sort.Reverse(bigIntArray(pendingPrices)))
+
+		capacity := (len(txPrices) + blocks - 1) / blocks
+		if len(pendingPrices) >= capacity && pendingPrices[capacity-1].Cmp(tiers.Fast) > 0 {
+			tiers.Fast = pendingPrices[capacity-1]
+		}
+		if len(pendingPrices) >= standardDepth*capacity && pendingPrices[standardDepth*capacity-1].Cmp(tiers.Standard) > 0 {
+			tiers.Standard = pendingPrices[standardDepth*capacity-1]
+		}
+	}
+	// Keep the tiers ordered and within the sane price range
+	if tiers.Standard.Cmp(tiers.Fast) > 0 {
+		tiers.Fast = tiers.Standard
+	}
+	for _, price := range []**big.Int{&tiers.Low, &tiers.Standard, &tiers.Fast} {
+		if (*price).Cmp(maxPrice) > 0 {
+			*price = new(big.Int).Set(maxPrice)
+		}
+	}
+	return tiers, nil
+}
+
+// recentPrices collects the gas prices of the transactions included in the
+// blocks up to and including the given one, skipping at most maxEmpty empty
+// blocks and looking at no more than maxBlocks. The prices are returned sorted
+// in ascending order, along with the number of non-empty blocks sampled.
+func (gpo *Oracle) recentPrices(ctx context.Context, blockNum uint64) ([]*big.Int, int, error) {
 	ch := make(chan getBlockPricesResult, gpo.checkBlocks)
 	sent := 0
 	exp := 0
+	blocks := 0
 	var txPrices []*big.Int
 	for sent < gpo.checkBlocks && blockNum > 0 {
 		go gpo.getBlockPrices(ctx, blockNum, ch)
@@ -112,11 +206,12 @@ func (gpo *Oracle) SuggestPrice(ctx context.Context) (*big.Int, error) {
 	for exp > 0 {
 		res := <-ch
 		if res.err != nil {
-			return lastPrice, res.err
+			return nil, 0, res.err
 		}
 		exp--
 		if len(res.prices) > 0 {
 			txPrices = append(txPrices, res.prices...)
+			blocks++
 			continue
 		}
 		if maxEmpty > 0 {
@@ -130,20 +225,8 @@ func (gpo *Oracle) SuggestPrice(ctx context.Context) (*big.Int, error) {
 			blockNum--
 		}
 	}
-	price := lastPrice
-	if len(txPrices) > 0 {
-		sort.Sort(bigIntArray(txPrices))
-		price = txPrices[(len(txPrices)-1)*gpo.percentile/100]
-	}
-	if price.Cmp(maxPrice) > 0 {
-		price = new(big.Int).Set(maxPrice)
-	}
-
-	gpo.cacheLock.Lock()
-	gpo.lastHead = headHash
-	gpo.lastPrice = price
-	gpo.cacheLock.Unlock()
-	return price, nil
+	sort.Sort(bigIntArray(txPrices))
+	return txPrices, blocks, nil
 }
 
 type getBlockPricesResult struct {
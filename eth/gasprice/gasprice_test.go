@@ -0,0 +1,181 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package gasprice
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/core/types"
+	"github.com/meitu/go-ethereum/crypto"
+	"github.com/meitu/go-ethereum/internal/ethapi"
+	"github.com/meitu/go-ethereum/rpc"
+)
+
+// testBackend serves a chain of blocks and a set of pending transactions.
+type testBackend struct {
+	ethapi.Backend
+	blocks   []*types.Block
+	receipts map[common.Hash]types.Receipts
+	pending  types.Transactions
+}
+
+// newTestBackend creates a chain whose block i (i > 0) contains i transactions
+// using 21000 gas each, priced at i, 2i, ... i*i wei.
+func newTestBackend(t *testing.T, length int) *testBackend {
+	key, _ := crypto.GenerateKey()
+	signer := types.HomesteadSigner{}
+
+	b := &testBackend{receipts: make(map[common.Hash]types.Receipts)}
+	nonce := uint64(0)
+	for i := 0; i < length; i++ {
+		var (
+			txs      types.Transactions
+			receipts types.Receipts
+		)
+		for j := 1; j <= i; j++ {
+			tx, err := types.SignTx(types.NewTransaction(types.Binary, nonce, common.Address{}, new(big.Int), big.NewInt(21000), big.NewInt(int64(i*j)), nil), signer, key)
+			if err != nil {
+				t.Fatalf("failed to sign transaction: %v", err)
+			}
+			nonce++
+			txs = append(txs, tx)
+			receipts = append(receipts, types.NewReceipt(nil, false, big.NewInt(int64(21000*j))))
+		}
+		header := &types.Header{
+			Number:   big.NewInt(int64(i)),
+			GasLimit: big.NewInt(21000 * int64(length)),
+			GasUsed:  big.NewInt(21000 * int64(i)),
+		}
+		block := types.NewBlock(header, txs, nil, receipts)
+		b.blocks = append(b.blocks, block)
+		b.receipts[block.Hash()] = receipts
+	}
+	return b
+}
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	if block, _ := b.BlockByNumber(ctx, number); block != nil {
+		return block.Header(), nil
+	}
+	return nil, nil
+}
+
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	if number == rpc.LatestBlockNumber {
+		return b.blocks[len(b.blocks)-1], nil
+	}
+	if int(number) < len(b.blocks) {
+		return b.blocks[number], nil
+	}
+	return nil, nil
+}
+
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return b.receipts[hash], nil
+}
+
+func (b *testBackend) GetPoolTransactions() (types.Transactions, error) {
+	return b.pending, nil
+}
+
+func TestFeeHistory(t *testing.T) {
+	backend := newTestBackend(t, 5)
+	oracle := NewOracle(backend, Config{Blocks: 2, Percentile: 60, Default: big.NewInt(1)})
+
+	tests := []struct {
+		blocks      int
+		last        rpc.BlockNumber
+		percentiles []float64
+		oldest      uint64
+		rewards     [][]int64
+		ratios      []float64
+		fail        bool
+	}{
+		{blocks: 2, last: rpc.LatestBlockNumber, oldest: 3, ratios: []float64{0.6, 0.8}},
+		{blocks: 10, last: 1, oldest: 0, ratios: []float64{0, 0.2}},
+		{blocks: 2, last: 4, percentiles: []float64{0, 50, 100}, oldest: 3, rewards: [][]int64{{3, 6, 9}, {4, 8, 16}}, ratios: []float64{0.6, 0.8}},
+		{blocks: 1, last: 0, percentiles: []float64{50}, oldest: 0, rewards: [][]int64{{0}}, ratios: []float64{0}},
+		{blocks: 1, last: 4, percentiles: []float64{50, 10}, fail: true},
+		{blocks: 1, last: 4, percentiles: []float64{101}, fail: true},
+		{blocks: 1, last: 10, fail: true},
+	}
+	for i, tt := range tests {
+		oldest, rewards, ratios, err := oracle.FeeHistory(context.Background(), tt.blocks, tt.last, tt.percentiles)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("test %d: expected error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: failed to retrieve fee history: %v", i, err)
+			continue
+		}
+		if oldest.Uint64() != tt.oldest {
+			t.Errorf("test %d: oldest block mismatch: have %v, want %d", i, oldest, tt.oldest)
+		}
+		if len(ratios) != len(tt.ratios) {
+			t.Errorf("test %d: gas used ratio count mismatch: have %d, want %d", i, len(ratios), len(tt.ratios))
+			continue
+		}
+		for j, ratio := range ratios {
+			if ratio != tt.ratios[j] {
+				t.Errorf("test %d, block %d: gas used ratio mismatch: have %v, want %v", i, j, ratio, tt.ratios[j])
+			}
+		}
+		if len(rewards) != len(tt.rewards) {
+			t.Errorf("test %d: reward count mismatch: have %d, want %d", i, len(rewards), len(tt.rewards))
+			continue
+		}
+		for j := range rewards {
+			for k, reward := range rewards[j] {
+				if reward.Int64() != tt.rewards[j][k] {
+					t.Errorf("test %d, block %d, percentile %d: reward mismatch: have %v, want %d", i, j, k, reward, tt.rewards[j][k])
+				}
+			}
+		}
+	}
+}
+
+func TestSuggestTiers(t *testing.T) {
+	backend := newTestBackend(t, 5)
+	oracle := NewOracle(backend, Config{Blocks: 2, Percentile: 60, Default: big.NewInt(1)})
+
+	// Blocks 3 and 4 contain prices 3, 4, 6, 8, 9, 12, 16, averaging 4 transactions
+	tiers, err := oracle.SuggestTiers(context.Background())
+	if err != nil {
+		t.Fatalf("failed to suggest tiers: %v", err)
+	}
+	if tiers.Low.Int64() != 4 || tiers.Standard.Int64() != 8 || tiers.Fast.Int64() != 9 {
+		t.Fatalf("idle pool tiers mismatch: have %v/%v/%v, want 4/8/9", tiers.Low, tiers.Standard, tiers.Fast)
+	}
+	// Fill the next blocks with pending transactions and ensure they're matched
+	key, _ := crypto.GenerateKey()
+	for i := 0; i < 12; i++ {
+		tx, _ := types.SignTx(types.NewTransaction(types.Binary, uint64(i), common.Address{}, new(big.Int), big.NewInt(21000), big.NewInt(int64(100-i)), nil), types.HomesteadSigner{}, key)
+		backend.pending = append(backend.pending, tx)
+	}
+	if tiers, err = oracle.SuggestTiers(context.Background()); err != nil {
+		t.Fatalf("failed to suggest tiers: %v", err)
+	}
+	if tiers.Low.Int64() != 4 || tiers.Standard.Int64() != 89 || tiers.Fast.Int64() != 97 {
+		t.Fatalf("busy pool tiers mismatch: have %v/%v/%v, want 4/89/97", tiers.Low, tiers.Standard, tiers.Fast)
+	}
+}
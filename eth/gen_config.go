@@ -25,6 +25,11 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		SkipBcVersionCheck      bool `toml:"-"`
 		DatabaseHandles         int  `toml:"-"`
 		DatabaseCache           int
+		DatabaseFreezer         string
+		NoPruning               bool
+		TrieCache               int
+		TrieFlush               uint64
+		NoSnapshot              bool
 		Validator               common.Address `toml:",omitempty"`
 		Coinbase                common.Address `toml:",omitempty"`
 		MinerThreads            int            `toml:",omitempty"`
@@ -48,6 +53,11 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.SkipBcVersionCheck = c.SkipBcVersionCheck
 	enc.DatabaseHandles = c.DatabaseHandles
 	enc.DatabaseCache = c.DatabaseCache
+	enc.DatabaseFreezer = c.DatabaseFreezer
+	enc.NoPruning = c.NoPruning
+	enc.TrieCache = c.TrieCache
+	enc.TrieFlush = c.TrieFlush
+	enc.NoSnapshot = c.NoSnapshot
 	enc.Validator = c.Validator
 	enc.Coinbase = c.Coinbase
 	enc.MinerThreads = c.MinerThreads
@@ -75,6 +85,11 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		SkipBcVersionCheck      *bool `toml:"-"`
 		DatabaseHandles         *int  `toml:"-"`
 		DatabaseCache           *int
+		DatabaseFreezer         *string
+		NoPruning               *bool
+		TrieCache               *int
+		TrieFlush               *uint64
+		NoSnapshot              *bool
 		Validator               *common.Address `toml:",omitempty"`
 		Coinbase                *common.Address `toml:",omitempty"`
 		MinerThreads            *int            `toml:",omitempty"`
@@ -117,6 +132,21 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.DatabaseCache != nil {
 		c.DatabaseCache = *dec.DatabaseCache
 	}
+	if dec.DatabaseFreezer != nil {
+		c.DatabaseFreezer = *dec.DatabaseFreezer
+	}
+	if dec.NoPruning != nil {
+		c.NoPruning = *dec.NoPruning
+	}
+	if dec.TrieCache != nil {
+		c.TrieCache = *dec.TrieCache
+	}
+	if dec.TrieFlush != nil {
+		c.TrieFlush = *dec.TrieFlush
+	}
+	if dec.NoSnapshot != nil {
+		c.NoSnapshot = *dec.NoSnapshot
+	}
 	if dec.Validator != nil {
 		c.Validator = *dec.Validator
 	}
@@ -253,6 +253,17 @@ func (ec *Client) TransactionReceipt(ctx context.Context, txHash common.Hash) (*
 	return r, err
 }
 
+// BlockReceipts returns the receipts of all the transactions of the given block,
+// referenced either by number or by hash.
+func (ec *Client) BlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]*types.Receipt, error) {
+	var r []*types.Receipt
+	err := ec.c.CallContext(ctx, &r, "eth_getBlockReceipts", blockNrOrHash)
+	if err == nil && r == nil {
+		return nil, ethereum.NotFound
+	}
+	return r, err
+}
+
 func toBlockNumArg(number *big.Int) string {
 	if number == nil {
 		return "latest"
@@ -476,6 +487,62 @@ func (ec *Client) SendTransaction(ctx context.Context, tx *types.Transaction) er
 	return ec.c.CallContext(ctx, nil, "eth_sendRawTransaction", common.ToHex(data))
 }
 
+// SendMultiTransfer requests the node to pay all the given recipients with a single
+// multi-transfer transaction from an account it manages. The node fills in the gas,
+// gas price and nonce, signs the transaction and returns its hash. The transfers
+// either all succeed or all fail.
+func (ec *Client) SendMultiTransfer(ctx context.Context, from common.Address, transfers []*types.Transfer) (common.Hash, error) {
+	arg := map[string]interface{}{
+		"from":      from,
+		"type":      types.MultiTransfer,
+		"transfers": transfers,
+	}
+	var hash common.Hash
+	err := ec.c.CallContext(ctx, &hash, "eth_sendTransaction", arg)
+	return hash, err
+}
+
+// LoginCandidate requests the node to register an account it manages as a dpos
+// candidate. The node fills in the gas, gas price and nonce, signs the transaction
+// and returns its hash.
+//
+// DPoS operations of locally held keys are built and signed by bind.TransactDpos,
+// the client serving as its backend.
+func (ec *Client) LoginCandidate(ctx context.Context, from common.Address) (common.Hash, error) {
+	return ec.sendDpos(ctx, from, types.LoginCandidate, nil)
+}
+
+// LogoutCandidate requests the node to withdraw the dpos candidacy of an account
+// it manages.
+func (ec *Client) LogoutCandidate(ctx context.Context, from common.Address) (common.Hash, error) {
+	return ec.sendDpos(ctx, from, types.LogoutCandidate, nil)
+}
+
+// Delegate requests the node to vote for the given candidate with the balance of
+// an account it manages.
+func (ec *Client) Delegate(ctx context.Context, from, candidate common.Address) (common.Hash, error) {
+	return ec.sendDpos(ctx, from, types.Delegate, &candidate)
+}
+
+// UnDelegate requests the node to withdraw the vote of an account it manages from
+// the given candidate.
+func (ec *Client) UnDelegate(ctx context.Context, from, candidate common.Address) (common.Hash, error) {
+	return ec.sendDpos(ctx, from, types.UnDelegate, &candidate)
+}
+
+func (ec *Client) sendDpos(ctx context.Context, from common.Address, txType types.TxType, candidate *common.Address) (common.Hash, error) {
+	arg := map[string]interface{}{
+		"from": from,
+		"type": txType,
+	}
+	if candidate != nil {
+		arg["to"] = candidate
+	}
+	var hash common.Hash
+	err := ec.c.CallContext(ctx, &hash, "eth_sendTransaction", arg)
+	return hash, err
+}
+
 func toCallArg(msg ethereum.CallMsg) interface{} {
 	arg := map[string]interface{}{
 		"from": msg.From,
@@ -16,7 +16,20 @@
 
 package ethclient
 
-import "github.com/meitu/go-ethereum"
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/meitu/go-ethereum"
+	"github.com/meitu/go-ethereum/accounts/abi/bind"
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/common/hexutil"
+	"github.com/meitu/go-ethereum/core/types"
+	"github.com/meitu/go-ethereum/crypto"
+	"github.com/meitu/go-ethereum/rlp"
+	"github.com/meitu/go-ethereum/rpc"
+)
 
 // Verify that Client implements the ethereum interfaces.
 var (
@@ -32,3 +45,114 @@ var (
 	// _ = ethereum.PendingStateEventer(&Client{})
 	_ = ethereum.PendingContractCaller(&Client{})
 )
+
+// SendTxArgs are the fields of the eth_sendTransaction requests checked by the
+// tests.
+type SendTxArgs struct {
+	From common.Address  `json:"from"`
+	To   *common.Address `json:"to"`
+	Type types.TxType    `json:"type"`
+}
+
+// DposService is an in-process eth namespace recording the transactions sent to
+// it, either signed by the node or by the client.
+type DposService struct {
+	requests []SendTxArgs
+	raw      []*types.Transaction
+}
+
+func (s *DposService) SendTransaction(args SendTxArgs) (common.Hash, error) {
+	s.requests = append(s.requests, args)
+	return common.Hash{byte(len(s.requests))}, nil
+}
+
+func (s *DposService) GetTransactionCount(addr common.Address, block string) hexutil.Uint64 {
+	return 7
+}
+
+func (s *DposService) GasPrice() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(3))
+}
+
+func (s *DposService) SendRawTransaction(data hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(data, tx); err != nil {
+		return common.Hash{}, err
+	}
+	s.raw = append(s.raw, tx)
+	return tx.Hash(), nil
+}
+
+func newDposClient(t *testing.T) (*rpc.Client, *DposService) {
+	service := new(DposService)
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", service); err != nil {
+		t.Fatalf("failed to register service: %v", err)
+	}
+	return rpc.DialInProc(server), service
+}
+
+// Tests that the DPoS helpers request the node to send operations of the right
+// type, to the candidate voted for.
+func TestDposHelpers(t *testing.T) {
+	rpcClient, service := newDposClient(t)
+	defer rpcClient.Close()
+	client := NewClient(rpcClient)
+
+	var (
+		ctx       = context.Background()
+		from      = common.Address{0x01}
+		candidate = common.Address{0x02}
+	)
+	if _, err := client.LoginCandidate(ctx, from); err != nil {
+		t.Fatalf("failed to login: %v", err)
+	}
+	if _, err := client.LogoutCandidate(ctx, from); err != nil {
+		t.Fatalf("failed to logout: %v", err)
+	}
+	if _, err := client.Delegate(ctx, from, candidate); err != nil {
+		t.Fatalf("failed to delegate: %v", err)
+	}
+	if _, err := client.UnDelegate(ctx, from, candidate); err != nil {
+		t.Fatalf("failed to undelegate: %v", err)
+	}
+	want := []SendTxArgs{
+		{From: from, Type: types.LoginCandidate},
+		{From: from, Type: types.LogoutCandidate},
+		{From: from, To: &candidate, Type: types.Delegate},
+		{From: from, To: &candidate, Type: types.UnDelegate},
+	}
+	if len(service.requests) != len(want) {
+		t.Fatalf("request count mismatch: have %d, want %d", len(service.requests), len(want))
+	}
+	for i, req := range service.requests {
+		if req.From != want[i].From || req.Type != want[i].Type || (req.To == nil) != (want[i].To == nil) || (req.To != nil && *req.To != *want[i].To) {
+			t.Errorf("request %d mismatch: have %+v, want %+v", i, req, want[i])
+		}
+	}
+}
+
+// Tests that the client serves as the backend of locally signed DPoS operations.
+func TestDposLocalSigning(t *testing.T) {
+	rpcClient, service := newDposClient(t)
+	defer rpcClient.Close()
+	client := NewClient(rpcClient)
+
+	key, _ := crypto.GenerateKey()
+	opts := bind.NewKeyedTransactor(key)
+
+	tx, err := bind.TransactDpos(opts, client, types.Delegate, common.Address{0x02})
+	if err != nil {
+		t.Fatalf("failed to delegate: %v", err)
+	}
+	if len(service.raw) != 1 || service.raw[0].Hash() != tx.Hash() {
+		t.Fatalf("sent transaction mismatch: have %v, want %x", service.raw, tx.Hash())
+	}
+	if tx.Type() != types.Delegate || tx.Nonce() != 7 || tx.GasPrice().Cmp(big.NewInt(3)) != 0 {
+		t.Errorf("transaction mismatch: have type %v nonce %d gas price %v", tx.Type(), tx.Nonce(), tx.GasPrice())
+	}
+	if from, err := types.Sender(types.HomesteadSigner{}, service.raw[0]); err != nil || from != opts.From {
+		t.Errorf("sender mismatch: have %x, %v, want %x", from, err, opts.From)
+	}
+}
@@ -0,0 +1,382 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	bolt "github.com/coreos/bbolt"
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/log"
+)
+
+const (
+	// boltFile is the name of the data file inside a bolt database directory.
+	boltFile = "data.bolt"
+
+	// boltInitialMmapSize is the size of the initial memory map. Bolt can't grow
+	// the map while read transactions are open, so reserving address space up
+	// front lets writers proceed concurrently with iterators and snapshots.
+	boltInitialMmapSize = 1 << 30
+
+	// boltMaxBatchDelay is the time individual writes wait for concurrent ones to
+	// be committed along with them in a single transaction.
+	boltMaxBatchDelay = time.Millisecond
+)
+
+var (
+	// boltBucket is the single bucket all key/value pairs are stored in.
+	boltBucket = []byte("ethdb")
+
+	// errBoltNotFound is returned if a requested key is missing.
+	errBoltNotFound = errors.New("not found")
+)
+
+// boltKey converts a database key into the key stored in bolt. Bolt rejects empty
+// keys, so all of them are prefixed by a marker byte, which preserves ordering.
+func boltKey(key []byte) []byte {
+	return append([]byte{0x00}, key...)
+}
+
+// BoltDatabase is a persistent key/value store backed by a single memory mapped
+// B+tree file. Compared to LevelDB it trades write throughput for predictable
+// read latencies and needs no background compaction.
+//
+// Iterators and snapshots hold a read transaction open until released. Once the
+// data file outgrows the initial memory map, writes that need to grow it block
+// until all of them are released, so they should not be kept open across writes
+// from the same goroutine.
+type BoltDatabase struct {
+	fn string   // Directory holding the data file, for reporting
+	db *bolt.DB // BoltDB instance
+
+	log log.Logger // Contextual logger tracking the database path
+}
+
+// NewBoltDatabase opens (or creates) a bolt database inside the given directory.
+func NewBoltDatabase(dir string) (*BoltDatabase, error) {
+	logger := log.New("database", dir)
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(filepath.Join(dir, boltFile), 0600, &bolt.Options{Timeout: time.Second, InitialMmapSize: boltInitialMmapSize})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	db.MaxBatchDelay = boltMaxBatchDelay
+	logger.Info("Opened bolt database")
+
+	return &BoltDatabase{
+		fn:  dir,
+		db:  db,
+		log: logger,
+	}, nil
+}
+
+// Path returns the path to the database directory.
+func (db *BoltDatabase) Path() string {
+	return db.fn
+}
+
+// Put inserts the given key / value pair into the database. Concurrent writes
+// are coalesced into a shared transaction, bulk writes should use a Batch.
+func (db *BoltDatabase) Put(key []byte, value []byte) error {
+	key, value = boltKey(key), common.CopyBytes(value)
+	return db.db.Batch(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put(key, value)
+	})
+}
+
+// Has checks whether a key is present in the database.
+func (db *BoltDatabase) Has(key []byte) (bool, error) {
+	var found bool
+	err := db.db.View(func(tx *bolt.Tx) error {
+		found = boltHas(tx.Bucket(boltBucket), key)
+		return nil
+	})
+	return found, err
+}
+
+// Get returns the given key if it's present.
+func (db *BoltDatabase) Get(key []byte) ([]byte, error) {
+	var value []byte
+	err := db.db.View(func(tx *bolt.Tx) (err error) {
+		value, err = boltGet(tx.Bucket(boltBucket), key)
+		return err
+	})
+	return value, err
+}
+
+// Delete removes the key from the database. Concurrent deletions are coalesced
+// into a shared transaction, just like writes.
+func (db *BoltDatabase) Delete(key []byte) error {
+	key = boltKey(key)
+	return db.db.Batch(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete(key)
+	})
+}
+
+// NewIteratorWithStart returns an iterator over the subset of database content
+// starting at a particular key. The iterator holds a read transaction open, so
+// it observes a consistent view of the database until released.
+func (db *BoltDatabase) NewIteratorWithStart(start []byte) Iterator {
+	return db.newIterator(start, nil)
+}
+
+// NewIteratorWithPrefix returns an iterator over the subset of database content
+// with a particular prefix. The iterator holds a read transaction open, so it
+// observes a consistent view of the database until released.
+func (db *BoltDatabase) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return db.newIterator(prefix, prefix)
+}
+
+// newIterator creates an iterator seeking to start and stopping at the first key
+// not matching prefix.
+func (db *BoltDatabase) newIterator(start []byte, prefix []byte) Iterator {
+	tx, err := db.db.Begin(false)
+	if err != nil {
+		return &boltIterator{err: err}
+	}
+	return &boltIterator{
+		tx:     tx,
+		cursor: tx.Bucket(boltBucket).Cursor(),
+		start:  boltKey(start),
+		prefix: boltKey(prefix),
+	}
+}
+
+// NewSnapshot creates a consistent point-in-time view of the database, backed
+// by a read transaction held open until the snapshot is released.
+func (db *BoltDatabase) NewSnapshot() (Snapshot, error) {
+	tx, err := db.db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	return &boltSnapshot{tx: tx, bucket: tx.Bucket(boltBucket)}, nil
+}
+
+// Stat returns a particular internal stat of the database. The only supported
+// property is "bolt.stats".
+func (db *BoltDatabase) Stat(property string) (string, error) {
+	if property != "bolt.stats" {
+		return "", errors.New("unknown property")
+	}
+	var (
+		stats = db.db.Stats()
+		size  int64
+	)
+	db.db.View(func(tx *bolt.Tx) error {
+		size = tx.Size()
+		return nil
+	})
+	return fmt.Sprintf("Size:          %v\nFree pages:    %d\nPending pages: %d\nFree bytes:    %d\nFreelist:      %d\nTransactions:  %d\nOpen reads:    %d\n",
+		common.StorageSize(size), stats.FreePageN, stats.PendingPageN, stats.FreeAlloc, stats.FreelistInuse, stats.TxN, stats.OpenTxN), nil
+}
+
+// Compact is a noop for bolt, which reuses freed pages in place instead of
+// rewriting its data files.
+func (db *BoltDatabase) Compact(start []byte, limit []byte) error {
+	return nil
+}
+
+// Close flushes and closes the database file.
+func (db *BoltDatabase) Close() {
+	if err := db.db.Close(); err != nil {
+		db.log.Error("Failed to close database", "err", err)
+		return
+	}
+	db.log.Info("Database closed")
+}
+
+// Bolt returns the underlying bolt database instance.
+func (db *BoltDatabase) Bolt() *bolt.DB {
+	return db.db
+}
+
+// NewBatch creates a write batch, which is committed in a single transaction.
+func (db *BoltDatabase) NewBatch() Batch {
+	return &boltBatch{db: db.db}
+}
+
+// boltHas checks whether a key is present in a bucket. Bolt returns nil values
+// both for missing keys and for empty values, so a cursor is needed to tell
+// them apart.
+func boltHas(bucket *bolt.Bucket, key []byte) bool {
+	key = boltKey(key)
+	k, _ := bucket.Cursor().Seek(key)
+	return k != nil && bytes.Equal(k, key)
+}
+
+// boltGet retrieves a copy of a value from a bucket, since bolt values are only
+// valid for the lifetime of their transaction.
+func boltGet(bucket *bolt.Bucket, key []byte) ([]byte, error) {
+	key = boltKey(key)
+	k, v := bucket.Cursor().Seek(key)
+	if k == nil || !bytes.Equal(k, key) {
+		return nil, errBoltNotFound
+	}
+	return common.CopyBytes(v), nil
+}
+
+type boltBatch struct {
+	db     *bolt.DB
+	writes []kv
+	size   int
+}
+
+func (b *boltBatch) Put(key, value []byte) error {
+	b.writes = append(b.writes, kv{common.CopyBytes(key), common.CopyBytes(value), false})
+	b.size += len(value)
+	return nil
+}
+
+func (b *boltBatch) Delete(key []byte) error {
+	b.writes = append(b.writes, kv{common.CopyBytes(key), nil, true})
+	b.size += len(key)
+	return nil
+}
+
+func (b *boltBatch) Write() error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltBucket)
+		for _, kv := range b.writes {
+			var err error
+			if kv.del {
+				err = bucket.Delete(boltKey(kv.k))
+			} else {
+				err = bucket.Put(boltKey(kv.k), kv.v)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (b *boltBatch) ValueSize() int {
+	return b.size
+}
+
+func (b *boltBatch) Reset() {
+	b.writes = b.writes[:0]
+	b.size = 0
+}
+
+// boltIterator walks a bucket within a read transaction.
+type boltIterator struct {
+	tx     *bolt.Tx
+	cursor *bolt.Cursor
+	start  []byte
+	prefix []byte
+
+	key, value []byte
+	started    bool
+	err        error
+}
+
+func (it *boltIterator) Next() bool {
+	if it.cursor == nil {
+		return false
+	}
+	if !it.started {
+		it.key, it.value = it.cursor.Seek(it.start)
+		it.started = true
+	} else {
+		it.key, it.value = it.cursor.Next()
+	}
+	if it.key == nil || !bytes.HasPrefix(it.key, it.prefix) {
+		it.key, it.value, it.cursor = nil, nil, nil
+		return false
+	}
+	return true
+}
+
+func (it *boltIterator) Error() error {
+	return it.err
+}
+
+func (it *boltIterator) Key() []byte {
+	if it.key == nil {
+		return nil
+	}
+	return it.key[1:]
+}
+
+func (it *boltIterator) Value() []byte {
+	return it.value
+}
+
+func (it *boltIterator) Release() {
+	if it.tx != nil {
+		it.tx.Rollback()
+	}
+	it.tx, it.cursor, it.key, it.value = nil, nil, nil, nil
+}
+
+// boltSnapshot serves reads from a long lived read transaction. Bolt transactions
+// are not safe for concurrent use, so access is serialized.
+type boltSnapshot struct {
+	tx     *bolt.Tx
+	bucket *bolt.Bucket
+	lock   sync.Mutex
+}
+
+func (s *boltSnapshot) Get(key []byte) ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.tx == nil {
+		return nil, errBoltNotFound
+	}
+	return boltGet(s.bucket, key)
+}
+
+func (s *boltSnapshot) Has(key []byte) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.tx == nil {
+		return false, nil
+	}
+	return boltHas(s.bucket, key), nil
+}
+
+func (s *boltSnapshot) Release() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.tx != nil {
+		s.tx.Rollback()
+		s.tx, s.bucket = nil, nil
+	}
+}
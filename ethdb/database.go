@@ -17,6 +17,7 @@
 package ethdb
 
 import (
+	"bytes"
 	"strconv"
 	"strings"
 	"sync"
@@ -29,6 +30,7 @@ import (
 	"github.com/syndtr/goleveldb/leveldb/filter"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 
 	gometrics "github.com/rcrowley/go-metrics"
 )
@@ -149,6 +151,39 @@ func (db *LDBDatabase) NewIterator() iterator.Iterator {
 	return db.db.NewIterator(nil, nil)
 }
 
+// NewIteratorWithStart creates an iterator over a subset of database content
+// starting at a particular initial key (or after, if it does not exist).
+func (db *LDBDatabase) NewIteratorWithStart(start []byte) Iterator {
+	return db.db.NewIterator(&util.Range{Start: start}, nil)
+}
+
+// NewIteratorWithPrefix returns an iterator to iterate over subset of database
+// content with a particular prefix.
+func (db *LDBDatabase) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return db.db.NewIterator(util.BytesPrefix(prefix), nil)
+}
+
+// NewSnapshot creates a consistent point-in-time view of the database.
+func (db *LDBDatabase) NewSnapshot() (Snapshot, error) {
+	snap, err := db.db.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &ldbSnapshot{snap: snap}, nil
+}
+
+// Stat returns a particular internal stat of the database.
+func (db *LDBDatabase) Stat(property string) (string, error) {
+	return db.db.GetProperty(property)
+}
+
+// Compact flattens the underlying data store for the given key range. In essence,
+// deleted and overwritten versions are discarded, and the data is rearranged to
+// reduce the cost of operations needed to access them.
+func (db *LDBDatabase) Compact(start []byte, limit []byte) error {
+	return db.db.CompactRange(util.Range{Start: start, Limit: limit})
+}
+
 func (db *LDBDatabase) Close() {
 	// Stop the metrics collection to avoid internal database races
 	db.quitLock.Lock()
@@ -291,6 +326,12 @@ func (b *ldbBatch) Put(key, value []byte) error {
 	return nil
 }
 
+func (b *ldbBatch) Delete(key []byte) error {
+	b.b.Delete(key)
+	b.size += len(key)
+	return nil
+}
+
 func (b *ldbBatch) Write() error {
 	return b.db.Write(b.b, nil)
 }
@@ -299,6 +340,28 @@ func (b *ldbBatch) ValueSize() int {
 	return b.size
 }
 
+func (b *ldbBatch) Reset() {
+	b.b.Reset()
+	b.size = 0
+}
+
+// ldbSnapshot wraps a LevelDB snapshot to satisfy the Snapshot interface.
+type ldbSnapshot struct {
+	snap *leveldb.Snapshot
+}
+
+func (s *ldbSnapshot) Get(key []byte) ([]byte, error) {
+	return s.snap.Get(key, nil)
+}
+
+func (s *ldbSnapshot) Has(key []byte) (bool, error) {
+	return s.snap.Has(key, nil)
+}
+
+func (s *ldbSnapshot) Release() {
+	s.snap.Release()
+}
+
 type table struct {
 	db     Database
 	prefix string
@@ -333,6 +396,98 @@ func (dt *table) Close() {
 	// Do nothing; don't close the underlying DB.
 }
 
+// NewIteratorWithStart returns an iterator over the table's content starting
+// at the given key. The table prefix is stripped from the returned keys.
+func (dt *table) NewIteratorWithStart(start []byte) Iterator {
+	return &tableIterator{
+		it:     dt.db.NewIteratorWithStart(append([]byte(dt.prefix), start...)),
+		prefix: dt.prefix,
+		limit:  util.BytesPrefix([]byte(dt.prefix)).Limit,
+	}
+}
+
+// NewIteratorWithPrefix returns an iterator over the table's content with the
+// given key prefix. The table prefix is stripped from the returned keys.
+func (dt *table) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return &tableIterator{
+		it:     dt.db.NewIteratorWithPrefix(append([]byte(dt.prefix), prefix...)),
+		prefix: dt.prefix,
+	}
+}
+
+func (dt *table) NewSnapshot() (Snapshot, error) {
+	snap, err := dt.db.NewSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &tableSnapshot{snap: snap, prefix: dt.prefix}, nil
+}
+
+func (dt *table) Stat(property string) (string, error) {
+	return dt.db.Stat(property)
+}
+
+// Compact flattens the given key range of the table. Nil bounds are limited to
+// the table's own key space.
+func (dt *table) Compact(start []byte, limit []byte) error {
+	start = append([]byte(dt.prefix), start...)
+	if limit == nil {
+		limit = util.BytesPrefix([]byte(dt.prefix)).Limit
+	} else {
+		limit = append([]byte(dt.prefix), limit...)
+	}
+	return dt.db.Compact(start, limit)
+}
+
+// tableIterator wraps a database iterator, stripping the table prefix.
+type tableIterator struct {
+	it     Iterator
+	prefix string
+	limit  []byte // Key after the table's key space, nil if unbounded
+	done   bool
+}
+
+func (it *tableIterator) Next() bool {
+	if it.done || !it.it.Next() {
+		return false
+	}
+	if it.limit != nil && bytes.Compare(it.it.Key(), it.limit) >= 0 {
+		it.done = true
+		return false
+	}
+	return true
+}
+
+func (it *tableIterator) Error() error  { return it.it.Error() }
+func (it *tableIterator) Value() []byte { return it.it.Value() }
+func (it *tableIterator) Release()      { it.it.Release() }
+
+func (it *tableIterator) Key() []byte {
+	key := it.it.Key()
+	if key == nil || it.done {
+		return nil
+	}
+	return key[len(it.prefix):]
+}
+
+// tableSnapshot wraps a database snapshot, prefixing all keys.
+type tableSnapshot struct {
+	snap   Snapshot
+	prefix string
+}
+
+func (s *tableSnapshot) Get(key []byte) ([]byte, error) {
+	return s.snap.Get(append([]byte(s.prefix), key...))
+}
+
+func (s *tableSnapshot) Has(key []byte) (bool, error) {
+	return s.snap.Has(append([]byte(s.prefix), key...))
+}
+
+func (s *tableSnapshot) Release() {
+	s.snap.Release()
+}
+
 type tableBatch struct {
 	batch  Batch
 	prefix string
@@ -351,6 +506,10 @@ func (tb *tableBatch) Put(key, value []byte) error {
 	return tb.batch.Put(append([]byte(tb.prefix), key...), value)
 }
 
+func (tb *tableBatch) Delete(key []byte) error {
+	return tb.batch.Delete(append([]byte(tb.prefix), key...))
+}
+
 func (tb *tableBatch) Write() error {
 	return tb.batch.Write()
 }
@@ -358,3 +517,7 @@ func (tb *tableBatch) Write() error {
 func (tb *tableBatch) ValueSize() int {
 	return tb.batch.ValueSize()
 }
+
+func (tb *tableBatch) Reset() {
+	tb.batch.Reset()
+}
@@ -44,6 +44,22 @@ func newTestLDB() (*ethdb.LDBDatabase, func()) {
 	}
 }
 
+func newTestBolt() (*ethdb.BoltDatabase, func()) {
+	dirname, err := ioutil.TempDir(os.TempDir(), "ethdb_test_")
+	if err != nil {
+		panic("failed to create test file: " + err.Error())
+	}
+	db, err := ethdb.NewBoltDatabase(dirname)
+	if err != nil {
+		panic("failed to create test database: " + err.Error())
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dirname)
+	}
+}
+
 var test_values = []string{"", "a", "1251", "\x00123\x00"}
 
 func TestLDB_PutGet(t *testing.T) {
@@ -52,6 +68,12 @@ func TestLDB_PutGet(t *testing.T) {
 	testPutGet(db, t)
 }
 
+func TestBolt_PutGet(t *testing.T) {
+	db, remove := newTestBolt()
+	defer remove()
+	testPutGet(db, t)
+}
+
 func TestMemoryDB_PutGet(t *testing.T) {
 	db, _ := ethdb.NewMemDatabase()
 	testPutGet(db, t)
@@ -130,6 +152,12 @@ func TestLDB_ParallelPutGet(t *testing.T) {
 	testParallelPutGet(db, t)
 }
 
+func TestBolt_ParallelPutGet(t *testing.T) {
+	db, remove := newTestBolt()
+	defer remove()
+	testParallelPutGet(db, t)
+}
+
 func TestMemoryDB_ParallelPutGet(t *testing.T) {
 	db, _ := ethdb.NewMemDatabase()
 	testParallelPutGet(db, t)
@@ -190,3 +218,152 @@ func testParallelPutGet(db ethdb.Database, t *testing.T) {
 	}
 	pending.Wait()
 }
+
+func TestLDB_Iterator(t *testing.T) {
+	db, remove := newTestLDB()
+	defer remove()
+	testIterator(db, t)
+}
+
+func TestBolt_Iterator(t *testing.T) {
+	db, remove := newTestBolt()
+	defer remove()
+	testIterator(db, t)
+}
+
+func TestMemoryDB_Iterator(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	testIterator(db, t)
+}
+
+func TestTable_Iterator(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	db.Put([]byte("aa"), []byte("outside"))
+	db.Put([]byte("c"), []byte("outside"))
+	testIterator(ethdb.NewTable(db, "b"), t)
+}
+
+func testIterator(db ethdb.Database, t *testing.T) {
+	keys := []string{"", "1", "2", "20", "21", "3", "\xff"}
+
+	batch := db.NewBatch()
+	for _, key := range keys {
+		if err := batch.Put([]byte(key), []byte("v"+key)); err != nil {
+			t.Fatalf("batch put failed: %v", err)
+		}
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatalf("batch write failed: %v", err)
+	}
+	tests := []struct {
+		prefix, start string
+		want          []string
+	}{
+		{"", "", keys},
+		{"2", "", []string{"2", "20", "21"}},
+		{"20", "", []string{"20"}},
+		{"4", "", nil},
+		{"", "2", []string{"2", "20", "21", "3", "\xff"}},
+		{"", "200", []string{"21", "3", "\xff"}},
+		{"", "\xff\xff", nil},
+	}
+	for i, tt := range tests {
+		var it ethdb.Iterator
+		if tt.start != "" {
+			it = db.NewIteratorWithStart([]byte(tt.start))
+		} else {
+			it = db.NewIteratorWithPrefix([]byte(tt.prefix))
+		}
+		var have []string
+		for it.Next() {
+			if !bytes.Equal(it.Value(), []byte("v"+string(it.Key()))) {
+				t.Errorf("test %d: value mismatch for key %q: have %q", i, it.Key(), it.Value())
+			}
+			have = append(have, string(it.Key()))
+		}
+		if err := it.Error(); err != nil {
+			t.Errorf("test %d: iteration failed: %v", i, err)
+		}
+		it.Release()
+
+		if fmt.Sprint(have) != fmt.Sprint(tt.want) {
+			t.Errorf("test %d: keys mismatch: have %q, want %q", i, have, tt.want)
+		}
+	}
+}
+
+func TestLDB_Snapshot(t *testing.T) {
+	db, remove := newTestLDB()
+	defer remove()
+	testSnapshot(db, t)
+}
+
+func TestBolt_Snapshot(t *testing.T) {
+	db, remove := newTestBolt()
+	defer remove()
+	testSnapshot(db, t)
+}
+
+func TestMemoryDB_Snapshot(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	testSnapshot(db, t)
+}
+
+func testSnapshot(db ethdb.Database, t *testing.T) {
+	db.Put([]byte("old"), []byte("1"))
+	db.Put([]byte("deleted"), []byte("1"))
+
+	snap, err := db.NewSnapshot()
+	if err != nil {
+		t.Fatalf("failed to create snapshot: %v", err)
+	}
+	defer snap.Release()
+
+	db.Put([]byte("old"), []byte("2"))
+	db.Put([]byte("new"), []byte("2"))
+	db.Delete([]byte("deleted"))
+
+	if data, err := snap.Get([]byte("old")); err != nil || !bytes.Equal(data, []byte("1")) {
+		t.Errorf("snapshot value mismatch: have %q, %v, want %q", data, err, "1")
+	}
+	if ok, _ := snap.Has([]byte("deleted")); !ok {
+		t.Errorf("deleted key missing from snapshot")
+	}
+	if ok, _ := snap.Has([]byte("new")); ok {
+		t.Errorf("new key present in snapshot")
+	}
+	if data, _ := db.Get([]byte("old")); !bytes.Equal(data, []byte("2")) {
+		t.Errorf("database value mismatch: have %q, want %q", data, "2")
+	}
+}
+
+func TestNewDatabaseEngineMismatch(t *testing.T) {
+	dirname, err := ioutil.TempDir(os.TempDir(), "ethdb_test_")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dirname)
+
+	db, err := ethdb.NewDatabase(ethdb.EngineBolt, dirname, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to create bolt database: %v", err)
+	}
+	db.Put([]byte("key"), []byte("value"))
+	db.Close()
+
+	if _, err := ethdb.NewDatabase(ethdb.EngineLevelDB, dirname, 0, 0); err == nil {
+		t.Fatalf("opened bolt database with leveldb")
+	}
+	db, err = ethdb.NewDatabase("", dirname, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	defer db.Close()
+
+	if _, ok := db.(*ethdb.BoltDatabase); !ok {
+		t.Fatalf("engine mismatch: have %T, want bolt", db)
+	}
+	if data, _ := db.Get([]byte("key")); !bytes.Equal(data, []byte("value")) {
+		t.Fatalf("value mismatch: have %q", data)
+	}
+}
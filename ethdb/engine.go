@@ -0,0 +1,63 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Storage engines supported by NewDatabase.
+const (
+	EngineLevelDB = "leveldb"
+	EngineBolt    = "bolt"
+)
+
+// NewDatabase opens a persistent database in the given directory using the
+// requested storage engine. An empty engine reuses whichever engine created
+// the directory, defaulting to LevelDB for new databases. Opening an existing
+// database with a different engine is refused.
+func NewDatabase(engine string, dir string, cache int, handles int) (Database, error) {
+	existing := detectEngine(dir)
+	if engine == "" {
+		engine = existing
+	}
+	if existing != "" && existing != engine {
+		return nil, fmt.Errorf("database %s was created by %s, cannot open with %s", dir, existing, engine)
+	}
+	switch engine {
+	case "", EngineLevelDB:
+		return NewLDBDatabase(dir, cache, handles)
+	case EngineBolt:
+		return NewBoltDatabase(dir)
+	default:
+		return nil, fmt.Errorf("unknown database engine %q", engine)
+	}
+}
+
+// detectEngine returns the storage engine an existing database directory was
+// created with, or an empty string if there's no database there yet.
+func detectEngine(dir string) string {
+	if _, err := os.Stat(filepath.Join(dir, boltFile)); err == nil {
+		return EngineBolt
+	}
+	if _, err := os.Stat(filepath.Join(dir, "CURRENT")); err == nil {
+		return EngineLevelDB
+	}
+	return ""
+}
@@ -0,0 +1,233 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"github.com/meitu/go-ethereum/log"
+)
+
+var (
+	// errUnknownTable is returned if the user attempts to read from a table
+	// that is not tracked by the freezer.
+	errUnknownTable = errors.New("unknown table")
+
+	// errMissingBlob is returned if an appended item lacks the blob of one of
+	// the tables tracked by the freezer.
+	errMissingBlob = errors.New("missing blob")
+)
+
+// freezer is an append-only ancient store made up of a set of freezer tables,
+// one per kind of data, which always contain the same number of items.
+type freezer struct {
+	frozen uint64 // Number of items already frozen (atomic access)
+
+	tables map[string]*freezerTable
+	lock   sync.Mutex // Mutex serializing appends and truncations
+}
+
+// newFreezer opens the ancient store in the given directory. The tables map
+// lists the kinds of data stored, along with whether they are compressed.
+func newFreezer(datadir string, tables map[string]bool) (*freezer, error) {
+	f := &freezer{tables: make(map[string]*freezerTable)}
+	for name, compress := range tables {
+		table, err := newTable(datadir, name, compress)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		f.tables[name] = table
+	}
+	if err := f.repair(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	log.Info("Opened ancient database", "path", datadir, "items", atomic.LoadUint64(&f.frozen))
+	return f, nil
+}
+
+// repair truncates all tables to the length of the shortest one, undoing any
+// partially appended item.
+func (f *freezer) repair() error {
+	min := uint64(math.MaxUint64)
+	for _, table := range f.tables {
+		if items := table.Items(); items < min {
+			min = items
+		}
+	}
+	if len(f.tables) == 0 {
+		min = 0
+	}
+	for _, table := range f.tables {
+		if err := table.truncate(min); err != nil {
+			return err
+		}
+	}
+	atomic.StoreUint64(&f.frozen, min)
+	return nil
+}
+
+// HasAncient returns whether the item of the given kind with the given number
+// is stored in the freezer.
+func (f *freezer) HasAncient(kind string, number uint64) (bool, error) {
+	if _, ok := f.tables[kind]; !ok {
+		return false, errUnknownTable
+	}
+	return number < atomic.LoadUint64(&f.frozen), nil
+}
+
+// Ancient retrieves the blob of the given kind stored for an item.
+func (f *freezer) Ancient(kind string, number uint64) ([]byte, error) {
+	table, ok := f.tables[kind]
+	if !ok {
+		return nil, errUnknownTable
+	}
+	if number >= atomic.LoadUint64(&f.frozen) {
+		return nil, errOutOfBounds
+	}
+	return table.Retrieve(number)
+}
+
+// Ancients returns the number of items in the freezer.
+func (f *freezer) Ancients() (uint64, error) {
+	return atomic.LoadUint64(&f.frozen), nil
+}
+
+// AppendAncient injects the blobs of all kinds of the next item into the
+// freezer. If any of them fails, the already appended ones are rolled back.
+func (f *freezer) AppendAncient(number uint64, blobs map[string][]byte) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if frozen := atomic.LoadUint64(&f.frozen); number != frozen {
+		return fmt.Errorf("appending unexpected item: want %d, have %d", frozen, number)
+	}
+	for kind := range blobs {
+		if _, ok := f.tables[kind]; !ok {
+			return errUnknownTable
+		}
+	}
+	for kind := range f.tables {
+		if _, ok := blobs[kind]; !ok {
+			return errMissingBlob
+		}
+	}
+	for kind, table := range f.tables {
+		if err := table.Append(number, blobs[kind]); err != nil {
+			log.Error("Failed to append ancient item", "kind", kind, "number", number, "err", err)
+			for _, table := range f.tables {
+				table.truncate(number)
+			}
+			return err
+		}
+	}
+	atomic.StoreUint64(&f.frozen, number+1)
+	return nil
+}
+
+// TruncateAncients discards all but the first n items from the freezer.
+func (f *freezer) TruncateAncients(items uint64) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if atomic.LoadUint64(&f.frozen) <= items {
+		return nil
+	}
+	for _, table := range f.tables {
+		if err := table.truncate(items); err != nil {
+			return err
+		}
+	}
+	atomic.StoreUint64(&f.frozen, items)
+	return nil
+}
+
+// Sync flushes all freezer tables to disk.
+func (f *freezer) Sync() error {
+	var errs []error
+	for _, table := range f.tables {
+		if err := table.Sync(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
+
+// Close closes all freezer tables.
+func (f *freezer) Close() error {
+	var errs []error
+	for _, table := range f.tables {
+		if err := table.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
+
+// freezerDatabase is a key-value database backed by an ancient store holding
+// immutable data moved out of it.
+type freezerDatabase struct {
+	Database
+	*freezer
+}
+
+// NewDatabaseWithFreezer wraps a key-value database with an ancient store
+// located in the given directory. The tables map lists the kinds of ancient
+// data stored, along with whether they are snappy compressed on disk.
+func NewDatabaseWithFreezer(db Database, dir string, tables map[string]bool) (Database, error) {
+	frdb, err := newFreezer(dir, tables)
+	if err != nil {
+		return nil, err
+	}
+	return &freezerDatabase{Database: db, freezer: frdb}, nil
+}
+
+// KeyValueStore returns the key-value database wrapped by an ancient store, or
+// the database itself if it has none.
+func KeyValueStore(db Database) Database {
+	if fdb, ok := db.(*freezerDatabase); ok {
+		return fdb.Database
+	}
+	return db
+}
+
+// Meter starts collecting metrics of the key-value database, if it's a LevelDB
+// instance.
+func (db *freezerDatabase) Meter(prefix string) {
+	if ldb, ok := db.Database.(*LDBDatabase); ok {
+		ldb.Meter(prefix)
+	}
+}
+
+// Close closes both the key-value database and the ancient store.
+func (db *freezerDatabase) Close() {
+	if err := db.freezer.Close(); err != nil {
+		log.Error("Failed to close ancient database", "err", err)
+	}
+	db.Database.Close()
+}
@@ -0,0 +1,411 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/golang/snappy"
+	"github.com/meitu/go-ethereum/log"
+)
+
+var (
+	// errClosed is returned if an operation attempts to use a closed freezer.
+	errClosed = errors.New("closed")
+
+	// errOutOfBounds is returned if the item requested is not contained within
+	// the freezer table.
+	errOutOfBounds = errors.New("out of bounds")
+)
+
+const (
+	// indexEntrySize is the size of a single index entry in bytes.
+	indexEntrySize = 8
+
+	// freezerTableSize is the maximum size of a single data file of a freezer
+	// table, after which a new one is started.
+	freezerTableSize = 2 * 1000 * 1000 * 1000
+)
+
+// indexEntry points to the end of an item within the data files of a table. An
+// item starts where the previous one ended, or at the beginning of its data file
+// if the previous one is stored in another file.
+type indexEntry struct {
+	filenum uint32 // data file the item is stored in
+	offset  uint32 // end offset of the item within its data file
+}
+
+// unmarshal decodes an index entry from its binary representation.
+func (e *indexEntry) unmarshal(b []byte) {
+	e.filenum = binary.BigEndian.Uint32(b[:4])
+	e.offset = binary.BigEndian.Uint32(b[4:8])
+}
+
+// marshal encodes an index entry into its binary representation.
+func (e *indexEntry) marshal() []byte {
+	b := make([]byte, indexEntrySize)
+	binary.BigEndian.PutUint32(b[:4], e.filenum)
+	binary.BigEndian.PutUint32(b[4:8], e.offset)
+	return b
+}
+
+// freezerTable is an append-only table of blobs, stored back to back in a set of
+// size capped data files, with an index file locating each of them. The first
+// index entry is a sentinel marking the start of the first data file, so item n
+// spans from entry n to entry n+1.
+type freezerTable struct {
+	items uint64 // Number of items stored in the table (atomic access)
+
+	name        string
+	path        string
+	compress    bool   // Whether blobs are snappy compressed on disk
+	maxFileSize uint32 // Maximum size of a data file before starting a new one
+
+	index     *os.File            // Index file locating all items
+	files     map[uint32]*os.File // Open data files, including the head one
+	head      *os.File            // Data file currently appended to
+	headId    uint32              // Number of the head data file
+	headBytes uint32              // Number of bytes written to the head data file
+
+	lock   sync.RWMutex // Mutex protecting the data files from concurrent truncation
+	logger log.Logger
+}
+
+// newTable opens a freezer table with the default data file size limit.
+func newTable(path string, name string, compress bool) (*freezerTable, error) {
+	return newCustomTable(path, name, freezerTableSize, compress)
+}
+
+// newCustomTable opens a freezer table, creating its files if they don't exist
+// yet, and repairs any inconsistency left over by an unclean shutdown.
+func newCustomTable(path string, name string, maxFileSize uint32, compress bool) (*freezerTable, error) {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, err
+	}
+	ext := "ridx"
+	if compress {
+		ext = "cidx"
+	}
+	index, err := os.OpenFile(filepath.Join(path, fmt.Sprintf("%s.%s", name, ext)), os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	t := &freezerTable{
+		name:        name,
+		path:        path,
+		compress:    compress,
+		maxFileSize: maxFileSize,
+		index:       index,
+		files:       make(map[uint32]*os.File),
+		logger:      log.New("table", name),
+	}
+	if err := t.repair(); err != nil {
+		t.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// dataPath returns the path of a numbered data file of the table.
+func (t *freezerTable) dataPath(num uint32) string {
+	ext := "rdat"
+	if t.compress {
+		ext = "cdat"
+	}
+	return filepath.Join(t.path, fmt.Sprintf("%s.%04d.%s", t.name, num, ext))
+}
+
+// openFile opens a numbered data file of the table, tracking it for retrievals.
+func (t *freezerTable) openFile(num uint32, flag int) (*os.File, error) {
+	if f, ok := t.files[num]; ok {
+		f.Close()
+	}
+	f, err := os.OpenFile(t.dataPath(num), flag, 0644)
+	if err != nil {
+		return nil, err
+	}
+	t.files[num] = f
+	return f, nil
+}
+
+// readEntry reads the index entry with the given position.
+func (t *freezerTable) readEntry(pos uint64) (indexEntry, error) {
+	var (
+		entry indexEntry
+		buf   = make([]byte, indexEntrySize)
+	)
+	if _, err := t.index.ReadAt(buf, int64(pos*indexEntrySize)); err != nil {
+		return entry, err
+	}
+	entry.unmarshal(buf)
+	return entry, nil
+}
+
+// repair cross checks the index against the data files, truncating whichever is
+// ahead of the other, then opens all data files for retrievals.
+func (t *freezerTable) repair() error {
+	stat, err := t.index.Stat()
+	if err != nil {
+		return err
+	}
+	// Create the sentinel entry for new tables and drop any partial entry
+	if stat.Size() == 0 {
+		if _, err := t.index.Write((&indexEntry{}).marshal()); err != nil {
+			return err
+		}
+		stat, err = t.index.Stat()
+		if err != nil {
+			return err
+		}
+	}
+	size := stat.Size()
+	if overflow := size % indexEntrySize; overflow != 0 {
+		size -= overflow
+		if err := t.index.Truncate(size); err != nil {
+			return err
+		}
+	}
+	last, err := t.readEntry(uint64(size/indexEntrySize) - 1)
+	if err != nil {
+		return err
+	}
+	// Align the head data file with the last index entry
+	head, err := t.openFile(last.filenum, os.O_RDWR|os.O_CREATE|os.O_APPEND)
+	if err != nil {
+		return err
+	}
+	if stat, err = head.Stat(); err != nil {
+		return err
+	}
+	contentSize := stat.Size()
+	for contentSize != int64(last.offset) {
+		if contentSize > int64(last.offset) {
+			// Data written without an index entry, truncate it away
+			t.logger.Warn("Truncating dangling freezer data", "indexed", last.offset, "stored", contentSize)
+			if err := head.Truncate(int64(last.offset)); err != nil {
+				return err
+			}
+			contentSize = int64(last.offset)
+			continue
+		}
+		// Index entry pointing to missing data, drop it
+		t.logger.Warn("Truncating dangling freezer index", "indexed", last.offset, "stored", contentSize)
+		size -= indexEntrySize
+		if err := t.index.Truncate(size); err != nil {
+			return err
+		}
+		prev, err := t.readEntry(uint64(size/indexEntrySize) - 1)
+		if err != nil {
+			return err
+		}
+		if prev.filenum != last.filenum {
+			head.Close()
+			delete(t.files, last.filenum)
+			os.Remove(t.dataPath(last.filenum))
+
+			if head, err = t.openFile(prev.filenum, os.O_RDWR|os.O_CREATE|os.O_APPEND); err != nil {
+				return err
+			}
+			if stat, err = head.Stat(); err != nil {
+				return err
+			}
+			contentSize = stat.Size()
+		}
+		last = prev
+	}
+	// Remove data files started after the last indexed item
+	for num := last.filenum + 1; ; num++ {
+		if _, err := os.Stat(t.dataPath(num)); err != nil {
+			break
+		}
+		os.Remove(t.dataPath(num))
+	}
+	// Open all preceding data files for retrievals
+	for num := uint32(0); num < last.filenum; num++ {
+		if _, err := t.openFile(num, os.O_RDONLY); err != nil {
+			return err
+		}
+	}
+	t.head, t.headId, t.headBytes = head, last.filenum, last.offset
+	atomic.StoreUint64(&t.items, uint64(size/indexEntrySize)-1)
+
+	return t.index.Sync()
+}
+
+// Items returns the number of items stored in the table.
+func (t *freezerTable) Items() uint64 {
+	return atomic.LoadUint64(&t.items)
+}
+
+// Append injects a blob at the end of the table. The item number must match
+// the number of items already stored, preventing gaps and overwrites.
+func (t *freezerTable) Append(item uint64, blob []byte) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil {
+		return errClosed
+	}
+	if items := atomic.LoadUint64(&t.items); item != items {
+		return fmt.Errorf("appending unexpected item: want %d, have %d", items, item)
+	}
+	if t.compress {
+		blob = snappy.Encode(nil, blob)
+	}
+	// Start a new data file if the blob doesn't fit into the current one
+	if t.headBytes > 0 && uint64(t.headBytes)+uint64(len(blob)) > uint64(t.maxFileSize) {
+		head, err := t.openFile(t.headId+1, os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND)
+		if err != nil {
+			return err
+		}
+		t.head, t.headId, t.headBytes = head, t.headId+1, 0
+	}
+	if _, err := t.head.Write(blob); err != nil {
+		return err
+	}
+	t.headBytes += uint32(len(blob))
+
+	entry := indexEntry{filenum: t.headId, offset: t.headBytes}
+	if _, err := t.index.Write(entry.marshal()); err != nil {
+		return err
+	}
+	atomic.AddUint64(&t.items, 1)
+	return nil
+}
+
+// Retrieve looks up the blob stored for the given item number.
+func (t *freezerTable) Retrieve(item uint64) ([]byte, error) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.index == nil {
+		return nil, errClosed
+	}
+	if item >= atomic.LoadUint64(&t.items) {
+		return nil, errOutOfBounds
+	}
+	start, err := t.readEntry(item)
+	if err != nil {
+		return nil, err
+	}
+	end, err := t.readEntry(item + 1)
+	if err != nil {
+		return nil, err
+	}
+	if start.filenum != end.filenum {
+		start.offset = 0
+	}
+	file, ok := t.files[end.filenum]
+	if !ok {
+		return nil, fmt.Errorf("missing data file %d", end.filenum)
+	}
+	blob := make([]byte, end.offset-start.offset)
+	if _, err := file.ReadAt(blob, int64(start.offset)); err != nil {
+		return nil, err
+	}
+	if t.compress {
+		return snappy.Decode(nil, blob)
+	}
+	return blob, nil
+}
+
+// truncate discards all but the first n items of the table.
+func (t *freezerTable) truncate(items uint64) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil {
+		return errClosed
+	}
+	if atomic.LoadUint64(&t.items) <= items {
+		return nil
+	}
+	t.logger.Warn("Truncating freezer table", "items", atomic.LoadUint64(&t.items), "limit", items)
+	if err := t.index.Truncate(int64(items+1) * indexEntrySize); err != nil {
+		return err
+	}
+	last, err := t.readEntry(items)
+	if err != nil {
+		return err
+	}
+	// Drop the data files started after the new last item
+	if last.filenum != t.headId {
+		for num := last.filenum + 1; num <= t.headId; num++ {
+			if f, ok := t.files[num]; ok {
+				f.Close()
+				delete(t.files, num)
+			}
+			os.Remove(t.dataPath(num))
+		}
+		head, err := t.openFile(last.filenum, os.O_RDWR|os.O_APPEND)
+		if err != nil {
+			return err
+		}
+		t.head, t.headId = head, last.filenum
+	}
+	if err := t.head.Truncate(int64(last.offset)); err != nil {
+		return err
+	}
+	t.headBytes = last.offset
+	atomic.StoreUint64(&t.items, items)
+	return nil
+}
+
+// Sync flushes the index and head data file to disk.
+func (t *freezerTable) Sync() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.index == nil {
+		return errClosed
+	}
+	if err := t.index.Sync(); err != nil {
+		return err
+	}
+	return t.head.Sync()
+}
+
+// Close closes all open files of the table.
+func (t *freezerTable) Close() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var errs []error
+	if t.index != nil {
+		if err := t.index.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		t.index = nil
+	}
+	for num, f := range t.files {
+		if err := f.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		delete(t.files, num)
+	}
+	t.head = nil
+	if len(errs) > 0 {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// freezerBlob generates a deterministic test blob for the given item.
+func freezerBlob(item byte, size int) []byte {
+	return bytes.Repeat([]byte{item}, size)
+}
+
+// checkTable verifies that a freezer table contains exactly the given number of
+// generated test blobs.
+func checkTable(t *testing.T, table *freezerTable, items int, size int) {
+	if have := table.Items(); have != uint64(items) {
+		t.Fatalf("item count mismatch: have %d, want %d", have, items)
+	}
+	for i := 0; i < items; i++ {
+		blob, err := table.Retrieve(uint64(i))
+		if err != nil {
+			t.Fatalf("item %d: failed to retrieve: %v", i, err)
+		}
+		if !bytes.Equal(blob, freezerBlob(byte(i), size)) {
+			t.Fatalf("item %d: content mismatch: have %x", i, blob)
+		}
+	}
+	if _, err := table.Retrieve(uint64(items)); err != errOutOfBounds {
+		t.Fatalf("item %d: retrieval error mismatch: have %v, want %v", items, err, errOutOfBounds)
+	}
+}
+
+// Tests that blobs can be appended and read back, both raw and compressed, and
+// that they are spread over multiple data files.
+func TestFreezerTableAppendRetrieve(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		dir, err := ioutil.TempDir("", "freezer")
+		if err != nil {
+			t.Fatalf("failed to create temp dir: %v", err)
+		}
+		defer os.RemoveAll(dir)
+
+		table, err := newCustomTable(dir, "test", 50, compress)
+		if err != nil {
+			t.Fatalf("failed to open table: %v", err)
+		}
+		for i := 0; i < 20; i++ {
+			if err := table.Append(uint64(i), freezerBlob(byte(i), 15)); err != nil {
+				t.Fatalf("item %d: failed to append: %v", i, err)
+			}
+		}
+		if err := table.Append(10, nil); err == nil {
+			t.Fatalf("overwrote existing item")
+		}
+		if err := table.Append(21, nil); err == nil {
+			t.Fatalf("appended item leaving a gap")
+		}
+		checkTable(t, table, 20, 15)
+		if !compress && len(table.files) != 7 {
+			t.Errorf("data file count mismatch: have %d, want %d", len(table.files), 7)
+		}
+		// Reopen the table and make sure everything is still there
+		table.Close()
+		if table, err = newCustomTable(dir, "test", 50, compress); err != nil {
+			t.Fatalf("failed to reopen table: %v", err)
+		}
+		checkTable(t, table, 20, 15)
+		table.Close()
+	}
+}
+
+// Tests that truncating a table discards the tail items and data files, and that
+// appending resumes at the truncation point.
+func TestFreezerTableTruncate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	table, err := newCustomTable(dir, "test", 50, false)
+	if err != nil {
+		t.Fatalf("failed to open table: %v", err)
+	}
+	defer table.Close()
+
+	for i := 0; i < 20; i++ {
+		table.Append(uint64(i), freezerBlob(byte(i), 15))
+	}
+	if err := table.truncate(5); err != nil {
+		t.Fatalf("failed to truncate table: %v", err)
+	}
+	checkTable(t, table, 5, 15)
+	if _, err := os.Stat(table.dataPath(2)); !os.IsNotExist(err) {
+		t.Errorf("truncated data file still present: %v", err)
+	}
+	for i := 5; i < 10; i++ {
+		if err := table.Append(uint64(i), freezerBlob(byte(i), 15)); err != nil {
+			t.Fatalf("item %d: failed to append: %v", i, err)
+		}
+	}
+	checkTable(t, table, 10, 15)
+}
+
+// Tests that a table left inconsistent by a crash is repaired on open, dropping
+// items missing either their data or their index entry.
+func TestFreezerTableRepair(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	table, err := newCustomTable(dir, "test", 50, false)
+	if err != nil {
+		t.Fatalf("failed to open table: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		table.Append(uint64(i), freezerBlob(byte(i), 15))
+	}
+	table.Close()
+
+	// Chop off part of the last data file, losing item 9
+	if err := os.Truncate(table.dataPath(3), 10); err != nil {
+		t.Fatalf("failed to truncate data file: %v", err)
+	}
+	if table, err = newCustomTable(dir, "test", 50, false); err != nil {
+		t.Fatalf("failed to reopen table: %v", err)
+	}
+	checkTable(t, table, 9, 15)
+	table.Close()
+
+	// Chop off part of the index, losing items 7 and 8
+	index := filepath.Join(dir, "test.ridx")
+	if err := os.Truncate(index, 8*indexEntrySize+3); err != nil {
+		t.Fatalf("failed to truncate index file: %v", err)
+	}
+	if table, err = newCustomTable(dir, "test", 50, false); err != nil {
+		t.Fatalf("failed to reopen table: %v", err)
+	}
+	defer table.Close()
+	checkTable(t, table, 7, 15)
+
+	if err := table.Append(7, freezerBlob(7, 15)); err != nil {
+		t.Fatalf("failed to append after repair: %v", err)
+	}
+	checkTable(t, table, 8, 15)
+}
+
+// Tests that the freezer keeps its tables aligned, rejecting partial items and
+// truncating tables left longer than the others by a crash.
+func TestFreezerTablesAligned(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tables := map[string]bool{"a": false, "b": true}
+	f, err := newFreezer(dir, tables)
+	if err != nil {
+		t.Fatalf("failed to open freezer: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		blobs := map[string][]byte{"a": freezerBlob(byte(i), 10), "b": freezerBlob(byte(i), 20)}
+		if err := f.AppendAncient(uint64(i), blobs); err != nil {
+			t.Fatalf("item %d: failed to append: %v", i, err)
+		}
+	}
+	if err := f.AppendAncient(5, map[string][]byte{"a": nil}); err != errMissingBlob {
+		t.Fatalf("partial item error mismatch: have %v, want %v", err, errMissingBlob)
+	}
+	if err := f.AppendAncient(5, map[string][]byte{"a": nil, "b": nil, "c": nil}); err != errUnknownTable {
+		t.Fatalf("unknown table error mismatch: have %v, want %v", err, errUnknownTable)
+	}
+	// Simulate a crash in the middle of an append
+	f.tables["a"].Append(5, freezerBlob(5, 10))
+	f.Close()
+
+	if f, err = newFreezer(dir, tables); err != nil {
+		t.Fatalf("failed to reopen freezer: %v", err)
+	}
+	defer f.Close()
+
+	if frozen, _ := f.Ancients(); frozen != 5 {
+		t.Fatalf("frozen item count mismatch: have %d, want %d", frozen, 5)
+	}
+	checkTable(t, f.tables["a"], 5, 10)
+	checkTable(t, f.tables["b"], 5, 20)
+
+	if ok, _ := f.HasAncient("b", 4); !ok {
+		t.Errorf("frozen item reported missing")
+	}
+	if ok, _ := f.HasAncient("b", 5); ok {
+		t.Errorf("missing item reported frozen")
+	}
+	if err := f.TruncateAncients(2); err != nil {
+		t.Fatalf("failed to truncate freezer: %v", err)
+	}
+	if _, err := f.Ancient("a", 2); err != errOutOfBounds {
+		t.Errorf("truncated item retrieval error mismatch: have %v, want %v", err, errOutOfBounds)
+	}
+}
+
+// Tests that the key-value database wrapped by an ancient store can be retrieved.
+func TestKeyValueStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	kvdb, _ := NewMemDatabase()
+	if db := KeyValueStore(kvdb); db != kvdb {
+		t.Errorf("plain database unwrapped: have %T", db)
+	}
+	db, err := NewDatabaseWithFreezer(kvdb, dir, map[string]bool{"a": false})
+	if err != nil {
+		t.Fatalf("failed to open freezer: %v", err)
+	}
+	defer db.Close()
+
+	if have := KeyValueStore(db); have != kvdb {
+		t.Errorf("wrapped database mismatch: have %T", have)
+	}
+}
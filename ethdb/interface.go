@@ -28,17 +28,118 @@ type Putter interface {
 // Database wraps all database operations. All methods are safe for concurrent use.
 type Database interface {
 	Putter
+	Iteratee
+	Snapshotter
 	Get(key []byte) ([]byte, error)
 	Has(key []byte) (bool, error)
 	Delete(key []byte) error
 	Close()
 	NewBatch() Batch
+
+	// Stat returns a particular internal stat of the database.
+	Stat(property string) (string, error)
+
+	// Compact flattens the underlying data store for the given key range. A nil
+	// start is treated as a key before all keys, and a nil limit as a key after
+	// all keys.
+	Compact(start []byte, limit []byte) error
+}
+
+// Iterator iterates over a database's key/value pairs in ascending key order.
+//
+// When it encounters an error any seek will return false and will yield no key/
+// value pairs. The error can be queried by calling the Error method. Calling
+// Release is still necessary.
+//
+// An iterator must be released after use, but it is not necessary to read an
+// iterator until exhaustion. An iterator is not safe for concurrent use, but it
+// is safe to use multiple iterators concurrently.
+type Iterator interface {
+	// Next moves the iterator to the next key/value pair. It returns whether the
+	// iterator is exhausted.
+	Next() bool
+
+	// Error returns any accumulated error. Exhausting all the key/value pairs
+	// is not considered to be an error.
+	Error() error
+
+	// Key returns the key of the current key/value pair, or nil if done. The
+	// caller should not modify the contents of the returned slice, and its
+	// contents may change on the next call to Next.
+	Key() []byte
+
+	// Value returns the value of the current key/value pair, or nil if done. The
+	// caller should not modify the contents of the returned slice, and its
+	// contents may change on the next call to Next.
+	Value() []byte
+
+	// Release releases associated resources. Release should always succeed and
+	// can be called multiple times without causing error.
+	Release()
+}
+
+// Iteratee wraps the NewIterator methods of a backing data store.
+type Iteratee interface {
+	// NewIteratorWithStart creates a binary-alphabetical iterator over a subset of
+	// database content starting at a particular initial key (or after, if it does
+	// not exist).
+	NewIteratorWithStart(start []byte) Iterator
+
+	// NewIteratorWithPrefix creates a binary-alphabetical iterator over the
+	// subset of database content with a particular key prefix. A nil prefix
+	// iterates over the entire database.
+	NewIteratorWithPrefix(prefix []byte) Iterator
+}
+
+// Snapshot is a consistent, read-only view of the database at the moment it
+// was created. Writes to the database afterwards are not visible through it.
+type Snapshot interface {
+	Get(key []byte) ([]byte, error)
+	Has(key []byte) (bool, error)
+
+	// Release releases associated resources. Release should always succeed and
+	// can be called multiple times without causing error.
+	Release()
+}
+
+// Snapshotter wraps the NewSnapshot method of a backing data store.
+type Snapshotter interface {
+	// NewSnapshot creates a database snapshot based on the current state.
+	NewSnapshot() (Snapshot, error)
 }
 
 // Batch is a write-only database that commits changes to its host database
 // when Write is called. Batch cannot be used concurrently.
 type Batch interface {
 	Putter
+	Delete(key []byte) error
 	ValueSize() int // amount of data in the batch
 	Write() error
+	// Reset resets the batch for reuse
+	Reset()
+}
+
+// AncientStore is an append-only store of immutable, numbered items, each of
+// them made up of one blob per kind (e.g. header, body, receipts). Items can
+// only be appended at the end and removed from the end.
+type AncientStore interface {
+	// HasAncient returns whether the item of the given kind with the given number
+	// is stored in the ancient store.
+	HasAncient(kind string, number uint64) (bool, error)
+
+	// Ancient retrieves the blob of the given kind stored for an item.
+	Ancient(kind string, number uint64) ([]byte, error)
+
+	// Ancients returns the number of items in the ancient store.
+	Ancients() (uint64, error)
+
+	// AppendAncient injects the blobs of all kinds of the next item into the
+	// ancient store. Either all of them are appended or none.
+	AppendAncient(number uint64, blobs map[string][]byte) error
+
+	// TruncateAncients discards all but the first n items from the ancient store.
+	TruncateAncients(n uint64) error
+
+	// Sync flushes all in-memory ancient store data to disk.
+	Sync() error
 }
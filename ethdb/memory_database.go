@@ -18,6 +18,8 @@ package ethdb
 
 import (
 	"errors"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/meitu/go-ethereum/common"
@@ -92,11 +94,79 @@ func (db *MemDatabase) Delete(key []byte) error {
 
 func (db *MemDatabase) Close() {}
 
+// NewIteratorWithStart creates an iterator over the subset of the database
+// content starting at a particular key. The iterator operates on a copy of the
+// matching entries, so later writes are not reflected in it.
+func (db *MemDatabase) NewIteratorWithStart(start []byte) Iterator {
+	return db.newIterator(func(key string) bool {
+		return key >= string(start)
+	})
+}
+
+// NewIteratorWithPrefix creates an iterator over the subset of the database
+// content with a particular key prefix. The iterator operates on a copy of the
+// matching entries, so later writes are not reflected in it.
+func (db *MemDatabase) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return db.newIterator(func(key string) bool {
+		return strings.HasPrefix(key, string(prefix))
+	})
+}
+
+// newIterator creates an iterator over all the keys accepted by the filter.
+func (db *MemDatabase) newIterator(filter func(key string) bool) Iterator {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	var keys []string
+	for key := range db.db {
+		if filter(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	it := &memIterator{
+		keys:   make([][]byte, len(keys)),
+		values: make([][]byte, len(keys)),
+		index:  -1,
+	}
+	for i, key := range keys {
+		it.keys[i] = []byte(key)
+		it.values[i] = common.CopyBytes(db.db[key])
+	}
+	return it
+}
+
+// NewSnapshot creates a copy of the current database content.
+func (db *MemDatabase) NewSnapshot() (Snapshot, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	snap := &memSnapshot{db: make(map[string][]byte, len(db.db))}
+	for key, value := range db.db {
+		snap.db[key] = common.CopyBytes(value)
+	}
+	return snap, nil
+}
+
+// Stat is not supported by the memory database.
+func (db *MemDatabase) Stat(property string) (string, error) {
+	return "", errors.New("unknown property")
+}
+
+// Compact is not supported by the memory database, but there's no need either.
+func (db *MemDatabase) Compact(start []byte, limit []byte) error {
+	return nil
+}
+
 func (db *MemDatabase) NewBatch() Batch {
 	return &memBatch{db: db}
 }
 
-type kv struct{ k, v []byte }
+type kv struct {
+	k, v []byte
+	del  bool
+}
 
 type memBatch struct {
 	db     *MemDatabase
@@ -105,16 +175,26 @@ type memBatch struct {
 }
 
 func (b *memBatch) Put(key, value []byte) error {
-	b.writes = append(b.writes, kv{common.CopyBytes(key), common.CopyBytes(value)})
+	b.writes = append(b.writes, kv{common.CopyBytes(key), common.CopyBytes(value), false})
 	b.size += len(value)
 	return nil
 }
 
+func (b *memBatch) Delete(key []byte) error {
+	b.writes = append(b.writes, kv{common.CopyBytes(key), nil, true})
+	b.size += len(key)
+	return nil
+}
+
 func (b *memBatch) Write() error {
 	b.db.lock.Lock()
 	defer b.db.lock.Unlock()
 
 	for _, kv := range b.writes {
+		if kv.del {
+			delete(b.db.db, string(kv.k))
+			continue
+		}
 		b.db.db[string(kv.k)] = kv.v
 	}
 	return nil
@@ -123,3 +203,66 @@ func (b *memBatch) Write() error {
 func (b *memBatch) ValueSize() int {
 	return b.size
 }
+
+func (b *memBatch) Reset() {
+	b.writes = b.writes[:0]
+	b.size = 0
+}
+
+// memIterator iterates over a sorted copy of a memory database's content.
+type memIterator struct {
+	keys   [][]byte
+	values [][]byte
+	index  int
+}
+
+func (it *memIterator) Next() bool {
+	if it.index >= len(it.keys) {
+		return false
+	}
+	it.index++
+	return it.index < len(it.keys)
+}
+
+func (it *memIterator) Error() error {
+	return nil
+}
+
+func (it *memIterator) Key() []byte {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return nil
+	}
+	return it.keys[it.index]
+}
+
+func (it *memIterator) Value() []byte {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return nil
+	}
+	return it.values[it.index]
+}
+
+func (it *memIterator) Release() {
+	it.keys, it.values, it.index = nil, nil, -1
+}
+
+// memSnapshot is a frozen copy of a memory database's content.
+type memSnapshot struct {
+	db map[string][]byte
+}
+
+func (s *memSnapshot) Get(key []byte) ([]byte, error) {
+	if entry, ok := s.db[string(key)]; ok {
+		return common.CopyBytes(entry), nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (s *memSnapshot) Has(key []byte) (bool, error) {
+	_, ok := s.db[string(key)]
+	return ok, nil
+}
+
+func (s *memSnapshot) Release() {
+	s.db = nil
+}
@@ -0,0 +1,151 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package graphql provides a read-only GraphQL interface to the blockchain data,
+// allowing clients to fetch blocks, transactions, receipts, logs, account states
+// and the DPoS context of a block in a single request.
+//
+// The endpoint is mounted on the HTTP-RPC server and executes the queries with
+// graph-gophers/graphql-go. A GET request without a query returns the schema.
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/errors"
+	"github.com/meitu/go-ethereum/internal/ethapi"
+	"github.com/meitu/go-ethereum/p2p"
+	"github.com/meitu/go-ethereum/rpc"
+)
+
+const (
+	// Path is the path the GraphQL endpoint is mounted at on the HTTP-RPC server.
+	Path = "/graphql"
+
+	maxRequestSize = 128 * 1024       // Maximum size of a GraphQL request body
+	queryTimeout   = 10 * time.Second // Maximum execution time of a query, unless the RPC limits are stricter
+)
+
+// Service is a node service serving GraphQL queries on the HTTP-RPC endpoint.
+type Service struct {
+	schema *graphql.Schema
+}
+
+// New creates a GraphQL service on top of the API backend of a full or light
+// client.
+func New(backend ethapi.Backend) *Service {
+	// The resolvers share the state and tries of their blocks, which aren't safe
+	// for concurrent use, so resolve a single field at a time
+	return &Service{
+		schema: graphql.MustParseSchema(schema, &queryResolver{backend: backend}, graphql.MaxParallelism(1)),
+	}
+}
+
+// Protocols implements node.Service, returning no P2P protocols.
+func (s *Service) Protocols() []p2p.Protocol { return nil }
+
+// APIs implements node.Service, returning no RPC APIs.
+func (s *Service) APIs() []rpc.API { return nil }
+
+// Start implements node.Service, there's nothing to start.
+func (s *Service) Start(server *p2p.Server) error { return nil }
+
+// Stop implements node.Service, there's nothing to stop.
+func (s *Service) Stop() error { return nil }
+
+// HTTPHandlers implements node.HTTPService, mounting the GraphQL endpoint.
+func (s *Service) HTTPHandlers() map[string]http.Handler {
+	return map[string]http.Handler{Path: s}
+}
+
+// request is a GraphQL request as posted by clients.
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// ServeHTTP executes a GraphQL query, either posted as JSON or passed in the URL
+// of a GET request.
+func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	switch r.Method {
+	case "GET":
+		query := r.URL.Query()
+		req.Query, req.OperationName = query.Get("query"), query.Get("operationName")
+		if req.Query == "" {
+			w.Header().Set("content-type", "text/plain; charset=utf-8")
+			io.WriteString(w, schema)
+			return
+		}
+		if vars := query.Get("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				writeError(w, http.StatusBadRequest, errors.Errorf("invalid variables: %v", err))
+				return
+			}
+		}
+	case "POST":
+		body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxRequestSize+1))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errors.Errorf("%v", err))
+			return
+		}
+		if len(body) > maxRequestSize {
+			writeError(w, http.StatusRequestEntityTooLarge, errors.Errorf("request too large"))
+			return
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			writeError(w, http.StatusBadRequest, errors.Errorf("invalid request: %v", err))
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), queryTimeout)
+	defer cancel()
+
+	resp := s.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+
+	// Malformed or invalid queries aren't executed at all
+	code := http.StatusOK
+	if resp.Data == nil && ctx.Err() == nil {
+		code = http.StatusBadRequest
+	}
+	writeResponse(w, code, resp)
+}
+
+// writeError replies with a single request level error.
+func writeError(w http.ResponseWriter, code int, err *errors.QueryError) {
+	writeResponse(w, code, &graphql.Response{Errors: []*errors.QueryError{err}})
+}
+
+func writeResponse(w http.ResponseWriter, code int, resp *graphql.Response) {
+	blob, err := json.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(code)
+	w.Write(blob)
+}
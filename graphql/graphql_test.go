@@ -0,0 +1,354 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/core"
+	"github.com/meitu/go-ethereum/core/state"
+	"github.com/meitu/go-ethereum/core/types"
+	"github.com/meitu/go-ethereum/crypto"
+	"github.com/meitu/go-ethereum/ethdb"
+	"github.com/meitu/go-ethereum/internal/ethapi"
+	"github.com/meitu/go-ethereum/params"
+	"github.com/meitu/go-ethereum/rpc"
+)
+
+var (
+	testKey, _    = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testSender    = crypto.PubkeyToAddress(testKey.PublicKey)
+	testRecipient = common.HexToAddress("0x1000000000000000000000000000000000000001")
+	testValidator = common.HexToAddress("0x2000000000000000000000000000000000000002")
+	testTopic     = common.HexToHash("0x3000000000000000000000000000000000000000000000000000000000000003")
+)
+
+// testBackend is a blockchain backend serving a fixed chain of two blocks, the
+// second one including a single transaction from the test sender.
+type testBackend struct {
+	ethapi.Backend // Panics on the methods not needed by the resolvers
+
+	db       ethdb.Database
+	blocks   []*types.Block
+	receipts []types.Receipts
+	state    *state.StateDB
+	dpos     *types.DposContext
+}
+
+func newTestBackend(t *testing.T) *testBackend {
+	db, _ := ethdb.NewMemDatabase()
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	statedb.SetBalance(testSender, big.NewInt(1000000))
+	statedb.SetNonce(testSender, 1)
+	statedb.SetState(testRecipient, common.Hash{1}, common.Hash{2})
+
+	dposContext, err := types.NewDposContext(db)
+	if err != nil {
+		t.Fatalf("failed to create dpos context: %v", err)
+	}
+	if err := dposContext.BecomeCandidate(testValidator); err != nil {
+		t.Fatalf("failed to add candidate: %v", err)
+	}
+	if err := dposContext.Delegate(testSender, testValidator); err != nil {
+		t.Fatalf("failed to delegate: %v", err)
+	}
+	if err := dposContext.SetValidators([]common.Address{testValidator}); err != nil {
+		t.Fatalf("failed to set validators: %v", err)
+	}
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, 3)
+	cnt := make([]byte, 8)
+	binary.BigEndian.PutUint64(cnt, 1)
+	dposContext.MintCntTrie().Update(append(key, testValidator.Bytes()...), cnt)
+
+	genesis := types.NewBlock(&types.Header{
+		Number:     big.NewInt(0),
+		Difficulty: big.NewInt(1),
+		GasLimit:   big.NewInt(4712388),
+		GasUsed:    new(big.Int),
+		Time:       new(big.Int),
+	}, nil, nil, nil)
+
+	signer := types.MakeSigner(params.TestChainConfig, big.NewInt(1))
+	tx, err := types.SignTx(types.NewTransaction(types.Binary, 0, testRecipient, big.NewInt(100), big.NewInt(50000), big.NewInt(1), []byte{0xde, 0xad}), signer, testKey)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	receipt := types.NewReceipt(nil, false, big.NewInt(21000))
+	receipt.TxHash = tx.Hash()
+	receipt.GasUsed = big.NewInt(21000)
+	receipt.Logs = []*types.Log{{Address: testRecipient, Topics: []common.Hash{testTopic}, Data: []byte{0x01}}}
+
+	block := types.NewBlock(&types.Header{
+		ParentHash: genesis.Hash(),
+		Validator:  testValidator,
+		Coinbase:   testValidator,
+		Number:     big.NewInt(1),
+		Difficulty: big.NewInt(1),
+		GasLimit:   big.NewInt(4712388),
+		GasUsed:    big.NewInt(21000),
+		Time:       big.NewInt(3*86400 + 10),
+	}, []*types.Transaction{tx}, nil, []*types.Receipt{receipt})
+
+	if err := core.WriteBlock(db, block); err != nil {
+		t.Fatalf("failed to write block: %v", err)
+	}
+	if err := core.WriteTxLookupEntries(db, block); err != nil {
+		t.Fatalf("failed to write transaction lookups: %v", err)
+	}
+	return &testBackend{
+		db:       db,
+		blocks:   []*types.Block{genesis, block},
+		receipts: []types.Receipts{nil, {receipt}},
+		state:    statedb,
+		dpos:     dposContext,
+	}
+}
+
+func (b *testBackend) ChainConfig() *params.ChainConfig { return params.TestChainConfig }
+func (b *testBackend) ChainDb() ethdb.Database          { return b.db }
+func (b *testBackend) CurrentBlock() *types.Block       { return b.blocks[len(b.blocks)-1] }
+func (b *testBackend) GetTd(hash common.Hash) *big.Int  { return big.NewInt(2) }
+
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	if number < 0 {
+		return b.CurrentBlock(), nil
+	}
+	if int(number) >= len(b.blocks) {
+		return nil, nil
+	}
+	return b.blocks[number], nil
+}
+
+func (b *testBackend) GetBlock(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	for _, block := range b.blocks {
+		if block.Hash() == hash {
+			return block, nil
+		}
+	}
+	return nil, nil
+}
+
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	for i, block := range b.blocks {
+		if block.Hash() == hash {
+			return b.receipts[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (b *testBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	block, _ := b.BlockByNumber(ctx, number)
+	if block == nil {
+		return nil, nil, nil
+	}
+	return b.state, block.Header(), nil
+}
+
+func (b *testBackend) DposContextAt(ctx context.Context, header *types.Header) (*types.DposContext, error) {
+	return b.dpos, nil
+}
+
+func (b *testBackend) GetPoolTransaction(hash common.Hash) *types.Transaction { return nil }
+
+// query posts a GraphQL request to the service and decodes the response.
+func query(t *testing.T, srv *httptest.Server, query string, vars map[string]interface{}) map[string]interface{} {
+	body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": vars})
+	resp, err := http.Post(srv.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return result
+}
+
+// Tests that a block, its receipts and the DPoS validator producing it can be
+// fetched in a single query.
+func TestBlockQuery(t *testing.T) {
+	backend := newTestBackend(t)
+	srv := httptest.NewServer(New(backend))
+	defer srv.Close()
+
+	result := query(t, srv, `query($number: Long) {
+		block(number: $number) {
+			number
+			parent { number }
+			validator { address }
+			transactions {
+				hash
+				from { address balance transactionCount }
+				to { address storage(slot: "0x0100000000000000000000000000000000000000000000000000000000000000") }
+				value
+				status
+				gasUsed
+				logs { index account { address } topics data }
+			}
+			dpos {
+				epoch
+				validators { address }
+				candidates { address }
+				delegations { delegator { address } candidate { address } }
+				vote(delegator: "`+testSender.Hex()+`") { address }
+				mintCount(validator: "`+testValidator.Hex()+`")
+			}
+		}
+	}`, map[string]interface{}{"number": 1})
+
+	if errs, ok := result["errors"]; ok {
+		t.Fatalf("query failed: %v", errs)
+	}
+	tx := backend.blocks[1].Transactions()[0]
+	want := map[string]interface{}{
+		"block": map[string]interface{}{
+			"number":    1.0,
+			"parent":    map[string]interface{}{"number": 0.0},
+			"validator": map[string]interface{}{"address": strings.ToLower(testValidator.Hex())},
+			"transactions": []interface{}{
+				map[string]interface{}{
+					"hash": tx.Hash().Hex(),
+					"from": map[string]interface{}{
+						"address":          strings.ToLower(testSender.Hex()),
+						"balance":          "0xf4240",
+						"transactionCount": 1.0,
+					},
+					"to": map[string]interface{}{
+						"address": strings.ToLower(testRecipient.Hex()),
+						"storage": common.Hash{2}.Hex(),
+					},
+					"value":   "0x64",
+					"status":  1.0,
+					"gasUsed": "0x5208",
+					"logs": []interface{}{
+						map[string]interface{}{
+							"index":   0.0,
+							"account": map[string]interface{}{"address": strings.ToLower(testRecipient.Hex())},
+							"topics":  []interface{}{testTopic.Hex()},
+							"data":    "0x01",
+						},
+					},
+				},
+			},
+			"dpos": map[string]interface{}{
+				"epoch":      3.0,
+				"validators": []interface{}{map[string]interface{}{"address": strings.ToLower(testValidator.Hex())}},
+				"candidates": []interface{}{map[string]interface{}{"address": strings.ToLower(testValidator.Hex())}},
+				"delegations": []interface{}{
+					map[string]interface{}{
+						"delegator": map[string]interface{}{"address": strings.ToLower(testSender.Hex())},
+						"candidate": map[string]interface{}{"address": strings.ToLower(testValidator.Hex())},
+					},
+				},
+				"vote":      map[string]interface{}{"address": strings.ToLower(testValidator.Hex())},
+				"mintCount": 1.0,
+			},
+		},
+	}
+	if !reflect.DeepEqual(result["data"], want) {
+		have, _ := json.MarshalIndent(result["data"], "", "  ")
+		t.Fatalf("result mismatch:\n%s", have)
+	}
+}
+
+// Tests that transactions are found by hash, that integer arguments may be hex
+// encoded and that failures are reported per field without discarding the rest
+// of the result.
+func TestTransactionQuery(t *testing.T) {
+	backend := newTestBackend(t)
+	srv := httptest.NewServer(New(backend))
+	defer srv.Close()
+
+	tx := backend.blocks[1].Transactions()[0]
+	result := query(t, srv, `{
+		known: transaction(hash: "`+tx.Hash().Hex()+`") { index block { number } }
+		unknown: transaction(hash: "0x0000000000000000000000000000000000000000000000000000000000000001") { index }
+		hex: block(number: "0x1") { number }
+		invalid: block(number: 0, hash: "`+backend.blocks[0].Hash().Hex()+`") { number }
+	}`, nil)
+
+	data, _ := result["data"].(map[string]interface{})
+	want := map[string]interface{}{
+		"known":   map[string]interface{}{"index": 0.0, "block": map[string]interface{}{"number": 1.0}},
+		"unknown": nil,
+		"hex":     map[string]interface{}{"number": 1.0},
+		"invalid": nil,
+	}
+	if !reflect.DeepEqual(data, want) {
+		t.Fatalf("data mismatch: have %v, want %v", data, want)
+	}
+	errs, _ := result["errors"].([]interface{})
+	if len(errs) != 1 {
+		t.Fatalf("error count mismatch: have %d, want 1", len(errs))
+	}
+	if path := errs[0].(map[string]interface{})["path"]; !reflect.DeepEqual(path, []interface{}{"invalid"}) {
+		t.Fatalf("error path mismatch: have %v, want [invalid]", path)
+	}
+}
+
+// Tests the plain HTTP behaviour of the endpoint.
+func TestHTTPRequests(t *testing.T) {
+	srv := httptest.NewServer(New(newTestBackend(t)))
+	defer srv.Close()
+
+	// A bare GET request returns the schema
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("schema request failed: %v", err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != schema {
+		t.Fatalf("schema mismatch: have %q", body)
+	}
+	// Queries may be passed in the URL
+	resp, err = http.Get(srv.URL + "?query=" + url.QueryEscape("{ block { number } }"))
+	if err != nil {
+		t.Fatalf("query request failed: %v", err)
+	}
+	body, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if want := `{"data":{"block":{"number":1}}}`; string(body) != want {
+		t.Fatalf("response mismatch: have %s, want %s", body, want)
+	}
+	// Malformed queries are rejected
+	resp, err = http.Post(srv.URL, "application/json", strings.NewReader(`{"query": "{ block { number }"}`))
+	if err != nil {
+		t.Fatalf("invalid request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("status mismatch: have %d, want %d", resp.StatusCode, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,720 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/common/hexutil"
+	"github.com/meitu/go-ethereum/consensus/dpos"
+	"github.com/meitu/go-ethereum/core"
+	"github.com/meitu/go-ethereum/core/state"
+	"github.com/meitu/go-ethereum/core/types"
+	"github.com/meitu/go-ethereum/internal/ethapi"
+	"github.com/meitu/go-ethereum/rpc"
+	"github.com/meitu/go-ethereum/trie"
+)
+
+// maxBlockRange is the maximum number of blocks a single blocks query may return.
+const maxBlockRange = 256
+
+// Long is a 64 bit integer, serialized as a JSON number and accepted as argument
+// either as a number or as a decimal or hex encoded string.
+type Long int64
+
+// ImplementsGraphQLType returns whether Long implements the given GraphQL type.
+func (l Long) ImplementsGraphQLType(name string) bool { return name == "Long" }
+
+// UnmarshalGraphQL parses a 64 bit integer from a GraphQL query input.
+func (l *Long) UnmarshalGraphQL(input interface{}) error {
+	switch input := input.(type) {
+	case int32:
+		*l = Long(input)
+	case float64:
+		if float64(int64(input)) != input {
+			return fmt.Errorf("invalid integer %v", input)
+		}
+		*l = Long(input)
+	case string:
+		var (
+			n   int64
+			err error
+		)
+		if strings.HasPrefix(input, "0x") || strings.HasPrefix(input, "0X") {
+			var u uint64
+			if u, err = hexutil.DecodeUint64(input); err == nil && u > 1<<63-1 {
+				err = fmt.Errorf("integer out of range")
+			}
+			n = int64(u)
+		} else {
+			n, err = strconv.ParseInt(input, 10, 64)
+		}
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", input)
+		}
+		*l = Long(n)
+	default:
+		return fmt.Errorf("unexpected type %T for Long", input)
+	}
+	return nil
+}
+
+// queryResolver is the root of all queries.
+type queryResolver struct {
+	backend ethapi.Backend
+}
+
+// Block fetches a block by number or by hash, the latest one if neither is given.
+func (q *queryResolver) Block(ctx context.Context, args struct {
+	Number *Long
+	Hash   *common.Hash
+}) (*blockResolver, error) {
+	switch {
+	case args.Number != nil && args.Hash != nil:
+		return nil, fmt.Errorf("only one of number or hash may be specified")
+	case args.Hash != nil:
+		return q.blockByHash(ctx, *args.Hash)
+	case args.Number != nil:
+		return q.blockByNumber(ctx, rpc.BlockNumber(*args.Number))
+	default:
+		return q.blockByNumber(ctx, rpc.LatestBlockNumber)
+	}
+}
+
+// Blocks fetches a range of consecutive blocks, up to the latest one by default.
+func (q *queryResolver) Blocks(ctx context.Context, args struct {
+	From Long
+	To   *Long
+}) ([]*blockResolver, error) {
+	to := Long(q.backend.CurrentBlock().NumberU64())
+	if args.To != nil {
+		to = *args.To
+	}
+	if to < args.From {
+		return []*blockResolver{}, nil
+	}
+	if to-args.From >= maxBlockRange {
+		return nil, fmt.Errorf("block range exceeds limit of %d", maxBlockRange)
+	}
+	blocks := []*blockResolver{}
+	for number := args.From; number <= to; number++ {
+		block, err := q.blockByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			break
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+// Transaction fetches a mined or pending transaction by its hash.
+func (q *queryResolver) Transaction(ctx context.Context, args struct{ Hash common.Hash }) (*transactionResolver, error) {
+	return q.transaction(ctx, args.Hash)
+}
+
+// Account fetches an account at a block, the latest one by default.
+func (q *queryResolver) Account(ctx context.Context, args struct {
+	Address     common.Address
+	BlockNumber *Long
+}) (*accountResolver, error) {
+	number := rpc.LatestBlockNumber
+	if args.BlockNumber != nil {
+		number = rpc.BlockNumber(*args.BlockNumber)
+	}
+	block, err := q.blockByNumber(ctx, number)
+	if block == nil || err != nil {
+		return nil, err
+	}
+	return &accountResolver{address: args.Address, block: block}, nil
+}
+
+// GasPrice returns the suggested gas price of new transactions.
+func (q *queryResolver) GasPrice(ctx context.Context) (hexutil.Big, error) {
+	price, err := q.backend.SuggestPrice(ctx)
+	if err != nil {
+		return hexutil.Big{}, err
+	}
+	return hexutil.Big(*price), nil
+}
+
+// ProtocolVersion returns the version of the Ethereum protocol of the node.
+func (q *queryResolver) ProtocolVersion() int32 {
+	return int32(q.backend.ProtocolVersion())
+}
+
+// blockByNumber resolves a block by its number, returning nil if it's unknown.
+func (q *queryResolver) blockByNumber(ctx context.Context, number rpc.BlockNumber) (*blockResolver, error) {
+	block, err := q.backend.BlockByNumber(ctx, number)
+	if block == nil || err != nil {
+		return nil, err
+	}
+	return &blockResolver{backend: q.backend, block: block, pending: number == rpc.PendingBlockNumber}, nil
+}
+
+// blockByHash resolves a block by its hash, returning nil if it's unknown.
+func (q *queryResolver) blockByHash(ctx context.Context, hash common.Hash) (*blockResolver, error) {
+	block, err := q.backend.GetBlock(ctx, hash)
+	if block == nil || err != nil {
+		return nil, err
+	}
+	return &blockResolver{backend: q.backend, block: block}, nil
+}
+
+// transaction resolves a transaction by its hash, either from the canonical chain
+// or from the transaction pool, returning nil if it's unknown.
+func (q *queryResolver) transaction(ctx context.Context, hash common.Hash) (*transactionResolver, error) {
+	if tx, blockHash, _, index := core.GetTransaction(q.backend.ChainDb(), hash); tx != nil {
+		block, err := q.blockByHash(ctx, blockHash)
+		if err != nil {
+			return nil, err
+		}
+		if block != nil {
+			return &transactionResolver{backend: q.backend, tx: tx, block: block, index: index}, nil
+		}
+	}
+	if tx := q.backend.GetPoolTransaction(hash); tx != nil {
+		return &transactionResolver{backend: q.backend, tx: tx}, nil
+	}
+	return nil, nil
+}
+
+// blockResolver resolves the fields of a block, caching the data shared by the
+// resolvers of its transactions, logs and accounts.
+//
+// The cached data isn't safe for concurrent use: all the resolvers accessing it
+// take a context, so they run asynchronously, which the service limits to one
+// resolver at a time.
+type blockResolver struct {
+	backend ethapi.Backend
+	block   *types.Block
+	pending bool // Whether the block is the pending one of the miner
+
+	receipts types.Receipts
+	state    *state.StateDB
+	dpos     *types.DposContext
+}
+
+func (b *blockResolver) Number() Long { return Long(b.block.NumberU64()) }
+
+func (b *blockResolver) Hash() common.Hash { return b.block.Hash() }
+
+func (b *blockResolver) Parent(ctx context.Context) (*blockResolver, error) {
+	if b.block.NumberU64() == 0 {
+		return nil, nil
+	}
+	parent, err := b.backend.GetBlock(ctx, b.block.ParentHash())
+	if parent == nil || err != nil {
+		return nil, err
+	}
+	return &blockResolver{backend: b.backend, block: parent}, nil
+}
+
+func (b *blockResolver) Nonce() hexutil.Bytes {
+	nonce := b.block.Header().Nonce
+	return hexutil.Bytes(nonce[:])
+}
+
+func (b *blockResolver) TransactionsRoot() common.Hash { return b.block.TxHash() }
+
+func (b *blockResolver) TransactionCount() int32 { return int32(len(b.block.Transactions())) }
+
+func (b *blockResolver) StateRoot() common.Hash { return b.block.Root() }
+
+func (b *blockResolver) ReceiptsRoot() common.Hash { return b.block.ReceiptHash() }
+
+// Miner resolves the account the block rewards are paid to.
+func (b *blockResolver) Miner() *accountResolver {
+	return &accountResolver{address: b.block.Coinbase(), block: b}
+}
+
+// Validator resolves the DPoS validator which produced the block.
+func (b *blockResolver) Validator() *accountResolver {
+	return &accountResolver{address: b.block.Validator(), block: b}
+}
+
+func (b *blockResolver) ExtraData() hexutil.Bytes { return hexutil.Bytes(b.block.Extra()) }
+
+func (b *blockResolver) GasLimit() hexutil.Big { return hexutil.Big(*b.block.GasLimit()) }
+
+func (b *blockResolver) GasUsed() hexutil.Big { return hexutil.Big(*b.block.GasUsed()) }
+
+func (b *blockResolver) Timestamp() Long { return Long(b.block.Time().Uint64()) }
+
+func (b *blockResolver) LogsBloom() hexutil.Bytes { return hexutil.Bytes(b.block.Bloom().Bytes()) }
+
+func (b *blockResolver) MixHash() common.Hash { return b.block.MixDigest() }
+
+func (b *blockResolver) Difficulty() hexutil.Big { return hexutil.Big(*b.block.Difficulty()) }
+
+// TotalDifficulty resolves the total difficulty of the chain up to the block,
+// nil for the pending one.
+func (b *blockResolver) TotalDifficulty() *hexutil.Big {
+	if b.pending {
+		return nil
+	}
+	return (*hexutil.Big)(b.backend.GetTd(b.block.Hash()))
+}
+
+func (b *blockResolver) OmmerCount() int32 { return int32(len(b.block.Uncles())) }
+
+func (b *blockResolver) Ommers() []*blockResolver {
+	ommers := []*blockResolver{}
+	for _, uncle := range b.block.Uncles() {
+		ommers = append(ommers, &blockResolver{backend: b.backend, block: types.NewBlockWithHeader(uncle)})
+	}
+	return ommers
+}
+
+func (b *blockResolver) Transactions() []*transactionResolver {
+	txs := []*transactionResolver{}
+	for i, tx := range b.block.Transactions() {
+		txs = append(txs, &transactionResolver{backend: b.backend, tx: tx, block: b, index: uint64(i)})
+	}
+	return txs
+}
+
+func (b *blockResolver) TransactionAt(args struct{ Index int32 }) *transactionResolver {
+	txs := b.block.Transactions()
+	if args.Index < 0 || int(args.Index) >= len(txs) {
+		return nil
+	}
+	return &transactionResolver{backend: b.backend, tx: txs[args.Index], block: b, index: uint64(args.Index)}
+}
+
+// Logs resolves the logs emitted by all the transactions of the block.
+func (b *blockResolver) Logs(ctx context.Context) ([]*logResolver, error) {
+	receipts, err := b.getReceipts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logs := []*logResolver{}
+	for i, tx := range b.block.Transactions() {
+		txr := &transactionResolver{backend: b.backend, tx: tx, block: b, index: uint64(i)}
+		logs = append(logs, txr.logResolvers(receipts[i], len(logs))...)
+	}
+	return logs, nil
+}
+
+// Account resolves an account in the state after the block.
+func (b *blockResolver) Account(args struct{ Address common.Address }) *accountResolver {
+	return &accountResolver{address: args.Address, block: b}
+}
+
+// Dpos resolves the delegated-proof-of-stake context after the block.
+func (b *blockResolver) Dpos() *dposResolver {
+	if b.block.Header().DposContext == nil {
+		return nil
+	}
+	return &dposResolver{block: b}
+}
+
+// getReceipts retrieves the receipts of all the transactions of the block.
+func (b *blockResolver) getReceipts(ctx context.Context) (types.Receipts, error) {
+	if b.receipts == nil {
+		receipts, err := b.backend.GetReceipts(ctx, b.block.Hash())
+		if err != nil {
+			return nil, err
+		}
+		if len(receipts) != len(b.block.Transactions()) {
+			return nil, fmt.Errorf("receipts of block %x not available", b.block.Hash())
+		}
+		b.receipts = receipts
+	}
+	return b.receipts, nil
+}
+
+// getState retrieves the state of the chain after the block.
+func (b *blockResolver) getState(ctx context.Context) (*state.StateDB, error) {
+	if b.state == nil {
+		number := rpc.BlockNumber(b.block.NumberU64())
+		if b.pending {
+			number = rpc.PendingBlockNumber
+		}
+		statedb, header, err := b.backend.StateAndHeaderByNumber(ctx, number)
+		if err != nil {
+			return nil, err
+		}
+		if statedb == nil || header.Hash() != b.block.Hash() {
+			return nil, fmt.Errorf("state of block %x not available", b.block.Hash())
+		}
+		b.state = statedb
+	}
+	return b.state, nil
+}
+
+// getDposContext retrieves the delegated-proof-of-stake context of the block.
+func (b *blockResolver) getDposContext(ctx context.Context) (*types.DposContext, error) {
+	if b.dpos == nil {
+		dposContext, err := b.backend.DposContextAt(ctx, b.block.Header())
+		if err != nil {
+			return nil, err
+		}
+		b.dpos = dposContext
+	}
+	return b.dpos, nil
+}
+
+// transactionResolver resolves the fields of a transaction, either included in
+// a block or pending in the transaction pool.
+type transactionResolver struct {
+	backend ethapi.Backend
+	tx      *types.Transaction
+	block   *blockResolver // Block including the transaction, nil if pending
+	index   uint64         // Index of the transaction within its block
+}
+
+func (t *transactionResolver) Hash() common.Hash { return t.tx.Hash() }
+
+func (t *transactionResolver) Nonce() Long { return Long(t.tx.Nonce()) }
+
+func (t *transactionResolver) Type() int32 { return int32(t.tx.Type()) }
+
+// Index resolves the position of the transaction within its block, nil if pending.
+func (t *transactionResolver) Index() *Long {
+	if t.block == nil {
+		return nil
+	}
+	index := Long(t.index)
+	return &index
+}
+
+func (t *transactionResolver) From(ctx context.Context) (*accountResolver, error) {
+	number := t.backend.CurrentBlock().Number()
+	if t.block != nil {
+		number = t.block.block.Number()
+	}
+	from, err := types.Sender(types.MakeSigner(t.backend.ChainConfig(), number), t.tx)
+	if err != nil {
+		return nil, err
+	}
+	return &accountResolver{address: from, block: t.accountBlock(ctx)}, nil
+}
+
+// To resolves the recipient, nil for contract creations, candidate logins and
+// multi-recipient transfers.
+func (t *transactionResolver) To(ctx context.Context) *accountResolver {
+	to := t.tx.To()
+	if to == nil {
+		return nil
+	}
+	return &accountResolver{address: *to, block: t.accountBlock(ctx)}
+}
+
+func (t *transactionResolver) Value() hexutil.Big { return hexutil.Big(*t.tx.Value()) }
+
+func (t *transactionResolver) GasPrice() hexutil.Big { return hexutil.Big(*t.tx.GasPrice()) }
+
+func (t *transactionResolver) Gas() hexutil.Big { return hexutil.Big(*t.tx.Gas()) }
+
+func (t *transactionResolver) InputData() hexutil.Bytes { return hexutil.Bytes(t.tx.Data()) }
+
+// Block resolves the block including the transaction, nil if pending.
+func (t *transactionResolver) Block() *blockResolver { return t.block }
+
+// Status resolves 1 on success and 0 on failure, nil if pending or pre-Byzantium.
+func (t *transactionResolver) Status(ctx context.Context) (*Long, error) {
+	receipt, err := t.receipt(ctx)
+	if receipt == nil || err != nil || len(receipt.PostState) > 0 {
+		return nil, err
+	}
+	status := Long(receipt.Status)
+	return &status, nil
+}
+
+func (t *transactionResolver) GasUsed(ctx context.Context) (*hexutil.Big, error) {
+	receipt, err := t.receipt(ctx)
+	if receipt == nil || err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(receipt.GasUsed), nil
+}
+
+func (t *transactionResolver) CumulativeGasUsed(ctx context.Context) (*hexutil.Big, error) {
+	receipt, err := t.receipt(ctx)
+	if receipt == nil || err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(receipt.CumulativeGasUsed), nil
+}
+
+func (t *transactionResolver) CreatedContract(ctx context.Context) (*accountResolver, error) {
+	if t.tx.To() != nil || t.tx.Type() != types.Binary {
+		return nil, nil
+	}
+	receipt, err := t.receipt(ctx)
+	if receipt == nil || err != nil {
+		return nil, err
+	}
+	return &accountResolver{address: receipt.ContractAddress, block: t.block}, nil
+}
+
+func (t *transactionResolver) Logs(ctx context.Context) (*[]*logResolver, error) {
+	receipt, err := t.receipt(ctx)
+	if receipt == nil || err != nil {
+		return nil, err
+	}
+	// Log indices are block wide, count the logs of the preceding transactions
+	receipts, _ := t.block.getReceipts(ctx)
+	offset := 0
+	for _, r := range receipts[:t.index] {
+		offset += len(r.Logs)
+	}
+	logs := t.logResolvers(receipt, offset)
+	return &logs, nil
+}
+
+// accountBlock returns the block the accounts referenced by the transaction are
+// resolved at: its own block or the pending one if not yet included.
+func (t *transactionResolver) accountBlock(ctx context.Context) *blockResolver {
+	if t.block != nil {
+		return t.block
+	}
+	q := &queryResolver{backend: t.backend}
+	if block, err := q.blockByNumber(ctx, rpc.PendingBlockNumber); block != nil && err == nil {
+		return block
+	}
+	return &blockResolver{backend: t.backend, block: t.backend.CurrentBlock()}
+}
+
+// receipt retrieves the receipt of the transaction, nil if it's still pending.
+func (t *transactionResolver) receipt(ctx context.Context) (*types.Receipt, error) {
+	if t.block == nil {
+		return nil, nil
+	}
+	receipts, err := t.block.getReceipts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return receipts[t.index], nil
+}
+
+// logResolvers creates the resolvers of the logs of the transaction's receipt,
+// numbered from the given block wide index.
+func (t *transactionResolver) logResolvers(receipt *types.Receipt, offset int) []*logResolver {
+	logs := []*logResolver{}
+	for i, log := range receipt.Logs {
+		logs = append(logs, &logResolver{tx: t, log: log, index: offset + i})
+	}
+	return logs
+}
+
+// logResolver resolves the fields of a log emitted by a transaction.
+type logResolver struct {
+	tx    *transactionResolver
+	log   *types.Log
+	index int // Index of the log within its block
+}
+
+func (l *logResolver) Index() int32 { return int32(l.index) }
+
+func (l *logResolver) Account() *accountResolver {
+	return &accountResolver{address: l.log.Address, block: l.tx.block}
+}
+
+func (l *logResolver) Topics() []common.Hash { return append([]common.Hash{}, l.log.Topics...) }
+
+func (l *logResolver) Data() hexutil.Bytes { return hexutil.Bytes(l.log.Data) }
+
+func (l *logResolver) Transaction() *transactionResolver { return l.tx }
+
+// accountResolver resolves the fields of an account in the state after a block.
+type accountResolver struct {
+	address common.Address
+	block   *blockResolver
+}
+
+func (a *accountResolver) Address() common.Address { return a.address }
+
+func (a *accountResolver) Balance(ctx context.Context) (hexutil.Big, error) {
+	statedb, err := a.block.getState(ctx)
+	if err != nil {
+		return hexutil.Big{}, err
+	}
+	balance := statedb.GetBalance(a.address)
+	return hexutil.Big(*balance), statedb.Error()
+}
+
+func (a *accountResolver) TransactionCount(ctx context.Context) (Long, error) {
+	statedb, err := a.block.getState(ctx)
+	if err != nil {
+		return 0, err
+	}
+	nonce := statedb.GetNonce(a.address)
+	return Long(nonce), statedb.Error()
+}
+
+func (a *accountResolver) Code(ctx context.Context) (hexutil.Bytes, error) {
+	statedb, err := a.block.getState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	code := common.CopyBytes(statedb.GetCode(a.address))
+	return hexutil.Bytes(code), statedb.Error()
+}
+
+func (a *accountResolver) Storage(ctx context.Context, args struct{ Slot common.Hash }) (common.Hash, error) {
+	statedb, err := a.block.getState(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	value := statedb.GetState(a.address, args.Slot)
+	return value, statedb.Error()
+}
+
+// dposResolver resolves the delegated-proof-of-stake data of a block: its
+// validators, the candidates and the delegations of the voters.
+type dposResolver struct {
+	block *blockResolver
+}
+
+// Epoch resolves the epoch the block belongs to.
+func (d *dposResolver) Epoch() Long {
+	return Long(dpos.EpochID(d.block.block.Time().Int64()))
+}
+
+func (d *dposResolver) Validators(ctx context.Context) ([]*accountResolver, error) {
+	dposContext, err := d.block.getDposContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	validators, err := dposContext.GetValidators()
+	if err != nil {
+		return nil, err
+	}
+	accounts := []*accountResolver{}
+	for _, validator := range validators {
+		accounts = append(accounts, &accountResolver{address: validator, block: d.block})
+	}
+	return accounts, nil
+}
+
+func (d *dposResolver) Candidates(ctx context.Context) ([]*accountResolver, error) {
+	dposContext, err := d.block.getDposContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	candidates, err := d.candidates(dposContext)
+	if err != nil {
+		return nil, err
+	}
+	accounts := []*accountResolver{}
+	for _, candidate := range candidates {
+		accounts = append(accounts, &accountResolver{address: candidate, block: d.block})
+	}
+	return accounts, nil
+}
+
+// Delegations resolves the votes for a candidate, or for all of them if omitted.
+func (d *dposResolver) Delegations(ctx context.Context, args struct{ Candidate *common.Address }) ([]*delegationResolver, error) {
+	dposContext, err := d.block.getDposContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []common.Address
+	if args.Candidate != nil {
+		candidates = []common.Address{*args.Candidate}
+	} else if candidates, err = d.candidates(dposContext); err != nil {
+		return nil, err
+	}
+	delegations := []*delegationResolver{}
+	for _, candidate := range candidates {
+		it := trie.NewIterator(dposContext.DelegateTrie().PrefixIterator(candidate.Bytes()))
+		for it.Next() {
+			delegations = append(delegations, &delegationResolver{
+				delegator: common.BytesToAddress(it.Value),
+				candidate: candidate,
+				block:     d.block,
+			})
+		}
+		if it.Err != nil {
+			return nil, it.Err
+		}
+	}
+	return delegations, nil
+}
+
+// Vote resolves the candidate a delegator voted for, nil if none.
+func (d *dposResolver) Vote(ctx context.Context, args struct{ Delegator common.Address }) (*accountResolver, error) {
+	dposContext, err := d.block.getDposContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	candidate, err := dposContext.VoteTrie().TryGet(args.Delegator.Bytes())
+	if candidate == nil || err != nil {
+		return nil, err
+	}
+	return &accountResolver{address: common.BytesToAddress(candidate), block: d.block}, nil
+}
+
+// MintCount resolves the number of blocks a validator produced in an epoch, the
+// one of the block by default.
+func (d *dposResolver) MintCount(ctx context.Context, args struct {
+	Validator common.Address
+	Epoch     *Long
+}) (Long, error) {
+	dposContext, err := d.block.getDposContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	epoch := d.Epoch()
+	if args.Epoch != nil {
+		epoch = *args.Epoch
+	}
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(epoch))
+	cnt, err := dposContext.MintCntTrie().TryGet(append(key, args.Validator.Bytes()...))
+	if err != nil {
+		return 0, err
+	}
+	if len(cnt) != 8 {
+		return 0, nil
+	}
+	return Long(binary.BigEndian.Uint64(cnt)), nil
+}
+
+// candidates lists the addresses of all the validator candidates.
+func (d *dposResolver) candidates(dposContext *types.DposContext) ([]common.Address, error) {
+	var candidates []common.Address
+	it := trie.NewIterator(dposContext.CandidateTrie().NodeIterator(nil))
+	for it.Next() {
+		candidates = append(candidates, common.BytesToAddress(it.Value))
+	}
+	return candidates, it.Err
+}
+
+// delegationResolver resolves a vote of a delegator for a validator candidate.
+type delegationResolver struct {
+	delegator common.Address
+	candidate common.Address
+	block     *blockResolver
+}
+
+func (d *delegationResolver) Delegator() *accountResolver {
+	return &accountResolver{address: d.delegator, block: d.block}
+}
+
+func (d *delegationResolver) Candidate() *accountResolver {
+	return &accountResolver{address: d.candidate, block: d.block}
+}
@@ -0,0 +1,148 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package graphql
+
+// schema describes the types and fields served by the resolvers, in the GraphQL
+// schema definition language. It's bound to the resolvers when the service is
+// created and served to clients as documentation.
+const schema = `# Bytes32 is a 32 byte binary string, represented as 0x-prefixed hexadecimal.
+scalar Bytes32
+# Address is a 20 byte Ethereum address, represented as 0x-prefixed hexadecimal.
+scalar Address
+# Bytes is an arbitrary length binary string, represented as 0x-prefixed hexadecimal.
+scalar Bytes
+# BigInt is a large integer, represented as 0x-prefixed hexadecimal.
+scalar BigInt
+# Long is a 64 bit unsigned integer. Arguments also accept decimal or 0x-prefixed
+# hexadecimal strings.
+scalar Long
+
+schema {
+    query: Query
+}
+
+type Query {
+    # Block fetches a block by number or by hash, the latest one if neither is given.
+    block(number: Long, hash: Bytes32): Block
+    # Blocks fetches at most 256 consecutive blocks, up to the latest one by default.
+    blocks(from: Long!, to: Long): [Block!]!
+    # Transaction fetches a mined or pending transaction by its hash.
+    transaction(hash: Bytes32!): Transaction
+    # Account fetches an account at a block, the latest one by default.
+    account(address: Address!, blockNumber: Long): Account
+    # GasPrice is the suggested gas price of new transactions.
+    gasPrice: BigInt!
+    # ProtocolVersion is the version of the Ethereum protocol of the node.
+    protocolVersion: Int!
+}
+
+type Block {
+    number: Long!
+    hash: Bytes32!
+    parent: Block
+    nonce: Bytes!
+    transactionsRoot: Bytes32!
+    transactionCount: Int!
+    stateRoot: Bytes32!
+    receiptsRoot: Bytes32!
+    # Miner is the account the block rewards are paid to.
+    miner: Account!
+    # Validator is the DPoS validator which produced the block.
+    validator: Account!
+    extraData: Bytes!
+    gasLimit: BigInt!
+    gasUsed: BigInt!
+    timestamp: Long!
+    logsBloom: Bytes!
+    mixHash: Bytes32!
+    difficulty: BigInt!
+    totalDifficulty: BigInt
+    ommerCount: Int!
+    ommers: [Block!]!
+    transactions: [Transaction!]!
+    transactionAt(index: Int!): Transaction
+    # Logs are the logs emitted by all the transactions of the block.
+    logs: [Log!]!
+    # Account fetches an account in the state after the block.
+    account(address: Address!): Account!
+    # Dpos is the delegated-proof-of-stake context after the block.
+    dpos: Dpos
+}
+
+type Transaction {
+    hash: Bytes32!
+    nonce: Long!
+    # Type is the kind of the transaction: 0 for transfers and contract calls,
+    # 1 and 2 to log in and out as candidate, 3 and 4 to delegate and undelegate,
+    # 5 to pay several recipients at once.
+    type: Int!
+    # Index is the position within the block, null if pending.
+    index: Long
+    from: Account!
+    # To is the recipient, null for contract creations, candidate logins and
+    # multi-recipient transfers.
+    to: Account
+    value: BigInt!
+    gasPrice: BigInt!
+    gas: BigInt!
+    inputData: Bytes!
+    # Block is the block including the transaction, null if pending.
+    block: Block
+    # Status is 1 on success and 0 on failure, null if pending or pre-Byzantium.
+    status: Long
+    gasUsed: BigInt
+    cumulativeGasUsed: BigInt
+    createdContract: Account
+    logs: [Log!]
+}
+
+type Log {
+    # Index is the position of the log within the block.
+    index: Int!
+    account: Account!
+    topics: [Bytes32!]!
+    data: Bytes!
+    transaction: Transaction!
+}
+
+type Account {
+    address: Address!
+    balance: BigInt!
+    transactionCount: Long!
+    code: Bytes!
+    storage(slot: Bytes32!): Bytes32!
+}
+
+type Dpos {
+    # Epoch is the epoch the block belongs to.
+    epoch: Long!
+    validators: [Account!]!
+    candidates: [Account!]!
+    # Delegations lists the votes for a candidate, or for all of them if omitted.
+    delegations(candidate: Address): [Delegation!]!
+    # Vote is the candidate a delegator voted for, null if none.
+    vote(delegator: Address!): Account
+    # MintCount is the number of blocks a validator produced in an epoch, the
+    # one of the block by default.
+    mintCount(validator: Address!, epoch: Long): Long!
+}
+
+type Delegation {
+    delegator: Account!
+    candidate: Account!
+}
+`
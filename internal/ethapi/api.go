@@ -63,6 +63,51 @@ func (s *PublicEthereumAPI) GasPrice(ctx context.Context) (*big.Int, error) {
 	return s.b.SuggestPrice(ctx)
 }
 
+// GasPriceTiers returns low, standard and fast gas price suggestions, taking the
+// pending transactions of the pool into account.
+func (s *PublicEthereumAPI) GasPriceTiers(ctx context.Context) (map[string]*hexutil.Big, error) {
+	low, standard, fast, err := s.b.SuggestPriceTiers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]*hexutil.Big{
+		"low":      (*hexutil.Big)(low),
+		"standard": (*hexutil.Big)(standard),
+		"fast":     (*hexutil.Big)(fast),
+	}, nil
+}
+
+// feeHistoryResult is the gas usage and the gas prices paid within a range of blocks.
+type feeHistoryResult struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+// FeeHistory returns the ratio of gas used to the gas limit of at most blockCount
+// blocks up to and including lastBlock, and the gas prices paid at the requested
+// percentiles of each block's gas usage.
+func (s *PublicEthereumAPI) FeeHistory(ctx context.Context, blockCount hexutil.Uint, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*feeHistoryResult, error) {
+	oldest, reward, gasUsedRatio, err := s.b.FeeHistory(ctx, int(blockCount), lastBlock, rewardPercentiles)
+	if err != nil {
+		return nil, err
+	}
+	result := &feeHistoryResult{
+		OldestBlock:  (*hexutil.Big)(oldest),
+		GasUsedRatio: gasUsedRatio,
+	}
+	if reward != nil {
+		result.Reward = make([][]*hexutil.Big, len(reward))
+		for i, prices := range reward {
+			result.Reward[i] = make([]*hexutil.Big, len(prices))
+			for j, price := range prices {
+				result.Reward[i][j] = (*hexutil.Big)(price)
+			}
+		}
+	}
+	return result, nil
+}
+
 // ProtocolVersion returns the current Ethereum protocol version this node supports
 func (s *PublicEthereumAPI) ProtocolVersion() hexutil.Uint {
 	return hexutil.Uint(s.b.ProtocolVersion())
@@ -129,12 +174,34 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransac
 	return content
 }
 
-// Status returns the number of pending and queued transaction in the pool.
-func (s *PublicTxPoolAPI) Status() map[string]hexutil.Uint {
+// txTypeNames are the names the transaction types are reported under.
+var txTypeNames = map[types.TxType]string{
+	types.Binary:          "binary",
+	types.LoginCandidate:  "loginCandidate",
+	types.LogoutCandidate: "logoutCandidate",
+	types.Delegate:        "delegate",
+	types.UnDelegate:      "unDelegate",
+	types.MultiTransfer:   "multiTransfer",
+}
+
+// Status returns the number of pending and queued transaction in the pool, in
+// total and by transaction type.
+func (s *PublicTxPoolAPI) Status() map[string]interface{} {
 	pending, queue := s.b.Stats()
-	return map[string]hexutil.Uint{
-		"pending": hexutil.Uint(pending),
-		"queued":  hexutil.Uint(queue),
+	pendingByType, queueByType := s.b.StatsByType()
+
+	byType := func(counts map[types.TxType]int) map[string]hexutil.Uint {
+		named := make(map[string]hexutil.Uint, len(txTypeNames))
+		for txType, name := range txTypeNames {
+			named[name] = hexutil.Uint(counts[txType])
+		}
+		return named
+	}
+	return map[string]interface{}{
+		"pending":       hexutil.Uint(pending),
+		"queued":        hexutil.Uint(queue),
+		"pendingByType": byType(pendingByType),
+		"queuedByType":  byType(queueByType),
 	}
 }
 
@@ -173,6 +240,88 @@ func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
 	return content
 }
 
+// ContentFrom returns the transactions contained within the transaction pool
+// that were sent from the given address.
+func (s *PublicTxPoolAPI) ContentFrom(addr common.Address) map[string]map[string]*RPCTransaction {
+	content := make(map[string]map[string]*RPCTransaction, 2)
+	pending, queue := s.b.TxPoolContentFrom(addr)
+
+	// Flatten the pending and queued transactions
+	dump := make(map[string]*RPCTransaction)
+	for _, tx := range pending {
+		dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx)
+	}
+	content["pending"] = dump
+
+	dump = make(map[string]*RPCTransaction)
+	for _, tx := range queue {
+		dump[fmt.Sprintf("%d", tx.Nonce())] = newRPCPendingTransaction(tx)
+	}
+	content["queued"] = dump
+
+	return content
+}
+
+// GetTransaction returns a transaction contained within the transaction pool,
+// along with whether it is pending or queued and the reason why. Nil is returned
+// if the pool doesn't contain the transaction.
+func (s *PublicTxPoolAPI) GetTransaction(hash common.Hash) map[string]interface{} {
+	tx, status, reason := s.b.TxPoolLookup(hash)
+	if tx == nil {
+		return nil
+	}
+	fields := map[string]interface{}{
+		"transaction": newRPCPendingTransaction(tx),
+		"reason":      reason,
+	}
+	switch status {
+	case core.TxStatusPending:
+		fields["status"] = "pending"
+	case core.TxStatusQueued:
+		fields["status"] = "queued"
+	}
+	return fields
+}
+
+// PrivateTxPoolAPI offers an API to evict transactions from the transaction pool.
+// As it can drop anyone's transactions it isn't public, so the HTTP and WebSocket
+// endpoints only serve it if the txpool module is explicitly enabled.
+type PrivateTxPoolAPI struct {
+	b Backend
+}
+
+// NewPrivateTxPoolAPI creates a new tx pool service that can evict transactions
+// from the transaction pool.
+func NewPrivateTxPoolAPI(b Backend) *PrivateTxPoolAPI {
+	return &PrivateTxPoolAPI{b}
+}
+
+// Remove drops a transaction from the transaction pool and the local transaction
+// journal. Any subsequent transactions of the sender are moved back to the queue.
+// It returns whether the transaction was contained in the pool.
+func (s *PrivateTxPoolAPI) Remove(hash common.Hash) bool {
+	if s.b.RemovePoolTransaction(hash) {
+		log.Info("Removed transaction from pool", "hash", hash)
+		return true
+	}
+	return false
+}
+
+// RemoveFrom drops all the transactions sent from the given address from the
+// transaction pool and the local transaction journal, returning their hashes.
+func (s *PrivateTxPoolAPI) RemoveFrom(addr common.Address) []common.Hash {
+	removed := s.b.RemovePoolTransactionsFrom(addr)
+
+	hashes := make([]common.Hash, len(removed))
+	for i, tx := range removed {
+		hashes[i] = tx.Hash()
+	}
+	if len(hashes) > 0 {
+		log.Info("Removed account transactions from pool", "from", addr, "count", len(hashes))
+	}
+	return hashes
+}
+
 // PublicAccountAPI provides an API to access accounts managed by this node.
 // It offers only methods that can retrieve accounts.
 type PublicAccountAPI struct {
@@ -680,6 +829,9 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (*
 	}
 	// Execute the binary search and hone in on an executable gas limit
 	for lo+1 < hi {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		mid := (hi + lo) / 2
 		if !executable(mid) {
 			lo = mid
@@ -818,21 +970,23 @@ func (s *PublicBlockChainAPI) rpcOutputBlock(b *types.Block, inclTx bool, fullTx
 
 // RPCTransaction represents a transaction that will serialize to the RPC representation of a transaction
 type RPCTransaction struct {
-	Type             types.TxType    `json:"type"`
-	BlockHash        common.Hash     `json:"blockHash"`
-	BlockNumber      *hexutil.Big    `json:"blockNumber"`
-	From             common.Address  `json:"from"`
-	Gas              *hexutil.Big    `json:"gas"`
-	GasPrice         *hexutil.Big    `json:"gasPrice"`
-	Hash             common.Hash     `json:"hash"`
-	Input            hexutil.Bytes   `json:"input"`
-	Nonce            hexutil.Uint64  `json:"nonce"`
-	To               *common.Address `json:"to"`
-	TransactionIndex hexutil.Uint    `json:"transactionIndex"`
-	Value            *hexutil.Big    `json:"value"`
-	V                *hexutil.Big    `json:"v"`
-	R                *hexutil.Big    `json:"r"`
-	S                *hexutil.Big    `json:"s"`
+	Type             types.TxType      `json:"type"`
+	BlockHash        common.Hash       `json:"blockHash"`
+	BlockNumber      *hexutil.Big      `json:"blockNumber"`
+	From             common.Address    `json:"from"`
+	Gas              *hexutil.Big      `json:"gas"`
+	GasPrice         *hexutil.Big      `json:"gasPrice"`
+	Hash             common.Hash       `json:"hash"`
+	Input            hexutil.Bytes     `json:"input"`
+	Nonce            hexutil.Uint64    `json:"nonce"`
+	To               *common.Address   `json:"to"`
+	TransactionIndex hexutil.Uint      `json:"transactionIndex"`
+	Value            *hexutil.Big      `json:"value"`
+	Transfers        []*types.Transfer `json:"transfers,omitempty"`
+	Typed            bool              `json:"typed,omitempty"`
+	V                *hexutil.Big      `json:"v"`
+	R                *hexutil.Big      `json:"r"`
+	S                *hexutil.Big      `json:"s"`
 }
 
 // newRPCTransaction returns a transaction that will serialize to the RPC
@@ -846,18 +1000,20 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 	v, r, s := tx.RawSignatureValues()
 
 	result := &RPCTransaction{
-		Type:     tx.Type(),
-		From:     from,
-		Gas:      (*hexutil.Big)(tx.Gas()),
-		GasPrice: (*hexutil.Big)(tx.GasPrice()),
-		Hash:     tx.Hash(),
-		Input:    hexutil.Bytes(tx.Data()),
-		Nonce:    hexutil.Uint64(tx.Nonce()),
-		To:       tx.To(),
-		Value:    (*hexutil.Big)(tx.Value()),
-		V:        (*hexutil.Big)(v),
-		R:        (*hexutil.Big)(r),
-		S:        (*hexutil.Big)(s),
+		Type:      tx.Type(),
+		From:      from,
+		Gas:       (*hexutil.Big)(tx.Gas()),
+		GasPrice:  (*hexutil.Big)(tx.GasPrice()),
+		Hash:      tx.Hash(),
+		Input:     hexutil.Bytes(tx.Data()),
+		Nonce:     hexutil.Uint64(tx.Nonce()),
+		To:        tx.To(),
+		Value:     (*hexutil.Big)(tx.Value()),
+		Transfers: tx.Transfers(),
+		Typed:     tx.Typed(),
+		V:         (*hexutil.Big)(v),
+		R:         (*hexutil.Big)(r),
+		S:         (*hexutil.Big)(s),
 	}
 	if blockHash != (common.Hash{}) {
 		result.BlockHash = blockHash
@@ -1008,7 +1164,48 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(hash common.Hash) (map[
 		return nil, nil
 	}
 	receipt, _, _, _ := core.GetReceipt(s.b.ChainDb(), hash) // Old receipts don't have the lookup data available
+	if receipt == nil {
+		return nil, nil
+	}
+	return newRPCReceipt(receipt, tx, blockHash, blockNumber, index), nil
+}
+
+// GetBlockReceipts returns the receipts of all the transactions of the block
+// with the given number or hash, in the order of the transactions.
+func (s *PublicTransactionPoolAPI) GetBlockReceipts(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash) ([]map[string]interface{}, error) {
+	var (
+		block *types.Block
+		err   error
+	)
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		block, err = s.b.GetBlock(ctx, hash)
+	} else if number, ok := blockNrOrHash.Number(); ok {
+		block, err = s.b.BlockByNumber(ctx, number)
+	}
+	if block == nil || err != nil {
+		return nil, err
+	}
+	txs := block.Transactions()
+	if len(txs) == 0 {
+		return []map[string]interface{}{}, nil
+	}
+	receipts, err := s.b.GetReceipts(ctx, block.Hash())
+	if err != nil {
+		return nil, err
+	}
+	if len(receipts) != len(txs) {
+		return nil, fmt.Errorf("receipts of block %x not available", block.Hash())
+	}
+	fields := make([]map[string]interface{}, len(receipts))
+	for i, receipt := range receipts {
+		fields[i] = newRPCReceipt(receipt, txs[i], block.Hash(), block.NumberU64(), uint64(i))
+	}
+	return fields, nil
+}
 
+// newRPCReceipt returns the RPC representation of a transaction receipt, along
+// with the fields derived from the transaction and its position in the chain.
+func newRPCReceipt(receipt *types.Receipt, tx *types.Transaction, blockHash common.Hash, blockNumber uint64, index uint64) map[string]interface{} {
 	var signer types.Signer = types.FrontierSigner{}
 	if tx.Protected() {
 		signer = types.NewEIP155Signer(tx.ChainId())
@@ -1018,7 +1215,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(hash common.Hash) (map[
 	fields := map[string]interface{}{
 		"blockHash":         blockHash,
 		"blockNumber":       hexutil.Uint64(blockNumber),
-		"transactionHash":   hash,
+		"transactionHash":   tx.Hash(),
 		"transactionIndex":  hexutil.Uint64(index),
 		"type":              tx.Type(),
 		"from":              from,
@@ -1043,7 +1240,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(hash common.Hash) (map[
 	if receipt.ContractAddress != (common.Address{}) {
 		fields["contractAddress"] = receipt.ContractAddress
 	}
-	return fields, nil
+	return fields
 }
 
 // sign is a helper function that signs a transaction with the private key of the given address.
@@ -1076,10 +1273,23 @@ type SendTxArgs struct {
 	Data     hexutil.Bytes   `json:"data"`
 	Nonce    *hexutil.Uint64 `json:"nonce"`
 	Type     types.TxType    `json:"type"`
+
+	// Recipients of a multi-transfer transaction
+	Transfers []*types.Transfer `json:"transfers"`
+
+	legacy bool // Whether DPoS operations are legacy encoded, before the typed transaction fork
 }
 
 // prepareSendTxArgs is a helper function that fills in default values for unspecified tx fields.
 func (args *SendTxArgs) setDefaults(ctx context.Context, b Backend) error {
+	args.legacy = !b.ChainConfig().IsTypedTx(new(big.Int).Add(b.CurrentBlock().Number(), big.NewInt(1)))
+
+	if args.Gas == nil && args.Type == types.MultiTransfer {
+		// Cover the creation of every recipient, unused gas is refunded
+		gas := core.IntrinsicGas(nil, len(args.Transfers), false, true)
+		gas.Add(gas, new(big.Int).SetUint64(params.CallNewAccountGas*uint64(len(args.Transfers))))
+		args.Gas = (*hexutil.Big)(gas)
+	}
 	if args.Gas == nil {
 		args.Gas = (*hexutil.Big)(big.NewInt(defaultGas))
 	}
@@ -1104,6 +1314,9 @@ func (args *SendTxArgs) setDefaults(ctx context.Context, b Backend) error {
 }
 
 func (args *SendTxArgs) toTransaction() *types.Transaction {
+	if args.Type == types.MultiTransfer {
+		return types.NewMultiTransfer(uint64(*args.Nonce), args.Transfers, (*big.Int)(args.Gas), (*big.Int)(args.GasPrice))
+	}
 	if args.Type == types.Binary && args.To == nil {
 		return types.NewContractCreation(uint64(*args.Nonce), (*big.Int)(args.Value), (*big.Int)(args.Gas), (*big.Int)(args.GasPrice), args.Data)
 	}
@@ -1111,6 +1324,9 @@ func (args *SendTxArgs) toTransaction() *types.Transaction {
 	if args.To != nil {
 		to = *args.To
 	}
+	if args.legacy {
+		return types.NewLegacyTransaction(args.Type, uint64(*args.Nonce), to, (*big.Int)(args.Value), (*big.Int)(args.Gas), (*big.Int)(args.GasPrice), args.Data)
+	}
 	return types.NewTransaction(args.Type, uint64(*args.Nonce), to, (*big.Int)(args.Value), (*big.Int)(args.Gas), (*big.Int)(args.GasPrice), args.Data)
 }
 
@@ -1122,7 +1338,9 @@ func submitTransaction(ctx context.Context, b Backend, tx *types.Transaction) (c
 	if err := b.SendTx(ctx, tx); err != nil {
 		return common.Hash{}, err
 	}
-	if tx.To() == nil {
+	if tx.Type() == types.MultiTransfer {
+		log.Info("Submitted multi-transfer", "fullhash", tx.Hash().Hex(), "recipients", len(tx.Transfers()))
+	} else if tx.To() == nil {
 		signer := types.MakeSigner(b.ChainConfig(), b.CurrentBlock().Number())
 		from, err := types.Sender(signer, tx)
 		if err != nil {
@@ -1,11 +1,18 @@
 package ethapi
 
 import (
+	"context"
 	"math/big"
+	"reflect"
 	"testing"
 
+	"github.com/meitu/go-ethereum/common"
 	"github.com/meitu/go-ethereum/common/hexutil"
+	"github.com/meitu/go-ethereum/core"
 	"github.com/meitu/go-ethereum/core/types"
+	"github.com/meitu/go-ethereum/crypto"
+	"github.com/meitu/go-ethereum/ethdb"
+	"github.com/meitu/go-ethereum/rpc"
 )
 
 func TestToTransaction(t *testing.T) {
@@ -23,3 +30,139 @@ func TestToTransaction(t *testing.T) {
 		t.Errorf("transaction receiptent nil is expected, but got %x", tx.To())
 	}
 }
+
+// receiptBackend serves a single block and its receipts from a memory database.
+type receiptBackend struct {
+	Backend
+	db    ethdb.Database
+	block *types.Block
+}
+
+func (b *receiptBackend) ChainDb() ethdb.Database { return b.db }
+
+func (b *receiptBackend) BlockByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Block, error) {
+	if blockNr == rpc.LatestBlockNumber || uint64(blockNr) == b.block.NumberU64() {
+		return b.block, nil
+	}
+	return nil, nil
+}
+
+func (b *receiptBackend) GetBlock(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	if hash == b.block.Hash() {
+		return b.block, nil
+	}
+	return nil, nil
+}
+
+func (b *receiptBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return core.GetBlockReceipts(b.db, hash, core.GetBlockNumber(b.db, hash)), nil
+}
+
+func TestGetBlockReceipts(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := types.NewEIP155Signer(big.NewInt(1))
+
+	var (
+		txs      []*types.Transaction
+		receipts []*types.Receipt
+	)
+	for i := 0; i < 3; i++ {
+		txType := types.Binary
+		if i == 1 {
+			txType = types.Delegate
+		}
+		tx, _ := types.SignTx(types.NewTransaction(txType, uint64(i), common.Address{0x01}, big.NewInt(0), big.NewInt(21000), big.NewInt(1), nil), signer, key)
+		receipt := types.NewReceipt(nil, false, big.NewInt(int64(21000*(i+1))))
+		receipt.TxHash = tx.Hash()
+		receipt.GasUsed = big.NewInt(21000)
+		receipt.Logs = []*types.Log{{Address: common.Address{0x01}, TxHash: tx.Hash(), TxIndex: uint(i), Index: uint(i)}}
+
+		txs, receipts = append(txs, tx), append(receipts, receipt)
+	}
+	block := types.NewBlock(&types.Header{Number: big.NewInt(1)}, txs, nil, receipts)
+
+	db, _ := ethdb.NewMemDatabase()
+	core.WriteBlock(db, block)
+	core.WriteBlockReceipts(db, block.Hash(), block.NumberU64(), receipts)
+	core.WriteTxLookupEntries(db, block)
+
+	api := NewPublicTransactionPoolAPI(&receiptBackend{db: db, block: block}, nil)
+	for _, ref := range []rpc.BlockNumberOrHash{rpc.BlockNumberOrHashWithNumber(1), rpc.BlockNumberOrHashWithHash(block.Hash())} {
+		fields, err := api.GetBlockReceipts(context.Background(), ref)
+		if err != nil {
+			t.Fatalf("failed to retrieve block receipts: %v", err)
+		}
+		if len(fields) != len(txs) {
+			t.Fatalf("receipt count mismatch: have %d, want %d", len(fields), len(txs))
+		}
+		for i, tx := range txs {
+			want, _ := api.GetTransactionReceipt(tx.Hash())
+			if !reflect.DeepEqual(fields[i], want) {
+				t.Errorf("receipt %d mismatch: have %v, want %v", i, fields[i], want)
+			}
+		}
+		if fields[1]["type"] != types.Delegate {
+			t.Errorf("transaction type mismatch: have %v, want %v", fields[1]["type"], types.Delegate)
+		}
+	}
+	// Unknown blocks have no receipts
+	fields, err := api.GetBlockReceipts(context.Background(), rpc.BlockNumberOrHashWithNumber(2))
+	if fields != nil || err != nil {
+		t.Fatalf("unknown block: have %v, %v, want nil", fields, err)
+	}
+}
+
+// txPoolBackend serves a pending and a queued transaction of a single account.
+type txPoolBackend struct {
+	Backend
+	pending, queued *types.Transaction
+	removed         []common.Hash
+}
+
+func (b *txPoolBackend) TxPoolContentFrom(addr common.Address) (types.Transactions, types.Transactions) {
+	return types.Transactions{b.pending}, types.Transactions{b.queued}
+}
+
+func (b *txPoolBackend) TxPoolLookup(hash common.Hash) (*types.Transaction, core.TxStatus, string) {
+	switch hash {
+	case b.pending.Hash():
+		return b.pending, core.TxStatusPending, "executable"
+	case b.queued.Hash():
+		return b.queued, core.TxStatusQueued, "nonce gap"
+	}
+	return nil, core.TxStatusUnknown, ""
+}
+
+func (b *txPoolBackend) RemovePoolTransactionsFrom(addr common.Address) types.Transactions {
+	b.removed = append(b.removed, b.pending.Hash(), b.queued.Hash())
+	return types.Transactions{b.pending, b.queued}
+}
+
+func TestTxPoolAccountInspection(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := types.NewEIP155Signer(big.NewInt(1))
+
+	pending, _ := types.SignTx(types.NewTransaction(types.Binary, 0, common.Address{0x01}, big.NewInt(0), big.NewInt(21000), big.NewInt(1), nil), signer, key)
+	queued, _ := types.SignTx(types.NewTransaction(types.Delegate, 2, common.Address{0x01}, big.NewInt(0), big.NewInt(21000), big.NewInt(1), nil), signer, key)
+	backend := &txPoolBackend{pending: pending, queued: queued}
+
+	api := NewPublicTxPoolAPI(backend)
+	content := api.ContentFrom(crypto.PubkeyToAddress(key.PublicKey))
+	if tx := content["pending"]["0"]; tx == nil || tx.Hash != pending.Hash() {
+		t.Errorf("pending content mismatch: have %v, want %x", tx, pending.Hash())
+	}
+	if tx := content["queued"]["2"]; tx == nil || tx.Hash != queued.Hash() {
+		t.Errorf("queued content mismatch: have %v, want %x", tx, queued.Hash())
+	}
+	fields := api.GetTransaction(queued.Hash())
+	if fields["status"] != "queued" || fields["reason"] != "nonce gap" {
+		t.Errorf("queued lookup mismatch: have %v/%v, want queued/nonce gap", fields["status"], fields["reason"])
+	}
+	if fields := api.GetTransaction(common.Hash{}); fields != nil {
+		t.Errorf("unknown transaction found: %v", fields)
+	}
+	hashes := NewPrivateTxPoolAPI(backend).RemoveFrom(crypto.PubkeyToAddress(key.PublicKey))
+	if !reflect.DeepEqual(hashes, backend.removed) {
+		t.Errorf("removed hashes mismatch: have %x, want %x", hashes, backend.removed)
+	}
+}
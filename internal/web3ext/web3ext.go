@@ -82,6 +82,21 @@ web3._extend({
 		}),
 	]
 });
+
+// The staking operations are sent as typed transactions from an account of the
+// node, the types being those of core/types.TxType.
+web3.dpos.loginCandidate = function(from) {
+	return web3.eth.sendTransaction({from: from, type: 1});
+};
+web3.dpos.logoutCandidate = function(from) {
+	return web3.eth.sendTransaction({from: from, type: 2});
+};
+web3.dpos.delegate = function(from, candidate) {
+	return web3.eth.sendTransaction({from: from, to: candidate, type: 3});
+};
+web3.dpos.unDelegate = function(from, candidate) {
+	return web3.eth.sendTransaction({from: from, to: candidate, type: 4});
+};
 `
 
 const Clique_JS = `
@@ -433,6 +448,18 @@ web3._extend({
 			params: 2,
 			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, web3._extend.utils.toHex]
 		}),
+		new web3._extend.Method({
+			name: 'getBlockReceipts',
+			call: 'eth_getBlockReceipts',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'feeHistory',
+			call: 'eth_feeHistory',
+			params: 3,
+			inputFormatter: [web3._extend.utils.fromDecimal, web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
@@ -447,6 +474,16 @@ web3._extend({
 				return formatted;
 			}
 		}),
+		new web3._extend.Property({
+			name: 'gasPriceTiers',
+			getter: 'eth_gasPriceTiers',
+			outputFormatter: function(tiers) {
+				for (var name in tiers) {
+					tiers[name] = web3._extend.utils.toBigNumber(tiers[name]);
+				}
+				return tiers;
+			}
+		}),
 	]
 });
 `
@@ -610,7 +647,30 @@ web3._extend({
 const TxPool_JS = `
 web3._extend({
 	property: 'txpool',
-	methods: [],
+	methods: [
+		new web3._extend.Method({
+			name: 'contentFrom',
+			call: 'txpool_contentFrom',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getTransaction',
+			call: 'txpool_getTransaction',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'remove',
+			call: 'txpool_remove',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'removeFrom',
+			call: 'txpool_removeFrom',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		}),
+	],
 	properties:
 	[
 		new web3._extend.Property({
@@ -627,6 +687,12 @@ web3._extend({
 			outputFormatter: function(status) {
 				status.pending = web3._extend.utils.toDecimal(status.pending);
 				status.queued = web3._extend.utils.toDecimal(status.queued);
+				for (var name in status.pendingByType) {
+					status.pendingByType[name] = web3._extend.utils.toDecimal(status.pendingByType[name]);
+				}
+				for (var name in status.queuedByType) {
+					status.queuedByType[name] = web3._extend.utils.toDecimal(status.queuedByType[name]);
+				}
 				return status;
 			}
 		}),
@@ -18,6 +18,7 @@ package les
 
 import (
 	"context"
+	"errors"
 	"math/big"
 
 	"github.com/meitu/go-ethereum/accounts"
@@ -37,6 +38,10 @@ import (
 	"github.com/meitu/go-ethereum/rpc"
 )
 
+// errDposContextUnavailable is returned when accessing the DPoS tries, which light
+// clients don't retrieve.
+var errDposContextUnavailable = errors.New("dpos context not available in light mode")
+
 type LesApiBackend struct {
 	eth *LightEthereum
 	gpo *gasprice.Oracle
@@ -91,6 +96,10 @@ func (b *LesApiBackend) GetTd(blockHash common.Hash) *big.Int {
 	return b.eth.blockchain.GetTdByHash(blockHash)
 }
 
+func (b *LesApiBackend) DposContextAt(ctx context.Context, header *types.Header) (*types.DposContext, error) {
+	return nil, errDposContextUnavailable
+}
+
 func (b *LesApiBackend) GetEVM(ctx context.Context, msg core.Message, state *state.StateDB, header *types.Header, vmCfg vm.Config) (*vm.EVM, func() error, error) {
 	state.SetBalance(msg.From(), math.MaxBig256)
 	context := core.NewEVMContext(msg, header, b.eth.blockchain, nil)
@@ -121,10 +130,46 @@ func (b *LesApiBackend) Stats() (pending int, queued int) {
 	return b.eth.txPool.Stats(), 0
 }
 
+func (b *LesApiBackend) StatsByType() (pending map[types.TxType]int, queued map[types.TxType]int) {
+	pending, queued = make(map[types.TxType]int), make(map[types.TxType]int)
+	txs, _ := b.eth.txPool.GetTransactions()
+	for _, tx := range txs {
+		pending[tx.Type()]++
+	}
+	return pending, queued
+}
+
 func (b *LesApiBackend) TxPoolContent() (map[common.Address]types.Transactions, map[common.Address]types.Transactions) {
 	return b.eth.txPool.Content()
 }
 
+func (b *LesApiBackend) TxPoolContentFrom(addr common.Address) (types.Transactions, types.Transactions) {
+	pending, _ := b.eth.txPool.Content()
+	return pending[addr], nil
+}
+
+func (b *LesApiBackend) TxPoolLookup(txHash common.Hash) (*types.Transaction, core.TxStatus, string) {
+	// There are no queued transactions in a light pool, only ones awaiting inclusion
+	if tx := b.eth.txPool.GetTransaction(txHash); tx != nil {
+		return tx, core.TxStatusPending, "awaiting inclusion"
+	}
+	return nil, core.TxStatusUnknown, ""
+}
+
+func (b *LesApiBackend) RemovePoolTransaction(txHash common.Hash) bool {
+	if b.eth.txPool.GetTransaction(txHash) == nil {
+		return false
+	}
+	b.eth.txPool.RemoveTx(txHash)
+	return true
+}
+
+func (b *LesApiBackend) RemovePoolTransactionsFrom(addr common.Address) types.Transactions {
+	pending, _ := b.eth.txPool.Content()
+	b.eth.txPool.RemoveTransactions(pending[addr])
+	return pending[addr]
+}
+
 func (b *LesApiBackend) SubscribeTxPreEvent(ch chan<- core.TxPreEvent) event.Subscription {
 	return b.eth.txPool.SubscribeTxPreEvent(ch)
 }
@@ -157,6 +202,18 @@ func (b *LesApiBackend) SuggestPrice(ctx context.Context) (*big.Int, error) {
 	return b.gpo.SuggestPrice(ctx)
 }
 
+func (b *LesApiBackend) SuggestPriceTiers(ctx context.Context) (*big.Int, *big.Int, *big.Int, error) {
+	tiers, err := b.gpo.SuggestTiers(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return tiers.Low, tiers.Standard, tiers.Fast, nil
+}
+
+func (b *LesApiBackend) FeeHistory(ctx context.Context, blocks int, lastBlock rpc.BlockNumber, percentiles []float64) (*big.Int, [][]*big.Int, []float64, error) {
+	return b.gpo.FeeHistory(ctx, blocks, lastBlock, percentiles)
+}
+
 func (b *LesApiBackend) ChainDb() ethdb.Database {
 	return b.eth.chainDb
 }
@@ -354,11 +354,18 @@ func testGetProofs(t *testing.T, protocol int) {
 	)
 	proofsV2 := light.NewNodeSet()
 
+	// The recent state of a non-archive node is only held in memory
+	if ok, _ := db.Has(bc.CurrentBlock().Root().Bytes()); ok {
+		t.Fatalf("head state flushed to disk")
+	}
 	accounts := []common.Address{testBankAddress, acc1Addr, acc2Addr, {}}
 	for i := uint64(0); i <= bc.CurrentBlock().NumberU64(); i++ {
 		header := bc.GetHeaderByNumber(i)
 		root := header.Root
-		trie, _ := trie.New(root, db)
+		trie, err := trie.New(root, bc.StateCache().TrieDB())
+		if err != nil {
+			t.Fatalf("block %d: failed to open state trie: %v", i, err)
+		}
 
 		for _, acc := range accounts {
 			req := ProofReq{
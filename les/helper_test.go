@@ -148,8 +148,12 @@ func newTestProtocolManager(lightSync bool, blocks int, generator func(int, *cor
 	if lightSync {
 		chain, _ = light.NewLightChain(odr, gspec.Config, engine)
 	} else {
-		blockchain, _ := core.NewBlockChain(db, gspec.Config, engine, vm.Config{})
-		gchain, _ := core.GenerateChain(gspec.Config, genesis, db, blocks, generator)
+		// Generate the blocks in a separate database, leaving the state of the
+		// served chain to its own non-archive trie node database
+		gendb, _ := ethdb.NewMemDatabase()
+		gspec.MustCommit(gendb)
+		blockchain, _ := core.NewBlockChain(db, nil, gspec.Config, engine, vm.Config{})
+		gchain, _ := core.GenerateChain(gspec.Config, genesis, gendb, blocks, generator)
 		if _, err := blockchain.InsertChain(gchain); err != nil {
 			panic(err)
 		}
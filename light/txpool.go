@@ -19,6 +19,7 @@ package light
 import (
 	"context"
 	"fmt"
+	"math/big"
 	"sync"
 	"time"
 
@@ -362,6 +363,15 @@ func (pool *TxPool) validateTx(ctx context.Context, tx *types.Transaction) error
 		return core.ErrGasLimit
 	}
 
+	// Reject the unknown transaction types, which invalidate any block
+	if !tx.Type().Valid() {
+		return types.ErrInvalidType
+	}
+	// Make sure the transaction is encoded the way the next block expects
+	if err := types.ValidateEncoding(pool.config, new(big.Int).Add(header.Number, big.NewInt(1)), tx); err != nil {
+		return err
+	}
+
 	// Transactions can't be negative. This may never happen
 	// using RLP decoded transactions but may occur if you create
 	// a transaction using the RPC for example.
@@ -369,14 +379,25 @@ func (pool *TxPool) validateTx(ctx context.Context, tx *types.Transaction) error
 		return core.ErrNegativeValue
 	}
 
+	// Multi-transfer transactions must be enabled and pay at least one recipient
+	if tx.Type() == types.MultiTransfer {
+		if !pool.config.IsMultiTransfer(new(big.Int).Add(header.Number, big.NewInt(1))) {
+			return core.ErrMultiTransferNotActive
+		}
+		if err := tx.Validate(); err != nil {
+			return err
+		}
+	}
+
 	// Transactor should have enough funds to cover the costs
-	// cost == V + GP * GL
+	// cost == V + GP * GL + sum of the transferred amounts
 	if b := currentState.GetBalance(from); b.Cmp(tx.Cost()) < 0 {
 		return core.ErrInsufficientFunds
 	}
 
 	// Should supply enough intrinsic gas
-	if tx.Gas().Cmp(core.IntrinsicGas(tx.Data(), tx.To() == nil, pool.homestead)) < 0 {
+	transfers := len(tx.Transfers())
+	if tx.Gas().Cmp(core.IntrinsicGas(tx.Data(), transfers, tx.To() == nil && transfers == 0, pool.homestead)) < 0 {
 		return core.ErrIntrinsicGas
 	}
 
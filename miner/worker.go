@@ -35,6 +35,7 @@ import (
 	"github.com/meitu/go-ethereum/ethdb"
 	"github.com/meitu/go-ethereum/event"
 	"github.com/meitu/go-ethereum/log"
+	"github.com/meitu/go-ethereum/metrics"
 	"github.com/meitu/go-ethereum/params"
 	"gopkg.in/fatih/set.v0"
 )
@@ -53,6 +54,12 @@ const (
 	chainSideChanSize = 10
 )
 
+var (
+	// Metrics for the DPoS governance transactions committed into reserved gas
+	governanceTxMeter  = metrics.NewMeter("miner/governance/txs")
+	governanceGasMeter = metrics.NewMeter("miner/governance/gas")
+)
+
 // Work is the workers current environment and holds
 // all of the current state information
 type Work struct {
@@ -286,7 +293,8 @@ func (self *worker) update() {
 				txs := map[common.Address]types.Transactions{acc: {ev.Tx}}
 				txset := types.NewTransactionsByPriceAndNonce(self.current.signer, txs)
 
-				self.current.commitTransactions(self.mux, txset, self.chain, self.coinbase)
+				gp := new(core.GasPool).AddGas(self.current.header.GasLimit)
+				self.current.commitTransactions(self.mux, txset, self.chain, self.coinbase, gp)
 				self.currentMu.Unlock()
 			}
 		// System stopped
@@ -353,7 +361,7 @@ func (self *worker) makeCurrent(parent *types.Block, header *types.Header) error
 	if err != nil {
 		return err
 	}
-	dposContext, err := types.NewDposContextFromProto(self.chainDb, parent.Header().DposContext)
+	dposContext, err := types.NewDposContextFromProto(self.chain.StateCache().TrieDB(), parent.Header().DposContext)
 	if err != nil {
 		return err
 	}
@@ -450,8 +458,7 @@ func (self *worker) createNewWork() (*Work, error) {
 	if err != nil {
 		return nil, fmt.Errorf("got error when fetch pending transactions, err: %s", err)
 	}
-	txs := types.NewTransactionsByPriceAndNonce(self.current.signer, pending)
-	work.commitTransactions(self.mux, txs, self.chain, self.coinbase)
+	work.commitPending(self.mux, pending, self.eth.TxPool().GovernanceReserve(), self.chain, self.coinbase)
 
 	// compute uncles for the new block.
 	var (
@@ -505,8 +512,50 @@ func (self *worker) commitUncle(work *Work, uncle *types.Header) error {
 	return nil
 }
 
-func (env *Work) commitTransactions(mux *event.TypeMux, txs *types.TransactionsByPriceAndNonce, bc *core.BlockChain, coinbase common.Address) {
-	gp := new(core.GasPool).AddGas(env.header.GasLimit)
+// commitPending fills the block with the pending transactions in two lanes. The
+// DPoS governance transactions are committed first into the given percentage of
+// the block gas limit reserved for them, ordered by their own price queue, then
+// all the remaining transactions compete by price for the rest of the block.
+func (env *Work) commitPending(mux *event.TypeMux, pending map[common.Address]types.Transactions, reserve uint64, bc *core.BlockChain, coinbase common.Address) {
+	gasLimit := new(big.Int).Set(env.header.GasLimit)
+
+	if lane := governanceLane(pending); reserve > 0 && len(lane) > 0 {
+		reserved := new(big.Int).Mul(gasLimit, new(big.Int).SetUint64(reserve))
+		reserved.Div(reserved, big.NewInt(100))
+
+		gp := new(core.GasPool).AddGas(reserved)
+		tcount := env.tcount
+		env.commitTransactions(mux, types.NewTransactionsByPriceAndNonce(env.signer, lane), bc, coinbase, gp)
+
+		// Release the reserved gas left unused to the other transactions
+		used := new(big.Int).Sub(reserved, (*big.Int)(gp))
+		gasLimit.Sub(gasLimit, used)
+
+		governanceTxMeter.Mark(int64(env.tcount - tcount))
+		governanceGasMeter.Mark(used.Int64())
+	}
+	// Already included governance transactions are skipped as nonce too low
+	txs := types.NewTransactionsByPriceAndNonce(env.signer, pending)
+	env.commitTransactions(mux, txs, bc, coinbase, new(core.GasPool).AddGas(gasLimit))
+}
+
+// governanceLane extracts the DPoS governance transactions of each account that
+// are executable ahead of any other transaction of the same account.
+func governanceLane(pending map[common.Address]types.Transactions) map[common.Address]types.Transactions {
+	lane := make(map[common.Address]types.Transactions)
+	for addr, txs := range pending {
+		n := 0
+		for n < len(txs) && core.IsGovernanceTx(txs[n]) {
+			n++
+		}
+		if n > 0 {
+			lane[addr] = txs[:n]
+		}
+	}
+	return lane
+}
+
+func (env *Work) commitTransactions(mux *event.TypeMux, txs *types.TransactionsByPriceAndNonce, bc *core.BlockChain, coinbase common.Address, gp *core.GasPool) {
 
 	var coalescedLogs []*types.Log
 
@@ -586,11 +635,9 @@ func (env *Work) commitTransactions(mux *event.TypeMux, txs *types.TransactionsB
 
 func (env *Work) commitTransaction(tx *types.Transaction, bc *core.BlockChain, coinbase common.Address, gp *core.GasPool) (error, []*types.Log) {
 	snap := env.state.Snapshot()
-	dposSnap := env.dposContext.Snapshot()
 	receipt, _, err := core.ApplyTransaction(env.config, env.dposContext, bc, &coinbase, gp, env.state, env.header, tx, env.header.GasUsed, vm.Config{})
 	if err != nil {
 		env.state.RevertToSnapshot(snap)
-		env.dposContext.RevertToSnapShot(dposSnap)
 		return err, nil
 	}
 	env.txs = append(env.txs, tx)
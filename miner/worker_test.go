@@ -0,0 +1,57 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package miner
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/core/types"
+)
+
+// Tests that the governance lane only contains the DPoS transactions executable
+// ahead of any other transaction of their senders.
+func TestGovernanceLane(t *testing.T) {
+	tx := func(txType types.TxType, nonce uint64) *types.Transaction {
+		return types.NewTransaction(txType, nonce, common.Address{0xff}, big.NewInt(0), big.NewInt(21000), big.NewInt(1), nil)
+	}
+	var (
+		delegator = common.Address{0x01}
+		candidate = common.Address{0x02}
+		sender    = common.Address{0x03}
+	)
+	pending := map[common.Address]types.Transactions{
+		delegator: {tx(types.Delegate, 0), tx(types.UnDelegate, 1), tx(types.Binary, 2), tx(types.Delegate, 3)},
+		candidate: {tx(types.Binary, 0), tx(types.LoginCandidate, 1)},
+		sender:    {tx(types.LogoutCandidate, 0)},
+	}
+	lane := governanceLane(pending)
+
+	if len(lane) != 2 {
+		t.Fatalf("lane account count mismatch: have %d, want %d", len(lane), 2)
+	}
+	if txs := lane[delegator]; len(txs) != 2 || txs[0] != pending[delegator][0] || txs[1] != pending[delegator][1] {
+		t.Errorf("delegator lane mismatch: have %v", txs)
+	}
+	if txs := lane[sender]; len(txs) != 1 || txs[0] != pending[sender][0] {
+		t.Errorf("sender lane mismatch: have %v", txs)
+	}
+	if len(pending[delegator]) != 4 {
+		t.Errorf("pending transactions modified")
+	}
+}
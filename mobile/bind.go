@@ -19,7 +19,10 @@
 package geth
 
 import (
+	"errors"
+	"fmt"
 	"math/big"
+	"reflect"
 	"strings"
 
 	"github.com/meitu/go-ethereum/accounts/abi"
@@ -102,11 +105,70 @@ func (opts *TransactOpts) SetGasPrice(price *BigInt)   { opts.opts.GasPrice = pr
 func (opts *TransactOpts) SetGasLimit(limit int64)     { opts.opts.GasLimit = big.NewInt(limit) }
 func (opts *TransactOpts) SetContext(context *Context) { opts.opts.Context = context.context }
 
+// FilterOpts is the collection of options to fine tune filtering for events
+// within a bound contract.
+type FilterOpts struct {
+	opts bind.FilterOpts
+}
+
+// NewFilterOpts creates a new option set for contract event filtering.
+func NewFilterOpts() *FilterOpts {
+	return new(FilterOpts)
+}
+
+func (opts *FilterOpts) GetStart() int64 { return int64(opts.opts.Start) }
+func (opts *FilterOpts) GetEnd() int64 {
+	if opts.opts.End == nil {
+		return -1
+	}
+	return int64(*opts.opts.End)
+}
+
+func (opts *FilterOpts) SetStart(start int64) { opts.opts.Start = uint64(start) }
+func (opts *FilterOpts) SetEnd(end int64) {
+	if end < 0 {
+		opts.opts.End = nil
+		return
+	}
+	number := uint64(end)
+	opts.opts.End = &number
+}
+func (opts *FilterOpts) SetContext(context *Context) { opts.opts.Context = context.context }
+
+// WatchOpts is the collection of options to fine tune subscribing for events
+// within a bound contract.
+type WatchOpts struct {
+	opts bind.WatchOpts
+}
+
+// NewWatchOpts creates a new option set for contract event subscriptions.
+func NewWatchOpts() *WatchOpts {
+	return new(WatchOpts)
+}
+
+func (opts *WatchOpts) GetStart() int64 {
+	if opts.opts.Start == nil {
+		return -1
+	}
+	return int64(*opts.opts.Start)
+}
+
+func (opts *WatchOpts) SetStart(start int64) {
+	if start < 0 {
+		opts.opts.Start = nil
+		return
+	}
+	number := uint64(start)
+	opts.opts.Start = &number
+}
+func (opts *WatchOpts) SetContext(context *Context) { opts.opts.Context = context.context }
+
 // BoundContract is the base wrapper object that reflects a contract on the
 // Ethereum network. It contains a collection of methods that are used by the
 // higher level contract bindings to operate.
 type BoundContract struct {
 	contract *bind.BoundContract
+	abi      abi.ABI
 	address  common.Address
 	deployer *types.Transaction
 }
@@ -125,6 +187,7 @@ func DeployContract(opts *TransactOpts, abiJSON string, bytecode []byte, client
 	}
 	return &BoundContract{
 		contract: bound,
+		abi:      parsed,
 		address:  addr,
 		deployer: tx,
 	}, nil
@@ -138,7 +201,8 @@ func BindContract(address *Address, abiJSON string, client *EthereumClient) (con
 		return nil, err
 	}
 	return &BoundContract{
-		contract: bind.NewBoundContract(address.address, parsed, client.client, client.client),
+		contract: bind.NewBoundContract(address.address, parsed, client.client, client.client, client.client),
+		abi:      parsed,
 		address:  address.address,
 	}, nil
 }
@@ -181,3 +245,126 @@ func (c *BoundContract) Transfer(opts *TransactOpts) (tx *Transaction, _ error)
 	}
 	return &Transaction{rawTx}, nil
 }
+
+// FilterLogs retrieves the past logs of the given contract event. The query holds
+// one entry for each indexed event argument, a nil value matching anything.
+func (c *BoundContract) FilterLogs(opts *FilterOpts, event string, query *Interfaces) (logs *Logs, _ error) {
+	rules, err := c.topicRules(event, query)
+	if err != nil {
+		return nil, err
+	}
+	ch, sub, err := c.contract.FilterLogs(&opts.opts, event, rules...)
+	if err != nil {
+		return nil, err
+	}
+	defer sub.Unsubscribe()
+
+	var res []*types.Log
+	for {
+		select {
+		case log := <-ch:
+			res = append(res, &log)
+
+		case err := <-sub.Err():
+			if err != nil {
+				return nil, err
+			}
+			// Delivery finished, collect anything still buffered
+			for {
+				select {
+				case log := <-ch:
+					res = append(res, &log)
+				default:
+					return &Logs{res}, nil
+				}
+			}
+		}
+	}
+}
+
+// WatchLogs subscribes to the future logs of the given contract event, feeding
+// them into the handler. The query is interpreted as for FilterLogs.
+func (c *BoundContract) WatchLogs(opts *WatchOpts, event string, query *Interfaces, handler FilterLogsHandler, buffer int) (sub *Subscription, _ error) {
+	rules, err := c.topicRules(event, query)
+	if err != nil {
+		return nil, err
+	}
+	ch, rawSub, err := c.contract.WatchLogs(&opts.opts, event, rules...)
+	if err != nil {
+		return nil, err
+	}
+	// Start up a dispatcher to feed into the callback
+	go func() {
+		for {
+			select {
+			case log := <-ch:
+				handler.OnFilterLogs(&Log{&log})
+
+			case err := <-rawSub.Err():
+				if err != nil {
+					handler.OnError(err.Error())
+				}
+				return
+			}
+		}
+	}()
+	return &Subscription{rawSub}, nil
+}
+
+// UnpackLog unpacks a retrieved event log into the output values, which must
+// hold one entry for each event argument, in declaration order.
+func (c *BoundContract) UnpackLog(out *Interfaces, event string, log *Log) error {
+	ev, ok := c.abi.Events[event]
+	if !ok {
+		return fmt.Errorf("abi: could not locate event %q", event)
+	}
+	if len(out.objects) != len(ev.Inputs) {
+		return fmt.Errorf("abi: event %s has %d arguments, got %d outputs", event, len(ev.Inputs), len(out.objects))
+	}
+	// Assemble a struct mirroring the event so the bind package can fill it
+	fields := make([]reflect.StructField, len(ev.Inputs))
+	for i, input := range ev.Inputs {
+		typ := reflect.TypeOf(out.objects[i])
+		if typ == nil || typ.Kind() != reflect.Ptr {
+			return fmt.Errorf("abi: output %d is not a settable value", i)
+		}
+		if len(input.Name) == 0 {
+			return errors.New("abi: cannot unpack events with unnamed arguments")
+		}
+		fields[i] = reflect.StructField{
+			Name: strings.ToUpper(input.Name[:1]) + input.Name[1:],
+			Type: typ.Elem(),
+		}
+	}
+	result := reflect.New(reflect.StructOf(fields))
+	if err := c.contract.UnpackLog(result.Interface(), event, *log.log); err != nil {
+		return err
+	}
+	for i := range out.objects {
+		reflect.ValueOf(out.objects[i]).Elem().Set(result.Elem().Field(i))
+	}
+	return nil
+}
+
+// topicRules converts the mobile filter query into the per indexed argument
+// rule lists expected by the bind package.
+func (c *BoundContract) topicRules(event string, query *Interfaces) ([][]interface{}, error) {
+	if _, ok := c.abi.Events[event]; !ok {
+		return nil, fmt.Errorf("abi: could not locate event %q", event)
+	}
+	if query == nil {
+		return nil, nil
+	}
+	rules := make([][]interface{}, len(query.objects))
+	for i, object := range query.objects {
+		if object == nil {
+			continue
+		}
+		value := reflect.ValueOf(object)
+		if value.Kind() == reflect.Ptr {
+			value = value.Elem()
+		}
+		rules[i] = []interface{}{value.Interface()}
+	}
+	return rules, nil
+}
@@ -21,6 +21,8 @@ package geth
 import (
 	"math/big"
 
+	"github.com/meitu/go-ethereum/accounts/abi/bind"
+	"github.com/meitu/go-ethereum/common"
 	"github.com/meitu/go-ethereum/core/types"
 	"github.com/meitu/go-ethereum/ethclient"
 )
@@ -310,3 +312,44 @@ func (ec *EthereumClient) EstimateGas(ctx *Context, msg *CallMsg) (gas *BigInt,
 func (ec *EthereumClient) SendTransaction(ctx *Context, tx *Transaction) error {
 	return ec.client.SendTransaction(ctx.context, tx.tx)
 }
+
+// LoginCandidate builds a transaction registering the transactor as a dpos
+// candidate, signs it with the transactor and sends it to the node. Unset nonce,
+// gas price and gas limit options are filled in automatically.
+func (ec *EthereumClient) LoginCandidate(opts *TransactOpts) (tx *Transaction, _ error) {
+	rawTx, err := bind.TransactDpos(&opts.opts, ec.client, types.LoginCandidate, common.Address{})
+	if err != nil {
+		return nil, err
+	}
+	return &Transaction{rawTx}, nil
+}
+
+// LogoutCandidate builds, signs and sends a transaction withdrawing the dpos
+// candidacy of the transactor.
+func (ec *EthereumClient) LogoutCandidate(opts *TransactOpts) (tx *Transaction, _ error) {
+	rawTx, err := bind.TransactDpos(&opts.opts, ec.client, types.LogoutCandidate, common.Address{})
+	if err != nil {
+		return nil, err
+	}
+	return &Transaction{rawTx}, nil
+}
+
+// Delegate builds, signs and sends a transaction voting for the given candidate
+// with the balance of the transactor.
+func (ec *EthereumClient) Delegate(opts *TransactOpts, candidate *Address) (tx *Transaction, _ error) {
+	rawTx, err := bind.TransactDpos(&opts.opts, ec.client, types.Delegate, candidate.address)
+	if err != nil {
+		return nil, err
+	}
+	return &Transaction{rawTx}, nil
+}
+
+// UnDelegate builds, signs and sends a transaction withdrawing the vote of the
+// transactor from the given candidate.
+func (ec *EthereumClient) UnDelegate(opts *TransactOpts, candidate *Address) (tx *Transaction, _ error) {
+	rawTx, err := bind.TransactDpos(&opts.opts, ec.client, types.UnDelegate, candidate.address)
+	if err != nil {
+		return nil, err
+	}
+	return &Transaction{rawTx}, nil
+}
@@ -216,6 +216,36 @@ func NewTransaction(nonce int64, to *Address, amount, gasLimit, gasPrice *BigInt
 	return &Transaction{types.NewTransaction(types.Binary, uint64(nonce), to.address, amount.bigint, gasLimit.bigint, gasPrice.bigint, common.CopyBytes(data))}
 }
 
+// Transaction types, telling plain transfers and contract interactions apart
+// from the dpos staking operations and multi-transfers.
+const (
+	TxTypeBinary          = int(types.Binary)
+	TxTypeLoginCandidate  = int(types.LoginCandidate)
+	TxTypeLogoutCandidate = int(types.LogoutCandidate)
+	TxTypeDelegate        = int(types.Delegate)
+	TxTypeUnDelegate      = int(types.UnDelegate)
+	TxTypeMultiTransfer   = int(types.MultiTransfer)
+)
+
+// NewDposTransaction creates a new dpos staking operation of the given type.
+// Logging in and out as a candidate takes no candidate, delegating and
+// undelegating need the one voted for. The operation is a typed transaction,
+// accepted from the typed transaction fork on.
+func NewDposTransaction(txType int, nonce int64, candidate *Address, gasLimit, gasPrice *BigInt) (tx *Transaction, _ error) {
+	var to common.Address
+	switch types.TxType(txType) {
+	case types.LoginCandidate, types.LogoutCandidate:
+	case types.Delegate, types.UnDelegate:
+		if candidate == nil {
+			return nil, errors.New("no candidate to vote for")
+		}
+		to = candidate.address
+	default:
+		return nil, types.ErrInvalidType
+	}
+	return &Transaction{types.NewTransaction(types.TxType(txType), uint64(nonce), to, nil, gasLimit.bigint, gasPrice.bigint, nil)}, nil
+}
+
 // NewTransactionFromRLP parses a transaction from an RLP data dump.
 func NewTransactionFromRLP(data []byte) (*Transaction, error) {
 	tx := &Transaction{
@@ -260,6 +290,7 @@ func (tx *Transaction) GetGas() int64        { return tx.tx.Gas().Int64() }
 func (tx *Transaction) GetGasPrice() *BigInt { return &BigInt{tx.tx.GasPrice()} }
 func (tx *Transaction) GetValue() *BigInt    { return &BigInt{tx.tx.Value()} }
 func (tx *Transaction) GetNonce() int64      { return int64(tx.tx.Nonce()) }
+func (tx *Transaction) GetType() int         { return int(tx.tx.Type()) }
 
 func (tx *Transaction) GetHash() *Hash   { return &Hash{tx.tx.Hash()} }
 func (tx *Transaction) GetCost() *BigInt { return &BigInt{tx.tx.Cost()} }
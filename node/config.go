@@ -26,6 +26,7 @@ import (
 	"strings"
 
 	"github.com/meitu/go-ethereum/accounts"
+	"github.com/meitu/go-ethereum/accounts/external"
 	"github.com/meitu/go-ethereum/accounts/keystore"
 	"github.com/meitu/go-ethereum/accounts/usbwallet"
 	"github.com/meitu/go-ethereum/common"
@@ -33,6 +34,7 @@ import (
 	"github.com/meitu/go-ethereum/log"
 	"github.com/meitu/go-ethereum/p2p"
 	"github.com/meitu/go-ethereum/p2p/discover"
+	"github.com/meitu/go-ethereum/rpc"
 )
 
 const (
@@ -66,6 +68,10 @@ type Config struct {
 	// in memory.
 	DataDir string
 
+	// DatabaseEngine is the storage engine used for newly created databases. An
+	// empty value selects LevelDB. Existing databases always keep their engine.
+	DatabaseEngine string `toml:",omitempty"`
+
 	// Configuration of peer-to-peer networking.
 	P2P p2p.Config
 
@@ -85,6 +91,10 @@ type Config struct {
 	// NoUSB disables hardware wallet monitoring and connectivity.
 	NoUSB bool `toml:",omitempty"`
 
+	// ExternalSigner is the endpoint of an external signer process, either the path
+	// of an IPC socket or an HTTP URL, which accounts are listed and signed with.
+	ExternalSigner string `toml:",omitempty"`
+
 	// IPCPath is the requested location to place the IPC endpoint. If the path is
 	// a simple file name, it is placed inside the data directory (or on the root
 	// pipe path on Windows), whereas if it's a resolvable path name (absolute or
@@ -135,6 +145,16 @@ type Config struct {
 	// *WARNING* Only set this if the node is running in a trusted network, exposing
 	// private APIs to untrusted users is a major security risk.
 	WSExposeAll bool `toml:",omitempty"`
+
+	// RPCAuthPolicy is the path of a JSON file with the credentials (API keys or
+	// HS256 JWT subjects) accepted by the HTTP and WebSocket endpoints, and the
+	// methods each of them may call. If empty, the endpoints are unauthenticated.
+	RPCAuthPolicy string `toml:",omitempty"`
+
+	// RPCLimits are the per-client quotas enforced on the HTTP and WebSocket
+	// endpoints: a rate limit weighted by method costs, the maximum batch and
+	// response sizes and the execution timeout of calls. If nil, no limits apply.
+	RPCLimits *rpc.RateLimits `toml:",omitempty"`
 }
 
 // IPCEndpoint resolves an IPC endpoint based on a configured value, taking into
@@ -407,6 +427,13 @@ func makeAccountManager(conf *Config) (*accounts.Manager, string, error) {
 	backends := []accounts.Backend{
 		keystore.NewKeyStore(keydir, scryptN, scryptP),
 	}
+	if conf.ExternalSigner != "" {
+		extapi, err := external.NewExternalBackend(conf.ExternalSigner)
+		if err != nil {
+			return nil, "", fmt.Errorf("error connecting to external signer: %v", err)
+		}
+		backends = append(backends, extapi)
+	}
 	if !conf.NoUSB {
 		// Start a USB hub for Ledger hardware wallets
 		if ledgerhub, err := usbwallet.NewLedgerHub(); err != nil {
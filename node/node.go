@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -51,8 +52,9 @@ type Node struct {
 	serviceFuncs []ServiceConstructor     // Service constructors (in dependency order)
 	services     map[reflect.Type]Service // Currently running services
 
-	rpcAPIs       []rpc.API   // List of APIs currently provided by the node
-	inprocHandler *rpc.Server // In-process RPC request handler to process the API requests
+	rpcAPIs       []rpc.API               // List of APIs currently provided by the node
+	httpHandlers  map[string]http.Handler // Extra HTTP endpoints provided by the services
+	inprocHandler *rpc.Server             // In-process RPC request handler to process the API requests
 
 	ipcEndpoint string       // IPC endpoint to listen at (empty = IPC disabled)
 	ipcListener net.Listener // IPC RPC listener socket to serve API requests
@@ -67,6 +69,8 @@ type Node struct {
 	wsListener net.Listener // Websocket RPC listener socket to server API requests
 	wsHandler  *rpc.Server  // Websocket RPC request handler to process the API requests
 
+	rpcAuth *rpc.Authenticator // Credential verifier of the HTTP and websocket endpoints (nil = unauthenticated)
+
 	stop chan struct{} // Channel to wait for termination notifications
 	lock sync.RWMutex
 }
@@ -245,9 +249,32 @@ func (n *Node) openDataDir() error {
 func (n *Node) startRPC(services map[reflect.Type]Service) error {
 	// Gather all the possible APIs to surface
 	apis := n.apis()
+	handlers := make(map[string]http.Handler)
 	for _, service := range services {
 		apis = append(apis, service.APIs()...)
+		if service, ok := service.(HTTPService); ok {
+			for path, handler := range service.HTTPHandlers() {
+				if _, exists := handlers[path]; exists {
+					return fmt.Errorf("duplicate HTTP handler for path %s", path)
+				}
+				handlers[path] = handler
+			}
+		}
 	}
+	// Load the authentication policy of the network facing endpoints
+	n.rpcAuth = nil
+	if n.config.RPCAuthPolicy != "" {
+		policy, err := rpc.LoadAuthPolicy(n.config.RPCAuthPolicy)
+		if err != nil {
+			return err
+		}
+		if n.rpcAuth, err = rpc.NewAuthenticator(policy); err != nil {
+			return err
+		}
+		log.Info("RPC authentication enabled", "policy", n.config.RPCAuthPolicy, "credentials", len(policy.Credentials))
+	}
+	n.httpHandlers = handlers
+
 	// Start the various API endpoints, terminating all in case of errors
 	if err := n.startInProc(apis); err != nil {
 		return err
@@ -370,6 +397,8 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 	}
 	// Register all the APIs exposed by the services
 	handler := rpc.NewServer()
+	handler.SetAuthenticator(n.rpcAuth)
+	handler.SetRateLimits(n.config.RPCLimits)
 	for _, api := range apis {
 		if whitelist[api.Namespace] || (len(whitelist) == 0 && api.Public) {
 			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
@@ -386,7 +415,15 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 	if listener, err = net.Listen("tcp", endpoint); err != nil {
 		return err
 	}
-	go rpc.NewHTTPServer(cors, handler).Serve(listener)
+	// Mount the HTTP endpoints of the services, guarded by the authentication
+	// policy and the rate limits under their path (e.g. "graphql" for /graphql)
+	handlers := make(map[string]http.Handler, len(n.httpHandlers))
+	for path, h := range n.httpHandlers {
+		method := strings.Trim(path, "/")
+		handlers[path] = n.rpcAuth.Protect(method, handler.Limit(method, h))
+		log.Debug(fmt.Sprintf("HTTP mounted %T at '%s'", h, path))
+	}
+	go rpc.NewHTTPServerWithHandlers(cors, handler, handlers).Serve(listener)
 	log.Info(fmt.Sprintf("HTTP endpoint opened: http://%s", endpoint))
 
 	// All listeners booted successfully
@@ -424,6 +461,8 @@ func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrig
 	}
 	// Register all the APIs exposed by the services
 	handler := rpc.NewServer()
+	handler.SetAuthenticator(n.rpcAuth)
+	handler.SetRateLimits(n.config.RPCLimits)
 	for _, api := range apis {
 		if exposeAll || whitelist[api.Namespace] || (len(whitelist) == 0 && api.Public) {
 			if err := handler.RegisterName(api.Namespace, api.Service); err != nil {
@@ -481,6 +520,7 @@ func (n *Node) Stop() error {
 	n.stopHTTP()
 	n.stopIPC()
 	n.rpcAPIs = nil
+	n.httpHandlers = nil
 	failure := &StopError{
 		Services: make(map[reflect.Type]error),
 	}
@@ -639,7 +679,40 @@ func (n *Node) OpenDatabase(name string, cache, handles int) (ethdb.Database, er
 	if n.config.DataDir == "" {
 		return ethdb.NewMemDatabase()
 	}
-	return ethdb.NewLDBDatabase(n.config.resolvePath(name), cache, handles)
+	return ethdb.NewDatabase(n.config.DatabaseEngine, n.config.resolvePath(name), cache, handles)
+}
+
+// OpenDatabaseWithFreezer opens an existing database with the given name (or
+// creates one if no previous can be found) from within the node's data directory,
+// backed by an ancient store for immutable chain data. If the node is an
+// ephemeral one, a memory database without an ancient store is returned.
+func (n *Node) OpenDatabaseWithFreezer(name string, cache, handles int, freezer string, tables map[string]bool) (ethdb.Database, error) {
+	if n.config.DataDir == "" {
+		return ethdb.NewMemDatabase()
+	}
+	return openDatabaseWithFreezer(n.config, name, cache, handles, freezer, tables)
+}
+
+// openDatabaseWithFreezer opens a persistent database along with its ancient
+// store, placing the latter within the database directory if no path is given.
+func openDatabaseWithFreezer(config *Config, name string, cache, handles int, freezer string, tables map[string]bool) (ethdb.Database, error) {
+	dir := config.resolvePath(name)
+	switch {
+	case freezer == "":
+		freezer = filepath.Join(dir, "ancient")
+	case !filepath.IsAbs(freezer):
+		freezer = config.resolvePath(freezer)
+	}
+	db, err := ethdb.NewDatabase(config.DatabaseEngine, dir, cache, handles)
+	if err != nil {
+		return nil, err
+	}
+	frdb, err := ethdb.NewDatabaseWithFreezer(db, freezer, tables)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return frdb, nil
 }
 
 // ResolvePath returns the absolute path of a resource in the instance directory.
@@ -17,6 +17,7 @@
 package node
 
 import (
+	"net/http"
 	"reflect"
 
 	"github.com/meitu/go-ethereum/accounts"
@@ -43,13 +44,26 @@ func (ctx *ServiceContext) OpenDatabase(name string, cache int, handles int) (et
 	if ctx.config.DataDir == "" {
 		return ethdb.NewMemDatabase()
 	}
-	db, err := ethdb.NewLDBDatabase(ctx.config.resolvePath(name), cache, handles)
+	db, err := ethdb.NewDatabase(ctx.config.DatabaseEngine, ctx.config.resolvePath(name), cache, handles)
 	if err != nil {
 		return nil, err
 	}
 	return db, nil
 }
 
+// OpenDatabaseWithFreezer opens an existing database with the given name (or
+// creates one if no previous can be found) from within the node's data directory,
+// backed by an ancient store for immutable chain data. An empty freezer path puts
+// the ancient store into an "ancient" folder within the database, a relative one
+// is resolved against the data directory. If the node is an ephemeral one, a
+// memory database without an ancient store is returned.
+func (ctx *ServiceContext) OpenDatabaseWithFreezer(name string, cache int, handles int, freezer string, tables map[string]bool) (ethdb.Database, error) {
+	if ctx.config.DataDir == "" {
+		return ethdb.NewMemDatabase()
+	}
+	return openDatabaseWithFreezer(ctx.config, name, cache, handles, freezer, tables)
+}
+
 // ResolvePath resolves a user path into the data directory if that was relative
 // and if the user actually uses persistent storage. It will return an empty string
 // for emphemeral storage and the user's own input for absolute paths.
@@ -96,3 +110,12 @@ type Service interface {
 	// are all terminated.
 	Stop() error
 }
+
+// HTTPService is an optional interface of services serving plain HTTP endpoints
+// beside the JSON-RPC API on the HTTP-RPC server, such as the GraphQL endpoint.
+// The handlers are subject to the same CORS and authentication settings as RPC.
+type HTTPService interface {
+	// HTTPHandlers retrieves the HTTP handlers of the service, keyed by the path
+	// they are mounted at.
+	HTTPHandlers() map[string]http.Handler
+}
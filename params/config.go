@@ -41,9 +41,28 @@ var (
 
 		Dpos: &DposConfig{},
 	}
-	TestChainConfig          = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil}
-	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil}
-	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil}
+	// DposTestChainConfig is the dpos chain configuration with the DPoS forks
+	// activated from the genesis block, for tests and simulated chains.
+	DposTestChainConfig = &ChainConfig{
+		ChainId:        big.NewInt(5),
+		HomesteadBlock: big.NewInt(0),
+		DAOForkBlock:   nil,
+		DAOForkSupport: false,
+		EIP150Block:    big.NewInt(0),
+		EIP150Hash:     common.Hash{},
+		EIP155Block:    big.NewInt(0),
+		EIP158Block:    big.NewInt(0),
+		ByzantiumBlock: big.NewInt(0),
+		TypedTxBlock:   big.NewInt(0),
+
+		MultiTransferBlock: big.NewInt(0),
+		DposRevertBlock:    big.NewInt(0),
+
+		Dpos: &DposConfig{},
+	}
+	TestChainConfig          = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil}
+	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil}
+	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil}
 )
 
 // ChainConfig is the core config which determines the blockchain settings.
@@ -68,6 +87,13 @@ type ChainConfig struct {
 
 	ByzantiumBlock *big.Int `json:"byzantiumBlock,omitempty"` // Byzantium switch block (nil = no fork, 0 = already on byzantium)
 
+	DposStateBlock   *big.Int `json:"dposStateBlock,omitempty"`   // Dpos state precompile switch block (nil = no fork, 0 = already activated)
+	DposStakingBlock *big.Int `json:"dposStakingBlock,omitempty"` // Dpos staking precompile switch block (nil = no fork, 0 = already activated)
+	TypedTxBlock     *big.Int `json:"typedTxBlock,omitempty"`     // Typed DPoS transaction switch block (nil = no fork, 0 = already activated)
+
+	MultiTransferBlock *big.Int `json:"multiTransferBlock,omitempty"` // Multi-transfer transaction switch block (nil = no fork, 0 = already activated)
+	DposRevertBlock    *big.Int `json:"dposRevertBlock,omitempty"`    // Dpos operations reverted with failed transactions switch block (nil = no fork, 0 = already activated)
+
 	Dpos *DposConfig `json:"dpos,omitempty"`
 }
 
@@ -94,7 +120,7 @@ func (d *DposConfig) String() string {
 
 // String implements the fmt.Stringer interface.
 func (c *ChainConfig) String() string {
-	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Engine: %v}",
+	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v DposState: %v DposStaking: %v TypedTx: %v MultiTransfer: %v DposRevert: %v Engine: %v}",
 		c.ChainId,
 		c.HomesteadBlock,
 		c.DAOForkBlock,
@@ -103,6 +129,11 @@ func (c *ChainConfig) String() string {
 		c.EIP155Block,
 		c.EIP158Block,
 		c.ByzantiumBlock,
+		c.DposStateBlock,
+		c.DposStakingBlock,
+		c.TypedTxBlock,
+		c.MultiTransferBlock,
+		c.DposRevertBlock,
 		c.Dpos,
 	)
 }
@@ -133,6 +164,36 @@ func (c *ChainConfig) IsByzantium(num *big.Int) bool {
 	return isForked(c.ByzantiumBlock, num)
 }
 
+// IsDposState returns whether num is either equal to the block activating the
+// dpos state precompile or greater.
+func (c *ChainConfig) IsDposState(num *big.Int) bool {
+	return isForked(c.DposStateBlock, num)
+}
+
+// IsDposStaking returns whether num is either equal to the block activating the
+// dpos staking precompile or greater.
+func (c *ChainConfig) IsDposStaking(num *big.Int) bool {
+	return isForked(c.DposStakingBlock, num)
+}
+
+// IsTypedTx returns whether num is either equal to the block switching the DPoS
+// operations to typed transactions or greater.
+func (c *ChainConfig) IsTypedTx(num *big.Int) bool {
+	return isForked(c.TypedTxBlock, num)
+}
+
+// IsMultiTransfer returns whether num is either equal to the block enabling the
+// multi-transfer transactions or greater.
+func (c *ChainConfig) IsMultiTransfer(num *big.Int) bool {
+	return isForked(c.MultiTransferBlock, num)
+}
+
+// IsDposRevert returns whether num is either equal to the block from which dpos
+// operations fail along with their transactions or greater.
+func (c *ChainConfig) IsDposRevert(num *big.Int) bool {
+	return isForked(c.DposRevertBlock, num)
+}
+
 // GasTable returns the gas table corresponding to the current phase (homestead or homestead reprice).
 //
 // The returned GasTable's fields shouldn't, under any circumstances, be changed.
@@ -193,6 +254,21 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.ByzantiumBlock, newcfg.ByzantiumBlock, head) {
 		return newCompatError("Byzantium fork block", c.ByzantiumBlock, newcfg.ByzantiumBlock)
 	}
+	if isForkIncompatible(c.DposStateBlock, newcfg.DposStateBlock, head) {
+		return newCompatError("Dpos state fork block", c.DposStateBlock, newcfg.DposStateBlock)
+	}
+	if isForkIncompatible(c.DposStakingBlock, newcfg.DposStakingBlock, head) {
+		return newCompatError("Dpos staking fork block", c.DposStakingBlock, newcfg.DposStakingBlock)
+	}
+	if isForkIncompatible(c.TypedTxBlock, newcfg.TypedTxBlock, head) {
+		return newCompatError("Typed transaction fork block", c.TypedTxBlock, newcfg.TypedTxBlock)
+	}
+	if isForkIncompatible(c.MultiTransferBlock, newcfg.MultiTransferBlock, head) {
+		return newCompatError("Multi-transfer fork block", c.MultiTransferBlock, newcfg.MultiTransferBlock)
+	}
+	if isForkIncompatible(c.DposRevertBlock, newcfg.DposRevertBlock, head) {
+		return newCompatError("Dpos revert fork block", c.DposRevertBlock, newcfg.DposRevertBlock)
+	}
 	return nil
 }
 
@@ -259,7 +335,7 @@ func (err *ConfigCompatError) Error() string {
 type Rules struct {
 	ChainId                                   *big.Int
 	IsHomestead, IsEIP150, IsEIP155, IsEIP158 bool
-	IsByzantium                               bool
+	IsByzantium, IsDposState, IsDposStaking   bool
 }
 
 func (c *ChainConfig) Rules(num *big.Int) Rules {
@@ -267,5 +343,5 @@ func (c *ChainConfig) Rules(num *big.Int) Rules {
 	if chainId == nil {
 		chainId = new(big.Int)
 	}
-	return Rules{ChainId: new(big.Int).Set(chainId), IsHomestead: c.IsHomestead(num), IsEIP150: c.IsEIP150(num), IsEIP155: c.IsEIP155(num), IsEIP158: c.IsEIP158(num), IsByzantium: c.IsByzantium(num)}
+	return Rules{ChainId: new(big.Int).Set(chainId), IsHomestead: c.IsHomestead(num), IsEIP150: c.IsEIP150(num), IsEIP155: c.IsEIP155(num), IsEIP158: c.IsEIP158(num), IsByzantium: c.IsByzantium(num), IsDposState: c.IsDposState(num), IsDposStaking: c.IsDposStaking(num)}
 }
@@ -52,6 +52,7 @@ const (
 	SuicideRefundGas uint64 = 24000 // Refunded following a suicide operation.
 	MemoryGas        uint64 = 3     // Times the address of the (highest referenced byte in memory + 1). NOTE: referencing happens on read, write and in instructions such as RETURN and CALL.
 	TxDataNonZeroGas uint64 = 68    // Per byte of data attached to a transaction that is not equal to zero. NOTE: Not payable on data of calls between transactions.
+	TxTransferGas    uint64 = 9000  // Per recipient of a multi-transfer transaction.
 
 	MaxCodeSize = 24576 // Maximum bytecode to permit for a contract
 
@@ -69,6 +70,9 @@ const (
 	Bn256ScalarMulGas       uint64 = 40000  // Gas needed for an elliptic curve scalar multiplication
 	Bn256PairingBaseGas     uint64 = 100000 // Base price for an elliptic curve pairing check
 	Bn256PairingPerPointGas uint64 = 80000  // Per-point price for an elliptic curve pairing check
+	DposStateQueryGas       uint64 = 700    // Base price of a query of the dpos state
+	DposStateItemGas        uint64 = 200    // Per-entry price of the dpos tries read by a query
+	DposStakingGas          uint64 = 20000  // Price of a dpos staking operation performed by a contract
 )
 
 var (
@@ -0,0 +1,276 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/meitu/go-ethereum/common/hexutil"
+	"github.com/meitu/go-ethereum/log"
+)
+
+var (
+	errMissingCredentials = errors.New("missing credentials")
+	errInvalidCredentials = errors.New("invalid credentials")
+)
+
+// defaultAuditRules are the methods audit logged if the policy doesn't list any.
+var defaultAuditRules = []string{"admin", "debug", "miner", "personal"}
+
+// AuthPolicy describes the credentials accepted by an RPC endpoint and the
+// methods each of them is allowed to call.
+//
+// Access rules are either "*" matching every method, a namespace such as "eth"
+// matching all the methods within, or a single method such as "miner_start".
+// Subscriptions are matched as the "<namespace>_subscribe" method.
+type AuthPolicy struct {
+	JWTSecret   string           `json:"jwtSecret,omitempty"` // Hex encoded HS256 secret, empty disables JWT
+	Anonymous   []string         `json:"anonymous,omitempty"` // Rules for requests without credentials
+	Credentials []AuthCredential `json:"credentials"`         // Known credentials and their access rules
+	Audit       []string         `json:"audit,omitempty"`     // Rules for calls to audit log, privileged namespaces by default
+}
+
+// AuthCredential is a single API key or JWT subject along with the methods it
+// may call.
+type AuthCredential struct {
+	Name    string   `json:"name"`              // Name identifying the credential in the audit log
+	APIKey  string   `json:"apiKey,omitempty"`  // Static key sent as a bearer token or in the X-API-Key header
+	Subject string   `json:"subject,omitempty"` // JWT "sub" claim granted the access rules
+	Allow   []string `json:"allow"`             // Access rules of the credential
+}
+
+// LoadAuthPolicy reads an authentication policy from a JSON file.
+func LoadAuthPolicy(path string) (*AuthPolicy, error) {
+	blob, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	policy := new(AuthPolicy)
+	if err := json.Unmarshal(blob, policy); err != nil {
+		return nil, fmt.Errorf("invalid auth policy %s: %v", path, err)
+	}
+	return policy, nil
+}
+
+// accessRules is a set of access rules, matching namespaces or single methods.
+type accessRules map[string]bool
+
+func newAccessRules(rules []string) accessRules {
+	set := make(accessRules)
+	for _, rule := range rules {
+		if rule = strings.TrimSpace(rule); rule != "" {
+			set[rule] = true
+		}
+	}
+	return set
+}
+
+// matches checks whether the fully qualified method is covered by the rules.
+func (r accessRules) matches(method string) bool {
+	if r["*"] || r[method] {
+		return true
+	}
+	if i := strings.Index(method, serviceMethodSeparator); i >= 0 {
+		return r[method[:i]]
+	}
+	return false
+}
+
+// principal is the authenticated originator of an RPC request.
+type principal struct {
+	name      string      // Name of the credential used, "anonymous" if none
+	anonymous bool        // Whether the request came without credentials
+	remote    string      // Remote address of the connection
+	allow     accessRules // Methods the principal may call
+}
+
+type principalKey struct{}
+
+// principalFromContext retrieves the authenticated principal of a request, or
+// nil if the request came through an unauthenticated transport.
+func principalFromContext(ctx context.Context) *principal {
+	p, _ := ctx.Value(principalKey{}).(*principal)
+	return p
+}
+
+// Authenticator verifies the credentials of HTTP and WebSocket requests against
+// an authentication policy.
+type Authenticator struct {
+	secret    []byte
+	anonymous accessRules
+	keys      map[string]*AuthCredential
+	subjects  map[string]*AuthCredential
+	rules     map[string]accessRules
+	audit     accessRules
+}
+
+// NewAuthenticator creates an authenticator enforcing the given policy.
+func NewAuthenticator(policy *AuthPolicy) (*Authenticator, error) {
+	auth := &Authenticator{
+		anonymous: newAccessRules(policy.Anonymous),
+		keys:      make(map[string]*AuthCredential),
+		subjects:  make(map[string]*AuthCredential),
+		rules:     make(map[string]accessRules),
+		audit:     newAccessRules(policy.Audit),
+	}
+	if policy.Audit == nil {
+		auth.audit = newAccessRules(defaultAuditRules)
+	}
+	if policy.JWTSecret != "" {
+		secret, err := hexutil.Decode(policy.JWTSecret)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWT secret: %v", err)
+		}
+		if len(secret) < 32 {
+			return nil, fmt.Errorf("JWT secret too short: have %d bytes, want at least 32", len(secret))
+		}
+		auth.secret = secret
+	}
+	for i := range policy.Credentials {
+		cred := &policy.Credentials[i]
+		if cred.Name == "" {
+			return nil, fmt.Errorf("credential #%d has no name", i)
+		}
+		if _, ok := auth.rules[cred.Name]; ok {
+			return nil, fmt.Errorf("duplicate credential %q", cred.Name)
+		}
+		if cred.APIKey == "" && cred.Subject == "" {
+			return nil, fmt.Errorf("credential %q has neither an API key nor a JWT subject", cred.Name)
+		}
+		if cred.APIKey != "" {
+			if _, ok := auth.keys[cred.APIKey]; ok {
+				return nil, fmt.Errorf("credential %q reuses an API key", cred.Name)
+			}
+			auth.keys[cred.APIKey] = cred
+		}
+		if cred.Subject != "" {
+			if auth.secret == nil {
+				return nil, fmt.Errorf("credential %q has a JWT subject but no JWT secret is configured", cred.Name)
+			}
+			if _, ok := auth.subjects[cred.Subject]; ok {
+				return nil, fmt.Errorf("credential %q reuses JWT subject %q", cred.Name, cred.Subject)
+			}
+			auth.subjects[cred.Subject] = cred
+		}
+		auth.rules[cred.Name] = newAccessRules(cred.Allow)
+	}
+	return auth, nil
+}
+
+// authenticate verifies the credentials of an HTTP request, either a bearer
+// token in the Authorization header or an API key in the X-API-Key header. A
+// request without credentials is only accepted if the policy grants anonymous
+// access.
+func (a *Authenticator) authenticate(r *http.Request) (*principal, error) {
+	var token string
+	if header := r.Header.Get("Authorization"); header != "" {
+		if len(header) <= 7 || !strings.EqualFold(header[:7], "bearer ") {
+			return nil, errInvalidCredentials
+		}
+		token = strings.TrimSpace(header[7:])
+	} else {
+		token = r.Header.Get("X-API-Key")
+	}
+	if token == "" {
+		if len(a.anonymous) == 0 {
+			return nil, errMissingCredentials
+		}
+		return &principal{name: "anonymous", anonymous: true, remote: r.RemoteAddr, allow: a.anonymous}, nil
+	}
+	var cred *AuthCredential
+	if a.secret != nil && strings.Count(token, ".") == 2 {
+		cred = a.verifyJWT(token)
+	} else {
+		cred = a.verifyKey(token)
+	}
+	if cred == nil {
+		return nil, errInvalidCredentials
+	}
+	return &principal{name: cred.Name, remote: r.RemoteAddr, allow: a.rules[cred.Name]}, nil
+}
+
+// verifyJWT checks the signature and validity period of an HS256 token and
+// resolves the credential of its subject.
+func (a *Authenticator) verifyJWT(token string) *AuthCredential {
+	parsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return a.secret, nil
+	})
+	if err != nil || !parsed.Valid {
+		return nil
+	}
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil
+	}
+	subject, _ := claims["sub"].(string)
+	return a.subjects[subject]
+}
+
+// verifyKey resolves the credential of a static API key in constant time.
+func (a *Authenticator) verifyKey(key string) *AuthCredential {
+	var match *AuthCredential
+	for known, cred := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(known), []byte(key)) == 1 {
+			match = cred
+		}
+	}
+	return match
+}
+
+// audited checks whether calls to the given method need to be audit logged.
+func (a *Authenticator) audited(method string) bool {
+	return a.audit.matches(method)
+}
+
+// Protect wraps an HTTP handler served beside the RPC endpoints, admitting only
+// requests whose credentials grant access to the given method. A nil
+// authenticator admits all requests.
+func (a *Authenticator) Protect(method string, handler http.Handler) http.Handler {
+	if a == nil {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, err := a.authenticate(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="rpc"`)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if !p.allow.matches(method) {
+			if a.audited(method) {
+				log.Warn("Denied privileged HTTP request", "method", method, "principal", p.name, "remote", p.remote)
+			}
+			http.Error(w, (&accessDeniedError{method}).Error(), http.StatusForbidden)
+			return
+		}
+		if a.audited(method) {
+			log.Info("Privileged HTTP request", "method", method, "principal", p.name, "remote", p.remote)
+		}
+		handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, p)))
+	})
+}
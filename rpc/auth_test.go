@@ -0,0 +1,223 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+var testJWTSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// newAuthTestServer starts an HTTP server exposing the test service behind an
+// authenticator enforcing the given policy.
+func newAuthTestServer(t *testing.T, policy *AuthPolicy) *httptest.Server {
+	auth, err := NewAuthenticator(policy)
+	if err != nil {
+		t.Fatalf("failed to create authenticator: %v", err)
+	}
+	server := NewServer()
+	if err := server.RegisterName("test", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	server.SetAuthenticator(auth)
+	return httptest.NewServer(server)
+}
+
+func testAuthPolicy() *AuthPolicy {
+	return &AuthPolicy{
+		JWTSecret: "0x3031323334353637383961626364656630313233343536373839616263646566",
+		Credentials: []AuthCredential{
+			{Name: "reader", APIKey: "reader-key", Allow: []string{"test_echo"}},
+			{Name: "admin", Subject: "ops", Allow: []string{"*"}},
+		},
+	}
+}
+
+func TestAuthAPIKey(t *testing.T) {
+	srv := newAuthTestServer(t, testAuthPolicy())
+	defer srv.Close()
+
+	client, err := DialHTTP(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if err := client.SetHeader("X-API-Key", "reader-key"); err != nil {
+		t.Fatal(err)
+	}
+	var result Result
+	if err := client.Call(&result, "test_echo", "hello", 10, &Args{"world"}); err != nil {
+		t.Fatalf("allowed call failed: %v", err)
+	}
+	if result.String != "hello" || result.Int != 10 || result.Args.S != "world" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	err = client.Call(nil, "test_rets")
+	if rpcErr, ok := err.(Error); !ok || rpcErr.ErrorCode() != -32001 {
+		t.Fatalf("denied call error mismatch: have %v, want code -32001", err)
+	}
+}
+
+func TestAuthJWT(t *testing.T) {
+	srv := newAuthTestServer(t, testAuthPolicy())
+	defer srv.Close()
+
+	client, err := DialHTTP(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	sign := func(claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(testJWTSecret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	// A valid token of a known subject grants its access rules
+	client.SetHeader("Authorization", "Bearer "+sign(jwt.MapClaims{"sub": "ops", "exp": time.Now().Add(time.Minute).Unix()}))
+	var result string
+	if err := client.Call(&result, "test_rets"); err != nil {
+		t.Fatalf("allowed call failed: %v", err)
+	}
+	// Expired tokens and unknown subjects must be rejected
+	client.SetHeader("Authorization", "Bearer "+sign(jwt.MapClaims{"sub": "ops", "exp": time.Now().Add(-time.Minute).Unix()}))
+	if err := client.Call(&result, "test_rets"); err == nil {
+		t.Fatal("expired token accepted")
+	}
+	client.SetHeader("Authorization", "Bearer "+sign(jwt.MapClaims{"sub": "nobody"}))
+	if err := client.Call(&result, "test_rets"); err == nil {
+		t.Fatal("unknown subject accepted")
+	}
+}
+
+func TestAuthUnauthorized(t *testing.T) {
+	srv := newAuthTestServer(t, testAuthPolicy())
+	defer srv.Close()
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"test_echo","params":["hello",10,{"S":"world"}]}`
+	tests := []struct {
+		header, value string
+		code          int
+	}{
+		{"", "", http.StatusUnauthorized},
+		{"X-API-Key", "wrong-key", http.StatusUnauthorized},
+		{"Authorization", "Basic cmVhZGVyOmtleQ==", http.StatusUnauthorized},
+		{"Authorization", "Bearer reader-key", http.StatusOK},
+	}
+	for i, tt := range tests {
+		req, _ := http.NewRequest("POST", srv.URL, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		if tt.header != "" {
+			req.Header.Set(tt.header, tt.value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("test %d: request failed: %v", i, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.code {
+			t.Errorf("test %d: status mismatch: have %d, want %d", i, resp.StatusCode, tt.code)
+		}
+	}
+}
+
+func TestAuthAnonymous(t *testing.T) {
+	policy := testAuthPolicy()
+	policy.Anonymous = []string{"test_rets"}
+
+	srv := newAuthTestServer(t, policy)
+	defer srv.Close()
+
+	client, err := DialHTTP(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	var result string
+	if err := client.Call(&result, "test_rets"); err != nil {
+		t.Fatalf("anonymous call failed: %v", err)
+	}
+	err = client.Call(nil, "test_echo", "hello", 10, &Args{"world"})
+	if rpcErr, ok := err.(Error); !ok || rpcErr.ErrorCode() != -32001 {
+		t.Fatalf("denied call error mismatch: have %v, want code -32001", err)
+	}
+}
+
+func TestAuthPolicyValidation(t *testing.T) {
+	tests := []*AuthPolicy{
+		{JWTSecret: "0x1234"},
+		{Credentials: []AuthCredential{{Name: "a"}}},
+		{Credentials: []AuthCredential{{Name: "a", Subject: "sub"}}},
+		{Credentials: []AuthCredential{{Name: "a", APIKey: "key"}, {Name: "a", APIKey: "other"}}},
+		{Credentials: []AuthCredential{{Name: "a", APIKey: "key"}, {Name: "b", APIKey: "key"}}},
+	}
+	for i, policy := range tests {
+		if _, err := NewAuthenticator(policy); err == nil {
+			t.Errorf("test %d: invalid policy accepted", i)
+		}
+	}
+}
+
+func TestAuthProtect(t *testing.T) {
+	policy := testAuthPolicy()
+	policy.Credentials = append(policy.Credentials, AuthCredential{Name: "graphql", APIKey: "graphql-key", Allow: []string{"graphql"}})
+	auth, err := NewAuthenticator(policy)
+	if err != nil {
+		t.Fatalf("failed to create authenticator: %v", err)
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	srv := httptest.NewServer(auth.Protect("graphql", handler))
+	defer srv.Close()
+
+	tests := []struct {
+		key  string
+		code int
+	}{
+		{"", http.StatusUnauthorized},
+		{"invalid-key", http.StatusUnauthorized},
+		{"reader-key", http.StatusForbidden},
+		{"graphql-key", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", srv.URL, nil)
+		if tt.key != "" {
+			req.Header.Set("X-API-Key", tt.key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("key %q: request failed: %v", tt.key, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.code {
+			t.Errorf("key %q: status mismatch: have %d, want %d", tt.key, resp.StatusCode, tt.code)
+		}
+	}
+	// A nil authenticator admits everything
+	if (*Authenticator)(nil).Protect("graphql", handler) == nil {
+		t.Fatal("nil authenticator dropped the handler")
+	}
+}
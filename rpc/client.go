@@ -39,6 +39,7 @@ var (
 	ErrClientQuit                = errors.New("client is closed")
 	ErrNoResult                  = errors.New("no result in JSON-RPC response")
 	ErrSubscriptionQueueOverflow = errors.New("subscription queue overflow")
+	ErrHeadersUnsupported        = errors.New("custom headers are only supported by HTTP clients")
 )
 
 const (
@@ -232,6 +233,17 @@ func (c *Client) Close() {
 	}
 }
 
+// SetHeader adds a custom HTTP header to all subsequent requests of the client,
+// such as the credentials of an authenticated endpoint. Websocket clients send
+// their headers during the handshake, see DialWebsocketWithHeader.
+func (c *Client) SetHeader(key, value string) error {
+	if !c.isHTTP {
+		return ErrHeadersUnsupported
+	}
+	c.writeConn.(*httpConn).setHeader(key, value)
+	return nil
+}
+
 // Call performs a JSON-RPC call with the given arguments and unmarshals into
 // result if no error occurred.
 //
@@ -58,6 +58,23 @@ func (e *callbackError) ErrorCode() int { return -32000 }
 
 func (e *callbackError) Error() string { return e.message }
 
+// issued when the credentials of a request don't grant access to the method
+type accessDeniedError struct{ method string }
+
+func (e *accessDeniedError) ErrorCode() int { return -32001 }
+
+func (e *accessDeniedError) Error() string {
+	return fmt.Sprintf("access to method %s denied", e.method)
+}
+
+// issued when a request exceeds the rate limit, batch size, response size or
+// execution time allowed by the server
+type limitExceededError struct{ message string }
+
+func (e *limitExceededError) ErrorCode() int { return -32005 }
+
+func (e *limitExceededError) Error() string { return e.message }
+
 // issued when a request is received after the server is issued to stop.
 type shutdownError struct{}
 
@@ -43,6 +43,8 @@ var nullAddr, _ = net.ResolveTCPAddr("tcp", "127.0.0.1:0")
 type httpConn struct {
 	client    *http.Client
 	req       *http.Request
+	headers   http.Header
+	headersMu sync.Mutex
 	closeOnce sync.Once
 	closed    chan struct{}
 }
@@ -71,15 +73,24 @@ func DialHTTP(endpoint string) (*Client, error) {
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Content-Type", contentType)
-	req.Header.Set("Accept", contentType)
+	headers := make(http.Header)
+	headers.Set("Content-Type", contentType)
+	headers.Set("Accept", contentType)
 
 	initctx := context.Background()
 	return newClient(initctx, func(context.Context) (net.Conn, error) {
-		return &httpConn{client: new(http.Client), req: req, closed: make(chan struct{})}, nil
+		return &httpConn{client: new(http.Client), req: req, headers: headers, closed: make(chan struct{})}, nil
 	})
 }
 
+// setHeader adds a custom HTTP header to all subsequent requests.
+func (hc *httpConn) setHeader(key, value string) {
+	hc.headersMu.Lock()
+	defer hc.headersMu.Unlock()
+
+	hc.headers.Set(key, value)
+}
+
 func (c *Client) sendHTTP(ctx context.Context, op *requestOp, msg interface{}) error {
 	hc := c.writeConn.(*httpConn)
 	respBody, err := hc.doRequest(ctx, msg)
@@ -121,6 +132,13 @@ func (hc *httpConn) doRequest(ctx context.Context, msg interface{}) (io.ReadClos
 	req.Body = ioutil.NopCloser(bytes.NewReader(body))
 	req.ContentLength = int64(len(body))
 
+	hc.headersMu.Lock()
+	req.Header = make(http.Header, len(hc.headers))
+	for key, values := range hc.headers {
+		req.Header[key] = append([]string(nil), values...)
+	}
+	hc.headersMu.Unlock()
+
 	resp, err := hc.client.Do(req)
 	if err != nil {
 		return nil, err
@@ -146,6 +164,21 @@ func NewHTTPServer(cors []string, srv *Server) *http.Server {
 	return &http.Server{Handler: newCorsHandler(srv, cors)}
 }
 
+// NewHTTPServerWithHandlers creates a new HTTP RPC server around an API provider,
+// additionally serving the given handlers at their paths. The RPC requests are
+// served at all other paths.
+func NewHTTPServerWithHandlers(cors []string, srv *Server, handlers map[string]http.Handler) *http.Server {
+	if len(handlers) == 0 {
+		return NewHTTPServer(cors, srv)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/", srv)
+	for path, handler := range handlers {
+		mux.Handle(path, handler)
+	}
+	return &http.Server{Handler: newCorsHandler(mux, cors)}
+}
+
 // ServeHTTP serves JSON-RPC requests over HTTP.
 func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Permit dumb empty requests for remote health-checks (AWS)
@@ -156,6 +189,20 @@ func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), code)
 		return
 	}
+	var (
+		ctx = context.Background()
+		p   *principal
+	)
+	if srv.auth != nil {
+		var err error
+		if p, err = srv.auth.authenticate(r); err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="rpc"`)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		ctx = context.WithValue(ctx, principalKey{}, p)
+	}
+	ctx = context.WithValue(ctx, clientKey{}, clientIdentity(r, p))
 	// All checks passed, create a codec that reads direct from the request body
 	// untilEOF and writes the response to w and order the server to process a
 	// single request.
@@ -163,7 +210,7 @@ func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	defer codec.Close()
 
 	w.Header().Set("content-type", contentType)
-	srv.ServeSingleRequest(codec, OptionMethodInvocation)
+	srv.serveRequest(ctx, codec, true, OptionMethodInvocation)
 }
 
 // validateRequest returns a non-zero response code and error message if the
@@ -184,7 +231,7 @@ func validateRequest(r *http.Request) (int, error) {
 	return 0, nil
 }
 
-func newCorsHandler(srv *Server, allowedOrigins []string) http.Handler {
+func newCorsHandler(srv http.Handler, allowedOrigins []string) http.Handler {
 	// disable CORS support if user has not specified a custom CORS configuration
 	if len(allowedOrigins) == 0 {
 		return srv
@@ -0,0 +1,198 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// limiterSweepInterval is the interval at which idle client quotas are dropped.
+const limiterSweepInterval = time.Minute
+
+// RateLimits configures the quotas enforced on the requests of RPC clients.
+//
+// Every call consumes the cost of its method from the quota of the client, which
+// is refilled at a constant rate up to the burst allowance. Clients are keyed by
+// the credential they authenticated with, or by their remote IP otherwise.
+type RateLimits struct {
+	Rate            float64            // Cost units refilled per second per client, 0 disables rate limiting
+	Burst           float64            // Cost units a client may spend at once, defaults to the rate
+	Costs           map[string]float64 // Cost of methods or whole namespaces, 1 if not listed
+	MaxBatchSize    int                // Maximum number of requests in a batch, 0 for unlimited
+	MaxResponseSize int                // Maximum size of the results of a call or batch in bytes, 0 for unlimited
+	RequestTimeout  time.Duration      // Maximum execution time of a call, enforced through its context, 0 for unlimited
+}
+
+// cost returns the quota consumed by a call to the fully qualified method, the
+// cost of a method taking precedence over the one of its namespace.
+func (l *RateLimits) cost(method string) float64 {
+	if cost, ok := l.Costs[method]; ok {
+		return cost
+	}
+	if i := strings.Index(method, serviceMethodSeparator); i >= 0 {
+		if cost, ok := l.Costs[method[:i]]; ok {
+			return cost
+		}
+	}
+	return 1
+}
+
+// quota is the token bucket of a single client.
+type quota struct {
+	tokens  float64   // Cost units currently available
+	updated time.Time // Time the tokens were last refilled
+}
+
+// rateLimiter tracks the quotas of the clients of a server.
+type rateLimiter struct {
+	limits *RateLimits
+	burst  float64
+
+	quotas map[string]*quota
+	swept  time.Time
+	lock   sync.Mutex
+}
+
+func newRateLimiter(limits *RateLimits) *rateLimiter {
+	burst := limits.Burst
+	if burst <= 0 {
+		burst = limits.Rate
+	}
+	return &rateLimiter{
+		limits: limits,
+		burst:  burst,
+		quotas: make(map[string]*quota),
+		swept:  time.Now(),
+	}
+}
+
+// take consumes the cost of a call from the quota of the client, returning false
+// if the client doesn't have enough of it left.
+func (l *rateLimiter) take(client string, cost float64) bool {
+	if l.limits.Rate <= 0 || cost <= 0 {
+		return true
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.swept) > limiterSweepInterval {
+		l.sweep(now)
+	}
+	q := l.quotas[client]
+	if q == nil {
+		q = &quota{tokens: l.burst, updated: now}
+		l.quotas[client] = q
+	}
+	l.refill(q, now)
+	if q.tokens < cost {
+		return false
+	}
+	q.tokens -= cost
+	return true
+}
+
+// refill credits the quota with the tokens accrued since its last update.
+func (l *rateLimiter) refill(q *quota, now time.Time) {
+	q.tokens += now.Sub(q.updated).Seconds() * l.limits.Rate
+	if q.tokens > l.burst {
+		q.tokens = l.burst
+	}
+	q.updated = now
+}
+
+// sweep drops the quotas which refilled completely, as they are equivalent to
+// the quota of a new client.
+func (l *rateLimiter) sweep(now time.Time) {
+	for client, q := range l.quotas {
+		if l.refill(q, now); q.tokens >= l.burst {
+			delete(l.quotas, client)
+		}
+	}
+	l.swept = now
+}
+
+// Limit wraps an HTTP handler served beside the RPC endpoints, charging requests
+// the cost of the given method from the quota of their client and bounding their
+// execution time through the request context, just like RPC calls. Clients over
+// their quota are refused.
+func (s *Server) Limit(method string, handler http.Handler) http.Handler {
+	limits, limiter := s.limits, s.limiter
+	if limits == nil {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := clientIdentity(r, principalFromContext(r.Context()))
+		if !limiter.take(client, limits.cost(method)) {
+			http.Error(w, fmt.Sprintf("rate limit exceeded for method %s", method), http.StatusTooManyRequests)
+			return
+		}
+		if limits.RequestTimeout > 0 {
+			ctx, cancel := context.WithTimeout(r.Context(), limits.RequestTimeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+type clientKey struct{}
+
+// clientFromContext retrieves the identity a request is rate limited by, or an
+// empty string if the request came through a transport without client identity.
+func clientFromContext(ctx context.Context) string {
+	client, _ := ctx.Value(clientKey{}).(string)
+	return client
+}
+
+// clientIdentity determines the key of the quota of an HTTP or websocket client,
+// which is its credential if authenticated, or its remote IP otherwise.
+func clientIdentity(r *http.Request, p *principal) string {
+	if p != nil && !p.anonymous {
+		return "cred:" + p.name
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+type responseSizeKey struct{}
+
+// reserveResponse accounts the size of a result against the response size limit
+// of the request it belongs to, returning false if the limit is exceeded.
+func (l *RateLimits) reserveResponse(ctx context.Context, size int) bool {
+	if l.MaxResponseSize <= 0 {
+		return true
+	}
+	used, _ := ctx.Value(responseSizeKey{}).(*int)
+	if used == nil {
+		return size <= l.MaxResponseSize
+	}
+	if *used+size > l.MaxResponseSize {
+		return false
+	}
+	*used += size
+	return true
+}
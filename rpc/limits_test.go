@@ -0,0 +1,208 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newLimitedTestClient starts an HTTP server exposing the test service with the
+// given limits and dials it.
+func newLimitedTestClient(t *testing.T, limits *RateLimits) (*Client, func()) {
+	server := NewServer()
+	if err := server.RegisterName("test", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	server.SetRateLimits(limits)
+	httpsrv := httptest.NewServer(server)
+
+	client, err := DialHTTP(httpsrv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, func() {
+		client.Close()
+		httpsrv.Close()
+	}
+}
+
+// checkLimitExceeded fails the test if err isn't a limit exceeded error.
+func checkLimitExceeded(t *testing.T, err error) {
+	if rpcErr, ok := err.(Error); !ok || rpcErr.ErrorCode() != -32005 {
+		t.Fatalf("error mismatch: have %v, want code -32005", err)
+	}
+}
+
+func TestRateLimitCosts(t *testing.T) {
+	client, stop := newLimitedTestClient(t, &RateLimits{
+		Rate:  0.001,
+		Burst: 10,
+		Costs: map[string]float64{"test": 2, "test_rets": 5},
+	})
+	defer stop()
+
+	// The namespace cost applies to test_echo, the method cost to test_rets
+	var result string
+	if err := client.Call(&result, "test_rets"); err != nil {
+		t.Fatalf("first call failed: %v", err)
+	}
+	if err := client.Call(nil, "test_echo", "hello", 10, &Args{"world"}); err != nil {
+		t.Fatalf("second call failed: %v", err)
+	}
+	checkLimitExceeded(t, client.Call(&result, "test_rets"))
+
+	if err := client.Call(nil, "test_echo", "hello", 10, &Args{"world"}); err != nil {
+		t.Fatalf("cheaper call failed: %v", err)
+	}
+	checkLimitExceeded(t, client.Call(nil, "test_echo", "hello", 10, &Args{"world"}))
+}
+
+func TestRateLimitRefill(t *testing.T) {
+	limiter := newRateLimiter(&RateLimits{Rate: 10})
+
+	for i := 0; i < 10; i++ {
+		if !limiter.take("a", 1) {
+			t.Fatalf("call %d rejected within burst", i)
+		}
+	}
+	if limiter.take("a", 1) {
+		t.Fatal("call accepted beyond burst")
+	}
+	if !limiter.take("b", 1) {
+		t.Fatal("other client rejected")
+	}
+	// Rewind the quota of the first client instead of sleeping
+	limiter.quotas["a"].updated = limiter.quotas["a"].updated.Add(-500 * time.Millisecond)
+	for i := 0; i < 5; i++ {
+		if !limiter.take("a", 1) {
+			t.Fatalf("call %d rejected after refill", i)
+		}
+	}
+	if limiter.take("a", 1) {
+		t.Fatal("call accepted beyond refill")
+	}
+	// Full quotas are dropped by the sweep
+	limiter.sweep(time.Now().Add(time.Minute))
+	if len(limiter.quotas) != 0 {
+		t.Fatalf("quotas not swept: %d left", len(limiter.quotas))
+	}
+}
+
+func TestMaxBatchSize(t *testing.T) {
+	client, stop := newLimitedTestClient(t, &RateLimits{MaxBatchSize: 2})
+	defer stop()
+
+	batch := []BatchElem{
+		{Method: "test_rets", Result: new(string)},
+		{Method: "test_rets", Result: new(string)},
+	}
+	if err := client.BatchCall(batch); err != nil {
+		t.Fatalf("batch within limit failed: %v", err)
+	}
+	for i, elem := range batch {
+		if elem.Error != nil {
+			t.Fatalf("batch element %d failed: %v", i, elem.Error)
+		}
+	}
+	batch = append(batch, BatchElem{Method: "test_rets", Result: new(string)})
+	if err := client.BatchCall(batch); err == nil {
+		t.Fatal("oversized batch accepted")
+	}
+}
+
+func TestMaxResponseSize(t *testing.T) {
+	client, stop := newLimitedTestClient(t, &RateLimits{MaxResponseSize: 64})
+	defer stop()
+
+	var result Result
+	if err := client.Call(&result, "test_echo", "hello", 10, &Args{"world"}); err != nil {
+		t.Fatalf("small response failed: %v", err)
+	}
+	if result.String != "hello" || result.Int != 10 || result.Args.S != "world" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	checkLimitExceeded(t, client.Call(&result, "test_echo", strings.Repeat("x", 64), 10, &Args{"world"}))
+
+	// The limit applies to the sum of the results of a batch
+	batch := []BatchElem{
+		{Method: "test_echo", Args: []interface{}{"hello", 10, &Args{"world"}}, Result: new(Result)},
+		{Method: "test_echo", Args: []interface{}{"hello", 10, &Args{"world"}}, Result: new(Result)},
+	}
+	if err := client.BatchCall(batch); err != nil {
+		t.Fatalf("batch failed: %v", err)
+	}
+	if batch[0].Error != nil {
+		t.Fatalf("first batch element failed: %v", batch[0].Error)
+	}
+	checkLimitExceeded(t, batch[1].Error)
+}
+
+func TestRequestTimeout(t *testing.T) {
+	client, stop := newLimitedTestClient(t, &RateLimits{RequestTimeout: 50 * time.Millisecond})
+	defer stop()
+
+	if err := client.Call(nil, "test_sleep", 10*time.Millisecond); err != nil {
+		t.Fatalf("quick call failed: %v", err)
+	}
+	start := time.Now()
+	checkLimitExceeded(t, client.Call(nil, "test_sleep", 5*time.Second))
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("call not aborted in time: %v", elapsed)
+	}
+}
+
+func TestLimitHTTPHandler(t *testing.T) {
+	server := NewServer()
+	server.SetRateLimits(&RateLimits{
+		Rate:           0.001,
+		Burst:          3,
+		Costs:          map[string]float64{"graphql": 2},
+		RequestTimeout: time.Minute,
+	})
+	var deadline bool
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, deadline = r.Context().Deadline()
+	})
+	srv := httptest.NewServer(server.Limit("graphql", handler))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("first request status mismatch: have %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if !deadline {
+		t.Error("request timeout not applied to the handler context")
+	}
+	if resp, err = http.Get(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("second request status mismatch: have %d, want %d", resp.StatusCode, http.StatusTooManyRequests)
+	}
+	if NewServer().Limit("graphql", handler) == nil {
+		t.Fatal("unlimited server dropped the handler")
+	}
+}
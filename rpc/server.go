@@ -18,12 +18,14 @@ package rpc
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"runtime"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/meitu/go-ethereum/log"
 	"gopkg.in/fatih/set.v0"
@@ -119,13 +121,30 @@ func (s *Server) RegisterName(name string, rcvr interface{}) error {
 	return nil
 }
 
+// SetAuthenticator enables authentication of HTTP and WebSocket requests with
+// the given authenticator, restricting them to the methods their credentials
+// grant access to. Codecs served directly, such as IPC, remain unrestricted.
+func (s *Server) SetAuthenticator(auth *Authenticator) {
+	s.auth = auth
+}
+
+// SetRateLimits enables the quotas of HTTP and WebSocket clients, rejecting the
+// requests exceeding them. Passing nil disables all limits. Codecs served
+// directly, such as IPC, remain unlimited.
+func (s *Server) SetRateLimits(limits *RateLimits) {
+	s.limits, s.limiter = limits, nil
+	if limits != nil {
+		s.limiter = newRateLimiter(limits)
+	}
+}
+
 // serveRequest will reads requests from the codec, calls the RPC callback and
 // writes the response to the given codec.
 //
 // If singleShot is true it will process a single request, otherwise it will handle
 // requests until the codec returns an error when reading a request (in most cases
 // an EOF). It executes requests in parallel when singleShot is false.
-func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecOption) error {
+func (s *Server) serveRequest(ctx context.Context, codec ServerCodec, singleShot bool, options CodecOption) error {
 	var pend sync.WaitGroup
 
 	defer func() {
@@ -140,7 +159,7 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 		s.codecsMu.Unlock()
 	}()
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	// if the codec supports notification include a notifier that callbacks can use
@@ -186,6 +205,15 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 			}
 			return nil
 		}
+		// Reject oversized batches before executing any of their requests
+		if batch && s.limits != nil && s.limits.MaxBatchSize > 0 && len(reqs) > s.limits.MaxBatchSize && clientFromContext(ctx) != "" {
+			err := &limitExceededError{fmt.Sprintf("batch of %d requests exceeds limit of %d", len(reqs), s.limits.MaxBatchSize)}
+			codec.Write(codec.CreateErrorResponse(nil, err))
+			if singleShot {
+				return nil
+			}
+			continue
+		}
 		// If a single shot request is executing, run and return immediately
 		if singleShot {
 			if batch {
@@ -215,14 +243,14 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 // stopped. In either case the codec is closed.
 func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) {
 	defer codec.Close()
-	s.serveRequest(codec, false, options)
+	s.serveRequest(context.Background(), codec, false, options)
 }
 
 // ServeSingleRequest reads and processes a single RPC request from the given codec. It will not
 // close the codec unless a non-recoverable error has occurred. Note, this method will return after
 // a single request has been processed!
 func (s *Server) ServeSingleRequest(codec ServerCodec, options CodecOption) {
-	s.serveRequest(codec, true, options)
+	s.serveRequest(context.Background(), codec, true, options)
 }
 
 // Stop will stop reading new requests, wait for stopPendingRequestTimeout to allow pending requests to finish,
@@ -258,6 +286,28 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	if req.err != nil {
 		return codec.CreateErrorResponse(&req.id, req.err), nil
 	}
+	// Enforce the access rules of authenticated requests, unsubscribing is always
+	// allowed as it only concerns the connection's own subscriptions
+	if p := principalFromContext(ctx); p != nil && !req.isUnsubscribe {
+		method := req.method()
+		if !p.allow.matches(method) {
+			if s.auth.audited(method) {
+				log.Warn("Denied privileged RPC call", "method", method, "principal", p.name, "remote", p.remote)
+			}
+			return codec.CreateErrorResponse(&req.id, &accessDeniedError{method}), nil
+		}
+		if s.auth.audited(method) {
+			log.Info("Privileged RPC call", "method", method, "principal", p.name, "remote", p.remote)
+		}
+	}
+	// Charge the call to the quota of the client
+	client := clientFromContext(ctx)
+	if s.limits != nil && client != "" && !req.isUnsubscribe {
+		method := req.method()
+		if !s.limiter.take(client, s.limits.cost(method)) {
+			return codec.CreateErrorResponse(&req.id, &limitExceededError{fmt.Sprintf("rate limit exceeded for method %s", method)}), nil
+		}
+	}
 
 	if req.isUnsubscribe { // cancel subscription, first param must be the subscription id
 		if len(req.args) >= 1 && req.args[0].Kind() == reflect.String {
@@ -299,6 +349,16 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 		return codec.CreateErrorResponse(&req.id, rpcErr), nil
 	}
 
+	// bound the execution time of the call. The deadline is carried by the context
+	// passed to the handler, which is expected to abort once it's cancelled. Calls
+	// ignoring their context run to completion, but their result is dropped.
+	var timeout time.Duration
+	if s.limits != nil && client != "" && s.limits.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		timeout = s.limits.RequestTimeout
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 	arguments := []reflect.Value{req.callb.rcvr}
 	if req.callb.hasCtx {
 		arguments = append(arguments, reflect.ValueOf(ctx))
@@ -309,6 +369,9 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 
 	// execute RPC method and return result
 	reply := req.callb.method.Func.Call(arguments)
+	if timeout > 0 && ctx.Err() == context.DeadlineExceeded {
+		return codec.CreateErrorResponse(&req.id, &limitExceededError{fmt.Sprintf("request timed out after %v", timeout)}), nil
+	}
 	if len(reply) == 0 {
 		return codec.CreateResponse(req.id, nil), nil
 	}
@@ -320,7 +383,28 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 			return res, nil
 		}
 	}
-	return codec.CreateResponse(req.id, reply[0].Interface()), nil
+	result := reply[0].Interface()
+	if s.limits != nil && client != "" && s.limits.MaxResponseSize > 0 {
+		// Encode the result up front to measure it, the codec writes it verbatim
+		enc, err := json.Marshal(result)
+		if err != nil {
+			return codec.CreateErrorResponse(&req.id, &callbackError{err.Error()}), nil
+		}
+		if !s.limits.reserveResponse(ctx, len(enc)) {
+			return codec.CreateErrorResponse(&req.id, &limitExceededError{fmt.Sprintf("response exceeds limit of %d bytes", s.limits.MaxResponseSize)}), nil
+		}
+		raw := json.RawMessage(enc)
+		result = &raw
+	}
+	return codec.CreateResponse(req.id, result), nil
+}
+
+// method returns the fully qualified name of the method invoked by the request.
+func (req *serverRequest) method() string {
+	if req.callb.isSubscribe {
+		return req.svcname + subscribeMethodSuffix
+	}
+	return req.svcname + serviceMethodSeparator + formatName(req.callb.method.Name)
 }
 
 // exec executes the given request and writes the result back using the codec.
@@ -349,6 +433,9 @@ func (s *Server) exec(ctx context.Context, codec ServerCodec, req *serverRequest
 func (s *Server) execBatch(ctx context.Context, codec ServerCodec, requests []*serverRequest) {
 	responses := make([]interface{}, len(requests))
 	var callbacks []func()
+
+	// The response size limit applies to the batch as a whole
+	ctx = context.WithValue(ctx, responseSizeKey{}, new(int))
 	for i, req := range requests {
 		if req.err != nil {
 			responses[i] = codec.CreateErrorResponse(&req.id, req.err)
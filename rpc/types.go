@@ -17,12 +17,15 @@
 package rpc
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"reflect"
 	"strings"
 	"sync"
 
+	"github.com/meitu/go-ethereum/common"
 	"github.com/meitu/go-ethereum/common/hexutil"
 	"gopkg.in/fatih/set.v0"
 )
@@ -70,6 +73,9 @@ type subscriptions map[string]*callback  // collection of subscription callbacks
 // Server represents a RPC server
 type Server struct {
 	services serviceRegistry
+	auth     *Authenticator // Credential verifier for HTTP and WebSocket requests, nil if disabled
+	limits   *RateLimits    // Quotas of HTTP and WebSocket clients, nil if disabled
+	limiter  *rateLimiter   // Per-client quota tracker enforcing the rate limits
 
 	run      int32
 	codecsMu sync.Mutex
@@ -163,3 +169,97 @@ func (bn *BlockNumber) UnmarshalJSON(data []byte) error {
 func (bn BlockNumber) Int64() int64 {
 	return (int64)(bn)
 }
+
+// encode returns the block number as used in JSON-RPC requests, either one of
+// the special tags or a hex encoded number.
+func (bn BlockNumber) encode() string {
+	switch bn {
+	case PendingBlockNumber:
+		return "pending"
+	case LatestBlockNumber:
+		return "latest"
+	case EarliestBlockNumber:
+		return "earliest"
+	}
+	return hexutil.EncodeUint64(uint64(bn))
+}
+
+// BlockNumberOrHash references a block either by its number (or one of the
+// special tags) or by its hash.
+type BlockNumberOrHash struct {
+	BlockNumber *BlockNumber `json:"blockNumber,omitempty"`
+	BlockHash   *common.Hash `json:"blockHash,omitempty"`
+}
+
+// BlockNumberOrHashWithNumber creates a block reference by number.
+func BlockNumberOrHashWithNumber(number BlockNumber) BlockNumberOrHash {
+	return BlockNumberOrHash{BlockNumber: &number}
+}
+
+// BlockNumberOrHashWithHash creates a block reference by hash.
+func BlockNumberOrHashWithHash(hash common.Hash) BlockNumberOrHash {
+	return BlockNumberOrHash{BlockHash: &hash}
+}
+
+// UnmarshalJSON parses the given JSON fragment into a block reference. It supports:
+// - a 32 byte hex encoded block hash
+// - a block number or tag, as accepted by BlockNumber
+// - an object with either a "blockNumber" or a "blockHash" field
+func (bnh *BlockNumberOrHash) UnmarshalJSON(data []byte) error {
+	input := strings.TrimSpace(string(data))
+	if strings.HasPrefix(input, "{") {
+		var ref struct {
+			BlockNumber *BlockNumber `json:"blockNumber"`
+			BlockHash   *common.Hash `json:"blockHash"`
+		}
+		if err := json.Unmarshal(data, &ref); err != nil {
+			return err
+		}
+		if (ref.BlockNumber == nil) == (ref.BlockHash == nil) {
+			return errors.New("exactly one of blockNumber or blockHash must be specified")
+		}
+		bnh.BlockNumber, bnh.BlockHash = ref.BlockNumber, ref.BlockHash
+		return nil
+	}
+	if len(input) == 2+2+2*common.HashLength && input[0] == '"' {
+		var hash common.Hash
+		if err := hash.UnmarshalJSON(data); err != nil {
+			return err
+		}
+		bnh.BlockNumber, bnh.BlockHash = nil, &hash
+		return nil
+	}
+	var number BlockNumber
+	if err := number.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	bnh.BlockNumber, bnh.BlockHash = &number, nil
+	return nil
+}
+
+// MarshalJSON encodes the block reference as a plain hash or block number.
+func (bnh BlockNumberOrHash) MarshalJSON() ([]byte, error) {
+	if bnh.BlockHash != nil {
+		return json.Marshal(bnh.BlockHash)
+	}
+	if bnh.BlockNumber != nil {
+		return json.Marshal(bnh.BlockNumber.encode())
+	}
+	return nil, errors.New("empty block reference")
+}
+
+// Number returns the referenced block number, if the reference is by number.
+func (bnh *BlockNumberOrHash) Number() (BlockNumber, bool) {
+	if bnh.BlockNumber != nil {
+		return *bnh.BlockNumber, true
+	}
+	return 0, false
+}
+
+// Hash returns the referenced block hash, if the reference is by hash.
+func (bnh *BlockNumberOrHash) Hash() (common.Hash, bool) {
+	if bnh.BlockHash != nil {
+		return *bnh.BlockHash, true
+	}
+	return common.Hash{}, false
+}
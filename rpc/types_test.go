@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"testing"
 
+	"github.com/meitu/go-ethereum/common"
 	"github.com/meitu/go-ethereum/common/math"
 )
 
@@ -64,3 +65,56 @@ func TestBlockNumberJSONUnmarshal(t *testing.T) {
 		}
 	}
 }
+
+func TestBlockNumberOrHashJSON(t *testing.T) {
+	hash := common.HexToHash("0x0102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20")
+	tests := []struct {
+		input    string
+		mustFail bool
+		number   BlockNumber
+		hash     *common.Hash
+	}{
+		0: {`"0x10"`, false, BlockNumber(16), nil},
+		1: {`"latest"`, false, LatestBlockNumber, nil},
+		2: {`"` + hash.Hex() + `"`, false, 0, &hash},
+		3: {`{"blockNumber": "pending"}`, false, PendingBlockNumber, nil},
+		4: {`{"blockHash": "` + hash.Hex() + `"}`, false, 0, &hash},
+		5: {`{"blockNumber": "0x1", "blockHash": "` + hash.Hex() + `"}`, true, 0, nil},
+		6: {`{}`, true, 0, nil},
+		7: {`"0x102"`, false, BlockNumber(258), nil},
+		8: {`"0xzz02030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f20"`, true, 0, nil},
+	}
+	for i, test := range tests {
+		var ref BlockNumberOrHash
+		err := json.Unmarshal([]byte(test.input), &ref)
+		if test.mustFail {
+			if err == nil {
+				t.Errorf("Test %d should fail", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d should pass but got err: %v", i, err)
+			continue
+		}
+		if test.hash != nil {
+			if h, ok := ref.Hash(); !ok || h != *test.hash {
+				t.Errorf("Test %d got unexpected hash %v", i, ref.BlockHash)
+			}
+			continue
+		}
+		if n, ok := ref.Number(); !ok || n != test.number {
+			t.Errorf("Test %d got unexpected number %v", i, ref.BlockNumber)
+		}
+		// Block numbers survive a round trip
+		blob, err := json.Marshal(ref)
+		if err != nil {
+			t.Errorf("Test %d failed to marshal: %v", i, err)
+			continue
+		}
+		var dec BlockNumberOrHash
+		if err := json.Unmarshal(blob, &dec); err != nil || *dec.BlockNumber != test.number {
+			t.Errorf("Test %d round trip mismatch: %s", i, blob)
+		}
+	}
+}
@@ -38,13 +38,46 @@ import (
 // To allow connections with any origin, pass "*".
 func (srv *Server) WebsocketHandler(allowedOrigins []string) http.Handler {
 	return websocket.Server{
-		Handshake: wsHandshakeValidator(allowedOrigins),
+		Handshake: srv.wsHandshakeAuthenticator(wsHandshakeValidator(allowedOrigins)),
 		Handler: func(conn *websocket.Conn) {
-			srv.ServeCodec(NewJSONCodec(conn), OptionMethodInvocation|OptionSubscriptions)
+			var (
+				ctx = context.Background()
+				p   *principal
+			)
+			if srv.auth != nil {
+				// The credentials were checked during the handshake already
+				var err error
+				if p, err = srv.auth.authenticate(conn.Request()); err != nil {
+					conn.Close()
+					return
+				}
+				ctx = context.WithValue(ctx, principalKey{}, p)
+			}
+			ctx = context.WithValue(ctx, clientKey{}, clientIdentity(conn.Request(), p))
+			codec := NewJSONCodec(conn)
+			defer codec.Close()
+			srv.serveRequest(ctx, codec, false, OptionMethodInvocation|OptionSubscriptions)
 		},
 	}
 }
 
+// wsHandshakeAuthenticator extends a websocket handshake validator to reject
+// connections without valid credentials, if the server requires them.
+func (srv *Server) wsHandshakeAuthenticator(validator func(*websocket.Config, *http.Request) error) func(*websocket.Config, *http.Request) error {
+	return func(cfg *websocket.Config, req *http.Request) error {
+		if err := validator(cfg, req); err != nil {
+			return err
+		}
+		if srv.auth != nil {
+			if _, err := srv.auth.authenticate(req); err != nil {
+				log.Warn("Rejected unauthenticated WS-RPC connection", "remote", req.RemoteAddr, "err", err)
+				return err
+			}
+		}
+		return nil
+	}
+}
+
 // NewWSServer creates a new websocket RPC server around an API provider.
 //
 // Deprecated: use Server.WebsocketHandler
@@ -96,6 +129,13 @@ func wsHandshakeValidator(allowedOrigins []string) func(*websocket.Config, *http
 // The context is used for the initial connection establishment. It does not
 // affect subsequent interactions with the client.
 func DialWebsocket(ctx context.Context, endpoint, origin string) (*Client, error) {
+	return DialWebsocketWithHeader(ctx, endpoint, origin, nil)
+}
+
+// DialWebsocketWithHeader creates a new RPC client that communicates with a
+// JSON-RPC server listening on the given endpoint, sending the given extra HTTP
+// headers, such as credentials, during the websocket handshake.
+func DialWebsocketWithHeader(ctx context.Context, endpoint, origin string, header http.Header) (*Client, error) {
 	if origin == "" {
 		var err error
 		if origin, err = os.Hostname(); err != nil {
@@ -111,6 +151,11 @@ func DialWebsocket(ctx context.Context, endpoint, origin string) (*Client, error
 	if err != nil {
 		return nil, err
 	}
+	for key, values := range header {
+		for _, value := range values {
+			config.Header.Add(key, value)
+		}
+	}
 
 	return newClient(ctx, func(ctx context.Context) (net.Conn, error) {
 		return wsDialContext(ctx, config)
@@ -0,0 +1,515 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"sync"
+	"time"
+
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/ethdb"
+	"github.com/meitu/go-ethereum/log"
+)
+
+// NodeDatabase is an intermediate write layer between the trie data structures
+// and the disk database. The aim is to accumulate trie writes in-memory and only
+// periodically flush a couple tries to disk, garbage collecting the remainder.
+//
+// Every cached trie node tracks the number of live nodes referencing it, so that
+// dereferencing an old root releases all the nodes that became unreachable.
+//
+// NodeDatabase implements the Database interface, so it can be used anywhere a
+// trie expects a backing store. All methods are safe for concurrent use.
+type NodeDatabase struct {
+	diskdb ethdb.Database // Persistent storage for matured trie nodes
+
+	nodes     map[common.Hash]*cachedNode // Data and references relationships of a trie node
+	oldest    common.Hash                 // Oldest tracked node, flush-list head
+	newest    common.Hash                 // Newest tracked node, flush-list tail
+	preimages map[string][]byte           // Non-node entries (e.g. secure key preimages)
+
+	gctime  time.Duration      // Time spent on garbage collection since last commit
+	gcnodes uint64             // Nodes garbage collected since last commit
+	gcsize  common.StorageSize // Data storage garbage collected since last commit
+
+	flushtime  time.Duration      // Time spent on data flushing since last commit
+	flushnodes uint64             // Nodes flushed since last commit
+	flushsize  common.StorageSize // Data storage flushed since last commit
+
+	nodesSize     common.StorageSize // Storage size of the nodes cache
+	preimagesSize common.StorageSize // Storage size of the preimages cache
+
+	lock sync.RWMutex
+}
+
+// cachedNode is all the information we know about a single cached trie node in
+// the memory database write layer.
+type cachedNode struct {
+	blob     []byte              // Cached data block of the trie node
+	parents  int                 // Number of live nodes referencing this one
+	children map[common.Hash]int // Children referenced by this node
+
+	flushPrev common.Hash // Previous node in the flush-list
+	flushNext common.Hash // Next node in the flush-list
+}
+
+// NewNodeDatabase creates a new trie node database to store ephemeral trie
+// content before it's written out to disk or garbage collected.
+func NewNodeDatabase(diskdb ethdb.Database) *NodeDatabase {
+	return &NodeDatabase{
+		diskdb: diskdb,
+		nodes: map[common.Hash]*cachedNode{
+			{}: {children: make(map[common.Hash]int)},
+		},
+		preimages: make(map[string][]byte),
+	}
+}
+
+// DiskDB retrieves the persistent storage backing the trie node database.
+func (db *NodeDatabase) DiskDB() ethdb.Database {
+	return db.diskdb
+}
+
+// Put inserts a trie node or an auxiliary entry into the memory database. Keys
+// of hash length are treated as trie nodes (or contract code) and are reference
+// counted, all other entries are cached until the next flush.
+func (db *NodeDatabase) Put(key []byte, value []byte) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if len(key) == common.HashLength {
+		db.insert(common.BytesToHash(key), value)
+		return nil
+	}
+	if _, ok := db.preimages[string(key)]; ok {
+		return nil
+	}
+	db.preimages[string(key)] = common.CopyBytes(value)
+	db.preimagesSize += common.StorageSize(len(key) + len(value))
+	return nil
+}
+
+// insert inserts a blob into the memory database and tracks the references it
+// holds to other cached nodes. The method assumes the lock is held.
+func (db *NodeDatabase) insert(hash common.Hash, blob []byte) {
+	// If the node's already cached, skip
+	if _, ok := db.nodes[hash]; ok {
+		return
+	}
+	entry := &cachedNode{
+		blob:      common.CopyBytes(blob),
+		children:  make(map[common.Hash]int),
+		flushPrev: db.newest,
+	}
+	for _, child := range childHashes(blob) {
+		if c, ok := db.nodes[child]; ok {
+			c.parents++
+			entry.children[child]++
+		}
+	}
+	db.nodes[hash] = entry
+
+	// Update the flush-list endpoints
+	if db.oldest == (common.Hash{}) {
+		db.oldest, db.newest = hash, hash
+	} else {
+		db.nodes[db.newest].flushNext, db.newest = hash, hash
+	}
+	db.nodesSize += common.StorageSize(common.HashLength + len(entry.blob))
+}
+
+// Get retrieves the entry associated with key, from the memory cache if it's
+// still there or from the persistent database otherwise.
+func (db *NodeDatabase) Get(key []byte) ([]byte, error) {
+	db.lock.RLock()
+	if len(key) == common.HashLength {
+		if node, ok := db.nodes[common.BytesToHash(key)]; ok && node.blob != nil {
+			db.lock.RUnlock()
+			return node.blob, nil
+		}
+	} else if blob, ok := db.preimages[string(key)]; ok {
+		db.lock.RUnlock()
+		return blob, nil
+	}
+	db.lock.RUnlock()
+
+	return db.diskdb.Get(key)
+}
+
+// Has checks whether an entry is present either in the memory cache or in the
+// persistent database.
+func (db *NodeDatabase) Has(key []byte) (bool, error) {
+	db.lock.RLock()
+	if len(key) == common.HashLength {
+		if node, ok := db.nodes[common.BytesToHash(key)]; ok && node.blob != nil {
+			db.lock.RUnlock()
+			return true, nil
+		}
+	} else if _, ok := db.preimages[string(key)]; ok {
+		db.lock.RUnlock()
+		return true, nil
+	}
+	db.lock.RUnlock()
+
+	return db.diskdb.Has(key)
+}
+
+// Nodes retrieves the hashes of all the nodes cached within the memory database.
+// This method is extremely expensive and should only be used to validate internal
+// states in test code.
+func (db *NodeDatabase) Nodes() []common.Hash {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	var hashes = make([]common.Hash, 0, len(db.nodes))
+	for hash := range db.nodes {
+		if hash != (common.Hash{}) { // Special case for "root" references/nodes
+			hashes = append(hashes, hash)
+		}
+	}
+	return hashes
+}
+
+// Reference adds a new reference from a parent node to a child node. Roots are
+// pinned by referencing them from the empty hash.
+func (db *NodeDatabase) Reference(child common.Hash, parent common.Hash) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	db.reference(child, parent)
+}
+
+// reference is the private locked version of Reference.
+func (db *NodeDatabase) reference(child common.Hash, parent common.Hash) {
+	// If the node does not exist, it's a node pulled from disk, skip
+	node, ok := db.nodes[child]
+	if !ok {
+		return
+	}
+	owner, ok := db.nodes[parent]
+	if !ok {
+		return
+	}
+	// If the reference already exists, only duplicate for roots
+	if _, ok = owner.children[child]; ok && parent != (common.Hash{}) {
+		return
+	}
+	node.parents++
+	owner.children[child]++
+}
+
+// Dereference removes an existing root pin, garbage collecting every cached
+// node that becomes unreachable as a result.
+func (db *NodeDatabase) Dereference(root common.Hash) {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	nodes, storage, start := len(db.nodes), db.nodesSize, time.Now()
+	db.dereference(root, common.Hash{})
+
+	db.gcnodes += uint64(nodes - len(db.nodes))
+	db.gcsize += storage - db.nodesSize
+	db.gctime += time.Since(start)
+
+	log.Debug("Dereferenced trie from memory database", "nodes", nodes-len(db.nodes), "size", storage-db.nodesSize, "time", time.Since(start),
+		"gcnodes", db.gcnodes, "gcsize", db.gcsize, "gctime", db.gctime, "livenodes", len(db.nodes), "livesize", db.nodesSize)
+}
+
+// dereference is the private locked version of Dereference.
+func (db *NodeDatabase) dereference(child common.Hash, parent common.Hash) {
+	// Dereference the parent-child
+	if owner, ok := db.nodes[parent]; ok {
+		if owner.children[child] > 1 {
+			owner.children[child]--
+		} else {
+			delete(owner.children, child)
+		}
+	}
+	// If the child does not exist, it's a previously committed node
+	node, ok := db.nodes[child]
+	if !ok {
+		return
+	}
+	// If there are no more references to the child, delete it and cascade
+	if node.parents > 0 {
+		// This is a special cornercase where a node loaded from disk (i.e. not in the
+		// memcache any more) gets reinjected as a new node (short node split into full,
+		// then reverted into short), causing a cached node to have no parents. That is
+		// no problem in itself, but don't make maxint parents out of it.
+		node.parents--
+	}
+	if node.parents == 0 {
+		db.unlink(child, node)
+		for hash, refs := range node.children {
+			for i := 0; i < refs; i++ {
+				db.dereference(hash, child)
+			}
+		}
+		delete(db.nodes, child)
+		db.nodesSize -= common.StorageSize(common.HashLength + len(node.blob))
+	}
+}
+
+// unlink removes a node from the flush-list, keeping the endpoints consistent.
+// The method assumes the lock is held.
+func (db *NodeDatabase) unlink(hash common.Hash, node *cachedNode) {
+	switch hash {
+	case db.oldest:
+		db.oldest = node.flushNext
+		if db.oldest != (common.Hash{}) {
+			db.nodes[db.oldest].flushPrev = common.Hash{}
+		} else {
+			db.newest = common.Hash{}
+		}
+	case db.newest:
+		db.newest = node.flushPrev
+		db.nodes[db.newest].flushNext = common.Hash{}
+	default:
+		db.nodes[node.flushPrev].flushNext = node.flushNext
+		db.nodes[node.flushNext].flushPrev = node.flushPrev
+	}
+}
+
+// Cap iteratively flushes old but still referenced trie nodes until the total
+// memory usage goes below the given threshold. Since nodes are inserted after
+// all their children, flushing in insertion order never leaves a dangling
+// reference on disk.
+func (db *NodeDatabase) Cap(limit common.StorageSize) error {
+	db.lock.RLock()
+
+	nodes, storage, start := len(db.nodes), db.nodesSize, time.Now()
+	batch := db.diskdb.NewBatch()
+
+	// Flush all the preimages first, they are cheap and not reference counted
+	size := db.nodesSize + db.preimagesSize
+	for key, preimage := range db.preimages {
+		if err := batch.Put([]byte(key), preimage); err != nil {
+			log.Error("Failed to commit preimage from trie database", "err", err)
+			db.lock.RUnlock()
+			return err
+		}
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				db.lock.RUnlock()
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	size -= db.preimagesSize
+
+	// Keep committing nodes from the flush-list until we're below the allowance
+	oldest := db.oldest
+	for size > limit && oldest != (common.Hash{}) {
+		node := db.nodes[oldest]
+		if err := batch.Put(oldest[:], node.blob); err != nil {
+			db.lock.RUnlock()
+			return err
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				log.Error("Failed to write flush list to disk", "err", err)
+				db.lock.RUnlock()
+				return err
+			}
+			batch.Reset()
+		}
+		size -= common.StorageSize(common.HashLength + len(node.blob))
+		oldest = node.flushNext
+	}
+	if err := batch.Write(); err != nil {
+		log.Error("Failed to write flush list to disk", "err", err)
+		db.lock.RUnlock()
+		return err
+	}
+	db.lock.RUnlock()
+
+	// Write successful, clear out the flushed data
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	db.preimages = make(map[string][]byte)
+	db.preimagesSize = 0
+
+	for db.oldest != oldest {
+		node := db.nodes[db.oldest]
+		delete(db.nodes, db.oldest)
+		db.oldest = node.flushNext
+
+		db.nodesSize -= common.StorageSize(common.HashLength + len(node.blob))
+	}
+	if db.oldest != (common.Hash{}) {
+		db.nodes[db.oldest].flushPrev = common.Hash{}
+	} else {
+		db.newest = common.Hash{}
+	}
+	db.flushnodes += uint64(nodes - len(db.nodes))
+	db.flushsize += storage - db.nodesSize
+	db.flushtime += time.Since(start)
+
+	log.Debug("Persisted nodes from memory database", "nodes", nodes-len(db.nodes), "size", storage-db.nodesSize, "time", time.Since(start),
+		"flushnodes", db.flushnodes, "flushsize", db.flushsize, "flushtime", db.flushtime, "livenodes", len(db.nodes), "livesize", db.nodesSize)
+
+	return nil
+}
+
+// Commit iterates over all the children of a particular node, writes them out
+// to disk, forcefully tearing down all references in both directions.
+//
+// As a side effect, all pre-images accumulated up to this point are also written.
+func (db *NodeDatabase) Commit(node common.Hash, report bool) error {
+	// Create a database batch to flush persistent data out. It is important that
+	// outside code doesn't see an inconsistent state (referenced data removed from
+	// memory cache during commit but not yet in persistent storage). This is ensured
+	// by only uncaching existing data when the database write finalizes.
+	db.lock.RLock()
+
+	start := time.Now()
+	batch := db.diskdb.NewBatch()
+
+	// Move all of the accumulated preimages into a write batch
+	for key, preimage := range db.preimages {
+		if err := batch.Put([]byte(key), preimage); err != nil {
+			log.Error("Failed to commit preimage from trie database", "err", err)
+			db.lock.RUnlock()
+			return err
+		}
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				db.lock.RUnlock()
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	// Move the trie itself into the batch, flushing if enough data is accumulated
+	nodes, storage := len(db.nodes), db.nodesSize
+	if err := db.commit(node, batch); err != nil {
+		log.Error("Failed to commit trie from trie database", "err", err)
+		db.lock.RUnlock()
+		return err
+	}
+	// Write batch ready, unlock for readers during persistence
+	if err := batch.Write(); err != nil {
+		log.Error("Failed to write trie to disk", "err", err)
+		db.lock.RUnlock()
+		return err
+	}
+	db.lock.RUnlock()
+
+	// Write successful, clear out the flushed data
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	db.preimages = make(map[string][]byte)
+	db.preimagesSize = 0
+
+	db.uncache(node)
+
+	logger := log.Info
+	if !report {
+		logger = log.Debug
+	}
+	logger("Persisted trie from memory database", "nodes", nodes-len(db.nodes), "size", storage-db.nodesSize, "time", time.Since(start),
+		"gcnodes", db.gcnodes, "gcsize", db.gcsize, "gctime", db.gctime, "livenodes", len(db.nodes), "livesize", db.nodesSize)
+
+	// Reset the garbage collection statistics
+	db.gcnodes, db.gcsize, db.gctime = 0, 0, 0
+	db.flushnodes, db.flushsize, db.flushtime = 0, 0, 0
+
+	return nil
+}
+
+// commit is the private locked version of Commit.
+func (db *NodeDatabase) commit(hash common.Hash, batch ethdb.Batch) error {
+	// If the node does not exist, it's a previously committed node
+	node, ok := db.nodes[hash]
+	if !ok {
+		return nil
+	}
+	for child := range node.children {
+		if err := db.commit(child, batch); err != nil {
+			return err
+		}
+	}
+	if err := batch.Put(hash[:], node.blob); err != nil {
+		return err
+	}
+	// If we've reached an optimal batch size, commit and start over
+	if batch.ValueSize() >= ethdb.IdealBatchSize {
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Reset()
+	}
+	return nil
+}
+
+// uncache is the post-processing step of a commit operation where the already
+// persisted trie is removed from the cache. The reason behind the two-phase
+// commit is to ensure consistent data availability while moving from memory
+// to disk.
+func (db *NodeDatabase) uncache(hash common.Hash) {
+	// If the node does not exist, we're done on this path
+	node, ok := db.nodes[hash]
+	if !ok {
+		return
+	}
+	// Node still exists, remove it from the flush-list and uncache its children
+	db.unlink(hash, node)
+	for child := range node.children {
+		db.uncache(child)
+	}
+	delete(db.nodes, hash)
+	db.nodesSize -= common.StorageSize(common.HashLength + len(node.blob))
+}
+
+// Size returns the current storage size of the memory cache in front of the
+// persistent database layer.
+func (db *NodeDatabase) Size() common.StorageSize {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	return db.nodesSize + db.preimagesSize
+}
+
+// childHashes returns the hashes of all the nodes directly referenced by an RLP
+// encoded trie node, descending into embedded children. Blobs that don't decode
+// as trie nodes (e.g. contract code) have no children.
+func childHashes(blob []byte) []common.Hash {
+	n, err := decodeNode(nil, blob, 0)
+	if err != nil {
+		return nil
+	}
+	var hashes []common.Hash
+	gatherChildren(n, &hashes)
+	return hashes
+}
+
+// gatherChildren appends the hash references contained in n to hashes.
+func gatherChildren(n node, hashes *[]common.Hash) {
+	switch n := n.(type) {
+	case *shortNode:
+		gatherChildren(n.Val, hashes)
+	case *fullNode:
+		for i := 0; i < 16; i++ {
+			gatherChildren(n.Children[i], hashes)
+		}
+	case hashNode:
+		*hashes = append(*hashes, common.BytesToHash(n))
+	}
+}
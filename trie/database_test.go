@@ -0,0 +1,169 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/meitu/go-ethereum/common"
+	"github.com/meitu/go-ethereum/ethdb"
+)
+
+// makeNodeDatabaseTrie commits a trie with n entries, keyed by prefix, into db.
+func makeNodeDatabaseTrie(t *testing.T, db *NodeDatabase, prefix string, n int) common.Hash {
+	tr, _ := New(common.Hash{}, db)
+	for i := 0; i < n; i++ {
+		tr.Update([]byte(fmt.Sprintf("%s-key-%d", prefix, i)), bytes.Repeat([]byte{byte(i)}, 40))
+	}
+	root, err := tr.CommitTo(db)
+	if err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	return root
+}
+
+// Tests that dereferencing a root garbage collects all of its nodes, while keeping
+// the ones shared with other live roots.
+func TestNodeDatabaseDereference(t *testing.T) {
+	diskdb, _ := ethdb.NewMemDatabase()
+	db := NewNodeDatabase(diskdb)
+
+	root1 := makeNodeDatabaseTrie(t, db, "a", 100)
+	db.Reference(root1, common.Hash{})
+
+	// Build a second trie sharing most of the nodes with the first one
+	tr, _ := New(root1, db)
+	tr.Update([]byte("a-key-0"), []byte("changed"))
+	root2, _ := tr.CommitTo(db)
+	db.Reference(root2, common.Hash{})
+
+	shared := len(db.Nodes())
+	db.Dereference(root1)
+	if nodes := len(db.Nodes()); nodes == 0 || nodes >= shared {
+		t.Fatalf("live nodes mismatch: have %d, want in (0, %d)", nodes, shared)
+	}
+	// The second trie must still be fully accessible
+	tr, err := New(root2, db)
+	if err != nil {
+		t.Fatalf("failed to reopen live trie: %v", err)
+	}
+	for i := 1; i < 100; i++ {
+		if val := tr.Get([]byte(fmt.Sprintf("a-key-%d", i))); !bytes.Equal(val, bytes.Repeat([]byte{byte(i)}, 40)) {
+			t.Fatalf("key %d: value mismatch: have %x", i, val)
+		}
+	}
+	db.Dereference(root2)
+	if nodes := len(db.Nodes()); nodes != 0 {
+		t.Fatalf("dangling nodes after full dereference: %d", nodes)
+	}
+	if size := len(diskdb.Keys()); size != 0 {
+		t.Fatalf("garbage collected data leaked to disk: %d entries", size)
+	}
+}
+
+// Tests that committing a root writes it and all its descendants to disk and
+// removes them from the memory cache.
+func TestNodeDatabaseCommit(t *testing.T) {
+	diskdb, _ := ethdb.NewMemDatabase()
+	db := NewNodeDatabase(diskdb)
+
+	root := makeNodeDatabaseTrie(t, db, "a", 100)
+	db.Reference(root, common.Hash{})
+
+	if err := db.Commit(root, false); err != nil {
+		t.Fatalf("failed to commit root: %v", err)
+	}
+	if nodes := len(db.Nodes()); nodes != 0 {
+		t.Fatalf("cached nodes after commit: %d", nodes)
+	}
+	tr, err := New(root, NewNodeDatabase(diskdb))
+	if err != nil {
+		t.Fatalf("failed to open committed trie: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		if val := tr.Get([]byte(fmt.Sprintf("a-key-%d", i))); !bytes.Equal(val, bytes.Repeat([]byte{byte(i)}, 40)) {
+			t.Fatalf("key %d: value mismatch: have %x", i, val)
+		}
+	}
+	// Dereferencing an already committed root must be a noop
+	db.Dereference(root)
+}
+
+// Tests that capping the memory database flushes the oldest nodes first and
+// keeps the persisted data consistent.
+func TestNodeDatabaseCap(t *testing.T) {
+	diskdb, _ := ethdb.NewMemDatabase()
+	db := NewNodeDatabase(diskdb)
+
+	root1 := makeNodeDatabaseTrie(t, db, "a", 100)
+	db.Reference(root1, common.Hash{})
+	size := db.Size()
+
+	root2 := makeNodeDatabaseTrie(t, db, "b", 100)
+	db.Reference(root2, common.Hash{})
+
+	if err := db.Cap(db.Size() - size); err != nil {
+		t.Fatalf("failed to cap database: %v", err)
+	}
+	if have := db.Size(); have > size {
+		t.Fatalf("cache size mismatch: have %v, want <= %v", have, size)
+	}
+	// The first trie must be fully available on disk
+	if _, err := New(root1, NewNodeDatabase(diskdb)); err != nil {
+		t.Fatalf("failed to open flushed trie: %v", err)
+	}
+	// The second one must still be available through the cache
+	tr, err := New(root2, db)
+	if err != nil {
+		t.Fatalf("failed to open cached trie: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		if val := tr.Get([]byte(fmt.Sprintf("b-key-%d", i))); !bytes.Equal(val, bytes.Repeat([]byte{byte(i)}, 40)) {
+			t.Fatalf("key %d: value mismatch: have %x", i, val)
+		}
+	}
+}
+
+// Tests that leaf callbacks can pin external references (e.g. storage tries)
+// to the nodes containing them.
+func TestNodeDatabaseLeafReference(t *testing.T) {
+	diskdb, _ := ethdb.NewMemDatabase()
+	db := NewNodeDatabase(diskdb)
+
+	storage := makeNodeDatabaseTrie(t, db, "s", 50)
+
+	tr, _ := New(common.Hash{}, db)
+	tr.Update([]byte("account"), append(storage.Bytes(), bytes.Repeat([]byte{0x01}, 8)...))
+	root, err := tr.CommitToWithCallback(db, func(leaf []byte, parent common.Hash) error {
+		db.Reference(common.BytesToHash(leaf[:common.HashLength]), parent)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	db.Reference(root, common.Hash{})
+
+	if _, err := New(storage, db); err != nil {
+		t.Fatalf("referenced storage trie missing: %v", err)
+	}
+	db.Dereference(root)
+	if nodes := len(db.Nodes()); nodes != 0 {
+		t.Fatalf("dangling nodes after dereference: %d", nodes)
+	}
+}